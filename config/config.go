@@ -16,8 +16,37 @@ type Config struct {
 		ReadTimeout  time.Duration `mapstructure:"SERVER_READ_TIMEOUT"`  // 读取超时时间
 		WriteTimeout time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"` // 写入超时时间
 		IdleTimeout  time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"`  // 空闲超时时间
+		// 处理器超时时间，限制单个请求的处理时间（不含响应传输），到期立即返回504并丢弃处理器的响应，0表示不限制。
+		// 调用 Flush 开始流式输出后不再受该值限制；WriteTimeout 作用于传输层并覆盖整个响应写出过程，应不小于该值
+		HandlerTimeout time.Duration `mapstructure:"SERVER_HANDLER_TIMEOUT"`
+		// 尾部斜杠处理方式：404（默认，不重定向）或 redirect（对应路径已注册时返回308）
+		TrailingSlash string `mapstructure:"SERVER_TRAILING_SLASH"`
+		// 允许的重定向目标，以"/"开头表示站内路径前缀，其余为主机名（支持 *.example.com）
+		RedirectAllowlist []string `mapstructure:"SERVER_REDIRECT_ALLOWLIST"`
+		// 部署平台：cloudflare/google-app-engine/flyio，设置后从平台请求头读取客户端IP，为空时使用连接地址
+		TrustedPlatform string `mapstructure:"SERVER_TRUSTED_PLATFORM"`
+		// 是否输出 Server-Timing 耗时明细响应头，debug模式下始终输出
+		Timing bool `mapstructure:"SERVER_TIMING"`
+		// 客户端重复使用 X-Request-ID 时的处理方式：keep（默认，原样使用）、suffix（追加随机后缀）或 regenerate（生成新的ID）
+		RequestIDDuplicateMode string `mapstructure:"SERVER_REQUEST_ID_DUPLICATE_MODE"`
+		// 判断请求ID重复的时间窗口，0使用默认值1分钟
+		RequestIDDuplicateTTL time.Duration `mapstructure:"SERVER_REQUEST_ID_DUPLICATE_TTL"`
+		// 查询参数的最大个数，超出返回400，0使用默认值100，负数表示不限制
+		MaxQueryParams int `mapstructure:"SERVER_MAX_QUERY_PARAMS"`
+		// 查询字符串的最大字节数，超出返回400，0使用默认值8192，负数表示不限制
+		MaxQueryLength int `mapstructure:"SERVER_MAX_QUERY_LENGTH"`
 	} `mapstructure:"server"`
 
+	// Startup 启动相关配置
+	Startup struct {
+		// 启动时等待MongoDB可用后再监听HTTP端口，默认false（连接失败立即退出）
+		WaitEnable   bool          `mapstructure:"STARTUP_WAIT_ENABLE"`
+		WaitTimeout  time.Duration `mapstructure:"STARTUP_WAIT_TIMEOUT"`  // 等待所有依赖的总时长，0使用默认值60秒
+		CheckTimeout time.Duration `mapstructure:"STARTUP_CHECK_TIMEOUT"` // 单次依赖检查的超时时间，0使用默认值5秒
+		// 等待超时后仍然启动（降级运行，/healthz 返回503直到依赖恢复），默认false（退出）
+		DegradedStart bool `mapstructure:"STARTUP_DEGRADED_START"`
+	} `mapstructure:"startup"`
+
 	// Database 数据库相关配置
 	Database struct {
 		Host            string        `mapstructure:"DATABASE_HOST"`              // 数据库主机地址
@@ -28,54 +57,142 @@ type Config struct {
 		MaxIdleConns    int           `mapstructure:"DATABASE_MAX_IDLE_CONNS"`    // 最大空闲连接数
 		MaxOpenConns    int           `mapstructure:"DATABASE_MAX_OPEN_CONNS"`    // 最大打开连接数
 		ConnMaxLifetime time.Duration `mapstructure:"DATABASE_CONN_MAX_LIFETIME"` // 连接最大生命周期
+		// 启动时执行尚未执行的MongoDB迁移（创建索引、初始化数据），默认false
+		AutoMigrate bool `mapstructure:"DATABASE_AUTO_MIGRATE"`
+		// 自动迁移时跳过创建默认管理员（admin/admin123），默认false
+		SkipAdminSeed bool `mapstructure:"DATABASE_SKIP_ADMIN_SEED"`
 	} `mapstructure:"database"`
 
 	// MongoDB MongoDB数据库相关配置
 	MongoDB struct {
-		URI      string `mapstructure:"MONGODB_URI"`      // MongoDB连接URI
-		Database string `mapstructure:"MONGODB_DATABASE"` // MongoDB数据库名称
-		Username string `mapstructure:"MONGODB_USERNAME"` // MongoDB用户名
-		Password string `mapstructure:"MONGODB_PASSWORD"` // MongoDB密码
+		URI         string `mapstructure:"MONGODB_URI"`          // MongoDB连接URI，设置后忽略下方的主机、端口和认证字段
+		Host        string `mapstructure:"MONGODB_HOST"`         // MongoDB主机，默认localhost，多个主机用逗号分隔（需自带端口）
+		Port        int    `mapstructure:"MONGODB_PORT"`         // MongoDB端口，默认27017
+		AuthSource  string `mapstructure:"MONGODB_AUTH_SOURCE"`  // 认证数据库，如 admin
+		Database    string `mapstructure:"MONGODB_DATABASE"`     // MongoDB数据库名称
+		Username    string `mapstructure:"MONGODB_USERNAME"`     // MongoDB用户名
+		Password    string `mapstructure:"MONGODB_PASSWORD"`     // MongoDB密码
+		DefaultSort string `mapstructure:"MONGODB_DEFAULT_SORT"` // 列表默认排序，如 -created_at（前缀-表示降序）
+		// 读操作遇到网络错误、主节点切换等可重试错误时的重试次数（不含首次），0表示不重试
+		ReadRetryAttempts int           `mapstructure:"MONGODB_READ_RETRY_ATTEMPTS"`
+		ReadRetryBackoff  time.Duration `mapstructure:"MONGODB_READ_RETRY_BACKOFF"` // 首次重试前的等待时间，之后每次翻倍
+		// 允许的最大复制延迟，超过后读请求返回503（健康检查除外），0表示不检查
+		MaxReplicationLag time.Duration `mapstructure:"MONGODB_MAX_REPLICATION_LAG"`
+		LagCheckInterval  time.Duration `mapstructure:"MONGODB_LAG_CHECK_INTERVAL"` // 复制延迟检查间隔，默认10秒
+		// 用户全文索引的字段权重，格式为 字段:权重（如 username:10,nickname:1），为空时使用默认值；修改后需重建索引
+		TextWeights []string `mapstructure:"MONGODB_TEXT_WEIGHTS"`
 	} `mapstructure:"mongodb"`
 
 	// JWT JWT认证相关配置
 	JWT struct {
-		Secret string        `mapstructure:"JWT_SECRET"` // JWT密钥
-		Expire time.Duration `mapstructure:"JWT_EXPIRE"` // JWT过期时间
+		Secret      string        `mapstructure:"JWT_SECRET"`        // JWT密钥
+		Expire      time.Duration `mapstructure:"JWT_EXPIRE"`        // JWT过期时间
+		MaxTokenAge time.Duration `mapstructure:"JWT_MAX_TOKEN_AGE"` // 令牌最大有效年龄（按签发时间计算，与过期时间无关），0表示不限制
+		// 校验 exp、nbf 和最大有效年龄时容忍的时钟偏差，默认30秒，0表示严格校验；超过5分钟按5分钟处理
+		ClockSkew time.Duration `mapstructure:"JWT_CLOCK_SKEW"`
+		// 关闭JWT认证，所有请求视为用户1，仅限本地开发使用，默认false（启用认证）
+		Disabled bool `mapstructure:"JWT_DISABLED"`
+		// 每个用户同时有效的登录会话数上限，0表示不限制（不记录会话）
+		MaxSessions int `mapstructure:"JWT_MAX_SESSIONS"`
+		// 达到会话数上限时的处理方式：evict_oldest（默认，吊销最早的会话）或 reject（拒绝新的登录，返回429）
+		SessionLimitPolicy string `mapstructure:"JWT_SESSION_LIMIT_POLICY"`
 	} `mapstructure:"jwt"`
 
 	// Signature API签名相关配置
 	Signature struct {
+		Enable    bool          `mapstructure:"SIGNATURE_ENABLE"`     // 是否启用签名验证，默认false
 		AppKey    string        `mapstructure:"SIGNATURE_APP_KEY"`    // 应用id
 		AppSecret string        `mapstructure:"SIGNATURE_APP_SECRET"` // 应用密钥
 		Expire    time.Duration `mapstructure:"SIGNATURE_EXPIRE"`     // 签名过期时间
+		Algorithm string        `mapstructure:"SIGNATURE_ALGORITHM"`  // 签名算法：md5（默认，兼容旧客户端）或 hmac-sha256（推荐）
+		// nonce存储：memory（默认，仅适用于单实例）或 mongodb（多实例共享）
+		NonceStore string `mapstructure:"SIGNATURE_NONCE_STORE"`
 	} `mapstructure:"signature"`
 
+	// Security 安全相关配置
+	Security struct {
+		PasswordChangeMaxAttempts int           `mapstructure:"SECURITY_PASSWORD_CHANGE_MAX_ATTEMPTS"` // 修改密码时原密码错误的最大次数，0使用默认值5
+		PasswordChangeWindow      time.Duration `mapstructure:"SECURITY_PASSWORD_CHANGE_WINDOW"`       // 修改密码失败次数的统计窗口，0使用默认值15分钟
+		LoginMaxAttempts          int           `mapstructure:"SECURITY_LOGIN_MAX_ATTEMPTS"`           // 连续登录失败多少次后锁定账户，0使用默认值5
+		LoginLockoutDuration      time.Duration `mapstructure:"SECURITY_LOGIN_LOCKOUT_DURATION"`       // 账户锁定时长，0使用默认值15分钟
+		// 注册和修改密码时检查密码是否出现在公开泄露的数据中（HaveIBeenPwned k-匿名接口），接口不可用时放行
+		BreachCheckEnable  bool          `mapstructure:"SECURITY_BREACH_CHECK_ENABLE"`
+		BreachCheckURL     string        `mapstructure:"SECURITY_BREACH_CHECK_URL"`     // 接口地址，默认 https://api.pwnedpasswords.com/range/
+		BreachCheckTimeout time.Duration `mapstructure:"SECURITY_BREACH_CHECK_TIMEOUT"` // 接口超时时间，默认3秒
+		// 分页游标的签名密钥，多实例部署时必须一致；为空时使用随机密钥，游标在重启后失效
+		CursorSecret string `mapstructure:"SECURITY_CURSOR_SECRET"`
+		// 登录失败记录中用户名哈希的密钥，为空时由JWT密钥派生（HKDF）；修改后新旧记录的哈希无法关联
+		LoginHashSecret string `mapstructure:"SECURITY_LOGIN_HASH_SECRET"`
+		// 字段加密密钥（base64编码的32字节AES-256密钥），为空时不加密
+		FieldEncryptionKey string `mapstructure:"SECURITY_FIELD_ENCRYPTION_KEY"`
+		// 需要加密存储的用户字段，目前支持 email
+		EncryptedFields []string `mapstructure:"SECURITY_ENCRYPTED_FIELDS"`
+		// 同一用户两次发送验证邮件的最小间隔，0使用默认值1分钟
+		VerificationResendCooldown time.Duration `mapstructure:"SECURITY_VERIFICATION_RESEND_COOLDOWN"`
+		// 邮箱验证链接地址，令牌以 token 查询参数附加在后面；为空时邮件中只包含令牌
+		VerificationURL string `mapstructure:"SECURITY_VERIFICATION_URL"`
+		// 密码重置链接地址，令牌以 token 查询参数附加在后面；为空时邮件中只包含令牌
+		PasswordResetURL string `mapstructure:"SECURITY_PASSWORD_RESET_URL"`
+	} `mapstructure:"security"`
+
 	// CORS 跨域相关配置
 	CORS struct {
 		AllowOrigins     []string      `mapstructure:"CORS_ALLOW_ORIGINS"`     // 允许的源
 		AllowCredentials bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"` // 是否允许凭证
 		MaxAge           time.Duration `mapstructure:"CORS_MAX_AGE"`           // 预检请求缓存时间
+		// 允许的请求方法和请求头，为空时使用默认值
+		AllowMethods []string `mapstructure:"CORS_ALLOW_METHODS"`
+		AllowHeaders []string `mapstructure:"CORS_ALLOW_HEADERS"`
+		// 允许浏览器脚本读取的响应头（如 X-Request-ID），默认不暴露额外的响应头
+		ExposeHeaders []string `mapstructure:"CORS_EXPOSE_HEADERS"`
 	} `mapstructure:"cors"`
 
 	// Whitelist 白名单相关配置
 	Whitelist struct {
-		IPWhitelist         []string `mapstructure:"WHITELIST_IP"`          // IP白名单列表
-		PathWhitelist       []string `mapstructure:"WHITELIST_PATH"`        // 路径白名单列表
-		EnableIPWhitelist   bool     `mapstructure:"WHITELIST_IP_ENABLE"`   // 是否启用IP白名单
-		EnablePathWhitelist bool     `mapstructure:"WHITELIST_PATH_ENABLE"` // 是否启用路径白名单
+		IPWhitelist         []string `mapstructure:"WHITELIST_IP"`                // IP白名单列表
+		PathWhitelist       []string `mapstructure:"WHITELIST_PATH"`              // 路径白名单列表，以*结尾的条目按前缀匹配
+		EnableIPWhitelist   bool     `mapstructure:"WHITELIST_IP_ENABLE"`         // 是否启用IP白名单
+		EnablePathWhitelist bool     `mapstructure:"WHITELIST_PATH_ENABLE"`       // 是否启用路径白名单
+		ExemptRateLimit     bool     `mapstructure:"WHITELIST_RATE_LIMIT_EXEMPT"` // 白名单IP是否豁免限流
+		MaxEntries          int      `mapstructure:"WHITELIST_MAX_ENTRIES"`       // IP白名单最大条目数，0表示不限制
 	} `mapstructure:"whitelist"`
 
+	// RateLimit 按IP限流相关配置
+	RateLimit struct {
+		Enable bool    `mapstructure:"RATE_LIMIT_ENABLE"` // 是否启用限流，默认false
+		Rate   float64 `mapstructure:"RATE_LIMIT_RATE"`   // 每个IP每秒补充的令牌数，0使用默认值10
+		Burst  int     `mapstructure:"RATE_LIMIT_BURST"`  // 每个IP允许的突发请求数，0使用默认值20
+	} `mapstructure:"ratelimit"`
+
 	// Logger 日志相关配置
 	Logger struct {
-		Dir           string `mapstructure:"LOGGER_DIR"`            // 日志目录
-		FileName      string `mapstructure:"LOGGER_FILENAME"`       // 日志文件名
-		MaxSize       int    `mapstructure:"LOGGER_MAX_SIZE"`       // 单个日志文件最大大小(MB)
-		MaxBackups    int    `mapstructure:"LOGGER_MAX_BACKUPS"`    // 最大保留旧日志文件数
-		MaxAge        int    `mapstructure:"LOGGER_MAX_AGE"`        // 日志保留天数
-		Compress      bool   `mapstructure:"LOGGER_COMPRESS"`       // 是否压缩旧日志文件
-		ConsoleOutput bool   `mapstructure:"LOGGER_CONSOLE_OUTPUT"` // 是否输出到控制台
-		RotateDaily   bool   `mapstructure:"LOGGER_ROTATE_DAILY"`   // 是否按天轮转日志
+		Dir             string `mapstructure:"LOGGER_DIR"`               // 日志目录
+		FileName        string `mapstructure:"LOGGER_FILENAME"`          // 日志文件名
+		MaxSize         int    `mapstructure:"LOGGER_MAX_SIZE"`          // 单个日志文件最大大小(MB)
+		MaxBackups      int    `mapstructure:"LOGGER_MAX_BACKUPS"`       // 最大保留旧日志文件数
+		MaxAge          int    `mapstructure:"LOGGER_MAX_AGE"`           // 日志保留天数
+		Compress        bool   `mapstructure:"LOGGER_COMPRESS"`          // 是否压缩旧日志文件
+		ConsoleOutput   bool   `mapstructure:"LOGGER_CONSOLE_OUTPUT"`    // 是否输出到控制台
+		RotateDaily     bool   `mapstructure:"LOGGER_ROTATE_DAILY"`      // 是否按天轮转日志
+		MaxParams       int    `mapstructure:"LOGGER_MAX_PARAMS"`        // 请求日志中最多记录的路径参数个数
+		MaxHeaderLength int    `mapstructure:"LOGGER_MAX_HEADER_LENGTH"` // 请求日志中单个请求头值的最大长度
+		// 请求日志是否记录请求头和路径参数，默认true；关闭后不再提取，减少内存分配和日志体积
+		LogHeaders bool `mapstructure:"LOGGER_LOG_HEADERS"`
+		LogParams  bool `mapstructure:"LOGGER_LOG_PARAMS"`
+		// 自动附加堆栈信息的最低日志级别：error/dpanic/panic/fatal/none，默认error；panic堆栈始终记录
+		StacktraceLevel string `mapstructure:"LOGGER_STACKTRACE_LEVEL"`
+		// 启动时的最低日志级别：debug/info/warn/error，默认debug；运行时可通过管理接口修改
+		Level string `mapstructure:"LOGGER_LEVEL"`
+		// 收到SIGHUP时重新打开日志文件，供外部logrotate轮转使用；关闭时轮转完全交给lumberjack
+		ReopenOnSignal bool `mapstructure:"LOGGER_REOPEN_ON_SIGHUP"`
+		// 请求日志格式：json（默认）或 logfmt，两种格式的字段相同
+		RequestLogFormat string `mapstructure:"LOGGER_REQUEST_LOG_FORMAT"`
+		// 不记录日志的路径，逗号分隔，以"*"结尾的条目按前缀匹配；设为空时记录所有请求
+		SkipPaths []string `mapstructure:"LOGGER_SKIP_PATHS"`
+		// 跳过路径的请求返回错误（状态码>=400）时仍然记录，默认true，便于发现健康检查失败
+		LogSkippedErrors bool `mapstructure:"LOGGER_LOG_SKIPPED_ERRORS"`
+		// 不记录日志的响应状态码，逗号分隔（如 304,404），对所有路径生效；默认为空
+		SkipStatuses []int `mapstructure:"LOGGER_SKIP_STATUSES"`
 	} `mapstructure:"logger"`
 }
 
@@ -94,11 +211,13 @@ func LoadConfig() *Config {
 	viper.SetConfigType("env")
 	viper.AddConfigPath(".")
 	viper.AutomaticEnv()
+	setDefaults()
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
 		panic("无法读取配置文件: " + err.Error())
 	}
+	bindKeys()
 
 	// 解析配置到结构体
 	var config Config
@@ -108,3 +227,22 @@ func LoadConfig() *Config {
 
 	return &config
 }
+
+// builtinDefaults 配置默认值
+// 仅用于零值有实际含义、无法在使用处判断是否配置的字段（如默认开启的布尔开关）
+var builtinDefaults = map[string]interface{}{
+	"logger.LOGGER_CONSOLE_OUTPUT":        true,
+	"logger.LOGGER_LOG_HEADERS":           true,
+	"logger.LOGGER_LOG_PARAMS":            true,
+	"logger.LOGGER_SKIP_PATHS":            []string{"/ping", "/healthz", "/metrics"},
+	"logger.LOGGER_LOG_SKIPPED_ERRORS":    true,
+	"mongodb.MONGODB_READ_RETRY_ATTEMPTS": 2,
+	"jwt.JWT_CLOCK_SKEW":                  30 * time.Second,
+}
+
+// setDefaults 设置配置默认值
+func setDefaults() {
+	for key, value := range builtinDefaults {
+		viper.SetDefault(key, value)
+	}
+}
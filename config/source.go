@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 配置值的来源
+const (
+	SourceEnv     = "env"     // 环境变量
+	SourceFile    = "file"    // 配置文件（.env.<APP_ENV>）
+	SourceDefault = "default" // setDefaults 中的内置默认值
+)
+
+// redactedValue 敏感配置在日志中的替代值
+const redactedValue = "******"
+
+// FieldSource 单个配置项的取值来源
+type FieldSource struct {
+	Name   string // 配置项名称，即环境变量名，如 SERVER_PORT
+	Source string // 来源：env、file 或 default
+	Value  string // 生效的值，敏感配置已脱敏
+}
+
+// configField 配置结构体中的一个字段
+type configField struct {
+	name string // 环境变量名，如 SERVER_PORT
+	key  string // viper中的完整键名，如 server.SERVER_PORT
+}
+
+// configFields 遍历 Config 结构体，返回所有配置项（分组.字段）
+func configFields() []configField {
+	var fields []configField
+	root := reflect.TypeOf(Config{})
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		prefix := section.Tag.Get("mapstructure")
+		if section.Type.Kind() != reflect.Struct || prefix == "" {
+			continue
+		}
+		for j := 0; j < section.Type.NumField(); j++ {
+			name := section.Type.Field(j).Tag.Get("mapstructure")
+			if name == "" {
+				continue
+			}
+			fields = append(fields, configField{name: name, key: prefix + "." + name})
+		}
+	}
+	return fields
+}
+
+/*
+bindKeys 将配置项的环境变量名和配置文件中的键映射到结构体对应的完整键名
+配置文件和环境变量使用不带分组的名称（如 SERVER_PORT），结构体按分组解析（server.SERVER_PORT），
+不做映射时 Unmarshal 读不到这些值；优先级：环境变量 > 配置文件 > 内置默认值
+*/
+func bindKeys() {
+	for _, f := range configFields() {
+		_ = viper.BindEnv(f.key, f.name)
+		if flat := strings.ToLower(f.name); viper.InConfig(flat) {
+			viper.SetDefault(f.key, viper.Get(flat))
+		}
+	}
+}
+
+/*
+Sources 返回已配置的配置项及其取值来源，用于启动时排查配置未生效的问题
+需在 LoadConfig 之后调用；未配置（使用代码中零值或默认值）的配置项不返回。
+godotenv 从 .env 文件加载的值已写入进程环境变量，来源显示为 env；
+密钥、密码类配置只显示是否设置，MongoDB URI 中的密码会被隐藏
+*/
+func Sources() []FieldSource {
+	var sources []FieldSource
+	for _, f := range configFields() {
+		source := fieldSource(f)
+		if source == "" {
+			continue
+		}
+		sources = append(sources, FieldSource{
+			Name:   f.name,
+			Source: source,
+			Value:  redactConfigValue(f.name, viper.Get(f.key)),
+		})
+	}
+	return sources
+}
+
+// fieldSource 判断配置项的来源，未配置时返回空字符串
+func fieldSource(f configField) string {
+	if value, ok := os.LookupEnv(f.name); ok && value != "" {
+		return SourceEnv
+	}
+	if viper.InConfig(strings.ToLower(f.name)) {
+		return SourceFile
+	}
+	if _, ok := builtinDefaults[f.key]; ok {
+		return SourceDefault
+	}
+	return ""
+}
+
+// redactConfigValue 隐藏敏感配置的值
+func redactConfigValue(name string, value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" {
+		return s
+	}
+	if strings.Contains(name, "SECRET") || strings.Contains(name, "PASSWORD") || strings.HasSuffix(name, "_KEY") {
+		return redactedValue
+	}
+	if strings.HasSuffix(name, "_URI") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return redactedValue
+		}
+		return u.Redacted()
+	}
+	return s
+}
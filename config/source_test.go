@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// loadTestConfig 在临时目录中写入 .env.test 并加载配置，测试结束后重置viper
+func loadTestConfig(t *testing.T, file string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.test"), []byte(file), 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	t.Chdir(dir)
+	t.Setenv("APP_ENV", "test")
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	return LoadConfig()
+}
+
+func TestSourcesReportsEnvOverride(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9090")
+	t.Setenv("JWT_SECRET", "env-secret")
+	t.Setenv("SERVER_MODE", "")
+	t.Setenv("LOGGER_CONSOLE_OUTPUT", "")
+
+	cfg := loadTestConfig(t, "SERVER_PORT=8080\nSERVER_MODE=release\n")
+	if cfg.Server.Port != "9090" {
+		t.Fatalf("Server.Port = %q, 环境变量应覆盖配置文件", cfg.Server.Port)
+	}
+
+	sources := map[string]FieldSource{}
+	for _, s := range Sources() {
+		sources[s.Name] = s
+	}
+	cases := []struct {
+		name, source, value string
+	}{
+		{"SERVER_PORT", SourceEnv, "9090"},
+		{"SERVER_MODE", SourceFile, "release"},
+		{"LOGGER_CONSOLE_OUTPUT", SourceDefault, "true"},
+		{"JWT_SECRET", SourceEnv, redactedValue},
+	}
+	for _, tc := range cases {
+		got, ok := sources[tc.name]
+		if !ok {
+			t.Errorf("%s 未出现在 Sources() 中", tc.name)
+			continue
+		}
+		if got.Source != tc.source || got.Value != tc.value {
+			t.Errorf("%s: source = %q, value = %q, want %q, %q", tc.name, got.Source, got.Value, tc.source, tc.value)
+		}
+	}
+}
+
+func TestRedactConfigValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"JWT_SECRET", "s3cret", redactedValue},
+		{"MONGODB_PASSWORD", "pw", redactedValue},
+		{"SIGNATURE_APP_KEY", "k", redactedValue},
+		{"JWT_SECRET", "", ""},
+		{"MONGODB_URI", "mongodb://app:pw@db:27017", "mongodb://app:xxxxx@db:27017"},
+		{"SERVER_PORT", 8080, "8080"},
+	}
+	for _, tc := range cases {
+		if got := redactConfigValue(tc.name, tc.value); got != tc.want {
+			t.Errorf("redactConfigValue(%s, %v) = %q, want %q", tc.name, tc.value, got, tc.want)
+		}
+	}
+}
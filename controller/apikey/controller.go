@@ -0,0 +1,96 @@
+package apikey
+
+import (
+	"net/http"
+
+	"go-app/ctxkeys"
+	"go-app/models/apikey"
+	"go-app/models/common"
+	"go-app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller API密钥控制器
+type Controller struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewController 创建API密钥控制器
+func NewController(apiKeyService service.APIKeyService) *Controller {
+	return &Controller{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Create 为当前用户创建API密钥
+func (c *Controller) Create(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req apikey.CreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	key, raw, err := c.apiKeyService.Create(userID, &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, common.SuccessResponse(&apikey.CreateResponse{
+		Response: key.ToResponse(),
+		Key:      raw,
+	}))
+}
+
+// List 获取当前用户的API密钥
+func (c *Controller) List(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	keys, err := c.apiKeyService.List(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		return
+	}
+
+	responses := make([]*apikey.Response, 0, len(keys))
+	for _, k := range keys {
+		responses = append(responses, k.ToResponse())
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(responses))
+}
+
+// Revoke 吊销当前用户的API密钥
+func (c *Controller) Revoke(ctx *gin.Context) {
+	userID, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.apiKeyService.Revoke(userID, ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusNotFound, common.ErrorResponse(404, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(nil))
+}
+
+// currentUser 获取当前用户ID
+// 通过API密钥认证的请求已由路由上的 RequireInteractiveAuth 拒绝
+func (c *Controller) currentUser(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return 0, false
+	}
+	return userID, true
+}
@@ -2,23 +2,68 @@ package controller
 
 import (
 	"go-app/config"
+	"go-app/controller/apikey"
+	"go-app/controller/health"
+	"go-app/controller/security"
+	"go-app/controller/signature"
+	"go-app/controller/system"
 	"go-app/controller/user"
+	"go-app/controller/whitelist"
 	"go-app/database/repositories"
+	"go-app/middleware"
 	"go-app/service"
+	"go-app/utils"
+
+	"go.uber.org/zap"
 )
 
 // Manager 控制器管理器
 type Manager struct {
-	User    *user.Controller
-	
+	User      *user.Controller
+	Whitelist *whitelist.Controller
+	APIKey    *apikey.Controller
+	Security  *security.Controller
+	Signature *signature.Controller
+	Health    *health.Controller
+	System    *system.Controller
+	// 认证中间件依赖，由服务层提供
+	Auth middleware.AuthOptions
+	// 只读模式中间件的状态读取函数，与系统控制器使用同一个系统设置服务
+	ReadOnly middleware.ReadOnlyLoadFunc
 }
 
 // NewManager 初始化所有控制器
 func NewManager(cfg *config.Config, repoManager *repositories.RepositoryManager) *Manager {
 	// 初始化用户服务
-	userService := service.NewUserService(repoManager.User, cfg)
+	userService := service.NewUserService(repoManager.User, repoManager.Audit, repoManager.Session, repoManager.APIKey, repoManager.Transactions, cfg)
+
+	// 初始化白名单服务，并加载持久化的白名单条目
+	whitelistService := service.NewWhitelistService(repoManager.Whitelist, repoManager.Audit)
+	if err := whitelistService.Load(); err != nil {
+		utils.Warn("加载持久化白名单失败", zap.Error(err))
+	}
+
+	// 初始化安全监控服务
+	securityService := service.NewSecurityService(repoManager.LoginEvent, cfg)
+
+	// 初始化系统设置服务
+	systemService := service.NewSystemService(repoManager.Setting, repoManager.Audit)
+
+	// 初始化API密钥服务
+	apiKeyService := service.NewAPIKeyService(repoManager.APIKey, repoManager.User)
 
 	return &Manager{
-		User:    user.NewController(userService, cfg),
+		User:      user.NewController(userService, securityService, cfg),
+		Whitelist: whitelist.NewController(whitelistService),
+		APIKey:    apikey.NewController(apiKeyService),
+		Security:  security.NewController(securityService),
+		Signature: signature.NewController(middleware.NewSignatureConfig(cfg)),
+		Health:    health.NewController(),
+		System:    system.NewController(systemService),
+		Auth: middleware.AuthOptions{
+			TokenValidator:      userService,
+			APIKeyAuthenticator: apiKeyService,
+		},
+		ReadOnly: systemService.IsReadOnly,
 	}
 }
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-app/database"
+	"go-app/models/health"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// pingTimeout 依赖检查的超时时间，负载均衡器的探测间隔通常只有几秒
+const pingTimeout = 2 * time.Second
+
+// PingFunc 依赖检查函数，返回往返耗时和错误
+type PingFunc func(ctx context.Context) (time.Duration, error)
+
+// Controller 健康检查控制器
+type Controller struct {
+	// 检查MongoDB主节点，决定是否就绪
+	pingPrimary PingFunc
+	// 检查MongoDB任一成员，仅用于展示整体连接状态
+	pingAny PingFunc
+}
+
+// NewController 创建健康检查控制器
+func NewController() *Controller {
+	return NewControllerWithPing(database.PingMongoDB, database.PingMongoDBAny)
+}
+
+// NewControllerWithPing 使用指定的检查函数创建健康检查控制器，便于模拟主节点故障等场景
+func NewControllerWithPing(pingPrimary, pingAny PingFunc) *Controller {
+	return &Controller{
+		pingPrimary: pingPrimary,
+		pingAny:     pingAny,
+	}
+}
+
+/*
+Readiness 就绪检查，分别检查MongoDB主节点和任一成员的连通性
+主节点不可用时写操作都会失败，因此即使从节点可用也返回503；mongodb_any 仅用于区分
+"整体连不上"和"只有主节点不可用"，不影响就绪状态。/ping 仅表示进程存活，不检查依赖
+*/
+func (c *Controller) Readiness(ctx *gin.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx.Request.Context(), pingTimeout)
+	defer cancel()
+
+	var primaryStatus, anyStatus health.DependencyStatus
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		primaryStatus = check(pingCtx, c.pingPrimary)
+	}()
+	go func() {
+		defer wg.Done()
+		anyStatus = check(pingCtx, c.pingAny)
+	}()
+	wg.Wait()
+
+	if primaryStatus.Status != health.StatusOK {
+		utils.Warn("就绪检查失败：MongoDB主节点不可用",
+			zap.String("error", primaryStatus.Error),
+			zap.Bool("any_member_reachable", anyStatus.Status == health.StatusOK),
+		)
+	}
+
+	result := health.ReadinessResponse{
+		Status: primaryStatus.Status,
+		Dependencies: map[string]health.DependencyStatus{
+			health.DependencyMongoDB:    primaryStatus,
+			health.DependencyMongoDBAny: anyStatus,
+		},
+	}
+
+	status := http.StatusOK
+	if result.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, result)
+}
+
+// check 执行一次依赖检查并转换为检查结果
+func check(ctx context.Context, ping PingFunc) health.DependencyStatus {
+	result := health.DependencyStatus{Status: health.StatusOK}
+	if database.MongoDB != nil {
+		result.Database = database.MongoDB.Name()
+	}
+
+	latency, err := ping(ctx)
+	result.LatencyMs = float64(latency) / float64(time.Millisecond)
+	if err != nil {
+		result.Status = health.StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
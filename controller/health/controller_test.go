@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-app/models/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+func pingOK(ctx context.Context) (time.Duration, error) { return time.Millisecond, nil }
+
+func pingDown(ctx context.Context) (time.Duration, error) {
+	return 0, errors.New("server selection timeout")
+}
+
+func serveReadiness(t *testing.T, c *Controller) (int, health.ReadinessResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", c.Readiness)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var resp health.ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v, body = %s", err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestReadinessNotReadyWhenPrimaryDown(t *testing.T) {
+	code, resp := serveReadiness(t, NewControllerWithPing(pingDown, pingOK))
+	if code != http.StatusServiceUnavailable || resp.Status != health.StatusUnavailable {
+		t.Fatalf("status = %d, body status = %q, want 503 unavailable", code, resp.Status)
+	}
+	primary := resp.Dependencies[health.DependencyMongoDB]
+	if primary.Status != health.StatusUnavailable || primary.Error == "" {
+		t.Fatalf("%s = %+v", health.DependencyMongoDB, primary)
+	}
+	if member := resp.Dependencies[health.DependencyMongoDBAny]; member.Status != health.StatusOK {
+		t.Fatalf("从节点可用时 %s = %+v, want ok", health.DependencyMongoDBAny, member)
+	}
+}
+
+func TestReadinessReadyWhenPrimaryUp(t *testing.T) {
+	code, resp := serveReadiness(t, NewControllerWithPing(pingOK, pingOK))
+	if code != http.StatusOK || resp.Status != health.StatusOK {
+		t.Fatalf("status = %d, body status = %q, want 200 ok", code, resp.Status)
+	}
+	if len(resp.Dependencies) != 2 {
+		t.Fatalf("dependencies = %v", resp.Dependencies)
+	}
+}
+
+func TestReadinessAllMembersDown(t *testing.T) {
+	code, resp := serveReadiness(t, NewControllerWithPing(pingDown, pingDown))
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", code)
+	}
+	if member := resp.Dependencies[health.DependencyMongoDBAny]; member.Status != health.StatusUnavailable {
+		t.Fatalf("%s = %+v, want unavailable", health.DependencyMongoDBAny, member)
+	}
+}
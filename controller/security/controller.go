@@ -0,0 +1,40 @@
+package security
+
+import (
+	"net/http"
+
+	"go-app/models/common"
+	"go-app/models/security"
+	"go-app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller 安全监控控制器
+type Controller struct {
+	securityService service.SecurityService
+}
+
+// NewController 创建安全监控控制器
+func NewController(securityService service.SecurityService) *Controller {
+	return &Controller{
+		securityService: securityService,
+	}
+}
+
+// FailedLogins 按时间段统计登录失败次数（管理员）
+func (c *Controller) FailedLogins(ctx *gin.Context) {
+	var query security.FailedLoginQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	stats, err := c.securityService.FailedLoginStats(&query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(stats))
+}
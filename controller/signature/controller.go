@@ -0,0 +1,56 @@
+package signature
+
+import (
+	"errors"
+	"net/http"
+
+	"go-app/middleware"
+	"go-app/models/common"
+	"go-app/models/signature"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller 签名调试控制器
+type Controller struct {
+	config *middleware.SignatureConfig
+}
+
+// NewController 创建签名调试控制器
+func NewController(config *middleware.SignatureConfig) *Controller {
+	return &Controller{
+		config: config,
+	}
+}
+
+// Verify 校验请求签名但不执行任何操作，供接入方排查签名问题
+// 参数传递方式与正常请求相同；不记录nonce，同一组参数可以反复校验；
+// debug模式下返回服务端的签名字符串（密钥已隐藏），不会返回服务端计算出的签名值
+func (c *Controller) Verify(ctx *gin.Context) {
+	algorithm := c.config.Algorithm
+	if algorithm == "" {
+		algorithm = utils.SignatureAlgoMD5
+	}
+	if !utils.IsSupportedSignatureAlgo(algorithm) {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, "签名算法配置错误"))
+		return
+	}
+
+	result := signature.VerifyResponse{Valid: true, Algorithm: algorithm}
+
+	_, signParams, err := middleware.VerifySignature(ctx, c.config)
+	if err != nil {
+		result.Valid = false
+		result.Reason = err.Error()
+		var sigErr *middleware.SignatureError
+		if errors.As(err, &sigErr) && sigErr.Err != nil {
+			result.Reason += ": " + sigErr.Err.Error()
+		}
+	}
+	if gin.IsDebugging() && signParams != nil {
+		result.SignString = utils.SignatureString(signParams, algorithm)
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
@@ -0,0 +1,93 @@
+package signature
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-app/middleware"
+	"go-app/models/signature"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAppKey    = "test-app"
+	testAppSecret = "test-secret-value"
+)
+
+func verify(t *testing.T, mode string, params map[string]string) signature.VerifyResponse {
+	t.Helper()
+	gin.SetMode(mode)
+	defer gin.SetMode(gin.TestMode)
+
+	ctrl := NewController(&middleware.SignatureConfig{
+		Enable:    true,
+		AppKey:    testAppKey,
+		AppSecret: testAppSecret,
+		Expire:    time.Minute,
+	})
+	r := gin.New()
+	r.POST("/verify", ctrl.Verify)
+
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/verify?"+query.Encode(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data signature.VerifyResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if strings.Contains(w.Body.String(), testAppSecret) {
+		t.Fatal("响应中不应包含签名密钥")
+	}
+	return resp.Data
+}
+
+func TestVerifyValidSignature(t *testing.T) {
+	params := utils.GenerateAPIParams(testAppKey, testAppSecret, map[string]string{"name": "alice"})
+
+	got := verify(t, gin.TestMode, params)
+	if !got.Valid || got.Reason != "" {
+		t.Fatalf("valid = %v, reason = %q", got.Valid, got.Reason)
+	}
+	if got.SignString != "" {
+		t.Fatal("非debug模式不应返回签名字符串")
+	}
+}
+
+func TestVerifyInvalidSignature(t *testing.T) {
+	params := utils.GenerateAPIParams(testAppKey, testAppSecret, map[string]string{"name": "alice"})
+	params["name"] = "mallory"
+
+	got := verify(t, gin.TestMode, params)
+	if got.Valid || got.Reason == "" {
+		t.Fatalf("valid = %v, reason = %q", got.Valid, got.Reason)
+	}
+}
+
+func TestVerifyDebugReturnsMaskedSignString(t *testing.T) {
+	params := utils.GenerateAPIParams(testAppKey, testAppSecret, map[string]string{"name": "alice"})
+	params["sign"] = "0000"
+
+	got := verify(t, gin.DebugMode, params)
+	if got.Valid {
+		t.Fatal("错误的签名不应通过")
+	}
+	if !strings.Contains(got.SignString, "name=alice") {
+		t.Fatalf("sign_string = %q", got.SignString)
+	}
+}
@@ -0,0 +1,89 @@
+package system
+
+import (
+	"net/http"
+
+	"go-app/ctxkeys"
+	"go-app/models/common"
+	"go-app/models/system"
+	"go-app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller 系统设置控制器
+type Controller struct {
+	systemService service.SystemService
+}
+
+// NewController 创建系统设置控制器
+func NewController(systemService service.SystemService) *Controller {
+	return &Controller{
+		systemService: systemService,
+	}
+}
+
+// GetReadOnly 查询只读模式状态（管理员）
+func (c *Controller) GetReadOnly(ctx *gin.Context) {
+	setting, err := c.systemService.ReadOnly(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(setting.ToResponse()))
+}
+
+// SetReadOnly 开启或关闭只读模式（管理员），只读模式下该接口仍然可用
+func (c *Controller) SetReadOnly(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	var req system.ReadOnlyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	setting, err := c.systemService.SetReadOnly(ctx.Request.Context(), *req.Enabled, req.Reason, operatorID, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(setting.ToResponse()))
+}
+
+// GetLogLevel 查询当前实例的日志级别（管理员）
+func (c *Controller) GetLogLevel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, common.SuccessResponse(&system.LogLevelResponse{
+		Level: c.systemService.LogLevel(),
+	}))
+}
+
+// SetLogLevel 修改当前实例的日志级别（管理员），立即生效，重启后恢复为配置值
+func (c *Controller) SetLogLevel(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	var req system.LogLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	if err := c.systemService.SetLogLevel(req.Level, operatorID, ctx.ClientIP()); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.GetLogLevel(ctx)
+}
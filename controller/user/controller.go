@@ -1,10 +1,19 @@
 package user
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"go-app/config"
+	"go-app/ctxkeys"
+	"go-app/middleware"
+	"go-app/models/audit"
 	"go-app/models/common"
 	"go-app/models/user"
 	"go-app/service"
@@ -14,15 +23,17 @@ import (
 
 // Controller 用户控制器
 type Controller struct {
-	userService service.UserService
-	cfg         *config.Config
+	userService     service.UserService
+	securityService service.SecurityService
+	cfg             *config.Config
 }
 
 // NewController 创建用户控制器
-func NewController(userService service.UserService, cfg *config.Config) *Controller {
+func NewController(userService service.UserService, securityService service.SecurityService, cfg *config.Config) *Controller {
 	return &Controller{
-		userService: userService,
-		cfg:         cfg,
+		userService:     userService,
+		securityService: securityService,
+		cfg:             cfg,
 	}
 }
 
@@ -31,14 +42,15 @@ func (c *Controller) Register(ctx *gin.Context) {
 	// 从上下文获取验证后的数据
 	var req user.RegisterRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		ctx.JSON(http.StatusBadRequest, middleware.BindErrorResponse(err))
 		return
 	}
 
 	// 调用服务层注册用户
-	u, err := c.userService.Register(&req)
+	u, err := c.userService.Register(ctx.Request.Context(), &req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		status := statusFromError(err, http.StatusBadRequest)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -46,6 +58,31 @@ func (c *Controller) Register(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, common.SuccessResponse(u.ToProfileResponse()))
 }
 
+// BatchRegister 批量创建用户（管理员），请求体为注册请求数组，已由 ValidateJSONSlice 逐个校验
+func (c *Controller) BatchRegister(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	reqs, ok := middleware.GetValidatedData(ctx).(*[]user.RegisterRequest)
+	if !ok {
+		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, "请求数据未经校验"))
+		return
+	}
+
+	result, err := c.userService.BatchRegister(ctx.Request.Context(), *reqs, operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
+
 // Login 用户登录
 func (c *Controller) Login(ctx *gin.Context) {
 	// 从上下文获取验证后的数据
@@ -56,9 +93,14 @@ func (c *Controller) Login(ctx *gin.Context) {
 	}
 
 	// 调用服务层登录
-	u, token, err := c.userService.Login(&req)
+	u, token, err := c.userService.Login(ctx.Request.Context(), &req, ctx.ClientIP())
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, err.Error()))
+		// 会话数达到上限或需要重置密码时密码是正确的，不计入登录失败
+		if !errors.Is(err, service.ErrTooManySessions) && !errors.Is(err, service.ErrPasswordResetRequired) {
+			c.securityService.RecordFailedLogin(req.Username, ctx.ClientIP())
+		}
+		status := statusFromError(err, http.StatusUnauthorized)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -75,17 +117,64 @@ func (c *Controller) Login(ctx *gin.Context) {
 	}))
 }
 
+// ResetPassword 使用重置令牌设置新密码
+func (c *Controller) ResetPassword(ctx *gin.Context) {
+	var req user.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, middleware.BindErrorResponse(err))
+		return
+	}
+
+	if err := c.userService.ResetPassword(ctx.Request.Context(), &req, ctx.ClientIP()); err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.NewResponse(200, "密码已重置，请使用新密码登录", nil))
+}
+
+// ValidateToken 校验当前令牌，返回当前用户和令牌剩余有效期
+func (c *Controller) ValidateToken(ctx *gin.Context) {
+	// 获取令牌
+	token, err := middleware.ExtractBearerToken(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, err.Error()))
+		return
+	}
+
+	// 调用服务层校验令牌
+	u, expiresAt, err := c.userService.ValidateToken(ctx.Request.Context(), token)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, err.Error()))
+		return
+	}
+
+	// 计算剩余有效秒数
+	expiresIn := 0
+	if !expiresAt.IsZero() {
+		expiresIn = int(time.Until(expiresAt).Seconds())
+	}
+
+	// 返回成功响应
+	ctx.JSON(http.StatusOK, common.SuccessResponse(&user.TokenValidationResponse{
+		User:      u.ToProfileResponse(),
+		ExpiresAt: expiresAt,
+		ExpiresIn: expiresIn,
+	}))
+}
+
 // GetProfile 获取当前用户资料
 func (c *Controller) GetProfile(ctx *gin.Context) {
 	// 获取当前用户ID
-	userID, exists := ctx.Get("userID")
+	userID, exists := ctxkeys.UserID(ctx)
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
 		return
 	}
 
 	// 调用服务层获取用户信息
-	u, err := c.userService.GetUserByID(userID.(uint))
+	u, err := c.userService.GetUserByID(ctx.Request.Context(), userID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, common.ErrorResponse(404, err.Error()))
 		return
@@ -96,6 +185,9 @@ func (c *Controller) GetProfile(ctx *gin.Context) {
 }
 
 // GetUsers 获取用户列表
+// 带 cursor 参数（可为空）时使用游标分页，返回 next_cursor；否则按页码分页
+// role 参数可重复或以逗号分隔（如 role=admin,user），匹配其中任意一个角色；verified=true/false 按邮箱验证状态过滤
+// search 参数使用全文索引搜索，结果按相关度排序，仅支持页码分页
 func (c *Controller) GetUsers(ctx *gin.Context) {
 	// 获取分页参数
 	var params common.PaginationParams
@@ -104,13 +196,44 @@ func (c *Controller) GetUsers(ctx *gin.Context) {
 	}
 
 	// 获取搜索参数
-	keyword := ctx.Query("keyword")
-	status, _ := strconv.Atoi(ctx.Query("status"))
+	filter, err := queryListFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+	filter.SortBy = strings.TrimSpace(ctx.Query("sort_by"))
+	filter.Order = strings.TrimSpace(ctx.Query("order"))
+
+	if cursor, ok := ctx.GetQuery("cursor"); ok {
+		// 游标分页不使用页码，单独读取每页数量
+		pageSize, _ := strconv.Atoi(ctx.Query("page_size"))
+		users, next, err := c.userService.GetUsersAfter(ctx.Request.Context(), cursor, pageSize, filter)
+		if err != nil {
+			if errors.Is(err, common.ErrInvalidCursor) {
+				ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+				return
+			}
+			code := statusFromError(err, http.StatusInternalServerError)
+			ctx.JSON(code, common.ErrorResponse(code, err.Error()))
+			return
+		}
+
+		userResponses := make([]*user.Response, 0, len(users))
+		for _, u := range users {
+			userResponses = append(userResponses, u.ToResponse())
+		}
+
+		ctx.JSON(http.StatusOK, common.SuccessResponse(
+			common.NewCursorPaginatedResponse(userResponses, next, next != ""),
+		))
+		return
+	}
 
 	// 调用服务层获取用户列表
-	users, total, err := c.userService.GetUsers(params.Page, params.PageSize, keyword, status)
+	users, total, err := c.userService.GetUsers(ctx.Request.Context(), params.Page, params.PageSize, filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		code := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(code, common.ErrorResponse(code, err.Error()))
 		return
 	}
 
@@ -131,6 +254,56 @@ func (c *Controller) GetUsers(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, common.SuccessResponse(paginatedResponse))
 }
 
+// CountUsers 统计符合过滤条件的用户数（管理员），过滤参数与用户列表相同
+func (c *Controller) CountUsers(ctx *gin.Context) {
+	filter, err := queryListFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	count, err := c.userService.CountUsers(ctx.Request.Context(), filter)
+	if err != nil {
+		code := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(code, common.ErrorResponse(code, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(gin.H{"count": count}))
+}
+
+// queryListFilter 读取用户列表的过滤参数：keyword、search、status、role、verified
+func queryListFilter(ctx *gin.Context) (user.ListFilter, error) {
+	status, _ := strconv.Atoi(ctx.Query("status"))
+	filter := user.ListFilter{
+		Keyword: ctx.Query("keyword"),
+		Search:  strings.TrimSpace(ctx.Query("search")),
+		Status:  status,
+		Roles:   queryRoles(ctx),
+	}
+	if v := ctx.Query("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("verified 参数只能是 true 或 false")
+		}
+		filter.EmailVerified = &verified
+	}
+	return filter, nil
+}
+
+// queryRoles 读取 role 查询参数，支持重复参数和逗号分隔，忽略空值
+func queryRoles(ctx *gin.Context) []string {
+	var roles []string
+	for _, value := range ctx.QueryArray("role") {
+		for _, role := range strings.Split(value, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
 // GetUser 获取用户详情
 func (c *Controller) GetUser(ctx *gin.Context) {
 	// 获取用户ID
@@ -142,7 +315,7 @@ func (c *Controller) GetUser(ctx *gin.Context) {
 	}
 
 	// 调用服务层获取用户
-	u, err := c.userService.GetUserByID(uint(id))
+	u, err := c.userService.GetUserByID(ctx.Request.Context(), uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, common.ErrorResponse(404, err.Error()))
 		return
@@ -152,10 +325,10 @@ func (c *Controller) GetUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, common.SuccessResponse(u.ToResponse()))
 }
 
-// UpdateProfile 更新用户资料
+// UpdateProfile 整体更新用户资料
 func (c *Controller) UpdateProfile(ctx *gin.Context) {
 	// 获取当前用户ID
-	userID, exists := ctx.Get("userID")
+	userID, exists := ctxkeys.UserID(ctx)
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
 		return
@@ -169,9 +342,38 @@ func (c *Controller) UpdateProfile(ctx *gin.Context) {
 	}
 
 	// 调用服务层更新资料
-	u, err := c.userService.UpdateProfile(userID.(uint), &req)
+	u, err := c.userService.UpdateProfile(ctx.Request.Context(), userID, &req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	// 返回成功响应
+	ctx.JSON(http.StatusOK, common.SuccessResponse(u.ToProfileResponse()))
+}
+
+// PatchProfile 部分更新用户资料
+func (c *Controller) PatchProfile(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取请求数据
+	var req user.PatchProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	// 调用服务层更新资料
+	u, err := c.userService.PatchProfile(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -182,7 +384,7 @@ func (c *Controller) UpdateProfile(ctx *gin.Context) {
 // ChangePassword 修改密码
 func (c *Controller) ChangePassword(ctx *gin.Context) {
 	// 获取当前用户ID
-	userID, exists := ctx.Get("userID")
+	userID, exists := ctxkeys.UserID(ctx)
 	if !exists {
 		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
 		return
@@ -191,14 +393,15 @@ func (c *Controller) ChangePassword(ctx *gin.Context) {
 	// 获取请求数据
 	var req user.ChangePasswordRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		ctx.JSON(http.StatusBadRequest, middleware.BindErrorResponse(err))
 		return
 	}
 
 	// 调用服务层修改密码
-	err := c.userService.ChangePassword(userID.(uint), &req)
+	err := c.userService.ChangePassword(ctx.Request.Context(), userID, &req, ctx.ClientIP())
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		status := statusFromError(err, http.StatusBadRequest)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
 		return
 	}
 
@@ -206,6 +409,29 @@ func (c *Controller) ChangePassword(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, common.SuccessResponse(nil))
 }
 
+// ResendVerification 重新发送当前用户的邮箱验证邮件，冷却期内重复请求返回429
+func (c *Controller) ResendVerification(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	alreadyVerified, err := c.userService.ResendVerification(ctx.Request.Context(), userID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	if alreadyVerified {
+		ctx.JSON(http.StatusOK, common.NewResponse(200, "邮箱已验证，无需重新发送", nil))
+		return
+	}
+	ctx.JSON(http.StatusOK, common.NewResponse(200, "验证邮件已发送", nil))
+}
+
 // DeleteUser 删除用户
 func (c *Controller) DeleteUser(ctx *gin.Context) {
 	// 获取用户ID
@@ -217,7 +443,7 @@ func (c *Controller) DeleteUser(ctx *gin.Context) {
 	}
 
 	// 调用服务层删除用户
-	if err := c.userService.DeleteUser(uint(id)); err != nil {
+	if err := c.userService.DeleteUser(ctx.Request.Context(), uint(id)); err != nil {
 		ctx.JSON(http.StatusInternalServerError, common.ErrorResponse(500, err.Error()))
 		return
 	}
@@ -225,3 +451,311 @@ func (c *Controller) DeleteUser(ctx *gin.Context) {
 	// 返回成功响应
 	ctx.JSON(http.StatusOK, common.SuccessResponse(nil))
 }
+
+// RestoreUser 恢复已删除的用户（管理员）
+func (c *Controller) RestoreUser(ctx *gin.Context) {
+	c.adminUserAction(ctx, c.userService.RestoreUser)
+}
+
+// HardDeleteUser 永久删除用户（管理员）
+func (c *Controller) HardDeleteUser(ctx *gin.Context) {
+	c.adminUserAction(ctx, c.userService.HardDeleteUser)
+}
+
+// adminUserAction 解析路径中的用户ID并执行管理员操作
+func (c *Controller) adminUserAction(ctx *gin.Context, action func(ctx context.Context, id uint, operatorID uint) error) {
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "无效的用户ID"))
+		return
+	}
+
+	if err := action(ctx.Request.Context(), uint(id), operatorID); err != nil {
+		status := statusFromError(err, http.StatusBadRequest)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(nil))
+}
+
+// MergeUsers 合并用户账户（管理员）
+func (c *Controller) MergeUsers(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取请求数据
+	var req user.MergeUsersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	// 调用服务层合并账户
+	result, err := c.userService.MergeUsers(ctx.Request.Context(), &req, operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusBadRequest)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	// 返回成功响应
+	ctx.JSON(http.StatusOK, common.SuccessResponse(&user.MergeResponse{
+		User:             result.Target.ToResponse(),
+		Conflicts:        result.Conflicts,
+		ReassignedAudits: result.ReassignedAudits,
+		RevokedSessions:  result.RevokedSessions,
+	}))
+}
+
+// BulkUpdateUsers 按过滤条件批量更新用户，dry_run 为true时只返回匹配的用户数
+func (c *Controller) BulkUpdateUsers(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取请求数据
+	var req user.BulkUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := c.userService.BulkUpdateUsers(ctx.Request.Context(), &req, operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
+
+// BulkVerifyUsers 按过滤条件批量将用户标记为邮箱已验证
+func (c *Controller) BulkVerifyUsers(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取请求数据
+	var req user.BulkVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := c.userService.BulkVerifyUsers(ctx.Request.Context(), &req, operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
+
+// ForcePasswordReset 按过滤条件强制用户重置密码，返回重置统计
+func (c *Controller) ForcePasswordReset(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取请求数据
+	var req user.ForcePasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := c.userService.ForcePasswordReset(ctx.Request.Context(), &req, operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
+
+// RehashPasswords 在后台启动密码批量迁移任务，返回202和任务的初始状态
+func (c *Controller) RehashPasswords(ctx *gin.Context) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	result, err := c.userService.StartRehashPasswords(operatorID)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, common.SuccessResponse(result))
+}
+
+// RehashPasswordsStatus 查询密码批量迁移任务的状态和进度
+func (c *Controller) RehashPasswordsStatus(ctx *gin.Context) {
+	result, err := c.userService.RehashPasswordsStatus()
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(result))
+}
+
+// GetActivity 分页获取当前用户的操作记录，支持 from、to 时间过滤
+func (c *Controller) GetActivity(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 获取分页参数
+	var params common.PaginationParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		params = *common.GetDefaultPagination()
+	}
+
+	// 获取时间范围，日期格式的 to 包含当天
+	var filter audit.ActivityFilter
+	var err error
+	if filter.Since, err = queryTime(ctx, "from", false); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+	if filter.Until, err = queryTime(ctx, "to", true); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	entries, total, err := c.userService.GetActivity(ctx.Request.Context(), userID, params.Page, params.PageSize, filter)
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	activities := make([]*audit.ActivityResponse, 0, len(entries))
+	for _, e := range entries {
+		activities = append(activities, e.ToActivityResponse())
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(
+		common.NewPaginatedResponse(total, params.Page, params.PageSize, activities),
+	))
+}
+
+/*
+queryTime 读取时间查询参数，支持 RFC3339（如 2024-01-02T15:04:05Z）和日期（如 2024-01-02，按UTC计算）
+name: 参数名
+endOfDay: 日期格式时是否取次日零点，用于包含当天的结束时间
+返回: 时间（参数未提供时为nil）, 错误
+*/
+func queryTime(ctx *gin.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s 参数格式错误，应为RFC3339时间或 YYYY-MM-DD 日期", name)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// ExportData 导出当前用户的个人数据，以JSON文件形式下载
+func (c *Controller) ExportData(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	// 调用服务层汇总个人数据
+	bundle, err := c.userService.ExportUserData(ctx.Request.Context(), userID, ctx.ClientIP())
+	if err != nil {
+		status := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(status, common.ErrorResponse(status, err.Error()))
+		return
+	}
+
+	// 以附件形式直接写出，避免整体序列化到内存后再写出
+	filename := fmt.Sprintf("user-%d-export-%s.json", userID, bundle.ExportedAt.Format("20060102150405"))
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	encoder := json.NewEncoder(ctx.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// RegistrationStats 按天统计注册用户数（管理员）
+func (c *Controller) RegistrationStats(ctx *gin.Context) {
+	var query user.RegistrationStatsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	stats, err := c.userService.RegistrationStats(ctx.Request.Context(), &query)
+	if err != nil {
+		code := statusFromError(err, http.StatusInternalServerError)
+		ctx.JSON(code, common.ErrorResponse(code, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(stats))
+}
+
+// GetDistinctValues 获取字段的不重复取值（管理员），用于筛选下拉框
+func (c *Controller) GetDistinctValues(ctx *gin.Context) {
+	field := ctx.Param("field")
+
+	values, err := c.userService.DistinctValues(ctx.Request.Context(), field)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(&user.DistinctResponse{
+		Field:  field,
+		Values: values,
+	}))
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterRejectsInvalidFieldsWithDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 参数校验失败时不会调用服务层
+	ctrl := NewController(nil, nil, nil)
+	r := gin.New()
+	r.POST("/register", ctrl.Register)
+
+	body := `{"username":"alice","email":"not-an-email","password":"abcdef"}`
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Code    int                     `json:"code"`
+		Details []middleware.FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("code = %d, want 400", resp.Code)
+	}
+
+	details := make(map[string]middleware.FieldError, len(resp.Details))
+	for _, d := range resp.Details {
+		details[d.Field] = d
+	}
+	if len(details) != 2 {
+		t.Fatalf("details = %+v, want email 和 password 两个字段", resp.Details)
+	}
+	if d := details["email"]; d.Rule != "email" || d.Message == "" {
+		t.Errorf("email: %+v", d)
+	}
+	if d := details["password"]; d.Rule != "password_strength" || d.Message == "" || d.Message == "密码强度不足" {
+		t.Errorf("password 应给出具体的强度要求: %+v", d)
+	}
+}
@@ -0,0 +1,50 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"go-app/database"
+	"go-app/database/repositories"
+	"go-app/service"
+)
+
+/*
+statusFromError 根据服务层返回的错误确定HTTP状态码
+err: 服务层错误
+fallback: 未识别的错误使用的状态码
+返回: HTTP状态码
+*/
+func statusFromError(err error, fallback int) int {
+	switch {
+	case errors.Is(err, repositories.ErrDocumentValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrWriteConcernTimeout),
+		errors.Is(err, database.ErrTransactionsUnsupported):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrRehashNotStarted):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRehashRunning):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrTooManyAttempts), errors.Is(err, service.ErrTooManySessions):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrAccountLocked):
+		return http.StatusLocked
+	case errors.Is(err, service.ErrPasswordResetRequired):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrBulkUpdateUnconfirmed),
+		errors.Is(err, service.ErrBulkUpdateEmptyPatch),
+		errors.Is(err, service.ErrBulkUpdateTooLarge),
+		errors.Is(err, service.ErrBatchTooLarge),
+		errors.Is(err, service.ErrInvalidTimeRange),
+		errors.Is(err, service.ErrStatsRangeTooLarge),
+		errors.Is(err, service.ErrSearchWithCursor),
+		errors.Is(err, service.ErrSortWithCursor),
+		errors.Is(err, service.ErrInvalidSortField),
+		errors.Is(err, service.ErrInvalidSortOrder),
+		errors.Is(err, service.ErrInvalidResetToken):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
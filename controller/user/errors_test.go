@@ -0,0 +1,38 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-app/database/repositories"
+	"go-app/service"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStatusFromWriteError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"文档校验失败", &repositories.WriteError{Kind: repositories.ErrDocumentValidation, Code: 121, Err: mongo.WriteException{}}, http.StatusBadRequest},
+		{"写入确认超时", &repositories.WriteError{Kind: repositories.ErrWriteConcernTimeout, Code: 64, Err: mongo.WriteException{}}, http.StatusServiceUnavailable},
+		{"服务层包装后的错误", fmt.Errorf("更新用户资料失败: %w", &repositories.WriteError{Kind: repositories.ErrDocumentValidation, Err: errors.New("x")}), http.StatusBadRequest},
+		{"未分类的错误", errors.New("网络错误"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if got := statusFromError(tc.err, http.StatusInternalServerError); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStatusFromInvalidRole(t *testing.T) {
+	err := fmt.Errorf("%w: %s", service.ErrInvalidRole, "root")
+	if got := statusFromError(err, http.StatusInternalServerError); got != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", got)
+	}
+}
@@ -0,0 +1,114 @@
+package whitelist
+
+import (
+	"net/http"
+
+	"go-app/ctxkeys"
+	"go-app/models/common"
+	"go-app/models/whitelist"
+	"go-app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller 白名单控制器
+type Controller struct {
+	whitelistService service.WhitelistService
+}
+
+// NewController 创建白名单控制器
+func NewController(whitelistService service.WhitelistService) *Controller {
+	return &Controller{
+		whitelistService: whitelistService,
+	}
+}
+
+// ListIPs 获取IP白名单（管理员）
+func (c *Controller) ListIPs(ctx *gin.Context) {
+	c.list(ctx, whitelist.TypeIP)
+}
+
+// AddIP 添加IP白名单（管理员）
+func (c *Controller) AddIP(ctx *gin.Context) {
+	c.add(ctx, whitelist.TypeIP)
+}
+
+// RemoveIP 移除IP白名单（管理员）
+func (c *Controller) RemoveIP(ctx *gin.Context) {
+	c.remove(ctx, whitelist.TypeIP)
+}
+
+// ListPaths 获取路径白名单（管理员）
+func (c *Controller) ListPaths(ctx *gin.Context) {
+	c.list(ctx, whitelist.TypePath)
+}
+
+// AddPath 添加路径白名单（管理员）
+func (c *Controller) AddPath(ctx *gin.Context) {
+	c.add(ctx, whitelist.TypePath)
+}
+
+// RemovePath 移除路径白名单（管理员）
+func (c *Controller) RemovePath(ctx *gin.Context) {
+	c.remove(ctx, whitelist.TypePath)
+}
+
+// list 返回指定类型的白名单
+func (c *Controller) list(ctx *gin.Context, entryType string) {
+	entries, err := c.whitelistService.List(entryType)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, common.SuccessResponse(&whitelist.ListResponse{
+		Type:    entryType,
+		Entries: entries,
+	}))
+}
+
+// add 添加指定类型的白名单条目
+func (c *Controller) add(ctx *gin.Context, entryType string) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	var req whitelist.EntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	if err := c.whitelistService.Add(entryType, req.Value, operatorID, ctx.ClientIP()); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.list(ctx, entryType)
+}
+
+// remove 移除指定类型的白名单条目，条目通过查询参数 value 指定
+func (c *Controller) remove(ctx *gin.Context, entryType string) {
+	// 获取当前操作人ID
+	operatorID, exists := ctxkeys.UserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, common.ErrorResponse(401, "未授权"))
+		return
+	}
+
+	var req whitelist.EntryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, "请求参数错误: "+err.Error()))
+		return
+	}
+
+	if err := c.whitelistService.Remove(entryType, req.Value, operatorID, ctx.ClientIP()); err != nil {
+		ctx.JSON(http.StatusBadRequest, common.ErrorResponse(400, err.Error()))
+		return
+	}
+
+	c.list(ctx, entryType)
+}
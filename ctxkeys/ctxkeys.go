@@ -0,0 +1,120 @@
+// Package ctxkeys 定义 gin.Context 中存储的键，并提供类型安全的读写函数，
+// 避免直接使用字符串键导致的拼写错误、键冲突以及类型断言失败引发的panic
+package ctxkeys
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文键，统一加包名前缀以避免与其他中间件冲突
+const (
+	userIDKey          = "ctxkeys.user_id"
+	requestIDKey       = "ctxkeys.request_id"
+	signatureParamsKey = "ctxkeys.signature_params"
+	validatedDataKey   = "ctxkeys.validated_data"
+	validatedQueryKey  = "ctxkeys.validated_query"
+	validatedParamsKey = "ctxkeys.validated_params"
+	apiKeyScopesKey    = "ctxkeys.api_key_scopes"
+	userRoleKey        = "ctxkeys.user_role"
+	clientRequestIDKey = "ctxkeys.client_request_id"
+)
+
+// SetUserID 设置当前认证用户ID
+func SetUserID(c *gin.Context, id uint) {
+	c.Set(userIDKey, id)
+}
+
+// UserID 获取当前认证用户ID，未设置或类型不符时返回 false
+func UserID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get(userIDKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+// SetUserRole 设置当前认证用户的角色
+func SetUserRole(c *gin.Context, role string) {
+	c.Set(userRoleKey, role)
+}
+
+// UserRole 获取当前认证用户的角色，未设置时返回空字符串
+func UserRole(c *gin.Context) string {
+	return c.GetString(userRoleKey)
+}
+
+// SetRequestID 设置请求ID
+func SetRequestID(c *gin.Context, id string) {
+	c.Set(requestIDKey, id)
+}
+
+// RequestID 获取请求ID，未设置时返回空字符串
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// SetClientRequestID 设置客户端传入的原始请求ID，仅在请求ID被替换时设置
+func SetClientRequestID(c *gin.Context, id string) {
+	c.Set(clientRequestIDKey, id)
+}
+
+// ClientRequestID 获取客户端传入的原始请求ID，请求ID未被替换时返回空字符串
+func ClientRequestID(c *gin.Context) string {
+	return c.GetString(clientRequestIDKey)
+}
+
+// SetSignatureParams 设置签名参数
+func SetSignatureParams(c *gin.Context, params interface{}) {
+	c.Set(signatureParamsKey, params)
+}
+
+// SignatureParams 获取签名参数
+func SignatureParams(c *gin.Context) (interface{}, bool) {
+	return c.Get(signatureParamsKey)
+}
+
+// SetValidatedData 设置校验后的请求体
+func SetValidatedData(c *gin.Context, data interface{}) {
+	c.Set(validatedDataKey, data)
+}
+
+// ValidatedData 获取校验后的请求体
+func ValidatedData(c *gin.Context) (interface{}, bool) {
+	return c.Get(validatedDataKey)
+}
+
+// SetValidatedQuery 设置校验后的查询参数
+func SetValidatedQuery(c *gin.Context, query interface{}) {
+	c.Set(validatedQueryKey, query)
+}
+
+// ValidatedQuery 获取校验后的查询参数
+func ValidatedQuery(c *gin.Context) (interface{}, bool) {
+	return c.Get(validatedQueryKey)
+}
+
+// SetValidatedParams 设置校验后的路径参数
+func SetValidatedParams(c *gin.Context, params interface{}) {
+	c.Set(validatedParamsKey, params)
+}
+
+// ValidatedParams 获取校验后的路径参数
+func ValidatedParams(c *gin.Context) (interface{}, bool) {
+	return c.Get(validatedParamsKey)
+}
+
+// SetAPIKeyScopes 设置通过API密钥认证时密钥的权限范围
+func SetAPIKeyScopes(c *gin.Context, scopes []string) {
+	c.Set(apiKeyScopesKey, scopes)
+}
+
+// APIKeyScopes 获取API密钥的权限范围，未通过API密钥认证时返回 false
+func APIKeyScopes(c *gin.Context) ([]string, bool) {
+	v, ok := c.Get(apiKeyScopesKey)
+	if !ok {
+		return nil, false
+	}
+	scopes, ok := v.([]string)
+	return scopes, ok
+}
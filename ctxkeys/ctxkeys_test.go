@@ -0,0 +1,88 @@
+package ctxkeys
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	return c
+}
+
+func TestAccessorsRoundTrip(t *testing.T) {
+	c := newTestContext()
+	SetUserID(c, 42)
+	SetUserRole(c, "admin")
+	SetRequestID(c, "req-1")
+	SetClientRequestID(c, "client-1")
+	SetAPIKeyScopes(c, []string{"read"})
+	SetSignatureParams(c, "sig")
+	SetValidatedData(c, "data")
+	SetValidatedQuery(c, "query")
+	SetValidatedParams(c, "params")
+
+	if id, ok := UserID(c); !ok || id != 42 {
+		t.Errorf("UserID = %d, %v", id, ok)
+	}
+	if got := UserRole(c); got != "admin" {
+		t.Errorf("UserRole = %q", got)
+	}
+	if got := RequestID(c); got != "req-1" {
+		t.Errorf("RequestID = %q", got)
+	}
+	if got := ClientRequestID(c); got != "client-1" {
+		t.Errorf("ClientRequestID = %q", got)
+	}
+	if scopes, ok := APIKeyScopes(c); !ok || !reflect.DeepEqual(scopes, []string{"read"}) {
+		t.Errorf("APIKeyScopes = %v, %v", scopes, ok)
+	}
+	getters := map[string]func(*gin.Context) (interface{}, bool){
+		"sig":    SignatureParams,
+		"data":   ValidatedData,
+		"query":  ValidatedQuery,
+		"params": ValidatedParams,
+	}
+	for want, get := range getters {
+		if v, ok := get(c); !ok || v != want {
+			t.Errorf("got %v, %v, want %q", v, ok, want)
+		}
+	}
+}
+
+func TestAccessorsMissingKey(t *testing.T) {
+	c := newTestContext()
+
+	if id, ok := UserID(c); ok || id != 0 {
+		t.Errorf("UserID = %d, %v, want 0, false", id, ok)
+	}
+	if scopes, ok := APIKeyScopes(c); ok || scopes != nil {
+		t.Errorf("APIKeyScopes = %v, %v, want nil, false", scopes, ok)
+	}
+	if UserRole(c) != "" || RequestID(c) != "" || ClientRequestID(c) != "" {
+		t.Error("未设置的字符串键应返回空字符串")
+	}
+	for _, get := range []func(*gin.Context) (interface{}, bool){SignatureParams, ValidatedData, ValidatedQuery, ValidatedParams} {
+		if _, ok := get(c); ok {
+			t.Error("未设置的键应返回 false")
+		}
+	}
+}
+
+func TestUserIDWrongTypeDoesNotPanic(t *testing.T) {
+	c := newTestContext()
+	// 其他代码以相同的键写入了错误类型的值
+	c.Set(userIDKey, "42")
+	c.Set(apiKeyScopesKey, "read")
+
+	if _, ok := UserID(c); ok {
+		t.Error("类型不符时 UserID 应返回 false")
+	}
+	if _, ok := APIKeyScopes(c); ok {
+		t.Error("类型不符时 APIKeyScopes 应返回 false")
+	}
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RunAggregation 在指定集合上执行聚合管道，供管理端报表等场景使用
+// 结果中的 ObjectID 转换为十六进制字符串、日期转换为 UTC 时间，便于直接序列化为JSON
+func RunAggregation(collection string, pipeline mongo.Pipeline) ([]bson.M, error) {
+	if MongoDB == nil {
+		return nil, fmt.Errorf("MongoDB未初始化")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var results []bson.M
+	err := WithReadRetry(ctx, func() error {
+		cursor, err := MongoDB.Collection(collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		results = nil
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("执行聚合失败: %w", err)
+	}
+
+	for i, doc := range results {
+		results[i] = NormalizeDocument(doc)
+	}
+
+	return results, nil
+}
+
+// DayLayout CountByDay 返回结果中日期键的格式
+const DayLayout = "2006-01-02"
+
+/*
+CountByDay 按天统计指定集合中日期字段落在时间范围内的文档数，日期按UTC划分
+ctx: 上下文，通常为请求上下文；内部另设10秒的超时上限
+collection: 集合名称
+dateField: 日期字段（数据库字段名），字段值必须是日期类型
+from: 开始时间（含）
+to: 结束时间（不含）
+extraMatch: 额外的过滤条件（如排除已删除的文档），与时间范围同时生效，可为nil
+返回: 日期（DayLayout 格式）到文档数的映射，不含文档数为0的日期, 错误
+*/
+func CountByDay(ctx context.Context, collection, dateField string, from, to time.Time, extraMatch bson.M) (map[string]int64, error) {
+	if MongoDB == nil {
+		return nil, fmt.Errorf("MongoDB未初始化")
+	}
+	if dateField == "" {
+		return nil, fmt.Errorf("日期字段不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pipeline := countByDayPipeline(dateField, from, to, extraMatch)
+
+	var buckets []struct {
+		Day   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	err := WithReadRetry(ctx, func() error {
+		cursor, err := MongoDB.Collection(collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		buckets = nil
+		return cursor.All(ctx, &buckets)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("按天统计失败: %w", err)
+	}
+
+	counts := make(map[string]int64, len(buckets))
+	for _, b := range buckets {
+		counts[b.Day] = b.Count
+	}
+	return counts, nil
+}
+
+// countByDayPipeline 构建按天统计的聚合管道，不修改 extraMatch；extraMatch 中的同名字段被时间范围覆盖
+func countByDayPipeline(dateField string, from, to time.Time, extraMatch bson.M) mongo.Pipeline {
+	match := make(bson.M, len(extraMatch)+1)
+	for k, v := range extraMatch {
+		match[k] = v
+	}
+	match[dateField] = bson.M{"$gte": from.UTC(), "$lt": to.UTC()}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$" + dateField,
+				"timezone": "UTC",
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+}
+
+// NormalizeDocument 将文档中的BSON特有类型转换为JSON友好的类型
+func NormalizeDocument(doc bson.M) bson.M {
+	normalized := make(bson.M, len(doc))
+	for k, v := range doc {
+		normalized[k] = normalizeValue(v)
+	}
+	return normalized
+}
+
+// normalizeValue 递归转换单个值
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case primitive.ObjectID:
+		return val.Hex()
+	case primitive.DateTime:
+		return val.Time().UTC()
+	case primitive.Timestamp:
+		return time.Unix(int64(val.T), 0).UTC()
+	case primitive.Decimal128:
+		return val.String()
+	case bson.M:
+		return NormalizeDocument(val)
+	case map[string]interface{}:
+		return NormalizeDocument(val)
+	case bson.D:
+		m := make(bson.M, len(val))
+		for _, e := range val {
+			m[e.Key] = normalizeValue(e.Value)
+		}
+		return m
+	case bson.A:
+		arr := make([]interface{}, len(val))
+		for i, item := range val {
+			arr[i] = normalizeValue(item)
+		}
+		return arr
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, item := range val {
+			arr[i] = normalizeValue(item)
+		}
+		return arr
+	default:
+		return v
+	}
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCountByDayPipelineMergesExtraMatch(t *testing.T) {
+	from := time.Date(2026, 1, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	to := from.AddDate(0, 0, 7)
+	extra := bson.M{"deleted": bson.M{"$ne": true}}
+
+	pipeline := countByDayPipeline("created_at", from, to, extra)
+
+	want := bson.M{
+		"deleted":    bson.M{"$ne": true},
+		"created_at": bson.M{"$gte": from.UTC(), "$lt": to.UTC()},
+	}
+	if got := pipeline[0][0].Value; !reflect.DeepEqual(got, want) {
+		t.Fatalf("$match = %v, want %v", got, want)
+	}
+	if len(extra) != 1 {
+		t.Fatalf("extraMatch 被修改: %v", extra)
+	}
+}
+
+func TestCountByDayPipelineWithoutExtraMatch(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pipeline := countByDayPipeline("created_at", from, from.AddDate(0, 0, 1), nil)
+	match, ok := pipeline[0][0].Value.(bson.M)
+	if !ok || len(match) != 1 {
+		t.Fatalf("$match = %v", pipeline[0][0].Value)
+	}
+}
+
+func TestCountByDayWithoutMongoDB(t *testing.T) {
+	saved := MongoDB
+	t.Cleanup(func() { MongoDB = saved })
+
+	MongoDB = nil
+	if _, err := CountByDay(context.Background(), "users", "created_at", time.Now(), time.Now(), nil); err == nil {
+		t.Fatal("MongoDB未初始化时应该返回错误")
+	}
+}
+
+func TestNormalizeDocument(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	doc := bson.M{
+		"_id":   id,
+		"last":  primitive.NewDateTimeFromTime(at),
+		"count": int32(3),
+		"nested": bson.D{
+			{Key: "owner", Value: id},
+			{Key: "tags", Value: bson.A{"a", primitive.NewDateTimeFromTime(at)}},
+		},
+	}
+
+	got := NormalizeDocument(doc)
+	if got["_id"] != id.Hex() {
+		t.Fatalf("_id = %v, want %s", got["_id"], id.Hex())
+	}
+	if got["last"] != at {
+		t.Fatalf("last = %v, want %v", got["last"], at)
+	}
+	nested, ok := got["nested"].(bson.M)
+	if !ok || nested["owner"] != id.Hex() {
+		t.Fatalf("nested = %#v", got["nested"])
+	}
+	if tags, ok := nested["tags"].([]interface{}); !ok || tags[1] != at {
+		t.Fatalf("tags = %#v", nested["tags"])
+	}
+
+	// 转换后的结果可直接序列化为JSON
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	if !strings.Contains(string(data), `"_id":"`+id.Hex()+`"`) || !strings.Contains(string(data), `"last":"2026-03-01T08:30:00Z"`) {
+		t.Fatalf("JSON = %s", data)
+	}
+}
+
+func TestRunAggregationWithoutMongoDB(t *testing.T) {
+	saved := MongoDB
+	t.Cleanup(func() { MongoDB = saved })
+
+	MongoDB = nil
+	if _, err := RunAggregation("users", mongo.Pipeline{}); err == nil {
+		t.Fatal("MongoDB未初始化时应该返回错误")
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 计数器集合名称常量
+const CounterCollection = "counters"
+
+// UserIDCounter 用户ID计数器的名称
+const UserIDCounter = "user_id"
+
+// counter 计数器文档
+type counter struct {
+	Name string `bson:"_id"`
+	Seq  int64  `bson:"seq"`
+}
+
+/*
+NextSequence 原子地递增计数器并返回递增后的值
+基于 findAndModify 的 $inc，多个实例并发调用时也不会得到重复的值；计数器不存在时自动创建
+name: 计数器名称
+返回: 新的序号, 错误
+*/
+func NextSequence(ctx context.Context, db *mongo.Database, name string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var c counter
+	err := db.Collection(CounterCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&c)
+	if err != nil {
+		// 两个请求同时创建计数器时，其中一个upsert会因 _id 重复失败，重试一次即可
+		if mongo.IsDuplicateKeyError(err) {
+			return NextSequence(ctx, db, name)
+		}
+		return 0, fmt.Errorf("获取序号失败: %w", err)
+	}
+
+	return c.Seq, nil
+}
+
+/*
+EnsureSequenceAtLeast 确保计数器不小于指定值
+使用 $max 保证幂等，可重复执行，也不会回退已经分配的序号
+name: 计数器名称
+min: 最小值
+*/
+func EnsureSequenceAtLeast(ctx context.Context, db *mongo.Database, name string, min int64) error {
+	_, err := db.Collection(CounterCollection).UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$max": bson.M{"seq": min}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("初始化计数器失败: %w", err)
+	}
+	return nil
+}
+
+/*
+SyncUserIDCounter 将用户ID计数器提升到现有用户的最大ID
+计数器引入前的用户（按时间戳生成的ID、默认管理员）不经过计数器分配，首次使用计数器前需要先同步，
+避免分配到已被占用的ID
+*/
+func SyncUserIDCounter(ctx context.Context, db *mongo.Database) error {
+	var last struct {
+		ID int64 `bson:"id"`
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}}).SetProjection(bson.M{"id": 1})
+	err := WithReadRetry(ctx, func() error {
+		return db.Collection(UserCollection).FindOne(ctx, bson.M{}, opts).Decode(&last)
+	})
+	if err != nil && err != mongo.ErrNoDocuments {
+		return fmt.Errorf("查询最大用户ID失败: %w", err)
+	}
+	return EnsureSequenceAtLeast(ctx, db, UserIDCounter, last.ID)
+}
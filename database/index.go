@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-app/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// codeIndexNotFound 删除的索引不存在
+const codeIndexNotFound = 27
+
+// 创建索引时表示索引已存在或与已有索引冲突的错误码
+var indexConflictCodes = []int{
+	68, // IndexAlreadyExists
+	85, // IndexOptionsConflict：相同的键已有索引，但名称或选项不同
+	86, // IndexKeySpecsConflict：相同名称已有索引，但键不同
+}
+
+/*
+ensureIndexes 逐个创建索引，已存在或与已有索引冲突的索引记录日志后跳过
+集合上可能已有旧版本或手动创建的索引（滚动部署时新旧版本的迁移也可能先后执行），
+CreateMany 遇到冲突会整体失败，这里逐个创建，冲突的索引保持原样，其余索引照常创建。
+冲突的索引不会被修改，选项不同（如缺少唯一约束）时需要手动删除后重新执行迁移
+返回: 冲突以外的错误（未包装，由调用方说明创建的是哪个索引）
+*/
+func ensureIndexes(ctx context.Context, collection *mongo.Collection, models ...mongo.IndexModel) error {
+	for _, model := range models {
+		_, err := collection.Indexes().CreateOne(ctx, model)
+		if err == nil {
+			continue
+		}
+		if !isIndexConflict(err) {
+			return err
+		}
+		utils.Warn("索引已存在或与已有索引冲突，跳过创建",
+			zap.String("collection", collection.Name()), zap.String("index", indexDisplayName(model)), zap.Error(err))
+	}
+	return nil
+}
+
+// isIndexConflict 判断创建索引的错误是否为索引已存在或与已有索引冲突
+func isIndexConflict(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	for _, code := range indexConflictCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexDisplayName 返回索引名称用于日志，未指定名称时按MongoDB的默认规则由键生成（如 created_at_-1）
+func indexDisplayName(model mongo.IndexModel) string {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name
+	}
+	keys, ok := model.Keys.(bson.D)
+	if !ok {
+		return fmt.Sprint(model.Keys)
+	}
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// dropIndexIfExists 按名称删除索引，索引不存在时忽略
+func dropIndexIfExists(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(codeIndexNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestIsIndexConflict(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"索引已存在", mongo.CommandError{Code: 68, Name: "IndexAlreadyExists"}, true},
+		{"选项冲突", mongo.CommandError{Code: 85, Name: "IndexOptionsConflict"}, true},
+		{"键冲突", fmt.Errorf("创建索引失败: %w", mongo.CommandError{Code: 86, Name: "IndexKeySpecsConflict"}), true},
+		{"其他服务端错误", mongo.CommandError{Code: 13, Name: "Unauthorized"}, false},
+		{"非服务端错误", errors.New("connection refused"), false},
+	}
+	for _, tc := range cases {
+		if got := isIndexConflict(tc.err); got != tc.want {
+			t.Errorf("%s: isIndexConflict = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIndexDisplayName(t *testing.T) {
+	named := mongo.IndexModel{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetName(UserIDIndex)}
+	if got := indexDisplayName(named); got != UserIDIndex {
+		t.Fatalf("indexDisplayName = %q, want %q", got, UserIDIndex)
+	}
+
+	compound := mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}}
+	if got := indexDisplayName(compound); got != "user_id_1_created_at_-1" {
+		t.Fatalf("indexDisplayName = %q, want user_id_1_created_at_-1", got)
+	}
+}
@@ -3,51 +3,154 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"go-app/middleware"
 	"go-app/models/user"
+	"go-app/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
 )
 
 // 集合名称常量
 const (
-	UserCollection = "users"
+	UserCollection        = "users"
+	NonceCollection       = "signature_nonces"
+	FailedLoginCollection = "failed_logins"
+	SessionCollection     = "sessions"
+	AuditCollection       = "audit_logs"
 )
 
-// InitMongoDB迁移 - 创建集合和索引
-func MigrateDB() error {
-	log.Println("开始MongoDB迁移...")
+// SeedAdminMigration 创建默认管理员（admin/admin123）的迁移版本
+const SeedAdminMigration = 2
 
-	if MongoDB == nil {
-		return fmt.Errorf("MongoDB未初始化")
-	}
+// 登录失败事件固定集合的大小上限（字节），写满后最早的事件被覆盖
+const failedLoginCappedSize = 16 * 1024 * 1024
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+func init() {
+	RegisterMigration(Migration{
+		Version: 1,
+		Name:    "create_user_indexes",
+		Up:      setupUserCollection,
+		Down:    dropUserIndexes,
+	})
+	RegisterMigration(Migration{
+		Version:       SeedAdminMigration,
+		Name:          "seed_default_admin",
+		Transactional: true,
+		Up:            createDefaultAdmin,
+		Down:          deleteDefaultAdmin,
+	})
+	RegisterMigration(Migration{
+		Version: 3,
+		Name:    "create_signature_nonce_ttl_index",
+		Up:      createNonceTTLIndex,
+		Down:    dropNonceTTLIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 4,
+		Name:    "create_user_email_hash_index",
+		Up:      createEmailHashIndex,
+		Down:    dropEmailHashIndex,
+	})
+	RegisterMigration(Migration{
+		Version:       5,
+		Name:          "assign_user_roles",
+		Transactional: true,
+		Up:            assignUserRoles,
+	})
+	RegisterMigration(Migration{
+		Version: 6,
+		Name:    "create_failed_login_collection",
+		Up:      createFailedLoginCollection,
+		Down:    dropFailedLoginCollection,
+	})
+	RegisterMigration(Migration{
+		Version: 7,
+		Name:    "hash_plaintext_passwords",
+		Up:      hashPlaintextPasswords,
+	})
+	RegisterMigration(Migration{
+		Version: 8,
+		Name:    "create_user_keyset_index",
+		Up:      createUserKeysetIndex,
+		Down:    dropUserKeysetIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 9,
+		Name:    "create_user_role_index",
+		Up:      createUserRoleIndex,
+		Down:    dropUserRoleIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 10,
+		Name:    "create_session_indexes",
+		Up:      createSessionIndexes,
+		Down:    dropSessionIndexes,
+	})
+	RegisterMigration(Migration{
+		Version: 11,
+		Name:    "create_audit_user_index",
+		Up:      createAuditUserIndex,
+		Down:    dropAuditUserIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 12,
+		Name:    "create_user_text_index",
+		Up:      createUserTextIndex,
+		Down:    dropUserTextIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 13,
+		Name:    "create_user_password_reset_index",
+		Up:      createPasswordResetIndex,
+		Down:    dropPasswordResetIndex,
+	})
+	RegisterMigration(Migration{
+		Version: 14,
+		Name:    "scope_user_unique_indexes_to_active_users",
+		Up:      createActiveUserUniqueIndexes,
+		Down:    dropActiveUserUniqueIndexes,
+	})
+	RegisterMigration(Migration{
+		Version: 15,
+		Name:    "create_user_id_unique_index",
+		Up:      createUserIDIndex,
+		Down:    dropUserIDIndex,
+	})
+}
 
-	// 初始化用户集合
-	if err := setupUserCollection(ctx); err != nil {
-		return fmt.Errorf("用户集合设置失败: %w", err)
-	}
+/*
+MigrateDB 执行所有尚未执行的MongoDB迁移（创建集合索引、初始化数据）
+seedAdmin: 是否创建默认管理员；为false时跳过且不记录，之后开启时会补充执行
+返回: 本次执行的迁移版本, 错误
+*/
+func MigrateDB(seedAdmin bool) ([]int, error) {
+	utils.Info("开始MongoDB迁移")
 
-	// 添加默认管理员用户(如果不存在)
-	if err := createDefaultAdmin(ctx); err != nil {
-		return fmt.Errorf("创建默认管理员失败: %w", err)
+	var skip []int
+	if !seedAdmin {
+		skip = append(skip, SeedAdminMigration)
+	}
+	executed, err := RunMigrations(MongoDB, skip...)
+	if err != nil {
+		return executed, err
 	}
 
-	log.Println("MongoDB迁移成功")
-	return nil
+	utils.Info("MongoDB迁移成功", zap.Ints("executed", executed))
+	return executed, nil
 }
 
+// 用户集合索引名称，回滚时按名称删除
+var userIndexNames = []string{"username_1", "email_1", "created_at_-1"}
+
 // 设置用户集合和索引
-func setupUserCollection(ctx context.Context) error {
+func setupUserCollection(ctx context.Context, db *mongo.Database) error {
 	// 获取集合
-	collection := MongoDB.Collection(UserCollection)
+	collection := db.Collection(UserCollection)
 
 	// 创建索引
 	indexModels := []mongo.IndexModel{
@@ -64,8 +167,8 @@ func setupUserCollection(ctx context.Context) error {
 		},
 	}
 
-	// 创建索引
-	_, err := collection.Indexes().CreateMany(ctx, indexModels)
+	// 创建索引，已存在或冲突的索引跳过
+	err := ensureIndexes(ctx, collection, indexModels...)
 	if err != nil {
 		return fmt.Errorf("创建索引失败: %w", err)
 	}
@@ -73,9 +176,20 @@ func setupUserCollection(ctx context.Context) error {
 	return nil
 }
 
+// 删除用户集合索引
+func dropUserIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := db.Collection(UserCollection).Indexes()
+	for _, name := range userIndexNames {
+		if _, err := indexes.DropOne(ctx, name); err != nil {
+			return fmt.Errorf("删除索引 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // 创建默认管理员用户(如果不存在)
-func createDefaultAdmin(ctx context.Context) error {
-	collection := MongoDB.Collection(UserCollection)
+func createDefaultAdmin(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(UserCollection)
 
 	// 检查管理员是否已存在
 	filter := bson.M{"username": "admin"}
@@ -86,7 +200,7 @@ func createDefaultAdmin(ctx context.Context) error {
 
 	// 如果已存在管理员，则跳过
 	if count > 0 {
-		log.Println("管理员用户已存在，跳过创建")
+		utils.Info("管理员用户已存在，跳过创建")
 		return nil
 	}
 
@@ -96,14 +210,24 @@ func createDefaultAdmin(ctx context.Context) error {
 		return fmt.Errorf("管理员密码加密失败: %w", err)
 	}
 
+	// 从计数器分配ID，先同步到现有用户的最大ID，避免与已注册的用户冲突
+	if err := SyncUserIDCounter(ctx, db); err != nil {
+		return err
+	}
+	id, err := NextSequence(ctx, db, UserIDCounter)
+	if err != nil {
+		return fmt.Errorf("分配管理员ID失败: %w", err)
+	}
+
 	// 创建管理员用户
 	admin := user.User{
-		ID:        1,
+		ID:        uint(id),
 		Username:  "admin",
 		Email:     "admin@example.com",
 		Password:  hashedPassword,
 		Nickname:  "管理员",
 		Status:    1,
+		Role:      user.RoleAdmin,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -114,6 +238,389 @@ func createDefaultAdmin(ctx context.Context) error {
 		return fmt.Errorf("插入管理员用户失败: %w", err)
 	}
 
-	log.Println("成功创建管理员用户")
+	utils.Info("成功创建管理员用户")
+	return nil
+}
+
+// 删除默认管理员用户
+func deleteDefaultAdmin(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).DeleteOne(ctx, bson.M{"username": "admin", "email": "admin@example.com"}); err != nil {
+		return fmt.Errorf("删除管理员用户失败: %w", err)
+	}
+	return nil
+}
+
+// 创建签名nonce的TTL索引，过期的nonce由MongoDB自动清理
+func createNonceTTLIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(NonceCollection), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("创建nonce TTL索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除签名nonce的TTL索引
+func dropNonceTTLIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(NonceCollection).Indexes().DropOne(ctx, "expires_at_1"); err != nil {
+		return fmt.Errorf("删除nonce TTL索引失败: %w", err)
+	}
+	return nil
+}
+
+// 创建邮箱哈希唯一索引，邮箱加密存储后由该索引保证邮箱唯一
+func createEmailHashIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "email_hash", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email_hash": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("创建邮箱哈希索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除邮箱哈希索引
+func dropEmailHashIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, "email_hash_1"); err != nil {
+		return fmt.Errorf("删除邮箱哈希索引失败: %w", err)
+	}
+	return nil
+}
+
+// 为没有角色的历史用户设置角色：默认管理员为admin，其余为user
+func assignUserRoles(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(UserCollection)
+	noRole := bson.M{"$in": bson.A{nil, ""}}
+
+	if _, err := collection.UpdateOne(ctx,
+		bson.M{"id": 1, "username": "admin", "role": noRole},
+		bson.M{"$set": bson.M{"role": user.RoleAdmin}},
+	); err != nil {
+		return fmt.Errorf("设置管理员角色失败: %w", err)
+	}
+
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"role": noRole},
+		bson.M{"$set": bson.M{"role": user.RoleUser}},
+	); err != nil {
+		return fmt.Errorf("设置用户角色失败: %w", err)
+	}
+
+	return nil
+}
+
+// 创建登录失败事件的固定大小集合及时间索引
+// 迁移执行前已有写入时集合会被自动创建为普通集合，此时转换为固定集合
+func createFailedLoginCollection(ctx context.Context, db *mongo.Database) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": FailedLoginCollection})
+	if err != nil {
+		return fmt.Errorf("查询登录失败事件集合失败: %w", err)
+	}
+
+	if len(names) == 0 {
+		opts := options.CreateCollection().SetCapped(true).SetSizeInBytes(failedLoginCappedSize)
+		if err := db.CreateCollection(ctx, FailedLoginCollection, opts); err != nil {
+			return fmt.Errorf("创建登录失败事件集合失败: %w", err)
+		}
+	} else {
+		cmd := bson.D{{Key: "convertToCapped", Value: FailedLoginCollection}, {Key: "size", Value: failedLoginCappedSize}}
+		if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+			return fmt.Errorf("转换登录失败事件集合失败: %w", err)
+		}
+	}
+
+	err = ensureIndexes(ctx, db.Collection(FailedLoginCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("创建登录失败事件索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除登录失败事件集合
+func dropFailedLoginCollection(ctx context.Context, db *mongo.Database) error {
+	if err := db.Collection(FailedLoginCollection).Drop(ctx); err != nil {
+		return fmt.Errorf("删除登录失败事件集合失败: %w", err)
+	}
+	return nil
+}
+
+// 将历史遗留的明文密码哈希后存储，登录不再接受明文密码匹配
+// 只更新密码字段，不经过存储库，加密字段保持原样
+func hashPlaintextPasswords(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(UserCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "password": 1}))
+	if err != nil {
+		return fmt.Errorf("查询用户密码失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID       interface{} `bson:"_id"`
+			Password string      `bson:"password"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("解析用户密码失败: %w", err)
+		}
+		if middleware.ClassifyStoredPassword(doc.Password) != middleware.PasswordPlaintext {
+			continue
+		}
+
+		hashed, err := middleware.HashPassword(doc.Password)
+		if err != nil {
+			return fmt.Errorf("密码加密失败: %w", err)
+		}
+		if _, err := collection.UpdateOne(ctx,
+			bson.M{"_id": doc.ID, "password": doc.Password},
+			bson.M{"$set": bson.M{"password": hashed, "password_reset_required": false}},
+		); err != nil {
+			return fmt.Errorf("更新用户密码失败: %w", err)
+		}
+		migrated++
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("遍历用户失败: %w", err)
+	}
+
+	utils.Info("已哈希明文密码", zap.Int("count", migrated))
+	return nil
+}
+
+// 创建用户列表游标分页使用的复合索引（创建时间倒序、ID倒序）
+func createUserKeysetIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("创建用户分页索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除用户列表游标分页索引
+func dropUserKeysetIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, "created_at_-1_id_-1"); err != nil {
+		return fmt.Errorf("删除用户分页索引失败: %w", err)
+	}
+	return nil
+}
+
+// 创建用户角色索引，支持按角色过滤用户列表
+func createUserRoleIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "role", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("创建用户角色索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除用户角色索引
+func dropUserRoleIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, "role_1"); err != nil {
+		return fmt.Errorf("删除用户角色索引失败: %w", err)
+	}
+	return nil
+}
+
+// 会话集合索引名称，回滚时按名称删除
+var sessionIndexNames = []string{"token_id_1", "user_id_1_created_at_1", "expires_at_1"}
+
+// 创建会话集合索引：按令牌ID校验会话、按用户查询活跃会话，以及清理过期会话的TTL索引
+func createSessionIndexes(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(SessionCollection),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "token_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("创建会话索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除会话集合索引
+func dropSessionIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, name := range sessionIndexNames {
+		if _, err := db.Collection(SessionCollection).Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("删除会话索引 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// 创建审计日志的用户和时间复合索引，用于按用户分页查询个人操作记录
+func createAuditUserIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(AuditCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("创建审计日志用户索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除审计日志的用户和时间复合索引
+func dropAuditUserIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(AuditCollection).Indexes().DropOne(ctx, "user_id_1_created_at_-1"); err != nil {
+		return fmt.Errorf("删除审计日志用户索引失败: %w", err)
+	}
+	return nil
+}
+
+// 创建用户全文索引，字段权重由 SetUserTextWeights 配置；修改权重后需回滚并重新执行该迁移
+func createUserTextIndex(ctx context.Context, db *mongo.Database) error {
+	keys := bson.D{}
+	weights := bson.D{}
+	for _, field := range userTextIndexFields() {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: userTextWeights[field]})
+	}
+
+	// 用户名、昵称不是自然语言，不做词干提取和停用词过滤
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetName(UserTextIndexName).
+			SetWeights(weights).
+			SetDefaultLanguage("none"),
+	})
+	if err != nil {
+		return fmt.Errorf("创建用户全文索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除用户全文索引
+func dropUserTextIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, UserTextIndexName); err != nil {
+		return fmt.Errorf("删除用户全文索引失败: %w", err)
+	}
+	return nil
+}
+
+// 创建密码重置令牌索引，只包含有待使用令牌的用户
+func createPasswordResetIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys: bson.D{{Key: "password_reset_token_hash", Value: 1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"password_reset_token_hash": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("创建密码重置令牌索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除密码重置令牌索引
+func dropPasswordResetIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, "password_reset_token_hash_1"); err != nil {
+		return fmt.Errorf("删除密码重置令牌索引失败: %w", err)
+	}
+	return nil
+}
+
+// 仅约束未删除用户的唯一索引名称，回滚时按名称删除
+var activeUserUniqueIndexNames = []string{"username_1_active", "email_1_active", "email_hash_1_active"}
+
+/*
+createActiveUserUniqueIndexes 将用户名、邮箱和邮箱哈希的唯一索引改为只约束未删除的用户
+软删除的用户仍保留在集合中，全量唯一索引会使其用户名和邮箱无法再被注册；
+部分索引以 deleted:false 为条件（部分索引不支持 $ne），因此先为缺少该字段的历史用户补上 deleted:false，
+再删除原有的全量唯一索引。恢复已删除的用户时，若其用户名或邮箱已被他人使用，将违反唯一索引而失败
+*/
+func createActiveUserUniqueIndexes(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection(UserCollection)
+
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"deleted": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted": false}},
+	); err != nil {
+		return fmt.Errorf("补充用户删除标记失败: %w", err)
+	}
+
+	for _, name := range []string{"username_1", "email_1", "email_hash_1"} {
+		if err := dropIndexIfExists(ctx, collection, name); err != nil {
+			return fmt.Errorf("删除索引 %s 失败: %w", name, err)
+		}
+	}
+
+	active := bson.M{"deleted": false}
+	err := ensureIndexes(ctx, collection,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName("username_1_active").SetUnique(true).SetPartialFilterExpression(active),
+		},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_1_active").SetUnique(true).SetPartialFilterExpression(active),
+		},
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "email_hash", Value: 1}},
+			Options: options.Index().SetName("email_hash_1_active").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"deleted": false, "email_hash": bson.M{"$exists": true}}),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("创建用户唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除只约束未删除用户的唯一索引，并恢复全量唯一索引；已删除用户与现有用户重名时恢复失败，需重新执行该迁移
+func dropActiveUserUniqueIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, name := range activeUserUniqueIndexNames {
+		if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("删除索引 %s 失败: %w", name, err)
+		}
+	}
+	if err := setupUserCollection(ctx, db); err != nil {
+		return err
+	}
+	return createEmailHashIndex(ctx, db)
+}
+
+// UserIDIndex 用户ID唯一索引的名称
+const UserIDIndex = "id_1"
+
+/*
+createUserIDIndex 为用户ID创建唯一索引
+用户ID由计数器分配，唯一索引兜底计数器之外写入的用户（如手动导入）造成的冲突；
+已有重复ID的集合上创建会失败，需要先修正重复的用户
+*/
+func createUserIDIndex(ctx context.Context, db *mongo.Database) error {
+	err := ensureIndexes(ctx, db.Collection(UserCollection), mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetName(UserIDIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("创建用户ID唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// 删除用户ID唯一索引
+func dropUserIDIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(UserCollection).Indexes().DropOne(ctx, UserIDIndex); err != nil {
+		return fmt.Errorf("删除用户ID唯一索引失败: %w", err)
+	}
 	return nil
 }
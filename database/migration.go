@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-app/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// MigrationsCollection 记录已执行迁移的集合
+const MigrationsCollection = "migrations"
+
+// migrationTimeout 单个迁移的执行超时时间
+const migrationTimeout = 60 * time.Second
+
+/*
+Migration 版本化迁移
+Version: 迁移版本号，按从小到大的顺序执行，发布后不可修改
+Name: 迁移名称，仅用于日志和记录
+Transactional: 是否在事务中执行；创建索引等不支持事务的操作应设为false。
+当前部署不支持事务（如单节点）时，即使设为true也会直接执行
+Up: 执行迁移
+Down: 回滚迁移，可为nil表示不支持回滚
+*/
+type Migration struct {
+	Version       int
+	Name          string
+	Transactional bool
+	Up            func(ctx context.Context, db *mongo.Database) error
+	Down          func(ctx context.Context, db *mongo.Database) error
+}
+
+// migrationRecord 已执行迁移的记录
+type migrationRecord struct {
+	Version   int       `bson:"version"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// 已注册的迁移
+var migrations = map[int]Migration{}
+
+// RegisterMigration 注册迁移，版本号重复时panic
+func RegisterMigration(m Migration) {
+	if m.Up == nil {
+		panic(fmt.Sprintf("迁移 %d 缺少Up函数", m.Version))
+	}
+	if _, exists := migrations[m.Version]; exists {
+		panic(fmt.Sprintf("迁移版本号重复: %d", m.Version))
+	}
+	migrations[m.Version] = m
+}
+
+// sortedMigrations 按版本号升序返回已注册的迁移
+func sortedMigrations() []Migration {
+	list := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+/*
+RunMigrations 按版本号顺序执行尚未执行的迁移
+每个迁移执行成功后立即记录，失败时停止并返回错误，已执行的迁移不会重复执行
+db: 目标数据库
+skip: 本次跳过的迁移版本，跳过的迁移不记录，之后不再跳过时会补充执行
+返回: 本次执行的迁移版本, 错误
+*/
+func RunMigrations(db *mongo.Database, skip ...int) ([]int, error) {
+	if db == nil {
+		return nil, fmt.Errorf("MongoDB未初始化")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	records := db.Collection(MigrationsCollection)
+	// 版本号唯一，避免多个实例同时启动时重复记录
+	if _, err := records.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return nil, fmt.Errorf("创建迁移记录索引失败: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	transactions := supportsTransactions(ctx, db)
+
+	skipped := make(map[int]bool, len(skip))
+	for _, v := range skip {
+		skipped[v] = true
+	}
+
+	var executed []int
+	for _, m := range sortedMigrations() {
+		if applied[m.Version] {
+			continue
+		}
+		if skipped[m.Version] {
+			utils.Info("跳过迁移", zap.Int("version", m.Version), zap.String("name", m.Name))
+			continue
+		}
+
+		utils.Info("执行迁移", zap.Int("version", m.Version), zap.String("name", m.Name))
+		if err := runMigration(db, m, transactions); err != nil {
+			return executed, fmt.Errorf("迁移 %d（%s）失败: %w", m.Version, m.Name, err)
+		}
+		executed = append(executed, m.Version)
+	}
+
+	return executed, nil
+}
+
+/*
+RollbackMigration 回滚最近执行的一个迁移
+db: 目标数据库
+返回: 被回滚的迁移版本号（没有可回滚的迁移时为0）, 错误
+*/
+func RollbackMigration(db *mongo.Database) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("MongoDB未初始化")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	records := db.Collection(MigrationsCollection)
+	var last migrationRecord
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	if err := records.FindOne(ctx, bson.M{}, opts).Decode(&last); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+
+	m, ok := migrations[last.Version]
+	if !ok || m.Down == nil {
+		return 0, fmt.Errorf("迁移 %d 不支持回滚", last.Version)
+	}
+
+	utils.Info("回滚迁移", zap.Int("version", m.Version), zap.String("name", m.Name))
+	if err := m.Down(ctx, db); err != nil {
+		return 0, fmt.Errorf("回滚迁移 %d 失败: %w", m.Version, err)
+	}
+	if _, err := records.DeleteOne(ctx, bson.M{"version": m.Version}); err != nil {
+		return 0, fmt.Errorf("删除迁移记录失败: %w", err)
+	}
+
+	return m.Version, nil
+}
+
+// runMigration 执行单个迁移并记录，可能时在事务中执行，使迁移内容和记录同时生效
+func runMigration(db *mongo.Database, m Migration, transactions bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	apply := func(ctx context.Context) error {
+		if err := m.Up(ctx, db); err != nil {
+			return err
+		}
+		_, err := db.Collection(MigrationsCollection).InsertOne(ctx, migrationRecord{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now(),
+		})
+		return err
+	}
+
+	if !m.Transactional || !transactions {
+		return apply(ctx)
+	}
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("创建会话失败: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, apply(sc)
+	})
+	return err
+}
+
+// appliedVersions 查询已执行的迁移版本
+func appliedVersions(ctx context.Context, records *mongo.Collection) (map[int]bool, error) {
+	cursor, err := records.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []migrationRecord
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("解析迁移记录失败: %w", err)
+	}
+
+	applied := make(map[int]bool, len(list))
+	for _, r := range list {
+		applied[r.Version] = true
+	}
+	return applied, nil
+}
+
+// supportsTransactions 判断当前部署是否支持事务（副本集或分片集群）
+func supportsTransactions(ctx context.Context, db *mongo.Database) bool {
+	var hello bson.M
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	if _, ok := hello["setName"]; ok {
+		return true
+	}
+	return hello["msg"] == "isdbgrid"
+}
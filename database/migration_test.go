@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// useTestMigrations 在测试期间替换已注册的迁移，测试结束后恢复
+func useTestMigrations(t *testing.T) {
+	t.Helper()
+	saved := migrations
+	migrations = map[int]Migration{}
+	t.Cleanup(func() { migrations = saved })
+}
+
+func TestSortedMigrationsOrdersByVersion(t *testing.T) {
+	useTestMigrations(t)
+	noop := func(ctx context.Context, db *mongo.Database) error { return nil }
+	for _, v := range []int{3, 1, 2} {
+		RegisterMigration(Migration{Version: v, Name: fmt.Sprint("m", v), Up: noop})
+	}
+
+	list := sortedMigrations()
+	for i, m := range list {
+		if m.Version != i+1 {
+			t.Fatalf("sortedMigrations()[%d].Version = %d, want %d", i, m.Version, i+1)
+		}
+	}
+}
+
+func TestRegisterMigrationRejectsInvalid(t *testing.T) {
+	useTestMigrations(t)
+	noop := func(ctx context.Context, db *mongo.Database) error { return nil }
+	RegisterMigration(Migration{Version: 1, Up: noop})
+
+	cases := map[string]Migration{
+		"版本号重复":  {Version: 1, Up: noop},
+		"缺少Up函数": {Version: 2},
+	}
+	for name, m := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("RegisterMigration 应该panic")
+				}
+			}()
+			RegisterMigration(m)
+		})
+	}
+}
+
+func TestRunMigrationsWithoutMongoDB(t *testing.T) {
+	if _, err := RunMigrations(nil); err == nil {
+		t.Fatal("MongoDB未初始化时应该返回错误")
+	}
+}
+
+// TestRunMigrationsIdempotent 在新数据库上执行全部迁移，再次执行时不应重复执行任何迁移
+// 需要设置 MONGODB_TEST_URI
+func TestRunMigrationsIdempotent(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("未设置 MONGODB_TEST_URI，跳过MongoDB集成测试")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("连接MongoDB失败: %v", err)
+	}
+	db := client.Database(fmt.Sprintf("go_app_migration_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	executed, err := RunMigrations(db)
+	if err != nil {
+		t.Fatalf("首次执行迁移失败: %v", err)
+	}
+	all := sortedMigrations()
+	if len(executed) != len(all) {
+		t.Fatalf("首次执行了 %d 个迁移, want %d", len(executed), len(all))
+	}
+	for i, m := range all {
+		if executed[i] != m.Version {
+			t.Fatalf("executed[%d] = %d, want %d", i, executed[i], m.Version)
+		}
+	}
+
+	executed, err = RunMigrations(db)
+	if err != nil {
+		t.Fatalf("再次执行迁移失败: %v", err)
+	}
+	if len(executed) != 0 {
+		t.Fatalf("再次执行时不应执行任何迁移, got %v", executed)
+	}
+
+	records, err := db.Collection(MigrationsCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("统计迁移记录失败: %v", err)
+	}
+	if records != int64(len(all)) {
+		t.Fatalf("迁移记录数 = %d, want %d", records, len(all))
+	}
+	admins, err := db.Collection(UserCollection).CountDocuments(ctx, bson.M{"username": "admin"})
+	if err != nil {
+		t.Fatalf("统计管理员失败: %v", err)
+	}
+	if admins != 1 {
+		t.Fatalf("管理员数量 = %d, want 1", admins)
+	}
+}
@@ -2,16 +2,22 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go-app/config"
+	"go-app/utils"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.uber.org/zap"
 )
 
 // MongoDB 全局MongoDB客户端
@@ -34,9 +40,22 @@ func NewMongoManager(cfg *config.Config) *MongoManager {
 	}
 }
 
-// InitMongoDB 初始化MongoDB连接
+// InitMongoDB 初始化MongoDB连接，主节点不可用时返回错误
 func InitMongoDB(cfg *config.Config) (*mongo.Database, error) {
-	log.Println("正在连接MongoDB...")
+	return connectMongoDB(cfg, true)
+}
+
+/*
+ConnectMongoDB 创建MongoDB客户端但不检查连通性，仅在URI无效时返回错误
+驱动在后台持续重连，服务器可用后操作自动恢复；配合 WaitForDependencies 等待MongoDB就绪
+*/
+func ConnectMongoDB(cfg *config.Config) (*mongo.Database, error) {
+	return connectMongoDB(cfg, false)
+}
+
+// connectMongoDB 创建MongoDB客户端并设置全局变量，ping 为true时要求主节点可用
+func connectMongoDB(cfg *config.Config, ping bool) (*mongo.Database, error) {
+	utils.Info("正在连接MongoDB")
 
 	// 处理空配置
 	if cfg == nil {
@@ -46,12 +65,8 @@ func InitMongoDB(cfg *config.Config) (*mongo.Database, error) {
 	// 直接从环境变量读取 MongoDB URI
 	uri := os.Getenv("MONGODB_URI")
 	if uri == "" {
-		// 如果环境变量不存在，则使用配置
-		uri = cfg.MongoDB.URI
-		// 如果配置也不存在，使用默认值
-		if uri == "" {
-			uri = "mongodb://localhost:27017"
-		}
+		// 如果环境变量不存在，则使用配置，未配置URI时由主机、端口和认证字段拼接
+		uri = BuildMongoURI(cfg)
 	}
 
 	// 直接从环境变量读取数据库名
@@ -65,14 +80,22 @@ func InitMongoDB(cfg *config.Config) (*mongo.Database, error) {
 		}
 	}
 
-	log.Printf("正在连接到 MongoDB: %s, 数据库: %s", uri, dbName)
+	utils.Info("正在连接到MongoDB", zap.String("uri", redactMongoURI(uri)), zap.String("database", dbName))
 
 	// 创建连接上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// 设置客户端选项 - 不使用身份验证
-	clientOptions := options.Client().ApplyURI(uri)
+	// 每条命令的耗时累加到请求上下文的 Server-Timing 记录中（未启用时上下文中没有记录，不做任何操作）
+	clientOptions := options.Client().ApplyURI(uri).SetMonitor(&event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			utils.AddServerTiming(ctx, "db", e.Duration)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			utils.AddServerTiming(ctx, "db", e.Duration)
+		},
+	})
 
 	// 连接到MongoDB
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -81,9 +104,10 @@ func InitMongoDB(cfg *config.Config) (*mongo.Database, error) {
 	}
 
 	// 检查连接
-	err = client.Ping(ctx, readpref.Primary())
-	if err != nil {
-		return nil, fmt.Errorf("MongoDB连接测试失败: %w", err)
+	if ping {
+		if err := client.Ping(ctx, readpref.Primary()); err != nil {
+			return nil, fmt.Errorf("MongoDB连接测试失败: %w", err)
+		}
 	}
 
 	// 设置全局客户端
@@ -95,10 +119,94 @@ func InitMongoDB(cfg *config.Config) (*mongo.Database, error) {
 	// 设置全局数据库
 	MongoDB = db
 
-	log.Println("MongoDB连接成功")
+	if ping {
+		utils.Info("MongoDB连接成功")
+	}
 	return db, nil
 }
 
+/*
+BuildMongoURI 根据配置生成MongoDB连接URI
+配置了 MONGODB_URI 时直接使用；否则由主机、端口、用户名、密码和认证数据库拼接，
+用户名和密码会进行URL编码，因此可以包含 @、:、/ 等特殊字符
+cfg: 应用配置
+返回: 连接URI
+*/
+func BuildMongoURI(cfg *config.Config) string {
+	if cfg.MongoDB.URI != "" {
+		return cfg.MongoDB.URI
+	}
+
+	host := cfg.MongoDB.Host
+	if host == "" {
+		host = "localhost"
+	}
+	// 多主机或已带端口时不再追加端口
+	if !strings.ContainsAny(host, ":,") {
+		port := cfg.MongoDB.Port
+		if port == 0 {
+			port = 27017
+		}
+		host += ":" + strconv.Itoa(port)
+	}
+
+	u := url.URL{Scheme: "mongodb", Host: host}
+	if cfg.MongoDB.Username != "" {
+		if cfg.MongoDB.Password != "" {
+			u.User = url.UserPassword(cfg.MongoDB.Username, cfg.MongoDB.Password)
+		} else {
+			u.User = url.User(cfg.MongoDB.Username)
+		}
+	}
+	if cfg.MongoDB.AuthSource != "" {
+		u.Path = "/"
+		u.RawQuery = url.Values{"authSource": {cfg.MongoDB.AuthSource}}.Encode()
+	}
+
+	return u.String()
+}
+
+// redactMongoURI 隐藏URI中的密码，用于日志输出
+func redactMongoURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "(无法解析的URI)"
+	}
+	return u.Redacted()
+}
+
+/*
+PingMongoDB 检查MongoDB主节点是否可用，主节点不可用时写操作都会失败
+ctx: 上下文，调用方应设置较短的超时时间
+返回: 往返耗时, 错误（未连接或主节点不可用时）
+*/
+func PingMongoDB(ctx context.Context) (time.Duration, error) {
+	return pingMongoDB(ctx, readpref.Primary())
+}
+
+/*
+PingMongoDBAny 检查是否能连接到MongoDB的任一成员（主节点或从节点）
+主节点故障、从节点仍可用时返回成功，用于区分整体连接问题和主节点问题
+ctx: 上下文，调用方应设置较短的超时时间
+返回: 往返耗时, 错误（未连接或所有成员都不可用时）
+*/
+func PingMongoDBAny(ctx context.Context) (time.Duration, error) {
+	return pingMongoDB(ctx, readpref.Nearest())
+}
+
+// pingMongoDB 按读偏好选择成员并执行ping
+func pingMongoDB(ctx context.Context, rp *readpref.ReadPref) (time.Duration, error) {
+	if MongoClient == nil {
+		return 0, errors.New("MongoDB未连接")
+	}
+
+	start := time.Now()
+	if err := MongoClient.Ping(ctx, rp); err != nil {
+		return time.Since(start), err
+	}
+	return time.Since(start), nil
+}
+
 // CloseMongoDB 关闭MongoDB连接
 func CloseMongoDB() error {
 	if MongoClient != nil {
@@ -107,7 +215,7 @@ func CloseMongoDB() error {
 		if err := MongoClient.Disconnect(ctx); err != nil {
 			return fmt.Errorf("关闭MongoDB连接失败: %w", err)
 		}
-		log.Println("MongoDB连接已关闭")
+		utils.Info("MongoDB连接已关闭")
 	}
 	return nil
 }
@@ -115,7 +223,7 @@ func CloseMongoDB() error {
 // GetCollection 获取MongoDB集合
 func GetCollection(name string) *mongo.Collection {
 	if MongoDB == nil {
-		log.Println("警告: 尝试在MongoDB未初始化时获取集合")
+		utils.Warn("尝试在MongoDB未初始化时获取集合", zap.String("collection", name))
 		return nil
 	}
 	return MongoDB.Collection(name)
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"go-app/config"
+
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+func TestBuildMongoURIEncodesCredentials(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.MongoDB.Host = "db.example.com"
+	cfg.MongoDB.Port = 27018
+	cfg.MongoDB.Username = "app@user:1"
+	cfg.MongoDB.Password = "p@ss:w/rd?#[]%"
+	cfg.MongoDB.AuthSource = "admin"
+
+	uri := BuildMongoURI(cfg)
+	if strings.Contains(uri, cfg.MongoDB.Password) {
+		t.Fatalf("密码应该经过URL编码: %s", uri)
+	}
+
+	cs, err := connstring.ParseAndValidate(uri)
+	if err != nil {
+		t.Fatalf("驱动无法解析生成的URI %q: %v", uri, err)
+	}
+	if cs.Username != cfg.MongoDB.Username || cs.Password != cfg.MongoDB.Password {
+		t.Fatalf("username = %q, password = %q", cs.Username, cs.Password)
+	}
+	if len(cs.Hosts) != 1 || cs.Hosts[0] != "db.example.com:27018" {
+		t.Fatalf("hosts = %v", cs.Hosts)
+	}
+	if cs.AuthSource != "admin" {
+		t.Fatalf("authSource = %q, want admin", cs.AuthSource)
+	}
+	if redacted := redactMongoURI(uri); strings.Contains(redacted, "w%2Frd") {
+		t.Fatalf("日志中的URI不应包含密码: %s", redacted)
+	}
+}
+
+func TestBuildMongoURIDefaults(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  func(c *config.Config)
+		want string
+	}{
+		{"默认主机和端口", func(c *config.Config) {}, "mongodb://localhost:27017"},
+		{"只有用户名", func(c *config.Config) { c.MongoDB.Username = "app" }, "mongodb://app@localhost:27017"},
+		{"主机自带端口", func(c *config.Config) { c.MongoDB.Host = "db:27000"; c.MongoDB.Port = 1 }, "mongodb://db:27000"},
+		{"多个主机", func(c *config.Config) { c.MongoDB.Host = "a:1,b:2" }, "mongodb://a:1,b:2"},
+		{"显式URI优先", func(c *config.Config) {
+			c.MongoDB.URI = "mongodb://explicit:27017"
+			c.MongoDB.Username = "ignored"
+		}, "mongodb://explicit:27017"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			tc.cfg(cfg)
+			if got := BuildMongoURI(cfg); got != tc.want {
+				t.Fatalf("BuildMongoURI() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errCodeNoReplicationEnabled 单节点部署执行 replSetGetStatus 时返回的错误码
+const errCodeNoReplicationEnabled = 76
+
+// replSetStatus replSetGetStatus 命令结果中需要的字段
+type replSetStatus struct {
+	Members []struct {
+		StateStr   string    `bson:"stateStr"`
+		Health     float64   `bson:"health"`
+		OptimeDate time.Time `bson:"optimeDate"`
+	} `bson:"members"`
+}
+
+/*
+ReplicationLag 查询副本集中延迟最大的从节点落后主节点的时间
+不可用的从节点不参与计算；单节点部署没有复制，返回0
+client: MongoDB客户端
+返回: 复制延迟, 错误
+*/
+func ReplicationLag(ctx context.Context, client *mongo.Client) (time.Duration, error) {
+	if client == nil {
+		return 0, errors.New("MongoDB未初始化")
+	}
+
+	var status replSetStatus
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(&status)
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == errCodeNoReplicationEnabled {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var primary time.Time
+	var oldest time.Time
+	for _, m := range status.Members {
+		switch {
+		case m.StateStr == "PRIMARY":
+			primary = m.OptimeDate
+		case m.StateStr == "SECONDARY" && m.Health == 1:
+			if oldest.IsZero() || m.OptimeDate.Before(oldest) {
+				oldest = m.OptimeDate
+			}
+		}
+	}
+
+	if primary.IsZero() || oldest.IsZero() || !oldest.Before(primary) {
+		return 0, nil
+	}
+	return primary.Sub(oldest), nil
+}
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/apikey"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// API密钥集合名称常量
+const APIKeyCollection = "api_keys"
+
+// APIKeyRepository API密钥存储库接口
+type APIKeyRepository interface {
+	Create(key *apikey.APIKey) error
+	FindByHash(keyHash string) (*apikey.APIKey, error)
+	FindByUser(userID uint) ([]*apikey.APIKey, error)
+	FindAllByUser(ctx context.Context, userID uint) ([]*apikey.APIKey, error)
+	Revoke(id string, userID uint) error
+	RevokeByUser(ctx context.Context, userID uint) (int64, error)
+	TouchLastUsed(id primitive.ObjectID, at time.Time) error
+}
+
+// MongoAPIKeyRepository MongoDB API密钥存储库实现
+type MongoAPIKeyRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewAPIKeyRepository 创建新的API密钥存储库
+func NewAPIKeyRepository(db *mongo.Database) APIKeyRepository {
+	if db == nil {
+		return &NullAPIKeyRepository{}
+	}
+
+	return &MongoAPIKeyRepository{
+		db:         db,
+		collection: db.Collection(APIKeyCollection),
+	}
+}
+
+// Create 创建API密钥
+func (r *MongoAPIKeyRepository) Create(key *apikey.APIKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return fmt.Errorf("创建API密钥失败: %w", classifyWriteError(err))
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		key.ID = id
+	}
+
+	return nil
+}
+
+// FindByHash 根据密钥哈希查找未吊销的API密钥
+func (r *MongoAPIKeyRepository) FindByHash(keyHash string) (*apikey.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var key apikey.APIKey
+	filter := bson.M{"key_hash": keyHash, "revoked_at": bson.M{"$exists": false}}
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, filter).Decode(&key)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("API密钥不存在")
+		}
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+
+	return &key, nil
+}
+
+// FindByUser 查询用户未吊销的API密钥，按创建时间升序
+func (r *MongoAPIKeyRepository) FindByUser(userID uint) ([]*apikey.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	keys := []*apikey.APIKey{}
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		keys = []*apikey.APIKey{}
+		return cursor.All(ctx, &keys)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+
+	return keys, nil
+}
+
+// FindAllByUser 查询用户的全部API密钥（包括已吊销的），按创建时间升序
+func (r *MongoAPIKeyRepository) FindAllByUser(ctx context.Context, userID uint) ([]*apikey.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	keys := []*apikey.APIKey{}
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		keys = []*apikey.APIKey{}
+		return cursor.All(ctx, &keys)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke 吊销用户的API密钥，吊销后的密钥保留记录但无法再使用
+func (r *MongoAPIKeyRepository) Revoke(id string, userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objID, "user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("吊销API密钥失败: %w", classifyWriteError(err))
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("API密钥不存在")
+	}
+
+	return nil
+}
+
+// RevokeByUser 吊销用户所有未吊销的API密钥，返回吊销的数量
+func (r *MongoAPIKeyRepository) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("吊销API密钥失败: %w", classifyWriteError(err))
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// TouchLastUsed 更新API密钥的最后使用时间
+func (r *MongoAPIKeyRepository) TouchLastUsed(id primitive.ObjectID, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}}); err != nil {
+		return fmt.Errorf("更新API密钥使用时间失败: %w", err)
+	}
+
+	return nil
+}
+
+// NullAPIKeyRepository 空API密钥存储库实现（空对象模式）
+type NullAPIKeyRepository struct{}
+
+// Create 创建API密钥 - 空实现
+func (r *NullAPIKeyRepository) Create(key *apikey.APIKey) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法创建API密钥")
+}
+
+// FindByHash 查找API密钥 - 空实现
+func (r *NullAPIKeyRepository) FindByHash(keyHash string) (*apikey.APIKey, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询API密钥")
+}
+
+// FindByUser 查询用户API密钥 - 空实现
+func (r *NullAPIKeyRepository) FindByUser(userID uint) ([]*apikey.APIKey, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询API密钥")
+}
+
+// FindAllByUser 查询用户的全部API密钥 - 空实现
+func (r *NullAPIKeyRepository) FindAllByUser(ctx context.Context, userID uint) ([]*apikey.APIKey, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询API密钥")
+}
+
+// Revoke 吊销API密钥 - 空实现
+func (r *NullAPIKeyRepository) Revoke(id string, userID uint) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法吊销API密钥")
+}
+
+// RevokeByUser 吊销用户所有API密钥 - 空实现
+func (r *NullAPIKeyRepository) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	return 0, fmt.Errorf("MongoDB数据库不可用，无法吊销API密钥")
+}
+
+// TouchLastUsed 更新API密钥使用时间 - 空实现
+func (r *NullAPIKeyRepository) TouchLastUsed(id primitive.ObjectID, at time.Time) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法更新API密钥")
+}
@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/audit"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 审计日志集合名称常量
+const AuditCollection = "audit_logs"
+
+// AuditRepository 审计日志存储库接口
+type AuditRepository interface {
+	Create(entry *audit.Entry) error
+	ReassignUser(ctx context.Context, fromUserID, toUserID uint) (int64, error)
+	FindByUser(userID uint) ([]*audit.Entry, error)
+	FindPageByUser(ctx context.Context, userID uint, filter audit.ActivityFilter, page, pageSize int) ([]*audit.Entry, int64, error)
+}
+
+// MongoAuditRepository MongoDB审计日志存储库实现
+type MongoAuditRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewAuditRepository 创建新的审计日志存储库
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	if db == nil {
+		return &NullAuditRepository{}
+	}
+
+	return &MongoAuditRepository{
+		db:         db,
+		collection: db.Collection(AuditCollection),
+	}
+}
+
+// Create 写入审计日志
+func (r *MongoAuditRepository) Create(entry *audit.Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+
+	return nil
+}
+
+// ReassignUser 将审计日志的所属用户从 fromUserID 转移到 toUserID，ctx 为事务上下文时在事务中执行
+func (r *MongoAuditRepository) ReassignUser(ctx context.Context, fromUserID, toUserID uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": fromUserID},
+		bson.M{"$set": bson.M{"user_id": toUserID}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("转移审计日志失败: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// FindByUser 查询用户的全部审计日志，按时间升序
+func (r *MongoAuditRepository) FindByUser(userID uint) ([]*audit.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	entries := []*audit.Entry{}
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		entries = []*audit.Entry{}
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+
+	return entries, nil
+}
+
+/*
+FindPageByUser 分页查询用户的审计日志，按时间倒序
+filter: 时间范围
+返回: 当前页的审计日志, 符合条件的总数, 错误
+*/
+func (r *MongoAuditRepository) FindPageByUser(ctx context.Context, userID uint, filter audit.ActivityFilter, page, pageSize int) ([]*audit.Entry, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": userID}
+	createdAt := bson.M{}
+	if filter.Since != nil {
+		createdAt["$gte"] = *filter.Since
+	}
+	if filter.Until != nil {
+		createdAt["$lt"] = *filter.Until
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	var total int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		total, err = r.collection.CountDocuments(ctx, query)
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计审计日志失败: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	entries := []*audit.Entry{}
+	err = database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, query, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		entries = []*audit.Entry{}
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// NullAuditRepository 空审计日志存储库实现（空对象模式）
+type NullAuditRepository struct{}
+
+// Create 写入审计日志 - 空实现
+func (r *NullAuditRepository) Create(entry *audit.Entry) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法写入审计日志")
+}
+
+// ReassignUser 转移审计日志 - 空实现
+func (r *NullAuditRepository) ReassignUser(ctx context.Context, fromUserID, toUserID uint) (int64, error) {
+	return 0, fmt.Errorf("MongoDB数据库不可用，无法转移审计日志")
+}
+
+// FindByUser 查询用户审计日志 - 空实现
+func (r *NullAuditRepository) FindByUser(userID uint) ([]*audit.Entry, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询审计日志")
+}
+
+// FindPageByUser 分页查询用户审计日志 - 空实现
+func (r *NullAuditRepository) FindPageByUser(ctx context.Context, userID uint, filter audit.ActivityFilter, page, pageSize int) ([]*audit.Entry, int64, error) {
+	return nil, 0, fmt.Errorf("MongoDB数据库不可用，无法查询审计日志")
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-app/models/audit"
+)
+
+func TestFindPageByUserReturnsOnlyOwnEntries(t *testing.T) {
+	repo := NewAuditRepository(newTestDatabase(t))
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, e := range []audit.Entry{
+		{UserID: 1, ActorID: 1, Action: audit.ActionUserLogin},
+		{UserID: 2, ActorID: 2, Action: audit.ActionUserLogin},
+		{UserID: 1, ActorID: 9, Action: audit.ActionUserRestore},
+		{UserID: 1, ActorID: 1, Action: audit.ActionUserLogin},
+	} {
+		e.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		if err := repo.Create(&e); err != nil {
+			t.Fatalf("写入审计日志失败: %v", err)
+		}
+	}
+
+	entries, total, err := repo.FindPageByUser(ctx, 1, audit.ActivityFilter{}, 1, 2)
+	if err != nil {
+		t.Fatalf("FindPageByUser: %v", err)
+	}
+	if total != 3 || len(entries) != 2 {
+		t.Fatalf("total = %d, len = %d, want 3, 2", total, len(entries))
+	}
+	for _, e := range entries {
+		if e.UserID != 1 {
+			t.Fatalf("返回了其他用户的记录: %+v", e)
+		}
+	}
+	if !entries[0].CreatedAt.After(entries[1].CreatedAt) {
+		t.Error("应按时间倒序")
+	}
+
+	since, until := base.Add(time.Hour), base.Add(3*time.Hour)
+	entries, total, err = repo.FindPageByUser(ctx, 1, audit.ActivityFilter{Since: &since, Until: &until}, 1, 10)
+	if err != nil {
+		t.Fatalf("FindPageByUser: %v", err)
+	}
+	if total != 1 || len(entries) != 1 || entries[0].Action != audit.ActionUserRestore {
+		t.Fatalf("时间过滤: total = %d, entries = %+v", total, entries)
+	}
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isDuplicateKeyOn 判断写入错误是否为指定唯一索引上的重复键冲突
+func isDuplicateKeyOn(err error, index string) bool {
+	var writeException mongo.WriteException
+	if !errors.As(err, &writeException) {
+		return false
+	}
+	for _, we := range writeException.WriteErrors {
+		if we.Code == codeDuplicateKey && strings.Contains(we.Message, "index: "+index+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// 写入错误分类，调用方可通过 errors.Is 判断并映射为相应的HTTP状态码
+var (
+	ErrDocumentValidation  = errors.New("文档校验失败")
+	ErrWriteConcernTimeout = errors.New("写入确认超时")
+)
+
+// ErrDuplicateUser 创建或恢复用户时用户名或邮箱与未删除的用户冲突，由唯一索引保证，不受并发注册影响
+var ErrDuplicateUser = errors.New("用户名或邮箱已存在")
+
+// MongoDB 错误码
+const (
+	codeDocumentValidation = 121 // DocumentValidationFailure
+	codeWriteConcernFailed = 64  // WriteConcernFailed，wtimeout 超时时返回
+	codeDuplicateKey       = 11000
+)
+
+// WriteError 分类后的写入错误
+// 保留原始错误便于记录日志，同时可通过 errors.Is 匹配到分类错误
+type WriteError struct {
+	Kind error // 分类错误，如 ErrDocumentValidation
+	Code int   // MongoDB 错误码
+	Err  error // 原始错误
+}
+
+func (e *WriteError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Is 判断是否属于指定的分类错误
+func (e *WriteError) Is(target error) bool {
+	return target == e.Kind
+}
+
+/*
+classifyWriteError 解析 mongo.WriteException、mongo.BulkWriteException 和 mongo.CommandError 的错误码，
+将已知类别转换为 WriteError；未知类别原样返回
+err: 驱动返回的错误
+返回: 分类后的错误
+*/
+func classifyWriteError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, code := range writeErrorCodes(err) {
+		switch code {
+		case codeDocumentValidation:
+			return &WriteError{Kind: ErrDocumentValidation, Code: code, Err: err}
+		case codeWriteConcernFailed:
+			return &WriteError{Kind: ErrWriteConcernTimeout, Code: code, Err: err}
+		}
+	}
+
+	return err
+}
+
+// writeErrorCodes 提取写入错误中的所有错误码
+func writeErrorCodes(err error) []int {
+	var codes []int
+
+	var writeException mongo.WriteException
+	if errors.As(err, &writeException) {
+		for _, we := range writeException.WriteErrors {
+			codes = append(codes, we.Code)
+		}
+		if writeException.WriteConcernError != nil {
+			codes = append(codes, writeException.WriteConcernError.Code)
+		}
+	}
+
+	var bulkException mongo.BulkWriteException
+	if errors.As(err, &bulkException) {
+		for _, we := range bulkException.WriteErrors {
+			codes = append(codes, we.Code)
+		}
+		if bulkException.WriteConcernError != nil {
+			codes = append(codes, bulkException.WriteConcernError.Code)
+		}
+	}
+
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) {
+		codes = append(codes, int(commandError.Code))
+	}
+
+	return codes
+}
+
+// BulkWriteFailure 批量写入中单个操作的失败信息
+type BulkWriteFailure struct {
+	Index   int    // 操作在输入列表中的下标
+	Code    int    // MongoDB 错误码
+	Message string // 错误信息
+}
+
+/*
+BulkWriteError 批量写入部分失败
+有序写入在第一个失败处停止，之后的操作未执行也不会出现在 Failures 中；
+写入确认失败时 Failures 可能为空，操作已执行但未达到要求的确认级别。
+可通过 errors.Is 匹配 ErrDocumentValidation 等分类错误
+*/
+type BulkWriteError struct {
+	Failures []BulkWriteFailure // 失败的操作，按下标排序
+	Err      error              // 分类后的原始错误
+}
+
+func (e *BulkWriteError) Error() string {
+	return fmt.Sprintf("批量写入部分失败（%d个操作失败）: %v", len(e.Failures), e.Err)
+}
+
+// Unwrap 返回分类后的原始错误
+func (e *BulkWriteError) Unwrap() error {
+	return e.Err
+}
+
+// newBulkWriteError 将 mongo.BulkWriteException 转换为 BulkWriteError，其他错误返回nil
+func newBulkWriteError(err error) *BulkWriteError {
+	var bulkException mongo.BulkWriteException
+	if !errors.As(err, &bulkException) {
+		return nil
+	}
+
+	failures := make([]BulkWriteFailure, 0, len(bulkException.WriteErrors))
+	for _, we := range bulkException.WriteErrors {
+		failures = append(failures, BulkWriteFailure{Index: we.Index, Code: we.Code, Message: we.Message})
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Index < failures[j].Index })
+
+	return &BulkWriteError{Failures: failures, Err: classifyWriteError(err)}
+}
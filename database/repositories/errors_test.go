@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestClassifyWriteError(t *testing.T) {
+	validation := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: codeDocumentValidation, Message: "Document failed validation"}}}
+	concern := mongo.WriteException{WriteConcernError: &mongo.WriteConcernError{Code: codeWriteConcernFailed, Message: "waiting for replication timed out"}}
+	bulk := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Index: 1, Code: codeDocumentValidation}}}}
+	command := mongo.CommandError{Code: codeWriteConcernFailed, Message: "timeout"}
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: codeDuplicateKey}}}
+
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"文档校验失败", validation, ErrDocumentValidation},
+		{"写入确认超时", concern, ErrWriteConcernTimeout},
+		{"批量写入中的校验失败", bulk, ErrDocumentValidation},
+		{"命令错误", command, ErrWriteConcernTimeout},
+		{"包装后的错误", fmt.Errorf("更新失败: %w", validation), ErrDocumentValidation},
+	}
+	for _, tc := range cases {
+		got := classifyWriteError(tc.err)
+		if !errors.Is(got, tc.want) {
+			t.Errorf("%s: classifyWriteError = %v, want %v", tc.name, got, tc.want)
+		}
+		// 保留原始错误
+		var we *WriteError
+		if !errors.As(got, &we) || we.Err.Error() != tc.err.Error() {
+			t.Errorf("%s: 原始错误丢失: %v", tc.name, got)
+		}
+	}
+
+	// 未知类别原样返回
+	for _, err := range []error{duplicate, errors.New("网络错误")} {
+		if got := classifyWriteError(err); got == nil || got.Error() != err.Error() {
+			t.Errorf("classifyWriteError(%v) = %v, want 原样返回", err, got)
+		}
+	}
+	if classifyWriteError(nil) != nil {
+		t.Error("classifyWriteError(nil) 应返回nil")
+	}
+}
+
+func TestNewBulkWriteErrorSortsFailures(t *testing.T) {
+	err := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 3, Code: codeDuplicateKey}},
+		{WriteError: mongo.WriteError{Index: 1, Code: codeDocumentValidation}},
+	}}
+
+	bulkErr := newBulkWriteError(err)
+	if bulkErr == nil || len(bulkErr.Failures) != 2 {
+		t.Fatalf("newBulkWriteError = %v", bulkErr)
+	}
+	if bulkErr.Failures[0].Index != 1 || bulkErr.Failures[1].Index != 3 {
+		t.Fatalf("Failures = %+v, want 按下标排序", bulkErr.Failures)
+	}
+	if !errors.Is(bulkErr, ErrDocumentValidation) {
+		t.Fatal("BulkWriteError 应能匹配分类错误")
+	}
+	if newBulkWriteError(errors.New("其他错误")) != nil {
+		t.Fatal("非批量写入错误应返回nil")
+	}
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"go-app/models/user"
+	"go-app/utils"
+)
+
+// 支持加密存储的用户字段
+const EncryptedFieldEmail = "email"
+
+// 字段加密配置，通过 SetFieldEncryption 设置，默认不加密
+var (
+	fieldCrypter   utils.Crypter
+	encryptedEmail bool
+)
+
+/*
+SetFieldEncryption 设置用户字段的加密存储
+crypter: 字段加密器，为nil时关闭加密
+fields: 需要加密的字段，目前支持 email；加密后按邮箱查询使用 email_hash 精确匹配，
+关键词搜索和取值统计不再覆盖该字段
+*/
+func SetFieldEncryption(crypter utils.Crypter, fields []string) {
+	fieldCrypter = crypter
+	encryptedEmail = false
+	if crypter == nil {
+		return
+	}
+
+	for _, field := range fields {
+		if strings.TrimSpace(field) == EncryptedFieldEmail {
+			encryptedEmail = true
+		}
+	}
+}
+
+// isEncryptedField 判断字段是否加密存储
+func isEncryptedField(field string) bool {
+	return field == EncryptedFieldEmail && encryptedEmail
+}
+
+// emailHash 计算用于查询的邮箱哈希，邮箱不区分大小写
+func emailHash(email string) string {
+	return fieldCrypter.Hash(strings.ToLower(strings.TrimSpace(email)))
+}
+
+// encryptUser 返回加密了指定字段的用户副本，原对象保持明文
+func encryptUser(u *user.User) (*user.User, error) {
+	if !encryptedEmail {
+		return u, nil
+	}
+
+	doc := *u
+	if doc.Email != "" {
+		ciphertext, err := fieldCrypter.Encrypt(doc.Email)
+		if err != nil {
+			return nil, fmt.Errorf("加密邮箱失败: %w", err)
+		}
+		doc.Email = ciphertext
+		doc.EmailHash = emailHash(u.Email)
+	}
+	return &doc, nil
+}
+
+// decryptUser 就地解密用户的加密字段，加密启用前写入的明文原样保留
+func decryptUser(u *user.User) error {
+	if fieldCrypter == nil || u.Email == "" {
+		return nil
+	}
+
+	email, err := fieldCrypter.Decrypt(u.Email)
+	if err != nil {
+		return fmt.Errorf("解密邮箱失败: %w", err)
+	}
+	u.Email = email
+	return nil
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"go-app/models/user"
+	"go-app/utils"
+)
+
+func enableEmailEncryption(t *testing.T) {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	crypter, err := utils.NewAESGCMCrypter(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetFieldEncryption(crypter, []string{" email "})
+	t.Cleanup(func() { SetFieldEncryption(nil, nil) })
+}
+
+func TestEncryptUserKeepsCallerPlaintext(t *testing.T) {
+	enableEmailEncryption(t)
+
+	u := &user.User{ID: 1, Email: "Alice@Example.com"}
+	doc, err := encryptUser(u)
+	if err != nil {
+		t.Fatalf("加密用户失败: %v", err)
+	}
+	if u.Email != "Alice@Example.com" || u.EmailHash != "" {
+		t.Fatalf("调用方的用户被修改: %+v", u)
+	}
+	if doc.Email == u.Email || doc.EmailHash != emailHash("alice@example.com") {
+		t.Fatalf("doc = %+v", doc)
+	}
+
+	if err := decryptUser(doc); err != nil || doc.Email != "Alice@Example.com" {
+		t.Fatalf("decryptUser = %q, %v", doc.Email, err)
+	}
+	if !isEncryptedField(EncryptedFieldEmail) {
+		t.Fatal("email 应为加密字段")
+	}
+}
+
+func TestEncryptUserDisabled(t *testing.T) {
+	SetFieldEncryption(nil, []string{EncryptedFieldEmail})
+
+	u := &user.User{ID: 1, Email: "alice@example.com"}
+	doc, err := encryptUser(u)
+	if err != nil || doc.Email != u.Email || doc.EmailHash != "" {
+		t.Fatalf("未启用加密时应原样返回: %+v, %v", doc, err)
+	}
+	if isEncryptedField(EncryptedFieldEmail) {
+		t.Fatal("未启用加密时 email 不应为加密字段")
+	}
+}
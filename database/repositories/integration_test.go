@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go-app/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTestURIEnv 集成测试使用的MongoDB连接串，未设置时跳过依赖MongoDB的测试
+const mongoTestURIEnv = "MONGODB_TEST_URI"
+
+/*
+newTestDatabase 连接 MONGODB_TEST_URI 并创建独立的测试数据库，执行除默认管理员以外的全部迁移
+测试结束时删除该数据库
+*/
+func newTestDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv(mongoTestURIEnv)
+	if uri == "" {
+		t.Skipf("未设置 %s，跳过MongoDB集成测试", mongoTestURIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("连接MongoDB失败: %v", err)
+	}
+	db := client.Database(fmt.Sprintf("go_app_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	if _, err := database.RunMigrations(db, database.SeedAdminMigration); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
+
+func TestRunAggregationGroupsAndNormalizes(t *testing.T) {
+	db := newTestDatabase(t)
+	// RunAggregation 使用全局数据库
+	saved := database.MongoDB
+	database.MongoDB = db
+	t.Cleanup(func() { database.MongoDB = saved })
+
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	docs := []interface{}{
+		bson.M{"kind": "a", "at": at},
+		bson.M{"kind": "a", "at": at.Add(time.Hour)},
+		bson.M{"kind": "b", "at": at},
+	}
+	if _, err := db.Collection("reports").InsertMany(context.Background(), docs); err != nil {
+		t.Fatalf("插入文档失败: %v", err)
+	}
+
+	results, err := database.RunAggregation("reports", mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$kind", "count": bson.M{"$sum": 1}, "last": bson.M{"$max": "$at"}, "first_id": bson.M{"$min": "$_id"}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		t.Fatalf("RunAggregation: %v", err)
+	}
+	if len(results) != 2 || results[0]["_id"] != "a" || results[0]["count"] != int32(2) {
+		t.Fatalf("results = %v", results)
+	}
+	if results[0]["last"] != at.Add(time.Hour) {
+		t.Fatalf("last = %#v, want %v", results[0]["last"], at.Add(time.Hour))
+	}
+	if _, ok := results[0]["first_id"].(string); !ok {
+		t.Fatalf("first_id = %#v, want ObjectID的十六进制字符串", results[0]["first_id"])
+	}
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/security"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 登录失败事件集合名称常量
+const FailedLoginCollection = "failed_logins"
+
+// LoginEventRepository 登录事件存储库接口
+type LoginEventRepository interface {
+	CreateFailure(event *security.FailedLogin) error
+	CountFailures(from, to time.Time, bucket string) ([]security.FailedLoginBucket, error)
+}
+
+// MongoLoginEventRepository MongoDB登录事件存储库实现
+// 集合为固定大小集合（由迁移创建），旧事件会被自动覆盖
+type MongoLoginEventRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewLoginEventRepository 创建新的登录事件存储库
+func NewLoginEventRepository(db *mongo.Database) LoginEventRepository {
+	if db == nil {
+		return &NullLoginEventRepository{}
+	}
+
+	return &MongoLoginEventRepository{
+		db:         db,
+		collection: db.Collection(FailedLoginCollection),
+	}
+}
+
+// CreateFailure 记录登录失败事件
+func (r *MongoLoginEventRepository) CreateFailure(event *security.FailedLogin) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, event); err != nil {
+		return fmt.Errorf("记录登录失败事件失败: %w", classifyWriteError(err))
+	}
+	return nil
+}
+
+/*
+CountFailures 按时间段统计登录失败次数（UTC）
+from: 开始时间（含）
+to: 结束时间（不含）
+bucket: 时间粒度，hour 或 day
+返回: 有记录的时间段及次数（按时间升序，不含次数为0的时间段）, 错误
+*/
+func (r *MongoLoginEventRepository) CountFailures(from, to time.Time, bucket string) ([]security.FailedLoginBucket, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	parts := bson.M{
+		"year":  bson.M{"$year": "$created_at"},
+		"month": bson.M{"$month": "$created_at"},
+		"day":   bson.M{"$dayOfMonth": "$created_at"},
+	}
+	if bucket == security.BucketHour {
+		parts["hour"] = bson.M{"$hour": "$created_at"}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateFromParts": parts},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	var buckets []security.FailedLoginBucket
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		buckets = nil
+		return cursor.All(ctx, &buckets)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("统计登录失败事件失败: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// NullLoginEventRepository 空登录事件存储库实现（空对象模式）
+type NullLoginEventRepository struct{}
+
+// CreateFailure 记录登录失败事件 - 空实现
+func (r *NullLoginEventRepository) CreateFailure(event *security.FailedLogin) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法记录登录事件")
+}
+
+// CountFailures 统计登录失败次数 - 空实现
+func (r *NullLoginEventRepository) CountFailures(from, to time.Time, bucket string) ([]security.FailedLoginBucket, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法统计登录事件")
+}
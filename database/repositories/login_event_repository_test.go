@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"go-app/models/security"
+)
+
+func TestCountFailuresGroupsByBucket(t *testing.T) {
+	repo := NewLoginEventRepository(newTestDatabase(t))
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{5 * time.Minute, 20 * time.Minute, 2*time.Hour + time.Minute, 26 * time.Hour} {
+		if err := repo.CreateFailure(&security.FailedLogin{UsernameHash: "h", IP: "10.0.0.1", CreatedAt: base.Add(offset)}); err != nil {
+			t.Fatalf("记录登录失败事件失败: %v", err)
+		}
+	}
+
+	hourly, err := repo.CountFailures(base, base.Add(4*time.Hour), security.BucketHour)
+	if err != nil {
+		t.Fatalf("按小时统计失败: %v", err)
+	}
+	wantHourly := []security.FailedLoginBucket{{Start: base, Count: 2}, {Start: base.Add(2 * time.Hour), Count: 1}}
+	assertBuckets(t, hourly, wantHourly)
+
+	daily, err := repo.CountFailures(base.Add(-10*time.Hour), base.Add(38*time.Hour), security.BucketDay)
+	if err != nil {
+		t.Fatalf("按天统计失败: %v", err)
+	}
+	day := base.Add(-10 * time.Hour)
+	wantDaily := []security.FailedLoginBucket{{Start: day, Count: 3}, {Start: day.Add(24 * time.Hour), Count: 1}}
+	assertBuckets(t, daily, wantDaily)
+}
+
+func assertBuckets(t *testing.T, got, want []security.FailedLoginBucket) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("buckets = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || got[i].Count != want[i].Count {
+			t.Fatalf("buckets[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -2,10 +2,15 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	"go-app/database"
+	"go-app/models/common"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongodb "go.mongodb.org/mongo-driver/mongo"
@@ -14,6 +19,8 @@ import (
 
 /*
 MongoRepository MongoDB通用存储库
+所有方法的第一个参数为调用方的上下文，查询在其基础上另设10秒的超时上限；
+传入 TransactionManager.WithTransaction 提供的 sessCtx 时，操作在该事务中执行
 db: 数据库
 collectionName: 集合名称
 返回: MongoDB存储库
@@ -51,20 +58,38 @@ func NewMongoRepository(db *mongodb.Database, collectionName string) *MongoRepos
 filter: 查询条件
 skip: 跳过数量
 limit: 限制数量
-sort: 排序
+sort: 排序，为空时使用默认排序，并始终以 _id 作为次级排序键
+返回: 文档列表, 总数, 错误
+*/
+func (r *MongoRepository) FindAll(ctx context.Context, filter bson.M, skip, limit int64, sort bson.D) ([]bson.M, int64, error) {
+	return r.FindAllWithOptions(ctx, filter, skip, limit, sort, nil)
+}
+
+/*
+查找所有文档，只返回指定字段
+filter: 查询条件
+skip: 跳过数量
+limit: 限制数量
+sort: 排序，为空时使用默认排序，并始终以 _id 作为次级排序键
+projection: 字段投影，如 bson.M{"username": 1} 或 bson.M{"password": 0}，为空时返回完整文档
 返回: 文档列表, 总数, 错误
 */
-func (r *MongoRepository) FindAll(filter bson.M, skip, limit int64, sort bson.D) ([]bson.M, int64, error) {
+func (r *MongoRepository) FindAllWithOptions(ctx context.Context, filter bson.M, skip, limit int64, sort bson.D, projection bson.M) ([]bson.M, int64, error) {
 	// 检查数据库连接和集合是否可用
 	if r.db == nil || r.collection == nil {
 		return nil, 0, fmt.Errorf("数据库连接不可用")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// 计算总数
-	count, err := r.collection.CountDocuments(ctx, filter)
+	var count int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		count, err = r.collection.CountDocuments(ctx, filter)
+		return err
+	})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -77,24 +102,248 @@ func (r *MongoRepository) FindAll(filter bson.M, skip, limit int64, sort bson.D)
 	if limit > 0 {
 		opts.SetLimit(limit)
 	}
-	if len(sort) > 0 {
-		opts.SetSort(sort)
+	opts.SetSort(stableSort(sort, "_id"))
+	if len(projection) > 0 {
+		opts.SetProjection(projection)
 	}
 
-	// 执行查询
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	// 执行查询并解析结果，遇到可重试错误时整体重试
+	var results []bson.M
+	err = database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		results = nil
+		return cursor.All(ctx, &results)
+	})
 	if err != nil {
 		return nil, 0, err
 	}
-	defer cursor.Close(ctx)
 
-	// 解析结果
+	return results, count, nil
+}
+
+// 分页查询的每页条数上限，与 common.PaginationParams 的校验规则一致
+const maxPageSize = 100
+
+/*
+分页查询文档，返回分页响应
+filter: 查询条件
+page: 页码，从1开始，小于1时按1处理
+pageSize: 每页条数，小于1时使用默认值，超过100时按100处理
+sort: 排序，规则同 FindAll
+返回: 分页响应（文档中的ObjectID转换为十六进制字符串、日期转换为UTC时间）, 错误
+*/
+func (r *MongoRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int, sort bson.D) (*common.PaginatedResponse, error) {
+	defaults := common.GetDefaultPagination()
+	if page < 1 {
+		page = defaults.Page
+	}
+	if pageSize < 1 {
+		pageSize = defaults.PageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	params := common.PaginationParams{Page: page, PageSize: pageSize}
+	results, total, err := r.FindAll(ctx, filter, int64(params.GetOffset()), int64(params.GetLimit()), sort)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]interface{}, len(results))
+	for i, doc := range results {
+		data[i] = database.NormalizeDocument(doc)
+	}
+
+	return common.NewPaginatedResponse(total, page, pageSize, data), nil
+}
+
+/*
+查询字段的不重复取值
+field: 字段名，支持嵌套字段（如 profile.country）
+filter: 查询条件，为nil时匹配全部文档
+返回: 不重复的取值列表, 错误
+*/
+func (r *MongoRepository) Distinct(ctx context.Context, field string, filter bson.M) ([]interface{}, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+
+	if field == "" || strings.HasPrefix(field, "$") {
+		return nil, fmt.Errorf("无效的字段名: %s", field)
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var values []interface{}
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		values, err = r.collection.Distinct(ctx, field, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+/*
+统计符合条件的文档数，只执行 CountDocuments，不查询和解码文档
+filter: 查询条件，为nil时统计全部文档
+返回: 文档数, 错误
+*/
+func (r *MongoRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return 0, fmt.Errorf("数据库连接不可用")
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		count, err = r.collection.CountDocuments(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+/*
+判断是否存在符合条件的文档，找到一个即停止计数
+filter: 查询条件，为nil时判断集合是否为空
+返回: 是否存在, 错误
+*/
+func (r *MongoRepository) Exists(ctx context.Context, filter bson.M) (bool, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return false, fmt.Errorf("数据库连接不可用")
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		count, err = r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+/*
+执行聚合管道，用于分组统计、关联查询（$lookup）等 Find 无法完成的查询
+pipeline: 聚合管道
+返回: 聚合结果, 错误
+*/
+func (r *MongoRepository) Aggregate(ctx context.Context, pipeline mongodb.Pipeline) ([]bson.M, error) {
 	var results []bson.M
-	if err = cursor.All(ctx, &results); err != nil {
+	if err := r.aggregateInto(ctx, pipeline, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// aggregateInto 执行聚合管道并将全部结果解码到 results（切片指针），遇到可重试错误时整体重试
+func (r *MongoRepository) aggregateInto(ctx context.Context, pipeline mongodb.Pipeline, results interface{}) error {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return fmt.Errorf("数据库连接不可用")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		return cursor.All(ctx, results)
+	})
+}
+
+// aggregatePage 分页聚合 $facet 阶段的输出
+type aggregatePage struct {
+	Data  []bson.M `bson:"data"`
+	Total []struct {
+		Count int64 `bson:"count"`
+	} `bson:"total"`
+}
+
+/*
+分页执行聚合管道，在管道末尾追加 $facet 阶段，一次查询同时返回当前页和总数
+$facet 的输出是单个文档，当前页的数据总量不能超过16MB；管道中不能包含 $out、$merge 等必须位于末尾的阶段
+pipeline: 聚合管道，不会被修改；需要稳定的分页顺序时应在管道中包含 $sort
+page: 页码，从1开始，小于1时按1处理
+pageSize: 每页条数，小于1时使用默认值，超过100时按100处理
+返回: 当前页的聚合结果, 总数, 错误
+*/
+func (r *MongoRepository) AggregatePaginated(ctx context.Context, pipeline mongodb.Pipeline, page, pageSize int) ([]bson.M, int64, error) {
+	defaults := common.GetDefaultPagination()
+	if page < 1 {
+		page = defaults.Page
+	}
+	if pageSize < 1 {
+		pageSize = defaults.PageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	params := common.PaginationParams{Page: page, PageSize: pageSize}
+
+	stages := make(mongodb.Pipeline, 0, len(pipeline)+1)
+	stages = append(stages, pipeline...)
+	stages = append(stages, bson.D{{Key: "$facet", Value: bson.D{
+		{Key: "data", Value: bson.A{
+			bson.D{{Key: "$skip", Value: params.GetOffset()}},
+			bson.D{{Key: "$limit", Value: params.GetLimit()}},
+		}},
+		{Key: "total", Value: bson.A{
+			bson.D{{Key: "$count", Value: "count"}},
+		}},
+	}}})
+
+	var pages []aggregatePage
+	if err := r.aggregateInto(ctx, stages, &pages); err != nil {
 		return nil, 0, err
 	}
+	if len(pages) == 0 || len(pages[0].Total) == 0 {
+		return []bson.M{}, 0, nil
+	}
 
-	return results, count, nil
+	return pages[0].Data, pages[0].Total[0].Count, nil
 }
 
 /*
@@ -102,8 +351,8 @@ func (r *MongoRepository) FindAll(filter bson.M, skip, limit int64, sort bson.D)
 id: 文档ID
 返回: 文档, 错误
 */
-func (r *MongoRepository) FindByID(id string) (bson.M, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) FindByID(ctx context.Context, id string) (bson.M, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -112,7 +361,9 @@ func (r *MongoRepository) FindByID(id string) (bson.M, error) {
 	}
 
 	var result bson.M
-	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&result)
+	err = database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&result)
+	})
 	if err != nil {
 		if err == mongodb.ErrNoDocuments {
 			return nil, fmt.Errorf("文档不存在")
@@ -128,12 +379,14 @@ func (r *MongoRepository) FindByID(id string) (bson.M, error) {
 filter: 查询条件
 返回: 文档, 错误
 */
-func (r *MongoRepository) FindOne(filter bson.M) (bson.M, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) FindOne(ctx context.Context, filter bson.M) (bson.M, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var result bson.M
-	err := r.collection.FindOne(ctx, filter).Decode(&result)
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, filter).Decode(&result)
+	})
 	if err != nil {
 		if err == mongodb.ErrNoDocuments {
 			return nil, fmt.Errorf("文档不存在")
@@ -149,41 +402,127 @@ func (r *MongoRepository) FindOne(filter bson.M) (bson.M, error) {
 document: 文档
 返回: 文档ID, 错误
 */
-func (r *MongoRepository) Create(document interface{}) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) Create(ctx context.Context, document interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// 确保创建和更新时间字段存在
-	rv := reflect.ValueOf(document)
-	if rv.Kind() == reflect.Ptr {
-		rv = rv.Elem()
+	setCreateTimestamps(document, time.Now())
+
+	result, err := r.collection.InsertOne(ctx, document)
+	if err != nil {
+		return "", classifyWriteError(err)
 	}
 
-	if rv.Kind() == reflect.Struct {
-		now := time.Now()
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("无法获取插入的ID")
+	}
 
-		createdAtField := rv.FieldByName("CreatedAt")
-		if createdAtField.IsValid() && createdAtField.CanSet() {
-			createdAtField.Set(reflect.ValueOf(now))
+	return id.Hex(), nil
+}
+
+/*
+批量创建文档，一次请求写入所有文档
+按顺序写入，遇到失败时停止，之后的文档不会写入
+documents: 文档列表，结构体文档的 CreatedAt、UpdatedAt 字段会被设置为当前时间
+返回: 已插入文档的ID（顺序与 documents 一致，ObjectID为十六进制字符串）, 错误（部分失败时为 *BulkWriteError，同时返回失败前已插入的ID）
+*/
+func (r *MongoRepository) CreateMany(ctx context.Context, documents []interface{}) ([]string, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("文档列表不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	for _, document := range documents {
+		setCreateTimestamps(document, now)
+	}
+
+	result, err := r.collection.InsertMany(ctx, documents)
+	if result == nil {
+		return nil, classifyWriteError(err)
+	}
+
+	// 有序写入时，第一个失败之前的文档均已插入
+	inserted := len(result.InsertedIDs)
+	bulkErr := newBulkWriteError(err)
+	if bulkErr != nil && len(bulkErr.Failures) > 0 && bulkErr.Failures[0].Index < inserted {
+		inserted = bulkErr.Failures[0].Index
+	}
+
+	ids := make([]string, 0, inserted)
+	for _, id := range result.InsertedIDs[:inserted] {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			ids = append(ids, oid.Hex())
+		} else {
+			ids = append(ids, fmt.Sprint(id))
 		}
+	}
 
-		updatedAtField := rv.FieldByName("UpdatedAt")
-		if updatedAtField.IsValid() && updatedAtField.CanSet() {
-			updatedAtField.Set(reflect.ValueOf(now))
+	if bulkErr != nil {
+		return ids, bulkErr
+	}
+	return ids, classifyWriteError(err)
+}
+
+/*
+批量执行混合的写入操作（插入、更新、替换、删除），一次请求发送
+按顺序执行，遇到失败时停止，之后的操作不会执行
+models: 写入操作，插入操作中结构体文档的 CreatedAt、UpdatedAt 字段会被设置为当前时间；更新操作不会自动追加 updated_at
+返回: 各类操作的影响数量, 错误（部分失败时为 *BulkWriteError，影响数量只包含已执行的操作）
+*/
+func (r *MongoRepository) BulkWrite(ctx context.Context, models []mongodb.WriteModel) (*mongodb.BulkWriteResult, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("写入操作不能为空")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	for _, model := range models {
+		if insert, ok := model.(*mongodb.InsertOneModel); ok {
+			setCreateTimestamps(insert.Document, now)
 		}
 	}
 
-	result, err := r.collection.InsertOne(ctx, document)
-	if err != nil {
-		return "", err
+	result, err := r.collection.BulkWrite(ctx, models)
+	if bulkErr := newBulkWriteError(err); bulkErr != nil {
+		return result, bulkErr
 	}
+	return result, classifyWriteError(err)
+}
 
-	id, ok := result.InsertedID.(primitive.ObjectID)
-	if !ok {
-		return "", fmt.Errorf("无法获取插入的ID")
+// setCreateTimestamps 将结构体文档的 CreatedAt、UpdatedAt 字段设置为指定时间，非结构体文档（如 bson.M）不做处理
+func setCreateTimestamps(document interface{}, now time.Time) {
+	rv := reflect.ValueOf(document)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return
 	}
 
-	return id.Hex(), nil
+	createdAtField := rv.FieldByName("CreatedAt")
+	if createdAtField.IsValid() && createdAtField.CanSet() {
+		createdAtField.Set(reflect.ValueOf(now))
+	}
+
+	updatedAtField := rv.FieldByName("UpdatedAt")
+	if updatedAtField.IsValid() && updatedAtField.CanSet() {
+		updatedAtField.Set(reflect.ValueOf(now))
+	}
 }
 
 /*
@@ -192,8 +531,8 @@ id: 文档ID
 update: 更新条件
 返回: 错误
 */
-func (r *MongoRepository) Update(id string, update bson.M) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) Update(ctx context.Context, id string, update bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -202,15 +541,14 @@ func (r *MongoRepository) Update(id string, update bson.M) error {
 	}
 
 	// 添加更新时间
-	if update["$set"] == nil {
-		update["$set"] = bson.M{}
+	update, err = withUpdatedAt(update, time.Now())
+	if err != nil {
+		return err
 	}
-	updateSet := update["$set"].(bson.M)
-	updateSet["updated_at"] = time.Now()
 
 	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 	if err != nil {
-		return err
+		return classifyWriteError(err)
 	}
 
 	if result.MatchedCount == 0 {
@@ -220,13 +558,140 @@ func (r *MongoRepository) Update(id string, update bson.M) error {
 	return nil
 }
 
+// reservedFields 不允许通过 UpdateFields 修改的字段
+var reservedFields = []string{"_id", "created_at"}
+
+/*
+按字段部分更新文档，未指定的字段保持不变
+id: 文档ID
+fields: 需要更新的字段及其新值，会自动包装为 $set 并追加 updated_at
+返回: 错误（尝试修改 _id、created_at 等保留字段时返回错误）
+*/
+func (r *MongoRepository) UpdateFields(ctx context.Context, id string, fields bson.M) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("更新字段不能为空")
+	}
+
+	for _, name := range reservedFields {
+		if _, ok := fields[name]; ok {
+			return fmt.Errorf("不允许修改保留字段: %s", name)
+		}
+	}
+	for name := range fields {
+		if strings.HasPrefix(name, "$") {
+			return fmt.Errorf("无效的字段名: %s", name)
+		}
+	}
+
+	// 复制字段，避免修改调用方传入的map
+	set := make(bson.M, len(fields)+1)
+	for k, v := range fields {
+		set[k] = v
+	}
+
+	return r.Update(ctx, id, bson.M{"$set": set})
+}
+
+/*
+withUpdatedAt 返回在 $set 中追加了 updated_at 的更新条件副本，不修改调用方传入的更新条件
+$set 支持 bson.M、map[string]interface{} 和 bson.D，其他类型返回错误
+update: 更新条件
+now: 更新时间
+返回: 新的更新条件, 错误
+*/
+func withUpdatedAt(update bson.M, now time.Time) (bson.M, error) {
+	result := make(bson.M, len(update)+1)
+	for k, v := range update {
+		result[k] = v
+	}
+
+	switch set := update["$set"].(type) {
+	case nil:
+		result["$set"] = bson.M{"updated_at": now}
+	case bson.M:
+		result["$set"] = copySetWithUpdatedAt(set, now)
+	case map[string]interface{}:
+		result["$set"] = copySetWithUpdatedAt(set, now)
+	case bson.D:
+		d := make(bson.D, 0, len(set)+1)
+		for _, e := range set {
+			if e.Key != "updated_at" {
+				d = append(d, e)
+			}
+		}
+		result["$set"] = append(d, bson.E{Key: "updated_at", Value: now})
+	default:
+		return nil, fmt.Errorf("不支持的 $set 类型: %T", set)
+	}
+	return result, nil
+}
+
+// copySetWithUpdatedAt 复制 $set 的字段并追加 updated_at
+func copySetWithUpdatedAt(set map[string]interface{}, now time.Time) bson.M {
+	cp := make(bson.M, len(set)+1)
+	for k, v := range set {
+		cp[k] = v
+	}
+	cp["updated_at"] = now
+	return cp
+}
+
+/*
+更新单个文档并返回更新后的文档
+filter: 查询条件
+update: 更新条件
+返回: 更新后的文档, 错误
+*/
+func (r *MongoRepository) FindOneAndUpdate(ctx context.Context, filter, update bson.M) (bson.M, error) {
+	var result bson.M
+	if err := r.FindOneAndUpdateInto(ctx, filter, update, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+/*
+更新单个文档并将更新后的文档解码到 result
+filter: 查询条件
+update: 更新条件
+result: 解码目标，需为指针
+返回: 错误
+*/
+func (r *MongoRepository) FindOneAndUpdateInto(ctx context.Context, filter, update bson.M, result interface{}) error {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return fmt.Errorf("数据库连接不可用")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 添加更新时间
+	update, err := withUpdatedAt(update, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// 原子地更新并返回更新后的文档
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			return fmt.Errorf("文档不存在")
+		}
+		return classifyWriteError(err)
+	}
+
+	return nil
+}
+
 /*
 删除文档
 id: 文档ID
 返回: 错误
 */
-func (r *MongoRepository) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -246,13 +711,54 @@ func (r *MongoRepository) Delete(id string) error {
 	return nil
 }
 
+// ErrEmptyFilter 批量删除的条件为空，删除全部文档需显式调用 DeleteAll
+var ErrEmptyFilter = errors.New("删除条件不能为空")
+
+/*
+删除所有符合条件的文档
+filter: 查询条件，不能为空，避免条件拼接出错时误删整个集合
+返回: 删除的文档数, 错误（条件为空时返回 ErrEmptyFilter）
+*/
+func (r *MongoRepository) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	if len(filter) == 0 {
+		return 0, ErrEmptyFilter
+	}
+	return r.deleteMany(ctx, filter)
+}
+
+/*
+删除集合中的所有文档，保留集合和索引
+返回: 删除的文档数, 错误
+*/
+func (r *MongoRepository) DeleteAll(ctx context.Context) (int64, error) {
+	return r.deleteMany(ctx, bson.M{})
+}
+
+// deleteMany 执行批量删除，不检查条件是否为空
+func (r *MongoRepository) deleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	// 检查数据库连接和集合是否可用
+	if r.db == nil || r.collection == nil {
+		return 0, fmt.Errorf("数据库连接不可用")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, classifyWriteError(err)
+	}
+
+	return result.DeletedCount, nil
+}
+
 /*
 保存文档（创建或更新）
 document: 文档
 返回: 错误
 */
-func (r *MongoRepository) Save(document interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoRepository) Save(ctx context.Context, document interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	rv := reflect.ValueOf(document)
@@ -286,7 +792,7 @@ func (r *MongoRepository) Save(document interface{}) error {
 
 		_, err := r.collection.InsertOne(ctx, document)
 		if err != nil {
-			return err
+			return classifyWriteError(err)
 		}
 
 		return nil
@@ -294,5 +800,5 @@ func (r *MongoRepository) Save(document interface{}) error {
 
 	// 否则更新
 	opts := options.FindOneAndReplace().SetUpsert(true)
-	return r.collection.FindOneAndReplace(ctx, filter, document, opts).Err()
+	return classifyWriteError(r.collection.FindOneAndReplace(ctx, filter, document, opts).Err())
 }
@@ -0,0 +1,377 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWithUpdatedAtDoesNotModifyCaller(t *testing.T) {
+	now := time.Now()
+	set := bson.M{"name": "a"}
+	update := bson.M{"$set": set, "$inc": bson.M{"n": 1}}
+
+	got, err := withUpdatedAt(update, now)
+	if err != nil {
+		t.Fatalf("withUpdatedAt: %v", err)
+	}
+
+	if _, ok := set["updated_at"]; ok {
+		t.Error("caller's $set was modified")
+	}
+	if got["$set"].(bson.M)["updated_at"] != now || got["$set"].(bson.M)["name"] != "a" {
+		t.Errorf("$set = %v", got["$set"])
+	}
+	if _, ok := got["$inc"]; !ok {
+		t.Error("$inc was dropped")
+	}
+}
+
+func TestWithUpdatedAtSetTypes(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		set  interface{}
+	}{
+		{"missing", nil},
+		{"bson.M", bson.M{"a": 1}},
+		{"map", map[string]interface{}{"a": 1}},
+		{"bson.D", bson.D{{Key: "a", Value: 1}, {Key: "updated_at", Value: "old"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := bson.M{}
+			if tt.set != nil {
+				update["$set"] = tt.set
+			}
+			got, err := withUpdatedAt(update, now)
+			if err != nil {
+				t.Fatalf("withUpdatedAt: %v", err)
+			}
+			switch set := got["$set"].(type) {
+			case bson.M:
+				if set["updated_at"] != now {
+					t.Errorf("updated_at = %v", set["updated_at"])
+				}
+			case bson.D:
+				if len(set) != 2 || set[1].Key != "updated_at" || set[1].Value != now {
+					t.Errorf("$set = %v", set)
+				}
+			default:
+				t.Fatalf("$set has type %T", set)
+			}
+		})
+	}
+}
+
+func TestWithUpdatedAtRejectsUnsupportedSet(t *testing.T) {
+	if _, err := withUpdatedAt(bson.M{"$set": "x"}, time.Now()); err == nil {
+		t.Fatal("withUpdatedAt accepted a string $set")
+	}
+}
+
+func TestFindPaginated(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "paginated_items")
+	ctx := context.Background()
+
+	docs := make([]interface{}, 25)
+	for i := range docs {
+		docs[i] = bson.M{"n": i}
+	}
+	if _, err := repo.CreateMany(ctx, docs); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	tests := []struct {
+		page, pageSize             int
+		wantPage, wantSize, wantN  int
+		wantTotalPages, wantFirstN int
+	}{
+		{1, 10, 1, 10, 10, 3, 0},
+		{3, 10, 3, 10, 5, 3, 20},
+		{4, 10, 4, 10, 0, 3, 0},
+		{0, 0, 1, 10, 10, 3, 0},    // 页码和每页条数使用默认值
+		{1, 500, 1, 100, 25, 1, 0}, // 每页条数超过上限
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("page=%d,size=%d", tt.page, tt.pageSize), func(t *testing.T) {
+			resp, err := repo.FindPaginated(ctx, bson.M{}, tt.page, tt.pageSize, bson.D{{Key: "n", Value: 1}})
+			if err != nil {
+				t.Fatalf("FindPaginated: %v", err)
+			}
+			data := resp.Data.([]interface{})
+			if resp.Total != 25 || resp.Page != tt.wantPage || resp.PageSize != tt.wantSize || resp.TotalPages != tt.wantTotalPages {
+				t.Fatalf("resp = {total:%d page:%d size:%d pages:%d}", resp.Total, resp.Page, resp.PageSize, resp.TotalPages)
+			}
+			if len(data) != tt.wantN {
+				t.Fatalf("len(data) = %d, want %d", len(data), tt.wantN)
+			}
+			if tt.wantN == 0 {
+				return
+			}
+			first := data[0].(bson.M)
+			if n, _ := first["n"].(int32); int(n) != tt.wantFirstN {
+				t.Fatalf("第一条 n = %v, want %d", first["n"], tt.wantFirstN)
+			}
+			if _, ok := first["_id"].(string); !ok {
+				t.Fatalf("_id 未转换为字符串: %T", first["_id"])
+			}
+		})
+	}
+}
+
+func TestUpdateFieldsRejectsReservedFields(t *testing.T) {
+	// 保留字段在访问数据库之前被拒绝
+	repo := NewMongoRepository(nil, "items")
+	for _, fields := range []bson.M{
+		{"_id": "x"},
+		{"created_at": time.Now(), "name": "a"},
+		{"$set": bson.M{"name": "a"}},
+		{},
+	} {
+		if err := repo.UpdateFields(context.Background(), "507f1f77bcf86cd799439011", fields); err == nil {
+			t.Errorf("UpdateFields(%v) 应该返回错误", fields)
+		}
+	}
+}
+
+func TestUpdateFields(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "update_fields_items")
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, bson.M{"name": "a", "extra": "keep"})
+	if err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+	fields := bson.M{"name": "b"}
+	if err := repo.UpdateFields(ctx, id, fields); err != nil {
+		t.Fatalf("UpdateFields: %v", err)
+	}
+	if len(fields) != 1 {
+		t.Fatalf("调用方的map被修改: %v", fields)
+	}
+
+	doc, err := repo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if doc["name"] != "b" || doc["extra"] != "keep" || doc["updated_at"] == nil {
+		t.Fatalf("doc = %v", doc)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "distinct_items")
+	ctx := context.Background()
+
+	docs := []interface{}{
+		bson.M{"status": "active", "tier": 1},
+		bson.M{"status": "active", "tier": 2},
+		bson.M{"status": "disabled", "tier": 1},
+		bson.M{"status": 3, "tier": 2},
+	}
+	if _, err := repo.CreateMany(ctx, docs); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	values, err := repo.Distinct(ctx, "status", nil)
+	if err != nil {
+		t.Fatalf("Distinct: %v", err)
+	}
+	got := make(map[string]bool, len(values))
+	for _, v := range values {
+		got[fmt.Sprint(v)] = true
+	}
+	if len(values) != 3 || !got["active"] || !got["disabled"] || !got["3"] {
+		t.Fatalf("Distinct(status) = %v", values)
+	}
+
+	values, err = repo.Distinct(ctx, "status", bson.M{"tier": 2})
+	if err != nil {
+		t.Fatalf("Distinct: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("Distinct(status, tier=2) = %v", values)
+	}
+
+	if _, err := repo.Distinct(ctx, "$where", nil); err == nil {
+		t.Fatal("以$开头的字段名应返回错误")
+	}
+}
+
+func TestFindAllWithProjection(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "projection_items")
+	ctx := context.Background()
+
+	docs := []interface{}{
+		bson.M{"name": "a", "secret": "x", "n": 1},
+		bson.M{"name": "b", "secret": "y", "n": 2},
+	}
+	if _, err := repo.CreateMany(ctx, docs); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	sort := bson.D{{Key: "n", Value: 1}}
+	results, total, err := repo.FindAllWithOptions(ctx, bson.M{}, 0, 10, sort, bson.M{"secret": 0})
+	if err != nil {
+		t.Fatalf("FindAllWithOptions: %v", err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("total = %d, len = %d", total, len(results))
+	}
+	for _, doc := range results {
+		if _, ok := doc["secret"]; ok {
+			t.Fatalf("被排除的字段仍然返回: %v", doc)
+		}
+		if doc["name"] == nil || doc["n"] == nil {
+			t.Fatalf("未排除的字段缺失: %v", doc)
+		}
+	}
+
+	// 只包含指定字段时，其余字段（_id 除外）都不返回
+	results, _, err = repo.FindAllWithOptions(ctx, bson.M{}, 0, 10, sort, bson.M{"name": 1})
+	if err != nil {
+		t.Fatalf("FindAllWithOptions: %v", err)
+	}
+	if len(results[0]) != 2 || results[0]["name"] != "a" || results[0]["_id"] == nil {
+		t.Fatalf("doc = %v", results[0])
+	}
+
+	// 原有的 FindAll 返回完整文档
+	results, _, err = repo.FindAll(ctx, bson.M{}, 0, 10, sort)
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if results[0]["secret"] != "x" {
+		t.Fatalf("FindAll 应返回完整文档: %v", results[0])
+	}
+}
+
+func TestExistsAndDeleteManyWithoutDB(t *testing.T) {
+	repo := NewMongoRepository(nil, "items")
+	ctx := context.Background()
+	if _, err := repo.Exists(ctx, bson.M{"name": "a"}); err == nil {
+		t.Fatal("数据库不可用时 Exists 应该返回错误")
+	}
+	if _, err := repo.DeleteMany(ctx, bson.M{"name": "a"}); err == nil {
+		t.Fatal("数据库不可用时 DeleteMany 应该返回错误")
+	}
+	// 空条件在访问数据库之前被拒绝
+	for _, filter := range []bson.M{nil, {}} {
+		if _, err := repo.DeleteMany(ctx, filter); !errors.Is(err, ErrEmptyFilter) {
+			t.Fatalf("DeleteMany(%v): err = %v, want ErrEmptyFilter", filter, err)
+		}
+	}
+}
+
+func TestExists(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "exists_items")
+	ctx := context.Background()
+
+	if ok, err := repo.Exists(ctx, nil); err != nil || ok {
+		t.Fatalf("空集合: exists = %v, err = %v", ok, err)
+	}
+	if _, err := repo.CreateMany(ctx, []interface{}{bson.M{"name": "a"}, bson.M{"name": "a"}}); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+	if ok, err := repo.Exists(ctx, bson.M{"name": "a"}); err != nil || !ok {
+		t.Fatalf("Exists(name=a) = %v, %v", ok, err)
+	}
+	if ok, err := repo.Exists(ctx, bson.M{"name": "b"}); err != nil || ok {
+		t.Fatalf("Exists(name=b) = %v, %v", ok, err)
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "delete_many_items")
+	ctx := context.Background()
+
+	docs := []interface{}{bson.M{"tag": "x"}, bson.M{"tag": "x"}, bson.M{"tag": "y"}}
+	if _, err := repo.CreateMany(ctx, docs); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	if _, err := repo.DeleteMany(ctx, bson.M{}); !errors.Is(err, ErrEmptyFilter) {
+		t.Fatalf("空条件: err = %v, want ErrEmptyFilter", err)
+	}
+	deleted, err := repo.DeleteMany(ctx, bson.M{"tag": "x"})
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteMany(tag=x) = %d, %v, want 2", deleted, err)
+	}
+	if ok, _ := repo.Exists(ctx, bson.M{"tag": "y"}); !ok {
+		t.Fatal("不符合条件的文档不应被删除")
+	}
+
+	deleted, err = repo.DeleteAll(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteAll() = %d, %v, want 1", deleted, err)
+	}
+}
+
+// statusGroupPipeline 按 status 分组统计数量，按 status 升序
+var statusGroupPipeline = mongo.Pipeline{
+	{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: "$status"},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+	}}},
+	{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+}
+
+func TestAggregateWithoutDB(t *testing.T) {
+	repo := NewMongoRepository(nil, "users")
+	if _, err := repo.Aggregate(context.Background(), statusGroupPipeline); err == nil {
+		t.Fatal("数据库不可用时 Aggregate 应该返回错误")
+	}
+	if _, _, err := repo.AggregatePaginated(context.Background(), statusGroupPipeline, 1, 10); err == nil {
+		t.Fatal("数据库不可用时 AggregatePaginated 应该返回错误")
+	}
+}
+
+func TestAggregateGroupsUsersByStatus(t *testing.T) {
+	repo := NewMongoRepository(newTestDatabase(t), "aggregate_users")
+	ctx := context.Background()
+
+	// status: 1 三个，2 两个，3 一个
+	var docs []interface{}
+	for i, status := range []int{1, 2, 1, 3, 1, 2} {
+		docs = append(docs, bson.M{"username": fmt.Sprintf("u%d", i), "status": status})
+	}
+	if _, err := repo.CreateMany(ctx, docs); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	results, err := repo.Aggregate(ctx, statusGroupPipeline)
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	want := map[string]string{"1": "3", "2": "2", "3": "1"}
+	if len(results) != len(want) {
+		t.Fatalf("results = %v", results)
+	}
+	for _, r := range results {
+		if got := fmt.Sprint(r["count"]); got != want[fmt.Sprint(r["_id"])] {
+			t.Fatalf("status %v: count = %s, want %s", r["_id"], got, want[fmt.Sprint(r["_id"])])
+		}
+	}
+
+	// 分页：每页一组，第二页为 status=2
+	page, total, err := repo.AggregatePaginated(ctx, statusGroupPipeline, 2, 1)
+	if err != nil {
+		t.Fatalf("AggregatePaginated: %v", err)
+	}
+	if total != 3 || len(page) != 1 || fmt.Sprint(page[0]["_id"]) != "2" {
+		t.Fatalf("page = %v, total = %d", page, total)
+	}
+
+	// 没有匹配的文档时返回空列表和0
+	empty := append(mongo.Pipeline{{{Key: "$match", Value: bson.M{"status": 9}}}}, statusGroupPipeline...)
+	page, total, err = repo.AggregatePaginated(ctx, empty, 1, 10)
+	if err != nil || total != 0 || len(page) != 0 {
+		t.Fatalf("page = %v, total = %d, err = %v", page, total, err)
+	}
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 签名nonce集合名称常量
+const NonceCollection = "signature_nonces"
+
+// NonceRepository 签名nonce存储库接口，实现了 middleware.NonceStore
+type NonceRepository interface {
+	Remember(nonce string, ttl time.Duration) (bool, error)
+}
+
+// MongoNonceRepository MongoDB签名nonce存储库实现
+// nonce作为 _id 保证唯一，过期的记录由 expires_at 上的TTL索引清理
+type MongoNonceRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewNonceRepository 创建新的签名nonce存储库
+func NewNonceRepository(db *mongo.Database) NonceRepository {
+	if db == nil {
+		return &NullNonceRepository{}
+	}
+
+	return &MongoNonceRepository{
+		db:         db,
+		collection: db.Collection(NonceCollection),
+	}
+}
+
+/*
+Remember 记录nonce
+nonce不存在或已过期（TTL索引尚未清理）时写入并返回true；在有效期内已存在时，
+upsert因 _id 重复而失败，返回false
+nonce: 请求的nonce
+ttl: 保留时间
+返回: nonce是否首次出现, 错误
+*/
+func (r *MongoNonceRepository) Remember(nonce string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": nonce, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"expires_at": now.Add(ttl)}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("记录nonce失败: %w", err)
+	}
+
+	return true, nil
+}
+
+// NullNonceRepository 空签名nonce存储库实现（空对象模式）
+type NullNonceRepository struct{}
+
+// Remember 记录nonce - 空实现
+func (r *NullNonceRepository) Remember(nonce string, ttl time.Duration) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法记录nonce")
+}
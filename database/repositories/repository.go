@@ -1,6 +1,10 @@
 package repositories
 
 import (
+	"sync"
+
+	"go-app/database"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -43,27 +47,93 @@ func NewBaseMongoRepository(db *mongo.Database, collectionName string) *BaseMong
 	}
 }
 
-// RepositoryManager 存储库管理器
-// 所有仓库的统一访问点
+/*
+RepositoryManager 存储库管理器
+所有仓库的统一访问点。内置仓库既可以通过类型化字段访问，也可以按集合名称通过 Get 访问；
+新增的仓库通过 Register 注册，无需修改管理器的结构
+*/
 type RepositoryManager struct {
-	mongoDB *mongo.Database
-	User    UserRepository
-	// 可以添加其他仓库...
+	mongoDB    *mongo.Database
+	User       UserRepository
+	Audit      AuditRepository
+	Whitelist  WhitelistRepository
+	APIKey     APIKeyRepository
+	Nonce      NonceRepository
+	LoginEvent LoginEventRepository
+	Setting    SettingRepository
+	Session    SessionRepository
+	// 跨存储库操作的事务，回调中将 sessCtx 作为存储库方法的 ctx 传入
+	Transactions *database.TransactionManager
+
+	// 按集合名称注册的仓库
+	mu       sync.RWMutex
+	registry map[string]Repository
 }
 
 // NewRepositoryManager 创建仓库管理器
 func NewRepositoryManager(mongoDB *mongo.Database) *RepositoryManager {
 	manager := &RepositoryManager{
-		mongoDB: mongoDB,
+		mongoDB:  mongoDB,
+		registry: make(map[string]Repository),
 	}
 
 	// 初始化各个仓库
 	if mongoDB != nil {
+		manager.Transactions = database.NewTransactionManager(mongoDB.Client())
 		// 使用MongoDB作为用户存储库的实现
 		manager.User = NewUserRepository(mongoDB)
+		manager.Audit = NewAuditRepository(mongoDB)
+		manager.Whitelist = NewWhitelistRepository(mongoDB)
+		manager.APIKey = NewAPIKeyRepository(mongoDB)
+		manager.Nonce = NewNonceRepository(mongoDB)
+		manager.LoginEvent = NewLoginEventRepository(mongoDB)
+		manager.Setting = NewSettingRepository(mongoDB)
+		manager.Session = NewSessionRepository(mongoDB)
 	} else {
+		manager.Transactions = database.NewTransactionManager(nil)
 		manager.User = &NullUserRepository{}
+		manager.Audit = &NullAuditRepository{}
+		manager.Whitelist = &NullWhitelistRepository{}
+		manager.APIKey = &NullAPIKeyRepository{}
+		manager.Nonce = &NullNonceRepository{}
+		manager.LoginEvent = &NullLoginEventRepository{}
+		manager.Setting = &NullSettingRepository{}
+		manager.Session = &NullSessionRepository{}
 	}
 
+	// 内置仓库同时按集合名称注册
+	manager.Register(UserCollection, manager.User)
+	manager.Register(AuditCollection, manager.Audit)
+	manager.Register(WhitelistCollection, manager.Whitelist)
+	manager.Register(APIKeyCollection, manager.APIKey)
+	manager.Register(NonceCollection, manager.Nonce)
+	manager.Register(FailedLoginCollection, manager.LoginEvent)
+	manager.Register(SettingCollection, manager.Setting)
+	manager.Register(SessionCollection, manager.Session)
+
 	return manager
 }
+
+/*
+Register 按集合名称注册仓库，名称已存在时替换原有仓库
+替换内置仓库只影响 Get 的结果，不会修改对应的类型化字段（如 User）
+name: 集合名称
+repo: 存储库
+*/
+func (m *RepositoryManager) Register(name string, repo Repository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.registry[name] = repo
+}
+
+/*
+Get 按集合名称获取已注册的仓库，未注册时返回nil
+调用方需断言为具体的存储库接口，如 m.Get(UserCollection).(UserRepository)
+name: 集合名称
+返回: 存储库
+*/
+func (m *RepositoryManager) Get(name string) Repository {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.registry[name]
+}
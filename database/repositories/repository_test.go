@@ -0,0 +1,39 @@
+package repositories
+
+import "testing"
+
+func TestRepositoryManagerRegistersBuiltins(t *testing.T) {
+	m := NewRepositoryManager(nil)
+
+	if _, ok := m.Get(UserCollection).(UserRepository); !ok {
+		t.Fatalf("Get(%s) = %T, want UserRepository", UserCollection, m.Get(UserCollection))
+	}
+	if _, ok := m.Get(SessionCollection).(SessionRepository); !ok {
+		t.Fatalf("Get(%s) = %T, want SessionRepository", SessionCollection, m.Get(SessionCollection))
+	}
+	if m.Get("unknown") != nil {
+		t.Fatal("未注册的集合应返回nil")
+	}
+}
+
+type customRepository struct{ name string }
+
+func TestRepositoryManagerRegister(t *testing.T) {
+	m := NewRepositoryManager(nil)
+
+	m.Register("reports", &customRepository{name: "reports"})
+	repo, ok := m.Get("reports").(*customRepository)
+	if !ok || repo.name != "reports" {
+		t.Fatalf("Get(reports) = %#v", m.Get("reports"))
+	}
+
+	// 替换内置仓库只影响 Get，不修改类型化字段
+	builtin := m.User
+	m.Register(UserCollection, &customRepository{name: "users"})
+	if _, ok := m.Get(UserCollection).(*customRepository); !ok {
+		t.Fatalf("Get(%s) = %T, want *customRepository", UserCollection, m.Get(UserCollection))
+	}
+	if m.User != builtin {
+		t.Fatal("Register 不应修改 User 字段")
+	}
+}
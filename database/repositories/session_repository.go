@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/session"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 会话集合名称常量
+const SessionCollection = "sessions"
+
+// SessionRepository 登录会话存储库接口
+type SessionRepository interface {
+	Create(ctx context.Context, s *session.Session) error
+	FindActiveByUser(ctx context.Context, userID uint) ([]*session.Session, error)
+	FindByUser(ctx context.Context, userID uint) ([]*session.Session, error)
+	IsActive(ctx context.Context, tokenID string) (bool, error)
+	Revoke(ctx context.Context, ids []primitive.ObjectID) error
+	RevokeByUser(ctx context.Context, userID uint) (int64, error)
+	RevokeByUsers(ctx context.Context, userIDs []uint) (int64, error)
+}
+
+// MongoSessionRepository MongoDB登录会话存储库实现
+// 过期的会话由 expires_at 上的TTL索引清理
+type MongoSessionRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewSessionRepository 创建新的登录会话存储库
+func NewSessionRepository(db *mongo.Database) SessionRepository {
+	if db == nil {
+		return &NullSessionRepository{}
+	}
+
+	return &MongoSessionRepository{
+		db:         db,
+		collection: db.Collection(SessionCollection),
+	}
+}
+
+// Create 创建会话
+func (r *MongoSessionRepository) Create(ctx context.Context, s *session.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, s)
+	if err != nil {
+		return fmt.Errorf("创建会话失败: %w", classifyWriteError(err))
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		s.ID = id
+	}
+
+	return nil
+}
+
+// FindActiveByUser 查询用户未吊销且未过期的会话，按创建时间从早到晚排序
+func (r *MongoSessionRepository) FindActiveByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := activeSessions(bson.M{"user_id": userID})
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	sessions := []*session.Session{}
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		sessions = []*session.Session{}
+		return cursor.All(ctx, &sessions)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// FindByUser 查询用户的全部会话（包括已吊销但尚未被TTL索引清理的会话），按创建时间从早到晚排序
+func (r *MongoSessionRepository) FindByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	sessions := []*session.Session{}
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		sessions = []*session.Session{}
+		return cursor.All(ctx, &sessions)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// IsActive 判断令牌ID对应的会话是否未吊销且未过期
+func (r *MongoSessionRepository) IsActive(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, activeSessions(bson.M{"token_id": tokenID}),
+			options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	return true, nil
+}
+
+// Revoke 吊销会话，已吊销的会话保持原吊销时间
+func (r *MongoSessionRepository) Revoke(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": bson.M{"$in": ids}, "revoked_at": bson.M{"$exists": false}}
+	if _, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}}); err != nil {
+		return fmt.Errorf("吊销会话失败: %w", classifyWriteError(err))
+	}
+	return nil
+}
+
+// RevokeByUser 吊销用户的全部有效会话，返回吊销的会话数
+func (r *MongoSessionRepository) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := activeSessions(bson.M{"user_id": userID})
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("吊销会话失败: %w", classifyWriteError(err))
+	}
+	return result.ModifiedCount, nil
+}
+
+// RevokeByUsers 吊销多个用户的全部有效会话，返回吊销的会话数
+func (r *MongoSessionRepository) RevokeByUsers(ctx context.Context, userIDs []uint) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := activeSessions(bson.M{"user_id": bson.M{"$in": userIDs}})
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("吊销会话失败: %w", classifyWriteError(err))
+	}
+	return result.ModifiedCount, nil
+}
+
+// activeSessions 在查询条件中加入未吊销且未过期的条件（TTL索引的清理有延迟）
+func activeSessions(filter bson.M) bson.M {
+	filter["revoked_at"] = bson.M{"$exists": false}
+	filter["expires_at"] = bson.M{"$gt": time.Now()}
+	return filter
+}
+
+// NullSessionRepository 空登录会话存储库实现（空对象模式）
+type NullSessionRepository struct{}
+
+// Create 创建会话 - 空实现
+func (r *NullSessionRepository) Create(ctx context.Context, s *session.Session) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法创建会话")
+}
+
+// FindActiveByUser 查询用户的会话 - 空实现
+func (r *NullSessionRepository) FindActiveByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询会话")
+}
+
+// FindByUser 查询用户的全部会话 - 空实现
+func (r *NullSessionRepository) FindByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询会话")
+}
+
+// IsActive 判断会话是否有效 - 空实现
+func (r *NullSessionRepository) IsActive(ctx context.Context, tokenID string) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法查询会话")
+}
+
+// Revoke 吊销会话 - 空实现
+func (r *NullSessionRepository) Revoke(ctx context.Context, ids []primitive.ObjectID) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法吊销会话")
+}
+
+// RevokeByUser 吊销用户的全部会话 - 空实现
+func (r *NullSessionRepository) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	return 0, fmt.Errorf("MongoDB数据库不可用，无法吊销会话")
+}
+
+// RevokeByUsers 吊销多个用户的全部会话 - 空实现
+func (r *NullSessionRepository) RevokeByUsers(ctx context.Context, userIDs []uint) (int64, error) {
+	return 0, fmt.Errorf("MongoDB数据库不可用，无法吊销会话")
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/system"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 系统设置集合名称常量
+const SettingCollection = "system_settings"
+
+// SettingRepository 系统设置存储库接口
+// 系统设置在所有实例间共享，每项设置是一个以设置ID为 _id 的文档
+type SettingRepository interface {
+	GetReadOnly(ctx context.Context) (*system.ReadOnlySetting, error)
+	SaveReadOnly(ctx context.Context, setting *system.ReadOnlySetting) error
+}
+
+// MongoSettingRepository MongoDB系统设置存储库实现
+type MongoSettingRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewSettingRepository 创建新的系统设置存储库
+func NewSettingRepository(db *mongo.Database) SettingRepository {
+	if db == nil {
+		return &NullSettingRepository{}
+	}
+
+	return &MongoSettingRepository{
+		db:         db,
+		collection: db.Collection(SettingCollection),
+	}
+}
+
+/*
+GetReadOnly 查询只读模式设置
+返回: 只读模式设置（从未设置过时为nil）, 错误
+*/
+func (r *MongoSettingRepository) GetReadOnly(ctx context.Context) (*system.ReadOnlySetting, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var setting system.ReadOnlySetting
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, bson.M{"_id": system.SettingReadOnly}).Decode(&setting)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询只读模式设置失败: %w", err)
+	}
+
+	return &setting, nil
+}
+
+// SaveReadOnly 保存只读模式设置，不存在时创建
+func (r *MongoSettingRepository) SaveReadOnly(ctx context.Context, setting *system.ReadOnlySetting) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	setting.ID = system.SettingReadOnly
+	if setting.UpdatedAt.IsZero() {
+		setting.UpdatedAt = time.Now()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": setting.ID}, setting, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("保存只读模式设置失败: %w", err)
+	}
+
+	return nil
+}
+
+// NullSettingRepository 空系统设置存储库实现（空对象模式）
+type NullSettingRepository struct{}
+
+// GetReadOnly 查询只读模式设置 - 空实现
+func (r *NullSettingRepository) GetReadOnly(ctx context.Context) (*system.ReadOnlySetting, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询只读模式设置")
+}
+
+// SaveReadOnly 保存只读模式设置 - 空实现
+func (r *NullSettingRepository) SaveReadOnly(ctx context.Context, setting *system.ReadOnlySetting) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法保存只读模式设置")
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// defaultSort 列表查询的默认排序，可通过 SetDefaultSort 配置
+var defaultSort = bson.D{{Key: "created_at", Value: -1}}
+
+/*
+SetDefaultSort 设置列表查询的默认排序
+spec: 排序字段，前缀"-"表示降序，如 "-created_at"、"username"
+为空时保持默认的 created_at 降序
+*/
+func SetDefaultSort(spec string) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return
+	}
+
+	order := 1
+	if strings.HasPrefix(spec, "-") {
+		order = -1
+		spec = strings.TrimPrefix(spec, "-")
+	} else if strings.HasPrefix(spec, "+") {
+		spec = strings.TrimPrefix(spec, "+")
+	}
+
+	if spec == "" {
+		return
+	}
+
+	defaultSort = bson.D{{Key: spec, Value: order}}
+}
+
+/*
+stableSort 为排序条件追加唯一字段作为次级排序键
+当主排序字段取值相同（如批量导入的用户 created_at 相同）时，
+保证分页结果稳定、不同页之间不会出现重复或遗漏
+sort: 排序条件，为空时使用默认排序
+tieBreaker: 唯一字段名，如 "_id" 或 "id"
+返回: 追加了次级排序键的排序条件
+*/
+func stableSort(sort bson.D, tieBreaker string) bson.D {
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+
+	result := make(bson.D, 0, len(sort)+1)
+	for _, e := range sort {
+		if e.Key == tieBreaker {
+			// 已包含唯一字段，无需追加
+			return append(result, sort...)
+		}
+	}
+	result = append(result, sort...)
+
+	// 次级排序方向与最后一个排序字段保持一致
+	order := sort[len(sort)-1].Value
+	return append(result, bson.E{Key: tieBreaker, Value: order})
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStableSortAppendsTieBreaker(t *testing.T) {
+	cases := []struct {
+		name string
+		sort bson.D
+		want bson.D
+	}{
+		{"默认排序", nil, bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}},
+		{"方向与最后一个字段一致", bson.D{{Key: "username", Value: 1}}, bson.D{{Key: "username", Value: 1}, {Key: "id", Value: 1}}},
+		{"已包含唯一字段", bson.D{{Key: "id", Value: 1}, {Key: "username", Value: -1}}, bson.D{{Key: "id", Value: 1}, {Key: "username", Value: -1}}},
+	}
+	for _, tc := range cases {
+		if got := stableSort(tc.sort, "id"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: stableSort = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSetDefaultSort(t *testing.T) {
+	saved := defaultSort
+	t.Cleanup(func() { defaultSort = saved })
+
+	cases := []struct {
+		spec string
+		want bson.D
+	}{
+		{"", saved},
+		{"-", saved},
+		{"username", bson.D{{Key: "username", Value: 1}}},
+		{"+updated_at", bson.D{{Key: "updated_at", Value: 1}}},
+		{" -created_at ", bson.D{{Key: "created_at", Value: -1}}},
+	}
+	for _, tc := range cases {
+		defaultSort = saved
+		SetDefaultSort(tc.spec)
+		if !reflect.DeepEqual(defaultSort, tc.want) {
+			t.Errorf("SetDefaultSort(%q) = %v, want %v", tc.spec, defaultSort, tc.want)
+		}
+	}
+}
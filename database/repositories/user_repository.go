@@ -10,8 +10,10 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"go-app/database"
 	"go-app/models/user"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -23,20 +25,42 @@ import (
 const UserCollection = "users"
 
 // UserRepository 用户存储库接口
+// ctx 通常为请求上下文，客户端断开或请求超时时查询随之取消；实现内部另设10秒的超时上限
+// ctx 为 TransactionManager.WithTransaction 提供的 sessCtx 时，操作在该事务中执行
 type UserRepository interface {
-	FindAll(page, pageSize int, conditions map[string]interface{}) ([]user.User, int64, error)
-	FindByID(id uint) (*user.User, error)
-	FindByUsername(username string) (*user.User, error)
-	FindByEmail(email string) (*user.User, error)
-	Create(user *user.User) error
-	Update(user *user.User) error
-	Delete(id uint) error
+	FindAll(ctx context.Context, page, pageSize int, conditions map[string]interface{}, sort bson.D) ([]user.User, int64, error)
+	FindAfter(ctx context.Context, lastCreatedAt time.Time, lastID uint, limit int, conditions map[string]interface{}) ([]user.User, error)
+	FindByID(ctx context.Context, id uint) (*user.User, error)
+	FindByUsername(ctx context.Context, username string) (*user.User, error)
+	FindByEmail(ctx context.Context, email string) (*user.User, error)
+	Create(ctx context.Context, user *user.User) error
+	Update(ctx context.Context, user *user.User) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	HardDelete(ctx context.Context, id uint) error
+	Distinct(ctx context.Context, field string) ([]interface{}, error)
+	ForEach(ctx context.Context, fn func(u *user.User) error) error
+	FindIDs(ctx context.Context, conditions map[string]interface{}, limit int) ([]uint, error)
+	IncrementFailedLogins(ctx context.Context, id uint, now time.Time) (int, bool, error)
+	LockUntil(ctx context.Context, id uint, attempts int, until time.Time) (bool, error)
+	ResetFailedLogins(ctx context.Context, id uint, now time.Time) (bool, error)
+	SetEmailVerificationToken(ctx context.Context, id uint, tokenHash string, expiresAt, sentAt, cooldownStart time.Time) (bool, error)
+	ForcePasswordReset(ctx context.Context, id uint, tokenHash string, expiresAt, changedAt time.Time) error
+	FindByPasswordResetToken(ctx context.Context, tokenHash string) (*user.User, error)
+	CompletePasswordReset(ctx context.Context, id uint, tokenHash, passwordHash string, changedAt time.Time) (bool, error)
+	ReplacePassword(ctx context.Context, id uint, oldPassword, newPassword string, resetRequired bool) (bool, error)
+	Count(ctx context.Context, conditions map[string]interface{}) (int64, error)
+	CountCreatedByDay(ctx context.Context, from, to time.Time) (map[string]int64, error)
+	UpdateMany(ctx context.Context, conditions map[string]interface{}, fields map[string]interface{}) (int64, int64, error)
 }
 
 // MongoUserRepository MongoDB用户存储库实现
 type MongoUserRepository struct {
 	db         *mongo.Database
 	collection *mongo.Collection
+	generic    *MongoRepository
+	// 用户ID计数器是否已按现有最大ID初始化
+	counterReady atomic.Bool
 }
 
 // NewUserRepository 创建新的用户存储库
@@ -48,42 +72,40 @@ func NewUserRepository(db *mongo.Database) UserRepository {
 	return &MongoUserRepository{
 		db:         db,
 		collection: db.Collection(UserCollection),
+		generic:    NewMongoRepository(db, UserCollection),
 	}
 }
 
-// FindAll 查找所有用户
-func (r *MongoUserRepository) FindAll(page, pageSize int, conditions map[string]interface{}) ([]user.User, int64, error) {
+// FindAll 查找所有用户，sort 为空时使用默认排序（全文搜索时按相关度排序）
+func (r *MongoUserRepository) FindAll(ctx context.Context, page, pageSize int, conditions map[string]interface{}, sort bson.D) ([]user.User, int64, error) {
 	// 处理分页
 	skip := int64((page - 1) * pageSize)
 	limit := int64(pageSize)
 
-	// 构建查询条件
-	filter := bson.M{}
-
-	// 添加状态过滤
-	if status, ok := conditions["status"]; ok && status != nil {
-		filter["status"] = status
+	// 构建查询条件，排除已删除用户
+	filter := userListFilter(conditions)
+
+	// 设置排序方式：默认按创建时间降序，并以用户ID作为次级排序键保证分页稳定
+	// 未指定排序的全文搜索按相关度降序，相关度相同时按用户ID降序
+	textScore := bson.M{"$meta": "textScore"}
+	_, isText := filter["$text"]
+	if isText && len(sort) == 0 {
+		sort = bson.D{{Key: "score", Value: textScore}, {Key: "id", Value: -1}}
+	} else {
+		sort = stableSort(sort, "id")
 	}
 
-	// 添加关键词搜索
-	if keyword, ok := conditions["keyword"].(string); ok && keyword != "" {
-		// 使用$or操作符实现多字段搜索
-		filter["$or"] = []bson.M{
-			{"username": bson.M{"$regex": keyword, "$options": "i"}},
-			{"email": bson.M{"$regex": keyword, "$options": "i"}},
-			{"nickname": bson.M{"$regex": keyword, "$options": "i"}},
-		}
-	}
-
-	// 设置排序方式：按创建时间降序
-	sort := bson.D{{"created_at", -1}}
-
 	// 获取上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// 计算总记录数
-	count, err := r.collection.CountDocuments(ctx, filter)
+	var count int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		count, err = r.collection.CountDocuments(ctx, filter)
+		return err
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("计算用户总数失败: %w", err)
 	}
@@ -93,77 +115,325 @@ func (r *MongoUserRepository) FindAll(page, pageSize int, conditions map[string]
 		SetSkip(skip).
 		SetLimit(limit).
 		SetSort(sort)
+	if isText {
+		opts.SetProjection(bson.M{"score": textScore})
+	}
+
+	// 执行查询并解析结果，遇到可重试错误时整体重试
+	var users []user.User
+	err = database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
 
-	// 执行查询
-	cursor, err := r.collection.Find(ctx, filter, opts)
+		users = nil
+		return cursor.All(ctx, &users)
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("查询用户列表失败: %w", err)
 	}
-	defer cursor.Close(ctx)
+	for i := range users {
+		if err := decryptUser(&users[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return users, count, nil
+}
+
+/*
+FindAfter 按创建时间倒序（相同时按ID倒序）查询位于指定位置之后的用户（键集分页）
+与 FindAll 的跳过方式不同，查询直接从索引定位，翻页深度不影响性能，但不返回总数
+lastCreatedAt: 上一页最后一个用户的创建时间，为零值时从第一条开始
+lastID: 上一页最后一个用户的ID
+limit: 返回数量
+conditions: 过滤条件，与 FindAll 相同
+返回: 用户列表, 错误
+*/
+func (r *MongoUserRepository) FindAfter(ctx context.Context, lastCreatedAt time.Time, lastID uint, limit int, conditions map[string]interface{}) ([]user.User, error) {
+	filter := userListFilter(conditions)
+	if !lastCreatedAt.IsZero() {
+		after := bson.M{"$or": []bson.M{
+			{"created_at": bson.M{"$lt": lastCreatedAt}},
+			{"created_at": lastCreatedAt, "id": bson.M{"$lt": lastID}},
+		}}
+		filter = bson.M{"$and": []bson.M{filter, after}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}})
 
-	// 解析结果
 	var users []user.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, 0, fmt.Errorf("解析用户列表失败: %w", err)
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		users = nil
+		return cursor.All(ctx, &users)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询用户列表失败: %w", err)
+	}
+	for i := range users {
+		if err := decryptUser(&users[i]); err != nil {
+			return nil, err
+		}
 	}
 
-	return users, count, nil
+	return users, nil
+}
+
+/*
+Count 统计符合条件的用户数，条件与 FindAll 相同
+返回: 用户数, 错误
+*/
+func (r *MongoUserRepository) Count(ctx context.Context, conditions map[string]interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		count, err = r.collection.CountDocuments(ctx, userListFilter(conditions))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("统计用户数失败: %w", err)
+	}
+	return count, nil
+}
+
+/*
+CountCreatedByDay 按天（UTC）统计注册用户数，不含已删除的用户，删除用户后历史日期的统计数随之减少
+from: 开始时间（含）
+to: 结束时间（不含）
+返回: 日期（database.DayLayout 格式）到注册数的映射，不含注册数为0的日期, 错误
+*/
+func (r *MongoUserRepository) CountCreatedByDay(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	counts, err := database.CountByDay(ctx, UserCollection, "created_at", from, to, notDeleted(bson.M{}))
+	if err != nil {
+		return nil, fmt.Errorf("统计注册用户数失败: %w", err)
+	}
+	return counts, nil
+}
+
+/*
+FindIDs 查询符合条件的用户ID，按ID升序，最多返回 limit 个，条件与 FindAll 相同
+批量操作先取出ID再按ID更新，更新的用户数不会超过 limit
+*/
+func (r *MongoUserRepository) FindIDs(ctx context.Context, conditions map[string]interface{}, limit int) ([]uint, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"id": 1})
+
+	var ids []uint
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, userListFilter(conditions), opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		var docs []struct {
+			ID uint `bson:"id"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+		ids = make([]uint, len(docs))
+		for i, d := range docs {
+			ids[i] = d.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询用户ID失败: %w", err)
+	}
+	return ids, nil
+}
+
+/*
+UpdateMany 更新所有符合条件的用户，条件与 FindAll 相同，同时更新 updated_at
+fields: 要设置的字段（数据库字段名），调用方负责限制可修改的字段
+返回: 匹配的用户数, 实际修改的用户数, 错误
+*/
+func (r *MongoUserRepository) UpdateMany(ctx context.Context, conditions map[string]interface{}, fields map[string]interface{}) (int64, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+
+	result, err := r.collection.UpdateMany(ctx, userListFilter(conditions), bson.M{"$set": set})
+	if err != nil {
+		return 0, 0, fmt.Errorf("批量更新用户失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount, result.ModifiedCount, nil
+}
+
+/*
+userListFilter 根据过滤条件构建查询条件，排除已删除用户
+支持的条件：status、role、email_verified、keyword、text（列表查询，text 为全文搜索，需要全文索引），
+以及 ids、created_before、created_after、exclude_id（批量操作）
+*/
+func userListFilter(conditions map[string]interface{}) bson.M {
+	filter := notDeleted(bson.M{})
+
+	// 添加用户ID过滤
+	if ids, ok := conditions["ids"].([]uint); ok && len(ids) > 0 {
+		filter["id"] = bson.M{"$in": ids}
+	}
+	if excludeID, ok := conditions["exclude_id"].(uint); ok {
+		if idFilter, ok := filter["id"].(bson.M); ok {
+			idFilter["$ne"] = excludeID
+		} else {
+			filter["id"] = bson.M{"$ne": excludeID}
+		}
+	}
+
+	// 添加创建时间范围过滤
+	createdAt := bson.M{}
+	if before, ok := conditions["created_before"].(time.Time); ok {
+		createdAt["$lt"] = before
+	}
+	if after, ok := conditions["created_after"].(time.Time); ok {
+		createdAt["$gte"] = after
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	// 添加状态过滤
+	if status, ok := conditions["status"]; ok && status != nil {
+		filter["status"] = status
+	}
+
+	// 添加角色过滤，多个角色时匹配其中任意一个
+	if roles, ok := conditions["role"].([]string); ok && len(roles) > 0 {
+		if len(roles) == 1 {
+			filter["role"] = roles[0]
+		} else {
+			filter["role"] = bson.M{"$in": roles}
+		}
+	}
+
+	// 添加邮箱验证状态过滤，未验证的用户没有 email_verified_at 字段或值为null
+	if verified, ok := conditions["email_verified"].(bool); ok {
+		if verified {
+			filter["email_verified_at"] = bson.M{"$ne": nil}
+		} else {
+			filter["email_verified_at"] = nil
+		}
+	}
+
+	// 添加全文搜索，使用 create_user_text_index 迁移创建的全文索引
+	if text, ok := conditions["text"].(string); ok && text != "" {
+		filter["$text"] = bson.M{"$search": text}
+	}
+
+	// 添加关键词搜索
+	if keyword, ok := conditions["keyword"].(string); ok && keyword != "" {
+		// 使用$or操作符实现多字段搜索
+		filter["$or"] = []bson.M{
+			{"username": bson.M{"$regex": keyword, "$options": "i"}},
+			{"email": bson.M{"$regex": keyword, "$options": "i"}},
+			{"nickname": bson.M{"$regex": keyword, "$options": "i"}},
+		}
+	}
+
+	return filter
 }
 
 // FindByID 根据ID查找用户
-func (r *MongoUserRepository) FindByID(id uint) (*user.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoUserRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var u user.User
-	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&u)
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, notDeleted(bson.M{"id": id})).Decode(&u)
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("用户不存在")
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
+	if err := decryptUser(&u); err != nil {
+		return nil, err
+	}
 
 	return &u, nil
 }
 
 // FindByUsername 根据用户名查找用户
-func (r *MongoUserRepository) FindByUsername(username string) (*user.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoUserRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var u user.User
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&u)
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, notDeleted(bson.M{"username": username})).Decode(&u)
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("用户不存在")
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
+	if err := decryptUser(&u); err != nil {
+		return nil, err
+	}
 
 	return &u, nil
 }
 
 // FindByEmail 根据邮箱查找用户
-func (r *MongoUserRepository) FindByEmail(email string) (*user.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	// 邮箱加密存储时按哈希精确匹配，同时兼容加密启用前写入的明文
+	filter := bson.M{"email": email}
+	if encryptedEmail {
+		filter = bson.M{"$or": []bson.M{{"email_hash": emailHash(email)}, {"email": email}}}
+	}
+
 	var u user.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&u)
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, notDeleted(filter)).Decode(&u)
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("用户不存在")
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
+	if err := decryptUser(&u); err != nil {
+		return nil, err
+	}
 
 	return &u, nil
 }
 
-// Create 创建用户
-func (r *MongoUserRepository) Create(u *user.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// Create 创建用户，用户名或邮箱违反唯一索引时返回 ErrDuplicateUser
+func (r *MongoUserRepository) Create(ctx context.Context, u *user.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// 设置创建和更新时间
@@ -171,33 +441,90 @@ func (r *MongoUserRepository) Create(u *user.User) error {
 	u.CreatedAt = now
 	u.UpdatedAt = now
 
-	// 如果ID未设置，生成一个
-	if u.ID == 0 {
-		u.ID = generateUserID()
+	// 如果ID未设置，从计数器分配
+	allocated := u.ID == 0
+	if allocated {
+		id, err := r.nextUserID(ctx)
+		if err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+		u.ID = id
 	}
 
-	_, err := r.collection.InsertOne(ctx, u)
+	err := r.insertUser(ctx, u)
+	// 计数器之外写入的用户（如手动导入）可能占用了计数器尚未分配到的ID，重新同步计数器后重试一次
+	if allocated && isDuplicateKeyOn(err, database.UserIDIndex) {
+		r.counterReady.Store(false)
+		id, nextErr := r.nextUserID(ctx)
+		if nextErr != nil {
+			return fmt.Errorf("创建用户失败: %w", nextErr)
+		}
+		u.ID = id
+		err = r.insertUser(ctx, u)
+	}
 	if err != nil {
-		return fmt.Errorf("创建用户失败: %w", err)
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateUser
+		}
+		return fmt.Errorf("创建用户失败: %w", classifyWriteError(err))
 	}
 
 	return nil
 }
 
-// Update 更新用户
-func (r *MongoUserRepository) Update(u *user.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// insertUser 加密敏感字段后插入用户
+func (r *MongoUserRepository) insertUser(ctx context.Context, u *user.User) error {
+	doc, err := encryptUser(u)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.InsertOne(ctx, doc)
+	return err
+}
+
+/*
+Update 更新用户资料（昵称、头像），并将数据库中更新后的最新状态写回 u
+只写入资料字段：u 通常来自较早的读取，整体写回会覆盖期间并发的修改（如强制重置密码设置的重置标记、登录失败次数）；
+密码、状态、角色、锁定和重置标记等字段由各自的原子操作修改
+*/
+func (r *MongoUserRepository) Update(ctx context.Context, u *user.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// 更新更新时间
 	u.UpdatedAt = time.Now()
 
 	filter := bson.M{"id": u.ID}
-	update := bson.M{"$set": u}
+	update := bson.M{"$set": bson.M{
+		"nickname":   u.Nickname,
+		"avatar":     u.Avatar,
+		"updated_at": u.UpdatedAt,
+	}}
+
+	// 原子地更新并取回最新的用户数据，避免再次查询
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(u)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("用户不存在")
+		}
+		return fmt.Errorf("更新用户失败: %w", classifyWriteError(err))
+	}
+
+	return decryptUser(u)
+}
+
+// Delete 软删除用户，设置删除标记和删除时间，数据保留以便恢复
+func (r *MongoUserRepository) Delete(ctx context.Context, id uint) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
+	now := time.Now()
+	filter := notDeleted(bson.M{"id": id})
+	update := bson.M{"$set": bson.M{"deleted": true, "deleted_at": now, "updated_at": now}}
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("更新用户失败: %w", err)
+		return fmt.Errorf("删除用户失败: %w", classifyWriteError(err))
 	}
 
 	if result.MatchedCount == 0 {
@@ -207,13 +534,251 @@ func (r *MongoUserRepository) Update(u *user.User) error {
 	return nil
 }
 
-// Delete 删除用户
-func (r *MongoUserRepository) Delete(id uint) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// Restore 恢复已软删除的用户，用户名或邮箱已被其他用户使用时返回 ErrDuplicateUser
+func (r *MongoUserRepository) Restore(ctx context.Context, id uint) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"id": id}
-	result, err := r.collection.DeleteOne(ctx, filter)
+	filter := bson.M{"id": id, "deleted": true}
+	update := bson.M{
+		"$set":   bson.M{"deleted": false, "updated_at": time.Now()},
+		"$unset": bson.M{"deleted_at": ""},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateUser
+		}
+		return fmt.Errorf("恢复用户失败: %w", classifyWriteError(err))
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("用户不存在或未被删除")
+	}
+
+	return nil
+}
+
+// notLocked 在查询条件中追加“在 now 时未锁定”的条件（未设置锁定时间或已过期）
+func notLocked(filter bson.M, now time.Time) bson.M {
+	filter["locked_until"] = bson.M{"$not": bson.M{"$gt": now}}
+	return filter
+}
+
+/*
+IncrementFailedLogins 原子地将连续登录失败次数加1
+是否锁定的判断和加1在同一次更新中完成：账户在 now 时处于锁定状态则不计数，返回 locked=true，
+避免锁定期间并发的失败请求继续累加
+返回: 加1后的次数, 账户是否处于锁定状态, 错误
+*/
+func (r *MongoUserRepository) IncrementFailedLogins(ctx context.Context, id uint, now time.Time) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var result struct {
+		FailedLoginCount int `bson:"failed_login_count"`
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"failed_login_count": 1})
+	err := r.collection.FindOneAndUpdate(ctx,
+		notLocked(notDeleted(bson.M{"id": id}), now),
+		bson.M{"$inc": bson.M{"failed_login_count": 1}},
+		opts,
+	).Decode(&result)
+	if err == nil {
+		return result.FailedLoginCount, false, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return 0, false, fmt.Errorf("记录登录失败次数失败: %w", classifyWriteError(err))
+	}
+
+	// 未更新：用户已锁定，或已被删除
+	count, err := r.collection.CountDocuments(ctx, notDeleted(bson.M{"id": id}))
+	if err != nil {
+		return 0, false, fmt.Errorf("记录登录失败次数失败: %w", err)
+	}
+	if count == 0 {
+		return 0, false, fmt.Errorf("用户不存在")
+	}
+	return 0, true, nil
+}
+
+/*
+LockUntil 连续登录失败次数仍不少于 attempts 时锁定账户到指定时间，并清零失败次数
+期间登录成功清零了失败次数时不锁定
+返回: 是否已锁定, 错误
+*/
+func (r *MongoUserRepository) LockUntil(ctx context.Context, id uint, attempts int, until time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"locked_until": until, "failed_login_count": 0}}
+	result, err := r.collection.UpdateOne(ctx,
+		notDeleted(bson.M{"id": id, "failed_login_count": bson.M{"$gte": attempts}}),
+		update,
+	)
+	if err != nil {
+		return false, fmt.Errorf("锁定账户失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount > 0, nil
+}
+
+/*
+ResetFailedLogins 账户在 now 时未锁定时清零连续登录失败次数并清除过期的锁定时间
+判断和清零在一次更新中完成，登录时读到的用户状态可能已过时（并发的失败请求刚刚锁定了账户），
+返回false时即使密码正确也应拒绝登录
+返回: 是否未锁定（已清零）, 错误
+*/
+func (r *MongoUserRepository) ResetFailedLogins(ctx context.Context, id uint, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"failed_login_count": 0},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := r.collection.UpdateOne(ctx, notLocked(bson.M{"id": id}, now), update)
+	if err != nil {
+		return false, fmt.Errorf("重置登录失败次数失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount > 0, nil
+}
+
+/*
+SetEmailVerificationToken 保存新的邮箱验证令牌并记录发送时间
+仅当邮箱未验证且上次发送时间早于 cooldownStart（或从未发送）时才会更新，判断和更新在一次操作中完成，
+并发的重发请求只有一个能成功
+返回: 是否已更新（false表示仍在冷却期内或邮箱已验证）, 错误
+*/
+func (r *MongoUserRepository) SetEmailVerificationToken(ctx context.Context, id uint, tokenHash string, expiresAt, sentAt, cooldownStart time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := notDeleted(bson.M{
+		"id":                id,
+		"email_verified_at": nil,
+		"$or": bson.A{
+			bson.M{"email_verification_sent_at": nil},
+			bson.M{"email_verification_sent_at": bson.M{"$lt": cooldownStart}},
+		},
+	})
+	update := bson.M{"$set": bson.M{
+		"email_verification_token_hash": tokenHash,
+		"email_verification_expires_at": expiresAt,
+		"email_verification_sent_at":    sentAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("保存邮箱验证令牌失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount > 0, nil
+}
+
+/*
+ForcePasswordReset 使用户当前的密码和已签发的令牌失效，并保存新的密码重置令牌
+用户在重置密码前无法登录；之前的重置令牌随之失效
+*/
+func (r *MongoUserRepository) ForcePasswordReset(ctx context.Context, id uint, tokenHash string, expiresAt, changedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"password_reset_required":   true,
+		"password_changed_at":       changedAt,
+		"password_reset_token_hash": tokenHash,
+		"password_reset_expires_at": expiresAt,
+		"updated_at":                changedAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, notDeleted(bson.M{"id": id}), update)
+	if err != nil {
+		return fmt.Errorf("重置用户密码失败: %w", classifyWriteError(err))
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("用户不存在")
+	}
+	return nil
+}
+
+// FindByPasswordResetToken 根据密码重置令牌的哈希查找用户，不检查令牌是否过期
+func (r *MongoUserRepository) FindByPasswordResetToken(ctx context.Context, tokenHash string) (*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var u user.User
+	err := database.WithReadRetry(ctx, func() error {
+		return r.collection.FindOne(ctx, notDeleted(bson.M{"password_reset_token_hash": tokenHash})).Decode(&u)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("用户不存在")
+		}
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if err := decryptUser(&u); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+/*
+CompletePasswordReset 使用重置令牌设置新密码，并清除重置令牌和重置标记
+仅当令牌仍属于该用户时更新，判断和更新在一次操作中完成，同一令牌只能使用一次
+返回: 是否已更新（false表示令牌已被使用或被新的令牌替换）, 错误
+*/
+func (r *MongoUserRepository) CompletePasswordReset(ctx context.Context, id uint, tokenHash, passwordHash string, changedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := notDeleted(bson.M{"id": id, "password_reset_token_hash": tokenHash})
+	update := bson.M{
+		"$set": bson.M{
+			"password":                passwordHash,
+			"password_reset_required": false,
+			"password_changed_at":     changedAt,
+			"updated_at":              changedAt,
+		},
+		"$unset": bson.M{"password_reset_token_hash": "", "password_reset_expires_at": ""},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("重置用户密码失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount > 0, nil
+}
+
+/*
+ReplacePassword 仅当已存储的密码仍为 oldPassword 时替换密码，不修改其他字段
+判断和更新在一次操作中完成，读取之后用户修改了密码或被重置时不会覆盖新的密码；
+resetRequired 为true时同时设置重置标记，为false时保留已有的标记，不会撤销并发的强制重置
+返回: 是否已更新（false表示密码已被修改）, 错误
+*/
+func (r *MongoUserRepository) ReplacePassword(ctx context.Context, id uint, oldPassword, newPassword string, resetRequired bool) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": id, "password": oldPassword}
+	set := bson.M{
+		"password":   newPassword,
+		"updated_at": time.Now(),
+	}
+	if resetRequired {
+		set["password_reset_required"] = true
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("更新用户密码失败: %w", classifyWriteError(err))
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// HardDelete 永久删除用户（无论是否已软删除），仅供管理员使用
+func (r *MongoUserRepository) HardDelete(ctx context.Context, id uint) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
 	if err != nil {
 		return fmt.Errorf("删除用户失败: %w", err)
 	}
@@ -225,48 +790,202 @@ func (r *MongoUserRepository) Delete(id uint) error {
 	return nil
 }
 
-// 生成用户ID - 简单实现
-func generateUserID() uint {
-	// 基于当前时间戳生成ID
-	// 实际应用中可以使用更复杂的ID生成算法
-	return uint(time.Now().UnixNano() / 1000000)
+// Distinct 查询未删除用户某个字段的不重复取值
+func (r *MongoUserRepository) Distinct(ctx context.Context, field string) ([]interface{}, error) {
+	if isEncryptedField(field) {
+		return nil, fmt.Errorf("字段已加密存储，无法查询取值: %s", field)
+	}
+
+	values, err := r.generic.Distinct(ctx, field, notDeleted(bson.M{}))
+	if err != nil {
+		return nil, fmt.Errorf("查询字段取值失败: %w", err)
+	}
+	return values, nil
+}
+
+/*
+ForEach 以游标方式逐个遍历所有用户（包含已删除用户），用于批量维护任务
+fn: 处理函数，返回错误时停止遍历并返回该错误
+返回: 错误
+*/
+func (r *MongoUserRepository) ForEach(ctx context.Context, fn func(u *user.User) error) error {
+
+	var cursor *mongo.Cursor
+	err := database.WithReadRetry(ctx, func() error {
+		var err error
+		cursor, err = r.collection.Find(ctx, bson.M{}, options.Find().SetBatchSize(100).SetSort(bson.D{{Key: "id", Value: 1}}))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var u user.User
+		if err := cursor.Decode(&u); err != nil {
+			return fmt.Errorf("解析用户数据失败: %w", err)
+		}
+		if err := decryptUser(&u); err != nil {
+			return err
+		}
+		if err := fn(&u); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("遍历用户失败: %w", err)
+	}
+	return nil
+}
+
+// notDeleted 为查询条件追加排除已删除用户的条件
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted"] = bson.M{"$ne": true}
+	return filter
+}
+
+/*
+nextUserID 分配新的用户ID
+ID来自 counters 集合中的递增序号，并发注册时不会重复。计数器首次使用前先以现有用户的最大ID初始化，
+避免与此前按时间戳生成的ID或默认管理员的ID冲突
+*/
+func (r *MongoUserRepository) nextUserID(ctx context.Context) (uint, error) {
+	if !r.counterReady.Load() {
+		if err := database.SyncUserIDCounter(ctx, r.db); err != nil {
+			return 0, err
+		}
+		r.counterReady.Store(true)
+	}
+
+	seq, err := database.NextSequence(ctx, r.db, database.UserIDCounter)
+	if err != nil {
+		return 0, err
+	}
+	return uint(seq), nil
 }
 
 // NullUserRepository 空用户存储库实现（空对象模式）
 // 当数据库不可用时提供一个不会崩溃的实现
 type NullUserRepository struct{}
 
+// FindAfter 键集分页查询用户 - 空实现
+func (r *NullUserRepository) FindAfter(ctx context.Context, lastCreatedAt time.Time, lastID uint, limit int, conditions map[string]interface{}) ([]user.User, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
 // FindAll 查找所有用户 - 空实现
-func (r *NullUserRepository) FindAll(page, pageSize int, conditions map[string]interface{}) ([]user.User, int64, error) {
+func (r *NullUserRepository) FindAll(ctx context.Context, page, pageSize int, conditions map[string]interface{}, sort bson.D) ([]user.User, int64, error) {
 	return []user.User{}, 0, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
 }
 
 // FindByID 根据ID查找用户 - 空实现
-func (r *NullUserRepository) FindByID(id uint) (*user.User, error) {
+func (r *NullUserRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
 	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
 }
 
 // FindByUsername 根据用户名查找用户 - 空实现
-func (r *NullUserRepository) FindByUsername(username string) (*user.User, error) {
+func (r *NullUserRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
 	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
 }
 
 // FindByEmail 根据邮箱查找用户 - 空实现
-func (r *NullUserRepository) FindByEmail(email string) (*user.User, error) {
+func (r *NullUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
 }
 
 // Create 创建用户 - 空实现
-func (r *NullUserRepository) Create(u *user.User) error {
+func (r *NullUserRepository) Create(ctx context.Context, u *user.User) error {
 	return fmt.Errorf("MongoDB数据库不可用，无法创建用户")
 }
 
 // Update 更新用户 - 空实现
-func (r *NullUserRepository) Update(u *user.User) error {
+func (r *NullUserRepository) Update(ctx context.Context, u *user.User) error {
 	return fmt.Errorf("MongoDB数据库不可用，无法更新用户")
 }
 
 // Delete 删除用户 - 空实现
-func (r *NullUserRepository) Delete(id uint) error {
+func (r *NullUserRepository) Delete(ctx context.Context, id uint) error {
 	return fmt.Errorf("MongoDB数据库不可用，无法删除用户")
 }
+
+// Restore 恢复用户 - 空实现
+func (r *NullUserRepository) Restore(ctx context.Context, id uint) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法恢复用户")
+}
+
+// HardDelete 永久删除用户 - 空实现
+func (r *NullUserRepository) HardDelete(ctx context.Context, id uint) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法删除用户")
+}
+
+// Distinct 查询字段取值 - 空实现
+func (r *NullUserRepository) Distinct(ctx context.Context, field string) ([]interface{}, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// ForEach 遍历用户 - 空实现
+func (r *NullUserRepository) ForEach(ctx context.Context, fn func(u *user.User) error) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// FindIDs 查询用户ID - 空实现
+func (r *NullUserRepository) FindIDs(ctx context.Context, conditions map[string]interface{}, limit int) ([]uint, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// IncrementFailedLogins 记录登录失败次数 - 空实现
+func (r *NullUserRepository) IncrementFailedLogins(ctx context.Context, id uint, now time.Time) (int, bool, error) {
+	return 0, false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// LockUntil 锁定账户 - 空实现
+func (r *NullUserRepository) LockUntil(ctx context.Context, id uint, attempts int, until time.Time) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// SetEmailVerificationToken 保存邮箱验证令牌 - 空实现
+func (r *NullUserRepository) SetEmailVerificationToken(ctx context.Context, id uint, tokenHash string, expiresAt, sentAt, cooldownStart time.Time) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// ForcePasswordReset 强制重置密码 - 空实现
+func (r *NullUserRepository) ForcePasswordReset(ctx context.Context, id uint, tokenHash string, expiresAt, changedAt time.Time) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// FindByPasswordResetToken 根据重置令牌查找用户 - 空实现
+func (r *NullUserRepository) FindByPasswordResetToken(ctx context.Context, tokenHash string) (*user.User, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// CompletePasswordReset 使用重置令牌设置新密码 - 空实现
+func (r *NullUserRepository) CompletePasswordReset(ctx context.Context, id uint, tokenHash, passwordHash string, changedAt time.Time) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// ReplacePassword 替换密码 - 空实现
+func (r *NullUserRepository) ReplacePassword(ctx context.Context, id uint, oldPassword, newPassword string, resetRequired bool) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// Count 统计用户数 - 空实现
+func (r *NullUserRepository) Count(ctx context.Context, conditions map[string]interface{}) (int64, error) {
+	return 0, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// CountCreatedByDay 按天统计注册用户数 - 空实现
+func (r *NullUserRepository) CountCreatedByDay(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询用户")
+}
+
+// UpdateMany 批量更新用户 - 空实现
+func (r *NullUserRepository) UpdateMany(ctx context.Context, conditions map[string]interface{}, fields map[string]interface{}) (int64, int64, error) {
+	return 0, 0, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
+
+// ResetFailedLogins 重置登录失败次数 - 空实现
+func (r *NullUserRepository) ResetFailedLogins(ctx context.Context, id uint, now time.Time) (bool, error) {
+	return false, fmt.Errorf("MongoDB数据库不可用，无法更新用户")
+}
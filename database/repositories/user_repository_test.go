@@ -0,0 +1,408 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-app/database"
+	"go-app/models/user"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFindAllPagesStablyWithSharedCreatedAt(t *testing.T) {
+	db := newTestDatabase(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// Create 会设置当前时间，直接插入相同 created_at 的用户，模拟批量导入
+	const n = 25
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		docs[i] = user.User{ID: uint(i + 1), Username: fmt.Sprintf("bulk%d", i), Email: fmt.Sprintf("bulk%d@example.com", i), Status: 1, CreatedAt: createdAt}
+	}
+	if _, err := db.Collection(UserCollection).InsertMany(ctx, docs); err != nil {
+		t.Fatalf("插入用户失败: %v", err)
+	}
+
+	seen := make(map[uint]bool, n)
+	for page := 1; page <= 4; page++ {
+		users, total, err := repo.FindAll(ctx, page, 7, nil, nil)
+		if err != nil {
+			t.Fatalf("第%d页: %v", page, err)
+		}
+		if total != n {
+			t.Fatalf("total = %d, want %d", total, n)
+		}
+		for _, u := range users {
+			if seen[u.ID] {
+				t.Fatalf("用户 %d 出现在多个页面", u.ID)
+			}
+			seen[u.ID] = true
+		}
+	}
+	if len(seen) != n {
+		t.Fatalf("分页共返回 %d 个用户, want %d", len(seen), n)
+	}
+}
+
+func TestUsernameReusableAfterSoftDelete(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	first := &user.User{Username: "alice", Email: "alice@example.com", Status: 1}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	dup := &user.User{Username: "alice", Email: "other@example.com", Status: 1}
+	if err := repo.Create(ctx, dup); !errors.Is(err, ErrDuplicateUser) {
+		t.Fatalf("未删除的同名用户: err = %v, want ErrDuplicateUser", err)
+	}
+
+	if err := repo.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("删除用户失败: %v", err)
+	}
+	second := &user.User{Username: "alice", Email: "alice@example.com", Status: 1}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("删除后重新注册失败: %v", err)
+	}
+
+	if err := repo.Restore(ctx, first.ID); !errors.Is(err, ErrDuplicateUser) {
+		t.Fatalf("恢复重名用户: err = %v, want ErrDuplicateUser", err)
+	}
+}
+
+func TestParallelCreateAssignsUniqueIDs(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	const n = 1000
+	ids := make([]uint, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			u := &user.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Status: 1}
+			errs[i] = repo.Create(context.Background(), u)
+			ids[i] = u.ID
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[uint]int, n)
+	for i, id := range ids {
+		if errs[i] != nil {
+			t.Fatalf("第%d个用户创建失败: %v", i, errs[i])
+		}
+		if prev, ok := seen[id]; ok {
+			t.Fatalf("用户%d和用户%d分配到相同的ID %d", prev, i, id)
+		}
+		seen[id] = i
+	}
+}
+
+func TestCreateSkipsIDTakenOutsideCounter(t *testing.T) {
+	db := newTestDatabase(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	first := &user.User{Username: "first", Email: "first@example.com", Status: 1}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	// 绕过计数器写入占用下一个ID的用户，如手动导入
+	if _, err := db.Collection(database.UserCollection).InsertOne(ctx, bson.M{
+		"id": first.ID + 1, "username": "imported", "email": "imported@example.com", "deleted": false,
+	}); err != nil {
+		t.Fatalf("写入导入用户失败: %v", err)
+	}
+
+	second := &user.User{Username: "second", Email: "second@example.com", Status: 1}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("ID被占用时应重新同步计数器: %v", err)
+	}
+	if second.ID <= first.ID+1 {
+		t.Fatalf("second.ID = %d, want > %d", second.ID, first.ID+1)
+	}
+}
+
+func TestIsDuplicateKeyOn(t *testing.T) {
+	dupID := mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    11000,
+		Message: "E11000 duplicate key error collection: app.users index: id_1 dup key: { id: 2 }",
+	}}}
+	dupName := mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    11000,
+		Message: "E11000 duplicate key error collection: app.users index: username_1_active dup key: { username: \"a\" }",
+	}}}
+
+	if !isDuplicateKeyOn(fmt.Errorf("包装: %w", dupID), database.UserIDIndex) {
+		t.Fatal("ID索引冲突未识别")
+	}
+	if isDuplicateKeyOn(dupName, database.UserIDIndex) {
+		t.Fatal("用户名索引冲突不应识别为ID冲突")
+	}
+	if isDuplicateKeyOn(errors.New("其他错误"), database.UserIDIndex) {
+		t.Fatal("非写入错误不应识别为ID冲突")
+	}
+}
+
+func TestFailedLoginLockIsAtomic(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	u := &user.User{Username: "alice", Email: "alice@example.com", Status: 1}
+	if err := repo.Create(ctx, u); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	now := time.Now()
+
+	for want := 1; want <= 3; want++ {
+		count, locked, err := repo.IncrementFailedLogins(ctx, u.ID, now)
+		if err != nil || locked || count != want {
+			t.Fatalf("IncrementFailedLogins = %d, %v, %v, want %d", count, locked, err, want)
+		}
+	}
+	if locked, err := repo.LockUntil(ctx, u.ID, 4, now.Add(time.Minute)); err != nil || locked {
+		t.Fatalf("未达到次数时 LockUntil = %v, %v, want false", locked, err)
+	}
+	if locked, err := repo.LockUntil(ctx, u.ID, 3, now.Add(time.Minute)); err != nil || !locked {
+		t.Fatalf("LockUntil = %v, %v, want true", locked, err)
+	}
+
+	// 锁定期间不计数，也不能清零
+	if _, locked, err := repo.IncrementFailedLogins(ctx, u.ID, now); err != nil || !locked {
+		t.Fatalf("锁定期间 IncrementFailedLogins locked = %v, %v, want true", locked, err)
+	}
+	if ok, err := repo.ResetFailedLogins(ctx, u.ID, now); err != nil || ok {
+		t.Fatalf("锁定期间 ResetFailedLogins = %v, %v, want false", ok, err)
+	}
+
+	// 锁定过期后可以清零
+	if ok, err := repo.ResetFailedLogins(ctx, u.ID, now.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("过期后 ResetFailedLogins = %v, %v, want true", ok, err)
+	}
+	got, err := repo.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if got.FailedLoginCount != 0 || got.LockedUntil != nil {
+		t.Fatalf("FailedLoginCount = %d, LockedUntil = %v", got.FailedLoginCount, got.LockedUntil)
+	}
+}
+
+func TestUserListFilterRole(t *testing.T) {
+	filter := userListFilter(map[string]interface{}{"role": []string{user.RoleAdmin}})
+	if filter["role"] != user.RoleAdmin {
+		t.Fatalf("单个角色应精确匹配: %v", filter["role"])
+	}
+
+	filter = userListFilter(map[string]interface{}{"role": []string{user.RoleAdmin, user.RoleUser}})
+	in, ok := filter["role"].(bson.M)["$in"].([]string)
+	if !ok || len(in) != 2 {
+		t.Fatalf("多个角色应使用$in: %v", filter["role"])
+	}
+
+	if _, ok := userListFilter(map[string]interface{}{})["role"]; ok {
+		t.Fatal("未指定角色时不应过滤角色")
+	}
+}
+
+func TestUserListFilterEmailVerified(t *testing.T) {
+	filter := userListFilter(map[string]interface{}{"email_verified": true})
+	if ne, ok := filter["email_verified_at"].(bson.M); !ok || ne["$ne"] != nil || len(ne) != 1 {
+		t.Fatalf("已验证: email_verified_at = %v", filter["email_verified_at"])
+	}
+
+	filter = userListFilter(map[string]interface{}{"email_verified": false})
+	if v, ok := filter["email_verified_at"]; !ok || v != nil {
+		t.Fatalf("未验证: email_verified_at = %v", v)
+	}
+}
+
+func TestFindAllFiltersByEmailVerified(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	verifiedAt := time.Now()
+	verified := map[uint]bool{}
+	for i := 0; i < 4; i++ {
+		u := &user.User{Username: fmt.Sprintf("v%d", i), Email: fmt.Sprintf("v%d@example.com", i), Status: 1}
+		if i%2 == 0 {
+			u.EmailVerifiedAt = &verifiedAt
+		}
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		verified[u.ID] = i%2 == 0
+	}
+
+	for _, want := range []bool{true, false} {
+		users, total, err := repo.FindAll(ctx, 1, 10, map[string]interface{}{"email_verified": want}, nil)
+		if err != nil {
+			t.Fatalf("FindAll(email_verified=%v): %v", want, err)
+		}
+		if total != 2 || len(users) != 2 {
+			t.Fatalf("FindAll(email_verified=%v): total = %d, len = %d, want 2", want, total, len(users))
+		}
+		for _, u := range users {
+			if verified[u.ID] != want {
+				t.Fatalf("FindAll(email_verified=%v) 返回了用户 %d", want, u.ID)
+			}
+		}
+	}
+}
+
+func TestFindIDsRespectsLimitAndConditions(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	var created []uint
+	for i := 0; i < 3; i++ {
+		u := &user.User{Username: fmt.Sprintf("u%d", i), Email: fmt.Sprintf("u%d@example.com", i), Status: 1}
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		created = append(created, u.ID)
+	}
+
+	ids, err := repo.FindIDs(ctx, map[string]interface{}{"exclude_id": created[0]}, 1)
+	if err != nil {
+		t.Fatalf("FindIDs: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != created[1] {
+		t.Fatalf("ids = %v, want [%d]", ids, created[1])
+	}
+}
+
+func TestTextSearchRanksUsernameAboveNickname(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	// 先创建仅昵称匹配的用户，排除按创建顺序得到正确结果的可能
+	byNickname := &user.User{Username: "bob", Email: "bob@example.com", Nickname: "alice", Status: 1}
+	byUsername := &user.User{Username: "alice", Email: "a1@example.com", Nickname: "ally", Status: 1}
+	other := &user.User{Username: "carol", Email: "carol@example.com", Nickname: "carol", Status: 1}
+	for _, u := range []*user.User{byNickname, byUsername, other} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+
+	users, total, err := repo.FindAll(ctx, 1, 10, map[string]interface{}{"text": "alice"}, nil)
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if total != 2 || len(users) != 2 {
+		t.Fatalf("total = %d, len = %d, want 2", total, len(users))
+	}
+	if users[0].ID != byUsername.ID || users[1].ID != byNickname.ID {
+		t.Fatalf("结果顺序 = [%d %d], want [%d %d]", users[0].ID, users[1].ID, byUsername.ID, byNickname.ID)
+	}
+}
+
+func TestConcurrentCreateSameEmail(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			u := &user.User{Username: fmt.Sprintf("racer%d", i), Email: "same@example.com", Status: 1}
+			errs[i] = repo.Create(ctx, u)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrDuplicateUser):
+			t.Errorf("第%d个创建: err = %v, want ErrDuplicateUser", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("成功创建 %d 个, want 1", succeeded)
+	}
+}
+
+func TestUpdateWritesOnlyProfileFields(t *testing.T) {
+	repo := NewUserRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	u := &user.User{Username: "alice", Email: "alice@example.com", Password: "hash", Status: 1}
+	if err := repo.Create(ctx, u); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	stale, err := repo.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+
+	now := time.Now()
+	if err := repo.ForcePasswordReset(ctx, u.ID, "token-hash", now.Add(time.Hour), now); err != nil {
+		t.Fatalf("强制重置密码失败: %v", err)
+	}
+	stale.Nickname = "Alice"
+	stale.Password = "stale"
+	stale.Status = 0
+	if err := repo.Update(ctx, stale); err != nil {
+		t.Fatalf("更新用户失败: %v", err)
+	}
+
+	got, err := repo.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if got.Nickname != "Alice" {
+		t.Fatalf("Nickname = %q, want Alice", got.Nickname)
+	}
+	if !got.PasswordResetRequired || got.Password != "hash" || got.Status != 1 {
+		t.Fatalf("资料更新覆盖了其他字段: reset=%v password=%q status=%d", got.PasswordResetRequired, got.Password, got.Status)
+	}
+}
+
+func TestCountCreatedByDayExcludesDeleted(t *testing.T) {
+	db := newTestDatabase(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// CountByDay 使用全局数据库
+	saved := database.MongoDB
+	database.MongoDB = db
+	t.Cleanup(func() { database.MongoDB = saved })
+
+	// Create 以当前时间作为注册时间
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		u := &user.User{Username: fmt.Sprintf("d%d", i), Email: fmt.Sprintf("d%d@example.com", i), Status: 1}
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		ids = append(ids, u.ID)
+	}
+	if err := repo.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("删除用户失败: %v", err)
+	}
+
+	counts, err := repo.CountCreatedByDay(ctx, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("CountCreatedByDay: %v", err)
+	}
+	if day := from.Format(database.DayLayout); counts[day] != 2 || len(counts) != 1 {
+		t.Fatalf("counts = %v, want map[%s:2]", counts, day)
+	}
+}
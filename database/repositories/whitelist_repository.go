@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-app/database"
+	"go-app/models/whitelist"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 白名单集合名称常量
+const WhitelistCollection = "whitelist_entries"
+
+// WhitelistRepository 白名单存储库接口
+type WhitelistRepository interface {
+	FindAll() ([]*whitelist.Entry, error)
+	Add(entry *whitelist.Entry) error
+	Remove(entryType, value string) error
+}
+
+// MongoWhitelistRepository MongoDB白名单存储库实现
+type MongoWhitelistRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewWhitelistRepository 创建新的白名单存储库
+func NewWhitelistRepository(db *mongo.Database) WhitelistRepository {
+	if db == nil {
+		return &NullWhitelistRepository{}
+	}
+
+	return &MongoWhitelistRepository{
+		db:         db,
+		collection: db.Collection(WhitelistCollection),
+	}
+}
+
+// FindAll 查询所有持久化的白名单条目
+func (r *MongoWhitelistRepository) FindAll() ([]*whitelist.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	var entries []*whitelist.Entry
+	err := database.WithReadRetry(ctx, func() error {
+		cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		entries = nil
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询白名单失败: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Add 持久化白名单条目，条目已存在时保持不变
+func (r *MongoWhitelistRepository) Add(entry *whitelist.Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	filter := bson.M{"type": entry.Type, "value": entry.Value}
+	update := bson.M{"$setOnInsert": bson.M{
+		"type":       entry.Type,
+		"value":      entry.Value,
+		"created_by": entry.CreatedBy,
+		"created_at": entry.CreatedAt,
+	}}
+	if _, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("保存白名单条目失败: %w", err)
+	}
+
+	return nil
+}
+
+// Remove 删除持久化的白名单条目
+func (r *MongoWhitelistRepository) Remove(entryType, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"type": entryType, "value": value}); err != nil {
+		return fmt.Errorf("删除白名单条目失败: %w", err)
+	}
+
+	return nil
+}
+
+// NullWhitelistRepository 空白名单存储库实现（空对象模式）
+type NullWhitelistRepository struct{}
+
+// FindAll 查询白名单 - 空实现
+func (r *NullWhitelistRepository) FindAll() ([]*whitelist.Entry, error) {
+	return nil, fmt.Errorf("MongoDB数据库不可用，无法查询白名单")
+}
+
+// Add 保存白名单条目 - 空实现
+func (r *NullWhitelistRepository) Add(entry *whitelist.Entry) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法保存白名单条目")
+}
+
+// Remove 删除白名单条目 - 空实现
+func (r *NullWhitelistRepository) Remove(entryType, value string) error {
+	return fmt.Errorf("MongoDB数据库不可用，无法删除白名单条目")
+}
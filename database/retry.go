@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 读操作重试配置，可通过 SetReadRetry 修改
+var (
+	readRetryAttempts = 2                      // 首次失败后的最大重试次数
+	readRetryBackoff  = 100 * time.Millisecond // 首次重试前的等待时间，之后每次翻倍
+)
+
+// 可重试的服务端错误码（主节点切换、节点关闭等）
+var retryableReadCodes = []int{
+	189,   // PrimarySteppedDown
+	91,    // ShutdownInProgress
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+/*
+SetReadRetry 设置读操作的重试策略
+attempts: 首次失败后的最大重试次数，0表示不重试，负数忽略
+backoff: 首次重试前的等待时间，之后每次翻倍，不大于0时保持原值
+*/
+func SetReadRetry(attempts int, backoff time.Duration) {
+	if attempts >= 0 {
+		readRetryAttempts = attempts
+	}
+	if backoff > 0 {
+		readRetryBackoff = backoff
+	}
+}
+
+/*
+WithReadRetry 执行幂等的读操作，遇到网络错误或主节点切换等可重试错误时按退避策略重试
+仅用于查询、计数、聚合等读操作；写操作的重试交给驱动的 retryable writes，不在此处理
+ctx: 操作的上下文，超时或取消后不再重试
+op: 读操作，每次重试都会重新执行整个操作（包括遍历游标）
+返回: 最后一次执行的错误
+*/
+func WithReadRetry(ctx context.Context, op func() error) error {
+	backoff := readRetryBackoff
+	err := op()
+	for attempt := 0; attempt < readRetryAttempts && IsRetryableReadError(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = op()
+	}
+	return err
+}
+
+// IsRetryableReadError 判断读操作错误是否可以重试
+func IsRetryableReadError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		if serverErr.HasErrorLabel("RetryableReadError") {
+			return true
+		}
+		for _, code := range retryableReadCodes {
+			if serverErr.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
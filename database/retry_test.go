@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// useReadRetry 修改读操作重试策略，测试结束后恢复
+func useReadRetry(t *testing.T, attempts int) {
+	t.Helper()
+	savedAttempts, savedBackoff := readRetryAttempts, readRetryBackoff
+	t.Cleanup(func() { readRetryAttempts, readRetryBackoff = savedAttempts, savedBackoff })
+	SetReadRetry(attempts, time.Millisecond)
+}
+
+func TestWithReadRetrySucceedsAfterTransientError(t *testing.T) {
+	useReadRetry(t, 2)
+
+	calls := 0
+	err := WithReadRetry(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestWithReadRetryStopsAfterMaxAttempts(t *testing.T) {
+	useReadRetry(t, 2)
+
+	calls := 0
+	networkErr := mongo.CommandError{Labels: []string{"NetworkError"}}
+	if err := WithReadRetry(context.Background(), func() error {
+		calls++
+		return networkErr
+	}); err == nil {
+		t.Fatal("持续失败时应返回错误")
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3（首次加2次重试）", calls)
+	}
+}
+
+func TestWithReadRetryDoesNotRetryOtherErrors(t *testing.T) {
+	useReadRetry(t, 2)
+
+	for _, want := range []error{
+		errors.New("查询语法错误"),
+		mongo.CommandError{Code: 2, Name: "BadValue"},
+		context.DeadlineExceeded,
+	} {
+		calls := 0
+		err := WithReadRetry(context.Background(), func() error {
+			calls++
+			return want
+		})
+		if calls != 1 || err == nil {
+			t.Errorf("%v: calls = %d, err = %v, want 不重试", want, calls, err)
+		}
+	}
+}
+
+func TestWithReadRetryStopsWhenContextDone(t *testing.T) {
+	useReadRetry(t, 5)
+	readRetryBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := WithReadRetry(ctx, func() error {
+		calls++
+		cancel()
+		return mongo.CommandError{Code: 189}
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("calls = %d, err = %v, want 取消后不再重试", calls, err)
+	}
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// 启动等待默认值，配置为0或负数时使用
+const (
+	defaultStartupWaitTimeout  = 60 * time.Second // 等待所有依赖的总时长
+	defaultStartupCheckTimeout = 5 * time.Second  // 单次检查的超时时间
+	startupCheckInterval       = 1 * time.Second  // 两轮检查之间的初始间隔
+	startupMaxCheckInterval    = 5 * time.Second  // 两轮检查之间的最大间隔
+	startupProgressInterval    = 10 * time.Second // 输出等待进度日志的间隔
+)
+
+// Dependency 启动时需要等待的外部依赖
+type Dependency struct {
+	Name  string                          // 依赖名称，用于日志
+	Check func(ctx context.Context) error // 检查依赖是否可用，ctx 带有单次检查的超时时间
+}
+
+// WaitConfig 启动等待配置
+type WaitConfig struct {
+	Timeout      time.Duration // 等待所有依赖的总时长
+	CheckTimeout time.Duration // 单次检查的超时时间
+	Interval     time.Duration // 两轮检查之间的初始间隔，之后每轮翻倍，最多5秒
+}
+
+// NewWaitConfig 从应用配置创建启动等待配置，未配置时使用默认值
+func NewWaitConfig(cfg *config.Config) WaitConfig {
+	conf := WaitConfig{
+		Timeout:      cfg.Startup.WaitTimeout,
+		CheckTimeout: cfg.Startup.CheckTimeout,
+		Interval:     startupCheckInterval,
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultStartupWaitTimeout
+	}
+	if conf.CheckTimeout <= 0 {
+		conf.CheckTimeout = defaultStartupCheckTimeout
+	}
+	return conf
+}
+
+/*
+WaitForDependencies 启动时等待所有依赖可用，在开始监听HTTP端口之前调用，避免进程过早接收流量
+每轮只检查尚未就绪的依赖，依赖就绪后不再检查；每隔10秒输出一次仍在等待的依赖
+ctx: 上下文，取消时立即返回
+conf: 等待配置
+deps: 需要等待的依赖
+返回: 错误（超过总时长仍有依赖不可用时，包含每个依赖最后一次检查的错误）
+*/
+func WaitForDependencies(ctx context.Context, conf WaitConfig, deps ...Dependency) error {
+	if conf.Interval <= 0 {
+		conf.Interval = startupCheckInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	lastProgress := start
+	interval := conf.Interval
+	pending := make(map[string]error, len(deps))
+	for _, dep := range deps {
+		pending[dep.Name] = errors.New("尚未检查")
+	}
+
+	for {
+		for _, dep := range deps {
+			if _, ok := pending[dep.Name]; !ok {
+				continue
+			}
+
+			checkCtx, checkCancel := context.WithTimeout(ctx, conf.CheckTimeout)
+			err := dep.Check(checkCtx)
+			checkCancel()
+			if err != nil {
+				pending[dep.Name] = err
+				continue
+			}
+
+			delete(pending, dep.Name)
+			utils.Info("依赖已就绪", zap.String("dependency", dep.Name), zap.Duration("waited", time.Since(start)))
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if time.Since(lastProgress) >= startupProgressInterval {
+			lastProgress = time.Now()
+			for name, err := range pending {
+				utils.Warn("等待依赖就绪", zap.String("dependency", name), zap.Duration("waited", time.Since(start)), zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return pendingError(pending, time.Since(start))
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > startupMaxCheckInterval {
+			interval = startupMaxCheckInterval
+		}
+	}
+}
+
+// pendingError 汇总仍未就绪的依赖及其最后一次检查的错误
+func pendingError(pending map[string]error, waited time.Duration) error {
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, pending[name]))
+	}
+	return fmt.Errorf("等待 %s 后依赖仍不可用: %s", waited.Round(time.Second), strings.Join(parts, "; "))
+}
+
+// MongoDBDependency 以主节点ping检查MongoDB，需要先调用 ConnectMongoDB
+func MongoDBDependency() Dependency {
+	return Dependency{
+		Name: "mongodb",
+		Check: func(ctx context.Context) error {
+			_, err := PingMongoDB(ctx)
+			return err
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForDependenciesReturnsOnceAvailable(t *testing.T) {
+	var calls atomic.Int32
+	dep := Dependency{
+		Name: "flaky",
+		Check: func(ctx context.Context) error {
+			// 前两次检查失败，第三次起可用
+			if calls.Add(1) < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}
+	var readyCalls atomic.Int32
+	ready := Dependency{
+		Name: "ready",
+		Check: func(ctx context.Context) error {
+			readyCalls.Add(1)
+			return nil
+		},
+	}
+
+	conf := WaitConfig{Timeout: 5 * time.Second, CheckTimeout: time.Second, Interval: 10 * time.Millisecond}
+	if err := WaitForDependencies(context.Background(), conf, dep, ready); err != nil {
+		t.Fatalf("WaitForDependencies: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("flaky 检查次数 = %d, want 3", got)
+	}
+	if got := readyCalls.Load(); got != 1 {
+		t.Fatalf("已就绪的依赖不应重复检查, 检查次数 = %d", got)
+	}
+}
+
+func TestWaitForDependenciesTimeoutReportsPending(t *testing.T) {
+	down := func(msg string) func(context.Context) error {
+		return func(ctx context.Context) error { return errors.New(msg) }
+	}
+	conf := WaitConfig{Timeout: 50 * time.Millisecond, CheckTimeout: 10 * time.Millisecond, Interval: 10 * time.Millisecond}
+	err := WaitForDependencies(context.Background(), conf,
+		Dependency{Name: "redis", Check: down("dial timeout")},
+		Dependency{Name: "mongodb", Check: down("no primary")},
+		Dependency{Name: "ok", Check: func(ctx context.Context) error { return nil }},
+	)
+	if err == nil {
+		t.Fatal("超时后应该返回错误")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "mongodb: no primary; redis: dial timeout") {
+		t.Fatalf("错误信息应按名称列出所有未就绪的依赖: %s", msg)
+	}
+	if strings.Contains(msg, "ok:") {
+		t.Fatalf("已就绪的依赖不应出现在错误中: %s", msg)
+	}
+}
+
+func TestWaitForDependenciesCheckTimeout(t *testing.T) {
+	// 单次检查阻塞时受 CheckTimeout 约束，不会占满总时长
+	var calls atomic.Int32
+	dep := Dependency{
+		Name: "slow",
+		Check: func(ctx context.Context) error {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	}
+	conf := WaitConfig{Timeout: 2 * time.Second, CheckTimeout: 20 * time.Millisecond, Interval: 10 * time.Millisecond}
+	start := time.Now()
+	if err := WaitForDependencies(context.Background(), conf, dep); err != nil {
+		t.Fatalf("WaitForDependencies: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("单次检查超时未生效, 耗时 %s", elapsed)
+	}
+}
+
+func TestWaitForDependenciesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	conf := WaitConfig{Timeout: time.Minute, CheckTimeout: time.Second, Interval: 10 * time.Millisecond}
+	err := WaitForDependencies(ctx, conf, Dependency{
+		Name:  "mongodb",
+		Check: func(ctx context.Context) error { return ctx.Err() },
+	})
+	if err == nil || !strings.Contains(err.Error(), "mongodb") {
+		t.Fatalf("上下文取消后应该立即返回错误, got %v", err)
+	}
+}
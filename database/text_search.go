@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UserTextIndexName 用户集合全文索引的名称
+const UserTextIndexName = "user_text"
+
+// userTextFields 可以加入用户全文索引的字段
+var userTextFields = map[string]bool{"username": true, "email": true, "nickname": true}
+
+// 用户全文索引的字段权重，用户名匹配的相关度高于邮箱和昵称，可通过 SetUserTextWeights 修改
+var userTextWeights = map[string]int32{
+	"username": 10,
+	"email":    5,
+	"nickname": 1,
+}
+
+/*
+ParseTextWeights 解析 字段:权重 格式的权重配置，如 username:10,nickname:2
+values: 配置项列表
+返回: 字段权重, 错误（格式错误、字段不支持或权重不在1到99999之间时）
+*/
+func ParseTextWeights(values []string) (map[string]int32, error) {
+	weights := make(map[string]int32, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		field, raw, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("全文索引权重格式错误，应为 字段:权重: %s", value)
+		}
+		field = strings.TrimSpace(field)
+		if !userTextFields[field] {
+			return nil, fmt.Errorf("字段 %s 不支持全文索引，可选字段: username、email、nickname", field)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || weight < 1 || weight > 99999 {
+			return nil, fmt.Errorf("字段 %s 的全文索引权重必须是1到99999之间的整数", field)
+		}
+		weights[field] = int32(weight)
+	}
+	return weights, nil
+}
+
+/*
+SetUserTextWeights 设置用户全文索引的字段权重，未列出的字段不加入索引
+权重在创建索引时生效，已创建的索引不会随之改变：修改权重后需要回滚并重新执行创建全文索引的迁移（重建索引）
+weights: 字段权重，为空时保留默认值
+*/
+func SetUserTextWeights(weights map[string]int32) {
+	if len(weights) == 0 {
+		return
+	}
+	userTextWeights = weights
+}
+
+// userTextIndexFields 按字段名排序返回全文索引的字段，保证每次生成的索引定义相同
+func userTextIndexFields() []string {
+	fields := make([]string, 0, len(userTextWeights))
+	for field := range userTextWeights {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
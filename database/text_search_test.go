@@ -0,0 +1,50 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTextWeights(t *testing.T) {
+	got, err := ParseTextWeights([]string{" username : 10", "", "nickname:1"})
+	if err != nil {
+		t.Fatalf("ParseTextWeights: %v", err)
+	}
+	want := map[string]int32{"username": 10, "nickname": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("weights = %v, want %v", got, want)
+	}
+}
+
+func TestParseTextWeightsRejectsInvalid(t *testing.T) {
+	for _, values := range [][]string{
+		{"username"},
+		{"password:10"},
+		{"username:0"},
+		{"username:100000"},
+		{"username:abc"},
+	} {
+		if _, err := ParseTextWeights(values); err == nil {
+			t.Errorf("ParseTextWeights(%q) 应该返回错误", values)
+		}
+	}
+}
+
+func TestSetUserTextWeights(t *testing.T) {
+	saved := userTextWeights
+	t.Cleanup(func() { userTextWeights = saved })
+
+	// 空配置保留默认权重，用户名权重高于昵称
+	SetUserTextWeights(nil)
+	if userTextWeights["username"] <= userTextWeights["nickname"] {
+		t.Fatalf("默认权重应使用户名匹配排在昵称匹配之前: %v", userTextWeights)
+	}
+
+	SetUserTextWeights(map[string]int32{"nickname": 3, "username": 7})
+	if got := userTextIndexFields(); !reflect.DeepEqual(got, []string{"nickname", "username"}) {
+		t.Fatalf("userTextIndexFields() = %v", got)
+	}
+	if userTextWeights["username"] != 7 {
+		t.Fatalf("username 权重 = %d, want 7", userTextWeights["username"])
+	}
+}
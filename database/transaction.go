@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrTransactionsUnsupported 当前MongoDB部署不支持事务
+var ErrTransactionsUnsupported = errors.New("当前MongoDB部署不支持事务，需要副本集或分片集群")
+
+/*
+TransactionManager 事务管理器，在同一事务中执行多个存储库操作
+存储库方法的 ctx 参数传入 WithTransaction 回调收到的 sessCtx 即可加入事务：
+会话随上下文传递，存储库内部基于它派生的超时上下文仍属于同一会话，不需要单独的事务版本方法。
+事务要求MongoDB部署为副本集或分片集群，单机部署返回 ErrTransactionsUnsupported；
+开发环境可以将单个 mongod 以单成员副本集方式启动（--replSet rs0 后执行 rs.initiate()）
+*/
+type TransactionManager struct {
+	client *mongo.Client
+	// 已确认部署支持事务，之后不再检查
+	supported atomic.Bool
+}
+
+// NewTransactionManager 创建事务管理器，client 为nil时所有事务返回错误
+func NewTransactionManager(client *mongo.Client) *TransactionManager {
+	return &TransactionManager{client: client}
+}
+
+/*
+WithTransaction 在事务中执行 fn，fn 返回nil时提交，返回错误时回滚
+遇到 TransientTransactionError、UnknownTransactionCommitResult 时驱动会自动重试整个 fn（总时长不超过120秒），
+因此 fn 必须可以重复执行，不能包含发送邮件等事务外的副作用。
+fn 内的所有数据库操作都必须使用 sessCtx，使用其他上下文的操作不属于该事务
+返回: fn 返回的错误或事务错误（部署不支持事务时为 ErrTransactionsUnsupported）
+*/
+func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	if m.client == nil {
+		return fmt.Errorf("MongoDB未初始化")
+	}
+
+	if !m.supported.Load() {
+		if !supportsTransactions(ctx, m.client.Database("admin")) {
+			return ErrTransactionsUnsupported
+		}
+		m.supported.Store(true)
+	}
+
+	session, err := m.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("创建会话失败: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
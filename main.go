@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"go-app/config"
+	"go-app/controller"
 	"go-app/database"
 	"go-app/database/repositories"
 	"go-app/middleware"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
@@ -60,8 +62,12 @@ func main() {
 		MaxBackups:    maxBackups,
 		MaxAge:        maxAge,
 		Compress:      cfg.Logger.Compress,
-		ConsoleOutput: true,
-		RotateDaily:   true, // 强制按天轮转
+		ConsoleOutput: cfg.Logger.ConsoleOutput, // 容器环境可关闭，避免与文件日志重复
+		RotateDaily:   true,                     // 强制按天轮转
+		// 仅对服务端错误附加堆栈，4xx以warn级别记录，不附加堆栈
+		StacktraceLevel: cfg.Logger.StacktraceLevel,
+		// 启动时的日志级别，运行时可通过管理接口修改
+		Level: cfg.Logger.Level,
 	})
 
 	// 初始化请求日志记录器
@@ -72,6 +78,8 @@ func main() {
 		MaxAge:      maxAge,
 		Compress:    cfg.Logger.Compress,
 		RotateDaily: true, // 按天生成日志文件
+		// 请求日志格式，json或logfmt
+		RequestLogFormat: cfg.Logger.RequestLogFormat,
 	})
 
 	// 确保日志在程序退出时正确刷新
@@ -79,43 +87,134 @@ func main() {
 
 	utils.Info("应用程序启动")
 
+	// 记录各配置项的取值来源，便于排查配置未生效的问题
+	for _, s := range config.Sources() {
+		utils.Debug("配置来源", zap.String("name", s.Name), zap.String("source", s.Source), zap.String("value", s.Value))
+	}
+
 	// 设置运行模式
 	gin.SetMode(cfg.Server.Mode)
 
 	// 初始化MongoDB连接
-	mongoDb, err := database.InitMongoDB(cfg)
+	mongoDb, err := initMongoDB(cfg)
 	if err != nil {
 		utils.Error("MongoDB初始化失败", zap.Error(err))
 		utils.Fatal("无法启动应用程序，MongoDB连接失败")
 		return
 	}
 
-	// 执行MongoDB迁移
-	// 暂时不执行迁移
-	// if err := database.MigrateDB(); err != nil {
-	// 	utils.Error("MongoDB迁移失败", zap.Error(err))
-	// 	utils.Warn("将继续运行，但可能缺少一些必要的初始数据")
-	// }
+	// 设置读操作的重试策略
+	database.SetReadRetry(cfg.MongoDB.ReadRetryAttempts, cfg.MongoDB.ReadRetryBackoff)
+
+	// 设置分页游标的签名密钥
+	utils.SetCursorSecret(cfg.Security.CursorSecret)
+
+	// 设置用户字段的加密存储
+	if cfg.Security.FieldEncryptionKey != "" {
+		crypter, err := utils.NewAESGCMCrypter(cfg.Security.FieldEncryptionKey)
+		if err != nil {
+			utils.Fatal("字段加密密钥无效", zap.Error(err))
+			return
+		}
+		repositories.SetFieldEncryption(crypter, cfg.Security.EncryptedFields)
+	}
+
+	// 设置用户全文索引的字段权重，在创建索引的迁移中生效
+	textWeights, err := database.ParseTextWeights(cfg.MongoDB.TextWeights)
+	if err != nil {
+		utils.Fatal("全文索引权重配置无效", zap.Error(err))
+		return
+	}
+	database.SetUserTextWeights(textWeights)
+
+	// 执行MongoDB迁移（创建索引、初始化数据），需在设置全文索引权重之后执行
+	// 迁移失败时拒绝启动，避免在缺少唯一索引的情况下写入重复数据
+	if cfg.Database.AutoMigrate {
+		start := time.Now()
+		executed, err := database.MigrateDB(!cfg.Database.SkipAdminSeed)
+		if err != nil {
+			utils.Fatal("MongoDB迁移失败，无法启动应用程序", zap.Ints("executed", executed), zap.Error(err))
+			return
+		}
+		utils.Info("MongoDB迁移完成", zap.Ints("executed", executed), zap.Duration("elapsed", time.Since(start)))
+	}
+
+	// 设置列表查询的默认排序
+	repositories.SetDefaultSort(cfg.MongoDB.DefaultSort)
 
 	// 创建存储库管理器，使用MongoDB
 	repoManager := repositories.NewRepositoryManager(mongoDb)
 	utils.Info("MongoDB初始化成功")
 
+	// 可信平台配置无效时拒绝启动，避免客户端IP退回为代理地址
+	if err := router.ValidateTrustedPlatform(cfg); err != nil {
+		utils.Fatal("可信平台配置无效", zap.Error(err))
+		return
+	}
+
 	// 创建Gin引擎
 	r := gin.New()
 
 	// 添加Recovery中间件
 	r.Use(gin.Recovery())
 
+	// 添加请求ID中间件
+	r.Use(middleware.RequestIDWithConfig(middleware.NewRequestIDConfig(cfg)))
+
+	// 添加耗时明细中间件，放在前面以统计完整的处理耗时
+	r.Use(middleware.ServerTiming(middleware.ServerTimingEnabled(cfg)))
+
+	// 添加请求指标中间件，指标通过 /metrics 暴露给 Prometheus
+	r.Use(middleware.Metrics())
+
+	// 添加查询字符串限制中间件，放在日志之前，超大的查询字符串不会被完整记录
+	r.Use(middleware.QueryLimit(middleware.NewQueryLimitConfig(cfg)))
+
 	// 添加日志和错误处理中间件
-	r.Use(middleware.Logger())
+	r.Use(middleware.LoggerWithConfig(middleware.NewLoggerConfig(cfg)))
 	r.Use(middleware.ErrorHandler())
 
-	// 添加CORS中间件
+	// 添加处理器超时中间件
+	r.Use(middleware.Timeout(cfg.Server.HandlerTimeout))
+
+	// 添加复制延迟保护中间件，MONGODB_MAX_REPLICATION_LAG 为0时不检查
+	r.Use(middleware.ReplicationLag(middleware.NewReplicationLagConfig(cfg, func(ctx context.Context) (time.Duration, error) {
+		return database.ReplicationLag(ctx, database.MongoClient)
+	})))
+
+	// 添加CORS中间件，跨域配置无效时拒绝启动
+	if _, err := middleware.ValidateCORS(cfg); err != nil {
+		utils.Fatal("跨域配置无效", zap.Error(err))
+		return
+	}
 	r.Use(middleware.Cors(cfg))
 
+	// 添加白名单中间件，管理接口的修改作用于该配置
+	middleware.DefaultWhitelistConfig = middleware.NewWhitelistConfig(cfg)
+	r.Use(middleware.Whitelist(middleware.DefaultWhitelistConfig))
+
+	// 添加按IP限流中间件，RATE_LIMIT_ENABLE 为false时直接放行
+	r.Use(middleware.RateLimit(middleware.NewRateLimitConfig(cfg)))
+
+	// 初始化控制器管理器，中间件和路由共用其中的服务
+	controllerManager := controller.NewManager(cfg, repoManager)
+
+	// 添加只读模式中间件，状态由管理接口切换并保存在MongoDB中，所有实例定期同步
+	r.Use(middleware.ReadOnly(middleware.NewReadOnlyConfig(controllerManager.ReadOnly)))
+
+	// 添加签名验证中间件，SIGNATURE_ENABLE 为false时直接放行，签名配置无效时拒绝启动
+	if err := middleware.ValidateSignature(cfg); err != nil {
+		utils.Fatal("签名配置无效", zap.Error(err))
+		return
+	}
+	signatureConfig := middleware.NewSignatureConfig(cfg)
+	if cfg.Signature.NonceStore == middleware.NonceStoreMongoDB {
+		signatureConfig.NonceStore = repoManager.Nonce
+	}
+	r.Use(middleware.Signature(signatureConfig))
+
 	// 设置路由
-	router.Setup(r, cfg, repoManager)
+	router.Setup(r, cfg, controllerManager)
 
 	// 配置服务器
 	port := cfg.Server.Port
@@ -135,6 +234,21 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// 由外部logrotate轮转日志时，收到SIGHUP重新打开日志文件
+	if cfg.Logger.ReopenOnSignal {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := utils.ReopenLogFiles(); err != nil {
+					utils.Error("重新打开日志文件失败", zap.Error(err))
+					continue
+				}
+				utils.Info("已重新打开日志文件")
+			}
+		}()
+	}
+
 	// 启动服务器
 	go func() {
 		utils.Info(fmt.Sprintf("服务器启动于 http://localhost:%s", port))
@@ -155,5 +269,39 @@ func main() {
 		utils.Error("服务器关闭出错", zap.Error(err))
 	}
 
+	// 请求处理结束后写出缓冲中的请求日志
+	if err := utils.CloseRequestLogger(ctx); err != nil {
+		utils.Error("关闭请求日志失败", zap.Error(err))
+	}
+
 	utils.Info("服务器已关闭")
 }
+
+/*
+initMongoDB 初始化MongoDB连接
+开启 STARTUP_WAIT_ENABLE 时在限定时间内等待MongoDB可用，超时后根据 STARTUP_DEGRADED_START 决定退出或降级启动；
+降级启动时客户端在后台重连，/healthz 在MongoDB恢复前返回503，负载均衡器不会转发流量
+*/
+func initMongoDB(cfg *config.Config) (*mongo.Database, error) {
+	if !cfg.Startup.WaitEnable {
+		return database.InitMongoDB(cfg)
+	}
+
+	mongoDb, err := database.ConnectMongoDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	waitConfig := database.NewWaitConfig(cfg)
+	utils.Info("等待依赖就绪",
+		zap.Duration("timeout", waitConfig.Timeout),
+		zap.Duration("check_timeout", waitConfig.CheckTimeout),
+	)
+	if err := database.WaitForDependencies(context.Background(), waitConfig, database.MongoDBDependency()); err != nil {
+		if !cfg.Startup.DegradedStart {
+			return nil, err
+		}
+		utils.Warn("依赖未就绪，降级启动", zap.Error(err))
+	}
+	return mongoDb, nil
+}
@@ -1,21 +1,148 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"go-app/config"
+	"go-app/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// corsAllowAll 允许所有源的特殊取值
+const corsAllowAll = "*"
+
+// corsRegexPrefix 以正则表达式匹配源的条目前缀，如 regex:https://pr-\d+\.example\.com（自动整体匹配）
+const corsRegexPrefix = "regex:"
+
+// corsWildcardLabels 通配符 * 匹配的内容：一级或多级域名标签，不能包含协议、路径和端口分隔符
+const corsWildcardLabels = `[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*`
+
+// 未配置 CORS_ALLOW_METHODS、CORS_ALLOW_HEADERS 时允许的请求方法和请求头
+var (
+	defaultCORSAllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	defaultCORSAllowHeaders = []string{
+		"Origin", "Content-Length", "Content-Type", "Authorization",
+		"Accept", "X-Requested-With", "X-CSRF-Token", "signature",
+		"app_key", "timestamp", "nonce", "sign",
+	}
+)
+
+// CORSOrigins 生效的允许源：精确匹配的源，以及由通配符和正则条目编译的匹配规则
+type CORSOrigins struct {
+	AllowAll bool             // 允许所有源（配置为 *）
+	Exact    []string         // 精确匹配的源
+	Patterns []*regexp.Regexp // 通配符和正则条目编译得到的规则
+}
+
+// Match 判断源是否与任一匹配规则相符（不含精确匹配的源）
+func (o *CORSOrigins) Match(origin string) bool {
+	for _, p := range o.Patterns {
+		if p.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ValidateCORS 校验跨域配置并返回生效的允许源
+CORS_ALLOW_ORIGINS 必须显式配置，允许所有源时配置为 *。除精确的源外还支持两种匹配规则：
+包含 * 的通配符条目（如 https://*.staging.example.com，* 匹配一级或多级子域名），
+以及以 regex: 开头的正则条目（整体匹配源）。
+浏览器不接受 * 与凭证同时使用；通配符和正则条目会把凭证开放给大量源，同样不能与 CORS_ALLOW_CREDENTIALS 同时使用
+cfg: 应用配置
+返回: 允许的源, 错误
+*/
+func ValidateCORS(cfg *config.Config) (*CORSOrigins, error) {
+	origins := cfg.CORS.AllowOrigins
+	if len(origins) == 0 {
+		return nil, errors.New("未配置CORS_ALLOW_ORIGINS，允许所有源请显式配置为 *")
+	}
+
+	result := &CORSOrigins{}
+	for _, origin := range origins {
+		switch {
+		case origin == corsAllowAll:
+			if cfg.CORS.AllowCredentials {
+				return nil, errors.New("CORS_ALLOW_ORIGINS 为 * 时不能开启 CORS_ALLOW_CREDENTIALS")
+			}
+			if len(origins) > 1 {
+				return nil, fmt.Errorf("CORS_ALLOW_ORIGINS 包含 * 时不能再配置其他源: %v", origins)
+			}
+			result.AllowAll = true
+		case strings.HasPrefix(origin, corsRegexPrefix), strings.Contains(origin, "*"):
+			if cfg.CORS.AllowCredentials {
+				return nil, fmt.Errorf("开启 CORS_ALLOW_CREDENTIALS 时不能使用通配符或正则匹配源，请逐个列出允许的源: %s", origin)
+			}
+			pattern, err := compileCORSPattern(origin)
+			if err != nil {
+				return nil, err
+			}
+			result.Patterns = append(result.Patterns, pattern)
+		default:
+			result.Exact = append(result.Exact, origin)
+		}
+	}
+
+	return result, nil
+}
+
+// compileCORSPattern 将通配符或正则条目编译为整体匹配源的正则表达式
+func compileCORSPattern(origin string) (*regexp.Regexp, error) {
+	var expr string
+	if strings.HasPrefix(origin, corsRegexPrefix) {
+		expr = strings.TrimPrefix(origin, corsRegexPrefix)
+	} else {
+		expr = strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, corsWildcardLabels)
+	}
+
+	pattern, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("CORS_ALLOW_ORIGINS 中的匹配规则无效 %s: %w", origin, err)
+	}
+	return pattern, nil
+}
+
 // Cors 跨域中间件
+// 配置无效时panic，应用启动时应先调用 ValidateCORS 校验
 func Cors(cfg *config.Config) gin.HandlerFunc {
 	// 配置跨域源
-	allowOrigins := []string{"http://localhost:3000", "http://localhost:8080"}
-	if len(cfg.CORS.AllowOrigins) > 0 {
-		allowOrigins = cfg.CORS.AllowOrigins
+	origins, err := ValidateCORS(cfg)
+	if err != nil {
+		panic("跨域配置无效: " + err.Error())
+	}
+	allowOrigins := origins.Exact
+	if origins.AllowAll {
+		allowOrigins = []string{corsAllowAll}
+	}
+	// 存在通配符或正则条目时，精确匹配失败后再按规则匹配
+	var allowOriginFunc func(origin string) bool
+	if len(origins.Patterns) > 0 {
+		allowOriginFunc = origins.Match
+	}
+	allowMethods := cfg.CORS.AllowMethods
+	if len(allowMethods) == 0 {
+		allowMethods = defaultCORSAllowMethods
+	}
+	allowHeaders := cfg.CORS.AllowHeaders
+	if len(allowHeaders) == 0 {
+		allowHeaders = defaultCORSAllowHeaders
 	}
+	utils.Info("跨域配置",
+		zap.Strings("allow_origins", allowOrigins),
+		zap.Int("origin_patterns", len(origins.Patterns)),
+		zap.Bool("allow_credentials", cfg.CORS.AllowCredentials),
+		zap.Strings("allow_methods", allowMethods),
+		zap.Strings("allow_headers", allowHeaders),
+		zap.Strings("expose_headers", cfg.CORS.ExposeHeaders),
+	)
 
 	// 配置有效期
 	maxAge := 12 * time.Hour
@@ -26,14 +153,14 @@ func Cors(cfg *config.Config) gin.HandlerFunc {
 	return cors.New(cors.Config{
 		// 允许的源
 		AllowOrigins: allowOrigins,
+		// 通配符和正则匹配
+		AllowOriginFunc: allowOriginFunc,
 		// 允许的请求方法
-		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowMethods: allowMethods,
 		// 允许的请求头
-		AllowHeaders: []string{
-			"Origin", "Content-Length", "Content-Type", "Authorization",
-			"Accept", "X-Requested-With", "X-CSRF-Token", "signature",
-			"app_key", "timestamp", "nonce", "sign",
-		},
+		AllowHeaders: allowHeaders,
+		// 允许浏览器脚本读取的响应头
+		ExposeHeaders: cfg.CORS.ExposeHeaders,
 		// 是否允许携带认证信息（如cookies）
 		AllowCredentials: cfg.CORS.AllowCredentials,
 		// 预检请求的有效期
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func corsConfig(credentials bool, origins ...string) *config.Config {
+	cfg := &config.Config{}
+	cfg.CORS.AllowOrigins = origins
+	cfg.CORS.AllowCredentials = credentials
+	return cfg
+}
+
+// preflight 发送预检请求，返回响应中允许的源
+func preflight(cfg *config.Config, origin string) string {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Cors(cfg))
+	r.GET("/res", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/res", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header().Get("Access-Control-Allow-Origin")
+}
+
+func TestValidateCORSRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"未配置允许源", corsConfig(false)},
+		{"* 与凭证同时使用", corsConfig(true, "*")},
+		{"* 与其他源同时配置", corsConfig(false, "*", "https://a.example.com")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateCORS(tt.cfg); err == nil {
+				t.Fatal("应返回错误")
+			}
+		})
+	}
+}
+
+func TestCorsPanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("配置无效时应panic")
+		}
+	}()
+	Cors(corsConfig(true, "*"))
+}
+
+func TestCorsExplicitOrigins(t *testing.T) {
+	cfg := corsConfig(true, "https://a.example.com", "https://b.example.com")
+	origins, err := ValidateCORS(cfg)
+	if err != nil {
+		t.Fatalf("ValidateCORS: %v", err)
+	}
+	if origins.AllowAll || len(origins.Exact) != 2 {
+		t.Fatalf("origins = %+v", origins)
+	}
+
+	if got := preflight(cfg, "https://b.example.com"); got != "https://b.example.com" {
+		t.Errorf("允许的源: Access-Control-Allow-Origin = %q", got)
+	}
+	if got := preflight(cfg, "https://evil.example.com"); got != "" {
+		t.Errorf("未允许的源: Access-Control-Allow-Origin = %q, want 空", got)
+	}
+}
+
+func TestCorsAllowAll(t *testing.T) {
+	cfg := corsConfig(false, "*")
+	origins, err := ValidateCORS(cfg)
+	if err != nil || !origins.AllowAll {
+		t.Fatalf("ValidateCORS = %+v, %v", origins, err)
+	}
+	if got := preflight(cfg, "https://any.example.com"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
+
+func TestCorsWildcardOrigins(t *testing.T) {
+	cfg := corsConfig(false, "https://app.example.com", "https://*.staging.example.com")
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://pr-1.staging.example.com", "https://pr-1.staging.example.com"},
+		{"https://a.b.staging.example.com", "https://a.b.staging.example.com"},
+		{"https://staging.example.com", ""},
+		{"http://pr-1.staging.example.com", ""},
+		{"https://pr-1.staging.example.com.evil.com", ""},
+		{"https://evil.com/.staging.example.com", ""},
+	}
+	for _, tt := range tests {
+		if got := preflight(cfg, tt.origin); got != tt.want {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCorsRegexOrigins(t *testing.T) {
+	cfg := corsConfig(false, `regex:https://pr-\d+\.example\.com`)
+
+	if got := preflight(cfg, "https://pr-42.example.com"); got != "https://pr-42.example.com" {
+		t.Errorf("匹配的源: Access-Control-Allow-Origin = %q", got)
+	}
+	// 正则整体匹配，前后不能有多余内容
+	for _, origin := range []string{"https://pr-x.example.com", "https://pr-42.example.com.evil.com", "http://a.com?https://pr-1.example.com"} {
+		if got := preflight(cfg, origin); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want 空", origin, got)
+		}
+	}
+}
+
+func TestValidateCORSRejectsPatternsWithCredentials(t *testing.T) {
+	for _, origin := range []string{"https://*.example.com", `regex:https://.*\.example\.com`} {
+		if _, err := ValidateCORS(corsConfig(true, origin)); err == nil {
+			t.Errorf("%s 与凭证同时使用时应返回错误", origin)
+		}
+	}
+	if _, err := ValidateCORS(corsConfig(false, "regex:https://(")); err == nil {
+		t.Error("无效的正则应返回错误")
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"go-app/utils"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // ErrorResponse 统一错误响应结构
@@ -28,8 +31,13 @@ func ErrorHandler() gin.HandlerFunc {
 				stack := string(debug.Stack())
 				stackLines := strings.Split(stack, "\n")
 
-				// 打印简化的堆栈信息到日志
-				fmt.Printf("Panic recovered: %v\nStack trace:\n%s\n", err, stack)
+				// 记录panic及完整堆栈，不受自动堆栈级别配置影响
+				utils.Error("请求处理发生panic",
+					zap.Any("panic", err),
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("panic_stack", stack),
+				)
 
 				// 对客户端隐藏完整堆栈信息，只显示必要的错误信息
 				errMsg := fmt.Sprintf("%v", err)
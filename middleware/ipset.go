@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ipSet IP集合
+// 单个IP使用map存储，查找为O(1)；CIDR网段使用按位前缀树存储，查找为O(前缀长度)
+type ipSet struct {
+	mu         sync.RWMutex
+	maxEntries int                   // 最大条目数，0表示不限制
+	exact      map[string]struct{}   // 单个IP（规范化后的字符串）
+	cidrs      map[string]*net.IPNet // CIDR网段（规范化后的字符串）
+	v4         *cidrNode             // IPv4网段前缀树
+	v6         *cidrNode             // IPv6网段前缀树
+}
+
+// cidrNode 前缀树节点，每层对应IP地址的一个比特位
+type cidrNode struct {
+	children [2]*cidrNode
+	terminal bool // 是否为某个网段的终点
+}
+
+// newIPSet 根据条目列表创建IP集合，超出最大条目数的部分返回错误
+func newIPSet(entries []string, maxEntries int) (*ipSet, error) {
+	s := &ipSet{
+		maxEntries: maxEntries,
+		exact:      make(map[string]struct{}),
+		cidrs:      make(map[string]*net.IPNet),
+	}
+	for _, entry := range entries {
+		if err := s.Add(entry); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// Add 添加单个IP或CIDR网段
+func (s *ipSet) Add(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return fmt.Errorf("IP不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("无效的CIDR网段: %s", entry)
+		}
+		key := ipNet.String()
+		if _, ok := s.cidrs[key]; ok {
+			return nil
+		}
+		if err := s.checkCapacity(); err != nil {
+			return err
+		}
+		s.cidrs[key] = ipNet
+		s.insertCIDR(ipNet)
+		return nil
+	}
+
+	key := normalizeIP(entry)
+	if _, ok := s.exact[key]; ok {
+		return nil
+	}
+	if err := s.checkCapacity(); err != nil {
+		return err
+	}
+	s.exact[key] = struct{}{}
+	return nil
+}
+
+// Remove 移除单个IP或CIDR网段，返回是否存在
+func (s *ipSet) Remove(entry string) bool {
+	entry = strings.TrimSpace(entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return false
+		}
+		key := ipNet.String()
+		if _, ok := s.cidrs[key]; !ok {
+			return false
+		}
+		delete(s.cidrs, key)
+		// 移除操作较少，直接重建前缀树
+		s.v4, s.v6 = nil, nil
+		for _, n := range s.cidrs {
+			s.insertCIDR(n)
+		}
+		return true
+	}
+
+	key := normalizeIP(entry)
+	if _, ok := s.exact[key]; !ok {
+		return false
+	}
+	delete(s.exact, key)
+	return true
+}
+
+// Contains 判断IP是否命中集合中的单个IP或网段
+func (s *ipSet) Contains(ip string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.exact[normalizeIP(ip)]; ok {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return lookupCIDR(s.v4, v4)
+	}
+	return lookupCIDR(s.v6, parsed.To16())
+}
+
+// List 返回集合中的全部条目（已排序）
+func (s *ipSet) List() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]string, 0, len(s.exact)+len(s.cidrs))
+	for k := range s.exact {
+		list = append(list, k)
+	}
+	for k := range s.cidrs {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// checkCapacity 检查是否已达到最大条目数，调用方需持有写锁
+func (s *ipSet) checkCapacity() error {
+	if s.maxEntries > 0 && len(s.exact)+len(s.cidrs) >= s.maxEntries {
+		return fmt.Errorf("白名单条目数已达上限: %d", s.maxEntries)
+	}
+	return nil
+}
+
+// insertCIDR 将网段插入前缀树，调用方需持有写锁
+func (s *ipSet) insertCIDR(ipNet *net.IPNet) {
+	ones, _ := ipNet.Mask.Size()
+	ip := ipNet.IP
+	root := &s.v6
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		root = &s.v4
+	}
+	if *root == nil {
+		*root = &cidrNode{}
+	}
+
+	node := *root
+	for i := 0; i < ones; i++ {
+		bit := ipBit(ip, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &cidrNode{}
+		}
+		node = node.children[bit]
+	}
+	node.terminal = true
+}
+
+// lookupCIDR 沿前缀树查找，途经任一网段终点即命中
+func lookupCIDR(root *cidrNode, ip net.IP) bool {
+	node := root
+	for i := 0; node != nil; i++ {
+		if node.terminal {
+			return true
+		}
+		if i >= len(ip)*8 {
+			return false
+		}
+		node = node.children[ipBit(ip, i)]
+	}
+	return false
+}
+
+// ipBit 返回IP地址第i个比特位
+func ipBit(ip net.IP, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
+
+// normalizeIP 规范化IP字符串，无法解析时原样返回
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(strings.TrimSpace(ip)); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
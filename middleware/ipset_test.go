@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"testing"
+)
+
+var ipSetEntries = []string{"10.0.0.1", "192.168.0.0/16", "172.16.5.0/24", "2001:db8::/32", "::1"}
+
+func TestIPSetMatchesScan(t *testing.T) {
+	s, err := newIPSet(ipSetEntries, 0)
+	if err != nil {
+		t.Fatalf("newIPSet: %v", err)
+	}
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.2", false},
+		{"192.168.255.1", true},
+		{"192.169.0.1", false},
+		{"172.16.5.200", true},
+		{"172.16.6.1", false},
+		{"2001:db8:1::5", true},
+		{"2001:db9::1", false},
+		{"::1", true},
+		{"not-an-ip", false},
+	}
+	for _, tc := range cases {
+		if got := s.Contains(tc.ip); got != tc.want {
+			t.Errorf("Contains(%q) = %v, want %v", tc.ip, got, tc.want)
+		}
+		if got := IsIPInWhitelist(tc.ip, ipSetEntries); got != tc.want {
+			t.Errorf("IsIPInWhitelist(%q) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+}
+
+func TestIPSetAddRemove(t *testing.T) {
+	s, _ := newIPSet(nil, 0)
+	if err := s.Add("10.0.0.0/8"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add("10.1.0.0/16"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add("bad/cidr"); err == nil {
+		t.Fatal("无效网段应返回错误")
+	}
+
+	// 移除较大的网段后，较小网段内的IP仍然命中
+	if !s.Remove("10.0.0.0/8") {
+		t.Fatal("Remove 应返回 true")
+	}
+	if s.Contains("10.2.0.1") {
+		t.Fatal("移除的网段不应再命中")
+	}
+	if !s.Contains("10.1.2.3") {
+		t.Fatal("未移除的网段应继续命中")
+	}
+	if s.Remove("10.0.0.0/8") {
+		t.Fatal("重复移除应返回 false")
+	}
+	if got := s.List(); len(got) != 1 || got[0] != "10.1.0.0/16" {
+		t.Fatalf("List() = %v", got)
+	}
+}
+
+func TestIPSetMaxEntries(t *testing.T) {
+	s, err := newIPSet([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, 2)
+	if err == nil {
+		t.Fatal("超出最大条目数应返回错误")
+	}
+	if len(s.List()) != 2 {
+		t.Fatalf("List() = %v", s.List())
+	}
+	// 已存在的条目不占用新的名额
+	if err := s.Add("10.0.0.1"); err != nil {
+		t.Fatalf("重复添加: %v", err)
+	}
+	s.Remove("10.0.0.1")
+	if err := s.Add("10.0.0.0/24"); err != nil {
+		t.Fatalf("移除后添加: %v", err)
+	}
+}
+
+// benchmarkWhitelist 生成包含 n 个单IP条目的白名单，查找的IP位于列表末尾
+func benchmarkWhitelist(n int) ([]string, string) {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf("10.%d.%d.%d", i/65536%256, i/256%256, i%256)
+	}
+	return list, list[n-1]
+}
+
+func BenchmarkIPWhitelistScan(b *testing.B) {
+	list, ip := benchmarkWhitelist(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		IsIPInWhitelist(ip, list)
+	}
+}
+
+func BenchmarkIPWhitelistSet(b *testing.B) {
+	list, ip := benchmarkWhitelist(10000)
+	s, err := newIPSet(list, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Contains(ip)
+	}
+}
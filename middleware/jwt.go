@@ -1,80 +1,205 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"go-app/config"
+	"go-app/ctxkeys"
+	"go-app/models/apikey"
+	"go-app/models/user"
+	"go-app/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 )
 
+// TokenValidator 令牌校验器，由服务层实现
+// 在解析令牌之外还会校验令牌对应的用户是否仍然有效
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*user.User, time.Time, error)
+}
+
+// APIKeyAuthenticator API密钥校验器，由服务层实现
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (uint, []string, error)
+}
+
+// disabledAuthUserID 认证关闭时使用的默认用户ID
+const disabledAuthUserID uint = 1
+
+// APIKeyHeader 携带API密钥的请求头
+const APIKeyHeader = "X-API-Key"
+
+// AuthOptions 认证中间件依赖
+type AuthOptions struct {
+	// 令牌校验器，为nil时仅解析令牌
+	TokenValidator TokenValidator
+	// API密钥校验器，为nil时不接受API密钥认证
+	APIKeyAuthenticator APIKeyAuthenticator
+}
+
 // JWTAuth JWT认证中间件
-func JWTAuth(cfg *config.Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 临时禁用JWT验证
-		c.Set("userID", uint(1)) // 设置一个默认用户ID
-		c.Next()
-		return
+func JWTAuth(cfg *config.Config, opts AuthOptions) gin.HandlerFunc {
+	if cfg.JWT.Disabled {
+		utils.Warn("JWT认证已关闭，所有请求均视为用户1，仅限本地开发使用", zap.Uint("user_id", disabledAuthUserID))
+	}
 
-		// 从请求头中获取token
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "请先登录",
-			})
-			c.Abort()
+	return func(c *gin.Context) {
+		// 认证关闭时（仅限本地开发），所有请求视为默认用户
+		if cfg.JWT.Disabled {
+			ctxkeys.SetUserID(c, disabledAuthUserID)
+			ctxkeys.SetUserRole(c, user.RoleAdmin)
+			c.Next()
 			return
 		}
 
-		// 检查token格式
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "无效的认证格式",
-			})
-			c.Abort()
+		// 机器客户端可使用API密钥代替JWT
+		if key := c.GetHeader(APIKeyHeader); key != "" && opts.APIKeyAuthenticator != nil {
+			apiKeyAuth(c, opts.APIKeyAuthenticator, key)
 			return
 		}
 
-		// 解析token
-		token := parts[1]
-		claims, err := ParseToken(token, cfg.JWT.Secret)
+		// 从请求头中获取token
+		token, err := ExtractBearerToken(c)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
-				"message": "认证失败: " + err.Error(),
+				"message": err.Error(),
 			})
 			c.Abort()
 			return
 		}
 
+		// 校验token
+		var userID uint
+		var role string
+		if opts.TokenValidator != nil {
+			u, _, err := opts.TokenValidator.ValidateToken(c.Request.Context(), token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    401,
+					"message": "认证失败: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+			// 角色以数据库中的当前值为准，角色变更立即生效
+			userID = u.ID
+			role = u.EffectiveRole()
+		} else {
+			claims, err := ParseTokenWithOptions(token, cfg.JWT.Secret, NewTokenOptions(cfg))
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    401,
+					"message": "认证失败: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+			userID = claims.UserID
+			role = claims.Role
+			if role == "" {
+				role = user.RoleUser
+			}
+		}
+
 		// 将用户信息保存到上下文
-		c.Set("userID", claims.UserID)
+		ctxkeys.SetUserID(c, userID)
+		ctxkeys.SetUserRole(c, role)
 		c.Next()
 	}
 }
 
+// apiKeyAuth 使用API密钥认证，并按请求方法校验密钥的权限范围
+func apiKeyAuth(c *gin.Context, authenticator APIKeyAuthenticator, key string) {
+	userID, scopes, err := authenticator.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "认证失败: " + err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	required := RequiredScope(c.Request.Method)
+	if !containsScope(scopes, required) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403,
+			"message": "API密钥缺少权限: " + required,
+		})
+		c.Abort()
+		return
+	}
+
+	ctxkeys.SetUserID(c, userID)
+	ctxkeys.SetAPIKeyScopes(c, scopes)
+	c.Next()
+}
+
+// RequiredScope 返回请求方法所需的API密钥权限：只读方法需要 read，其余需要 write
+func RequiredScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return apikey.ScopeRead
+	}
+	return apikey.ScopeWrite
+}
+
+// containsScope 判断权限范围中是否包含指定权限
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractBearerToken 从 Authorization 请求头中提取 Bearer 令牌
+func ExtractBearerToken(c *gin.Context) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return "", errors.New("请先登录")
+	}
+
+	// 检查token格式
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return "", errors.New("无效的认证格式")
+	}
+
+	return parts[1], nil
+}
+
 // Claims JWT claims
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken 生成JWT令牌
-func GenerateToken(userID uint, secret string, expire time.Duration) (string, error) {
+func GenerateToken(userID uint, role string, secret string, expire time.Duration) (string, error) {
+	return GenerateSessionToken(userID, role, secret, expire, "")
+}
+
+// GenerateSessionToken 生成关联登录会话的JWT令牌，tokenID 写入 jti，为空时不关联会话
+func GenerateSessionToken(userID uint, role string, secret string, expire time.Duration, tokenID string) (string, error) {
 	// 创建claims
 	claims := Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   "user_token",
+			ID:        tokenID,
 		},
 	}
 
@@ -85,12 +210,43 @@ func GenerateToken(userID uint, secret string, expire time.Duration) (string, er
 	return token.SignedString([]byte(secret))
 }
 
+// maxClockSkew 允许配置的最大时钟偏差，容忍范围过大会让过期令牌在较长时间内仍然可用
+const maxClockSkew = 5 * time.Minute
+
+// TokenOptions 令牌校验选项
+type TokenOptions struct {
+	// 令牌最大有效年龄，超过该时长的令牌即使未到 exp 也会被拒绝，0表示不限制
+	MaxTokenAge time.Duration
+	// 校验 exp、nbf 和最大有效年龄时容忍的时钟偏差，0表示严格校验
+	ClockSkew time.Duration
+}
+
+// NewTokenOptions 从应用配置创建令牌校验选项，时钟偏差超过 maxClockSkew 时按上限处理
+func NewTokenOptions(cfg *config.Config) TokenOptions {
+	skew := cfg.JWT.ClockSkew
+	if skew < 0 {
+		skew = 0
+	}
+	if skew > maxClockSkew {
+		skew = maxClockSkew
+	}
+	return TokenOptions{
+		MaxTokenAge: cfg.JWT.MaxTokenAge,
+		ClockSkew:   skew,
+	}
+}
+
 // ParseToken 解析JWT令牌
 func ParseToken(tokenString string, secret string) (*Claims, error) {
+	return ParseTokenWithOptions(tokenString, secret, TokenOptions{})
+}
+
+// ParseTokenWithOptions 使用校验选项解析JWT令牌
+func ParseTokenWithOptions(tokenString string, secret string, opts TokenOptions) (*Claims, error) {
 	// 解析token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithLeeway(opts.ClockSkew))
 
 	if err != nil {
 		return nil, err
@@ -107,5 +263,15 @@ func ParseToken(tokenString string, secret string) (*Claims, error) {
 		return nil, errors.New("无法获取令牌声明")
 	}
 
+	// 校验令牌年龄，与 exp 无关
+	if opts.MaxTokenAge > 0 {
+		if claims.IssuedAt == nil {
+			return nil, errors.New("令牌缺少签发时间")
+		}
+		if time.Since(claims.IssuedAt.Time) > opts.MaxTokenAge+opts.ClockSkew {
+			return nil, errors.New("令牌已超过最大有效期")
+		}
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/ctxkeys"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signTestToken 按给定的签发、生效和过期时间签发令牌
+func signTestToken(t *testing.T, issuedAt, notBefore, expiresAt time.Time) string {
+	t.Helper()
+	claims := Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(notBefore),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+func TestParseTokenRejectsTokenOlderThanMaxAge(t *testing.T) {
+	opts := TokenOptions{MaxTokenAge: time.Hour}
+
+	// 过期时间很远，但签发时间已超过最大年龄
+	now := time.Now()
+	old := signTestToken(t, now.Add(-2*time.Hour), now.Add(-2*time.Hour), now.Add(365*24*time.Hour))
+	if _, err := ParseTokenWithOptions(old, "test-secret", opts); err == nil {
+		t.Fatal("超过最大年龄的令牌应被拒绝")
+	}
+
+	fresh := signTestToken(t, now, now, now.Add(time.Hour))
+	if _, err := ParseTokenWithOptions(fresh, "test-secret", opts); err != nil {
+		t.Fatalf("新签发的令牌: %v", err)
+	}
+
+	// 未配置最大年龄时只按 exp 校验
+	if _, err := ParseTokenWithOptions(old, "test-secret", TokenOptions{}); err != nil {
+		t.Fatalf("未限制年龄: %v", err)
+	}
+}
+
+func TestParseTokenMaxAgeRequiresIssuedAt(t *testing.T) {
+	claims := Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	if _, err := ParseTokenWithOptions(token, "test-secret", TokenOptions{MaxTokenAge: time.Hour}); err == nil {
+		t.Fatal("限制最大年龄时缺少签发时间的令牌应被拒绝")
+	}
+	if _, err := ParseTokenWithOptions(token, "test-secret", TokenOptions{}); err != nil {
+		t.Fatalf("未限制年龄时不要求签发时间: %v", err)
+	}
+}
+
+// stubAPIKeys 按明文密钥返回预设的用户和权限
+type stubAPIKeys map[string][]string
+
+func (s stubAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (uint, []string, error) {
+	scopes, ok := s[key]
+	if !ok {
+		return 0, nil, errors.New("API密钥无效")
+	}
+	return 7, scopes, nil
+}
+
+func newAuthEngine(cfg *config.Config, opts AuthOptions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(JWTAuth(cfg, opts))
+	handler := func(c *gin.Context) {
+		id, _ := ctxkeys.UserID(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": id})
+	}
+	r.GET("/res", handler)
+	r.POST("/res", handler)
+	return r
+}
+
+func serveAuth(r *gin.Engine, method string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/res", nil)
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyScopesByMethod(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	r := newAuthEngine(cfg, AuthOptions{APIKeyAuthenticator: stubAPIKeys{
+		"gak_read":  {"read"},
+		"gak_write": {"read", "write"},
+	}})
+
+	cases := []struct {
+		name   string
+		method string
+		key    string
+		want   int
+	}{
+		{"只读密钥读取", http.MethodGet, "gak_read", http.StatusOK},
+		{"只读密钥写入", http.MethodPost, "gak_read", http.StatusForbidden},
+		{"读写密钥写入", http.MethodPost, "gak_write", http.StatusOK},
+		{"无效密钥", http.MethodGet, "gak_unknown", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		w := serveAuth(r, tc.method, http.Header{APIKeyHeader: {tc.key}})
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
+
+func TestAPIKeyIgnoredWithoutAuthenticator(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	r := newAuthEngine(cfg, AuthOptions{})
+
+	w := serveAuth(r, http.MethodGet, http.Header{APIKeyHeader: {"gak_read"}})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestJWTAuthRequiresTokenUnlessDisabled(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+
+	w := serveAuth(newAuthEngine(cfg, AuthOptions{}), http.MethodGet, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌: status = %d, want 401", w.Code)
+	}
+
+	cfg.JWT.Disabled = true
+	w = serveAuth(newAuthEngine(cfg, AuthOptions{}), http.MethodGet, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("认证关闭: status = %d, want 200", w.Code)
+	}
+}
+
+func TestParseTokenClockSkew(t *testing.T) {
+	now := time.Now()
+	skew := TokenOptions{ClockSkew: 30 * time.Second}
+	cases := []struct {
+		name  string
+		token string
+		opts  TokenOptions
+		valid bool
+	}{
+		{"刚过期，在容忍范围内", signTestToken(t, now.Add(-time.Hour), now.Add(-time.Hour), now.Add(-10*time.Second)), skew, true},
+		{"过期超出容忍范围", signTestToken(t, now.Add(-time.Hour), now.Add(-time.Hour), now.Add(-time.Minute)), skew, false},
+		{"即将生效，在容忍范围内", signTestToken(t, now, now.Add(10*time.Second), now.Add(time.Hour)), skew, true},
+		{"生效时间超出容忍范围", signTestToken(t, now, now.Add(time.Minute), now.Add(time.Hour)), skew, false},
+		{"严格校验刚过期", signTestToken(t, now.Add(-time.Hour), now.Add(-time.Hour), now.Add(-10*time.Second)), TokenOptions{}, false},
+		{"严格校验即将生效", signTestToken(t, now, now.Add(10*time.Second), now.Add(time.Hour)), TokenOptions{}, false},
+		{"最大年龄在容忍范围内", signTestToken(t, now.Add(-time.Hour-10*time.Second), now.Add(-2*time.Hour), now.Add(time.Hour)),
+			TokenOptions{MaxTokenAge: time.Hour, ClockSkew: 30 * time.Second}, true},
+		{"超过最大年龄", signTestToken(t, now.Add(-time.Hour-time.Minute), now.Add(-2*time.Hour), now.Add(time.Hour)),
+			TokenOptions{MaxTokenAge: time.Hour, ClockSkew: 30 * time.Second}, false},
+	}
+	for _, tc := range cases {
+		_, err := ParseTokenWithOptions(tc.token, "test-secret", tc.opts)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: err = %v, want valid = %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestNewTokenOptionsClampsClockSkew(t *testing.T) {
+	cases := []struct {
+		skew, want time.Duration
+	}{
+		{-time.Second, 0},
+		{30 * time.Second, 30 * time.Second},
+		{time.Hour, maxClockSkew},
+	}
+	for _, tc := range cases {
+		cfg := &config.Config{}
+		cfg.JWT.ClockSkew = tc.skew
+		if got := NewTokenOptions(cfg).ClockSkew; got != tc.want {
+			t.Errorf("ClockSkew(%s) = %s, want %s", tc.skew, got, tc.want)
+		}
+	}
+}
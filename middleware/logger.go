@@ -2,21 +2,100 @@ package middleware
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"go-app/config"
 	"go-app/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// Logger 日志中间件
+// truncatedMarker 截断标记，追加在被截断的值末尾
+const truncatedMarker = "...(truncated)"
+
+// LoggerConfig 日志中间件配置
+type LoggerConfig struct {
+	// 请求日志中最多记录的路径参数个数
+	MaxParams int
+	// 请求日志中单个请求头值的最大长度（字节），超出部分截断
+	MaxHeaderLength int
+	// 请求日志是否记录请求头和路径参数，关闭时不提取，日志中不出现对应字段
+	LogHeaders bool
+	LogParams  bool
+	// 不记录日志的路径，以"*"结尾的条目按前缀匹配（如 /static/*），其余精确匹配
+	SkipPaths []string
+	// 跳过路径的请求返回错误（状态码>=400）时仍然记录
+	LogSkippedErrors bool
+	// 不记录日志的响应状态码，对所有路径生效，优先于 LogSkippedErrors
+	SkipStatuses []int
+}
+
+// DefaultLoggerConfig 默认日志中间件配置
+var DefaultLoggerConfig = LoggerConfig{
+	MaxParams:        20,
+	MaxHeaderLength:  256,
+	LogHeaders:       true,
+	LogParams:        true,
+	SkipPaths:        []string{"/ping", "/healthz", MetricsPath},
+	LogSkippedErrors: true,
+}
+
+// NewLoggerConfig 从应用配置创建日志中间件配置
+func NewLoggerConfig(cfg *config.Config) LoggerConfig {
+	conf := DefaultLoggerConfig
+	if cfg.Logger.MaxParams > 0 {
+		conf.MaxParams = cfg.Logger.MaxParams
+	}
+	if cfg.Logger.MaxHeaderLength > 0 {
+		conf.MaxHeaderLength = cfg.Logger.MaxHeaderLength
+	}
+	if cfg.Logger.SkipPaths != nil {
+		conf.SkipPaths = cfg.Logger.SkipPaths
+	}
+	conf.LogHeaders = cfg.Logger.LogHeaders
+	conf.LogParams = cfg.Logger.LogParams
+	conf.LogSkippedErrors = cfg.Logger.LogSkippedErrors
+	conf.SkipStatuses = cfg.Logger.SkipStatuses
+	return conf
+}
+
+// Logger 日志中间件，使用默认配置
 func Logger() gin.HandlerFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig 使用自定义配置的日志中间件
+func LoggerWithConfig(conf LoggerConfig) gin.HandlerFunc {
+	skip := newPathMatcher(conf.SkipPaths)
+	skipStatuses := make(map[int]struct{}, len(conf.SkipStatuses))
+	for _, s := range conf.SkipStatuses {
+		skipStatuses[s] = struct{}{}
+	}
+
 	return func(c *gin.Context) {
+		// 跳过的路径既不记录应用日志，也不写请求日志；开启 LogSkippedErrors 时要等响应后再根据状态码决定
+		path := c.Request.URL.Path
+		skipped := skip(path)
+		if skipped && !conf.LogSkippedErrors {
+			c.Next()
+			return
+		}
+
+		// 请求体长度未知（如分块传输）时统计处理器实际读取的字节数
+		var body *countingReadCloser
+		if c.Request.ContentLength < 0 && c.Request.Body != nil {
+			body = &countingReadCloser{ReadCloser: c.Request.Body}
+			c.Request.Body = body
+		}
+
 		// 开始时间
 		start := time.Now()
-		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		// 查询参数中的密码、令牌等敏感值脱敏后再记录
+		query := utils.MaskQuery(c.Request.URL.RawQuery, utils.SensitiveKeys)
 
 		// 处理请求
 		c.Next()
@@ -27,9 +106,24 @@ func Logger() gin.HandlerFunc {
 
 		// 获取状态
 		status := c.Writer.Status()
+		if skipped && status < 400 {
+			return
+		}
+		if _, ok := skipStatuses[status]; ok {
+			return
+		}
 		clientIP := c.ClientIP()
 		method := c.Request.Method
 		userAgent := c.Request.UserAgent()
+		requestBytes := c.Request.ContentLength
+		if body != nil {
+			requestBytes = body.n
+		}
+		// gin 的 ResponseWriter 已累计写出的响应体字节数（包括流式输出），未写出时为-1
+		responseBytes := int64(c.Writer.Size())
+		if responseBytes < 0 {
+			responseBytes = 0
+		}
 
 		// 构建日志字段
 		fields := []zap.Field{
@@ -40,6 +134,8 @@ func Logger() gin.HandlerFunc {
 			zap.String("ip", clientIP),
 			zap.String("user-agent", userAgent),
 			zap.Duration("latency", latency),
+			zap.Int64("request_bytes", requestBytes),
+			zap.Int64("response_bytes", responseBytes),
 		}
 
 		// 收集错误信息
@@ -74,28 +170,85 @@ func Logger() gin.HandlerFunc {
 			IP:        clientIP,
 			UserAgent: userAgent,
 			LatencyMs: float64(latency.Microseconds()) / 1000.0, // 转换为毫秒
+			RequestID: GetRequestID(c),
 			Error:     errorMsg,
-			// 收集更多信息
-			Params:  extractParams(c),
-			Headers: extractHeaders(c),
+			// 传输字节数
+			RequestBytes:  requestBytes,
+			ResponseBytes: responseBytes,
+			// 请求ID被替换时客户端传入的原始值
+			ClientRequestID: GetClientRequestID(c),
+		}
+		// 收集更多信息，关闭时跳过提取
+		if conf.LogParams {
+			reqLog.Params = extractParams(c, conf.MaxParams)
+		}
+		if conf.LogHeaders {
+			reqLog.Headers = extractHeaders(c, conf.MaxHeaderLength)
+		}
+
+		// 请求日志进入缓冲区后由后台批量写入，不阻塞请求
+		utils.LogRequest(reqLog)
+	}
+}
+
+// countingReadCloser 统计已读取字节数的请求体
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read 读取并累计字节数
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// newPathMatcher 根据路径列表创建匹配函数，以"*"结尾的条目按前缀匹配，其余精确匹配
+func newPathMatcher(paths []string) func(path string) bool {
+	exact := make(map[string]struct{}, len(paths))
+	var prefixes []string
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.HasSuffix(p, "*") {
+			prefixes = append(prefixes, strings.TrimSuffix(p, "*"))
+			continue
 		}
+		exact[p] = struct{}{}
+	}
 
-		// 异步记录请求日志，不阻塞请求
-		go utils.LogRequest(reqLog)
+	return func(path string) bool {
+		if _, ok := exact[path]; ok {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
 	}
 }
 
-// 从Gin上下文中提取路径参数
-func extractParams(c *gin.Context) map[string]string {
+// 从Gin上下文中提取路径参数，最多保留 maxParams 个
+func extractParams(c *gin.Context, maxParams int) map[string]string {
 	params := make(map[string]string)
-	for _, param := range c.Params {
+	for i, param := range c.Params {
+		if maxParams > 0 && i >= maxParams {
+			// 记录被丢弃的参数个数，便于排查
+			params["_truncated"] = fmt.Sprintf("%d more", len(c.Params)-maxParams)
+			break
+		}
 		params[param.Key] = param.Value
 	}
-	return params
+	return utils.MaskMap(params, utils.SensitiveKeys)
 }
 
-// 从Gin上下文中提取请求头信息
-func extractHeaders(c *gin.Context) map[string]string {
+// 从Gin上下文中提取请求头信息，单个值超过 maxLength 时截断
+func extractHeaders(c *gin.Context, maxLength int) map[string]string {
 	headers := make(map[string]string)
 	// 只收集重要的请求头，避免日志过大
 	importantHeaders := []string{
@@ -105,8 +258,21 @@ func extractHeaders(c *gin.Context) map[string]string {
 
 	for _, name := range importantHeaders {
 		if value := c.GetHeader(name); value != "" {
-			headers[name] = value
+			headers[name] = truncate(value, maxLength)
 		}
 	}
 	return headers
 }
+
+// truncate 截断超过 maxLength 字节的字符串并追加截断标记，maxLength<=0 表示不限制
+// 在字符边界处截断，不会把多字节的UTF-8字符截成一半
+func truncate(s string, maxLength int) string {
+	if maxLength <= 0 || len(s) <= maxLength {
+		return s
+	}
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + truncatedMarker
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+waitRequestLogs 等待请求日志文件中出现 User-Agent 为 ua、路径为 path 的条目
+请求日志由单个goroutine按顺序写入，等到的条目之前发出的请求都已写入
+返回: 所有 User-Agent 为 ua 的条目
+*/
+func waitRequestLogs(t *testing.T, ua, path string) []utils.RequestLog {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		logs := readRequestLogs(t, ua)
+		for _, l := range logs {
+			if l.Path == path {
+				return logs
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("等待 %s 的请求日志超时", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// readRequestLogs 读取请求日志文件中 User-Agent 为 ua 的条目
+func readRequestLogs(t *testing.T, ua string) []utils.RequestLog {
+	t.Helper()
+	f, err := os.Open(filepath.Join(testLogDir, "requests", "requests.log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("打开请求日志失败: %v", err)
+	}
+	defer f.Close()
+
+	var logs []utils.RequestLog
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var l utils.RequestLog
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			continue
+		}
+		if l.UserAgent == ua {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+// newLoggerRouter 创建只挂载日志中间件的路由，所有路径都返回 status
+func newLoggerRouter(conf LoggerConfig, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LoggerWithConfig(conf))
+	r.NoRoute(func(c *gin.Context) { c.Status(status) })
+	return r
+}
+
+func TestExtractHeadersTruncatesLongValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("User-Agent", strings.Repeat("a", 1000))
+	c.Request.Header.Set("Accept", "application/json")
+
+	headers := extractHeaders(c, 16)
+	if want := strings.Repeat("a", 16) + truncatedMarker; headers["User-Agent"] != want {
+		t.Fatalf("User-Agent = %q, want %q", headers["User-Agent"], want)
+	}
+	if headers["Accept"] != "application/json" {
+		t.Fatalf("未超长的值不应截断: %q", headers["Accept"])
+	}
+
+	// maxLength<=0 时不限制
+	if got := extractHeaders(c, 0)["User-Agent"]; len(got) != 1000 {
+		t.Fatalf("不限制长度时 len = %d, want 1000", len(got))
+	}
+}
+
+func TestTruncateKeepsRuneBoundary(t *testing.T) {
+	cases := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"中文请求头", 15, "中文请求头"},
+		{"中文请求头", 7, "中文" + truncatedMarker},
+		{"中文请求头", 6, "中文" + truncatedMarker},
+		{"中文请求头", 2, truncatedMarker},
+		{"ab中文", 4, "ab" + truncatedMarker},
+	}
+	for _, tc := range cases {
+		got := truncate(tc.in, tc.max)
+		if got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) 输出了无效的UTF-8: %q", tc.in, tc.max, got)
+		}
+	}
+}
+
+func TestExtractParamsCapsCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	for i := 0; i < 5; i++ {
+		c.Params = append(c.Params, gin.Param{Key: "p" + strconv.Itoa(i), Value: "v"})
+	}
+
+	params := extractParams(c, 3)
+	if len(params) != 4 {
+		t.Fatalf("params = %v, want 3 个参数和截断标记", params)
+	}
+	if params["_truncated"] != "2 more" {
+		t.Fatalf("_truncated = %q, want %q", params["_truncated"], "2 more")
+	}
+	if _, ok := params["p3"]; ok {
+		t.Fatal("超出上限的参数不应记录")
+	}
+
+	if params := extractParams(c, 0); len(params) != 5 {
+		t.Fatalf("不限制个数时 params = %v", params)
+	}
+}
+
+func TestLoggerTruncatesOversizedHeaderInRequestLog(t *testing.T) {
+	conf := DefaultLoggerConfig
+	conf.MaxHeaderLength = 32
+	r := newLoggerRouter(conf, http.StatusOK)
+
+	ua := "truncate-test-" + strings.Repeat("x", 4096)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/truncate", nil)
+	req.Header.Set("User-Agent", ua)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	logs := waitRequestLogs(t, ua, "/api/v1/truncate")
+	got := logs[0].Headers["User-Agent"]
+	if want := ua[:32] + truncatedMarker; got != want {
+		t.Fatalf("请求日志中的 User-Agent = %q, want %q", got, want)
+	}
+}
+
+func TestLoggerOmitsHeadersAndParamsWhenDisabled(t *testing.T) {
+	cases := []struct {
+		name                  string
+		logHeaders, logParams bool
+	}{
+		{"disabled", false, false},
+		{"enabled", true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := DefaultLoggerConfig
+			conf.LogHeaders = tc.logHeaders
+			conf.LogParams = tc.logParams
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(LoggerWithConfig(conf))
+			r.GET("/api/v1/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			ua := "log-fields-test-" + tc.name + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/items/42", nil)
+			req.Header.Set("User-Agent", ua)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			l := waitRequestLogs(t, ua, "/api/v1/items/42")[0]
+			if (l.Headers != nil) != tc.logHeaders {
+				t.Fatalf("LogHeaders = %v, headers = %v", tc.logHeaders, l.Headers)
+			}
+			if (l.Params != nil) != tc.logParams {
+				t.Fatalf("LogParams = %v, params = %v", tc.logParams, l.Params)
+			}
+			if tc.logParams && l.Params["id"] != "42" {
+				t.Fatalf("params = %v", l.Params)
+			}
+		})
+	}
+}
+
+func TestLoggerSkipsExcludedPathsAndStatuses(t *testing.T) {
+	conf := DefaultLoggerConfig
+	conf.SkipStatuses = []int{http.StatusTeapot}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LoggerWithConfig(conf))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	r.GET("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/v1/teapot", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	r.GET("/api/v1/done", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	ua := "skip-paths-test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	for _, path := range []string{"/ping", "/api/v1/users", "/healthz", "/api/v1/teapot", "/api/v1/done"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", ua)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// 请求日志按顺序写入，等到最后一个请求出现时前面的请求都已写入
+	var got []string
+	for _, l := range waitRequestLogs(t, ua, "/api/v1/done") {
+		got = append(got, l.Path)
+	}
+	// /ping 被跳过；跳过路径 /healthz 返回错误时仍然记录；418 对所有路径跳过
+	want := []string{"/api/v1/users", "/healthz", "/api/v1/done"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("记录的路径 = %v, want %v", got, want)
+	}
+}
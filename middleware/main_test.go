@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"os"
+	"testing"
+
+	"go-app/utils"
+)
+
+// testLogDir 测试期间的日志目录，请求日志写入其下的 requests/requests.log
+var testLogDir string
+
+// TestMain 将测试期间的日志写入临时目录，避免在源码目录下生成日志文件
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "middleware-test-logs")
+	if err != nil {
+		panic(err)
+	}
+	testLogDir = dir
+	conf := utils.LogConfig{
+		LogDir:      dir,
+		LogFileName: "test.log",
+		MaxSize:     1,
+	}
+	utils.InitLoggerWithConfig(conf)
+	utils.InitRequestLogger(conf)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath Prometheus 指标抓取路径
+const MetricsPath = "/metrics"
+
+// unmatchedRoute 未匹配到路由时使用的路径标签，避免任意路径产生大量标签值
+const unmatchedRoute = "unmatched"
+
+var (
+	// httpRequestsTotal 请求总数
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP请求总数",
+	}, []string{"method", "path", "status"})
+
+	// httpRequestDuration 请求处理耗时分布
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP请求处理耗时（秒）",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+
+	// httpRequestsInFlight 正在处理的请求数，处理中的请求还没有状态码
+	httpRequestsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "正在处理的HTTP请求数",
+	}, []string{"method", "path"})
+)
+
+/*
+Metrics 请求指标中间件
+按请求方法、路由模板和状态码记录请求数、处理耗时和正在处理的请求数。
+路径标签使用路由模板（如 /api/v1/users/:id）而不是实际路径，避免ID等参数导致标签数量无限增长
+*/
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 抓取请求本身不计入指标
+		if c.Request.URL.Path == MetricsPath {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = unmatchedRoute
+		}
+		method := c.Request.Method
+
+		inFlight := httpRequestsInFlight.WithLabelValues(method, path)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequestsTotal.WithLabelValues(method, path, status).Inc()
+		httpRequestDuration.WithLabelValues(method, path, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsHandler Prometheus 指标抓取处理器，输出默认注册表中的所有指标（含Go运行时和进程指标）
+func MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
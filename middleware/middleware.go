@@ -18,15 +18,11 @@ func SetupMiddlewares(r *gin.Engine, cfg *config.Config) {
 	r.Use(Cors(cfg))
 
 	// 签名验证中间件
-	r.Use(Signature(&SignatureConfig{
-		AppKey:    cfg.Signature.AppKey,
-		AppSecret: cfg.Signature.AppSecret,
-		Expire:    cfg.Signature.Expire,
-	}))
+	r.Use(Signature(NewSignatureConfig(cfg)))
 }
 
 // SetupAuthMiddleware 设置认证中间件
-func SetupAuthMiddleware(r *gin.RouterGroup, cfg *config.Config) {
+func SetupAuthMiddleware(r *gin.RouterGroup, cfg *config.Config, opts AuthOptions) {
 	// JWT认证
-	r.Use(JWTAuth(cfg))
+	r.Use(JWTAuth(cfg, opts))
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceStore 签名nonce存储，用于拒绝重放请求
+// 多实例部署时应使用共享存储（如MongoDB实现），否则请求可以在另一个实例上重放
+type NonceStore interface {
+	// Remember 记录nonce并在ttl内保留，返回nonce此前是否未出现过（true表示首次出现）
+	Remember(nonce string, ttl time.Duration) (bool, error)
+}
+
+// SIGNATURE_NONCE_STORE 的可选值
+const (
+	NonceStoreMemory  = "memory"  // 内存存储（默认），仅适用于单实例
+	NonceStoreMongoDB = "mongodb" // MongoDB存储，多实例共享
+)
+
+// memoryNonceCleanupInterval 内存nonce存储清理过期条目的最小间隔
+const memoryNonceCleanupInterval = time.Minute
+
+// MemoryNonceStore 基于内存的nonce存储，仅在单实例部署时有效，重启后清空
+type MemoryNonceStore struct {
+	mu          sync.Mutex
+	nonces      map[string]time.Time // nonce -> 过期时间
+	lastCleanup time.Time
+}
+
+// NewMemoryNonceStore 创建内存nonce存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces:      make(map[string]time.Time),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Remember 记录nonce，nonce在有效期内已存在时返回false
+func (s *MemoryNonceStore) Remember(nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastCleanup) >= memoryNonceCleanupInterval {
+		for n, expiresAt := range s.nonces {
+			if !expiresAt.After(now) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	if expiresAt, ok := s.nonces[nonce]; ok && expiresAt.After(now) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
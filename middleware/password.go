@@ -1,17 +1,105 @@
 package middleware
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// 新密码的长度限制，bcrypt最多只处理72字节，超出部分会被忽略
+const (
+	PasswordMinLength = 8
+	PasswordMaxLength = 72
+)
+
+// StoredPasswordKind 数据库中已存储密码的类型
+type StoredPasswordKind int
+
+// 已存储密码的类型
+const (
+	PasswordHashCurrent StoredPasswordKind = iota // 强度不低于当前默认值的bcrypt哈希
+	PasswordHashLowCost                           // 强度低于当前默认值的bcrypt哈希，可在用户下次登录时升级
+	PasswordHashUnknown                           // 无法识别的哈希（如MD5、SHA1），没有原密码无法迁移
+	PasswordPlaintext                             // 明文密码
+)
+
 // HashPassword 密码加密
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
 }
 
+/*
+CheckPasswordStrength 检查新密码的强度：长度8到72字节，至少包含一个字母和一个数字
+password: 新密码
+返回: 不满足要求时返回说明原因的错误
+*/
+func CheckPasswordStrength(password string) error {
+	if len(password) < PasswordMinLength {
+		return fmt.Errorf("密码长度不能少于%d个字符", PasswordMinLength)
+	}
+	if len(password) > PasswordMaxLength {
+		return fmt.Errorf("密码长度不能超过%d字节", PasswordMaxLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("密码必须同时包含字母和数字")
+	}
+	return nil
+}
+
 // CheckPasswordHash 验证密码
 func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+/*
+ClassifyStoredPassword 判断已存储密码的类型
+以 $ 或 { 开头（crypt/LDAP格式）、或长度与常见摘要一致的十六进制串视为无法识别的哈希，其余视为明文
+stored: 数据库中保存的密码
+返回: 密码类型
+*/
+func ClassifyStoredPassword(stored string) StoredPasswordKind {
+	if cost, err := bcrypt.Cost([]byte(stored)); err == nil {
+		if cost < bcrypt.DefaultCost {
+			return PasswordHashLowCost
+		}
+		return PasswordHashCurrent
+	}
+
+	if stored == "" || strings.HasPrefix(stored, "$") || strings.HasPrefix(stored, "{") {
+		return PasswordHashUnknown
+	}
+	switch len(stored) {
+	case 32, 40, 64, 128: // MD5、SHA1、SHA256、SHA512的十六进制摘要
+		if _, err := hex.DecodeString(stored); err == nil {
+			return PasswordHashUnknown
+		}
+	}
+	// bcrypt最多只处理72字节，超出的无法安全地迁移
+	if len(stored) > 72 {
+		return PasswordHashUnknown
+	}
+
+	return PasswordPlaintext
+}
+
+// PasswordNeedsRehash 判断已存储的密码是否需要在用户下次登录成功时重新哈希
+func PasswordNeedsRehash(stored string) bool {
+	kind := ClassifyStoredPassword(stored)
+	return kind == PasswordHashLowCost || kind == PasswordPlaintext
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 查询字符串限制的默认值，配置为0时使用
+const (
+	defaultMaxQueryParams = 100  // 查询参数的最大个数
+	defaultMaxQueryLength = 8192 // 查询字符串的最大字节数（编码后）
+)
+
+// QueryLimitConfig 查询字符串限制配置
+type QueryLimitConfig struct {
+	MaxParams int // 查询参数的最大个数，同名参数重复出现时分别计数，负数表示不限制
+	MaxLength int // 查询字符串的最大字节数（编码后，不含"?"），负数表示不限制
+}
+
+// NewQueryLimitConfig 从应用配置创建查询字符串限制配置，未配置时使用默认值
+func NewQueryLimitConfig(cfg *config.Config) QueryLimitConfig {
+	conf := QueryLimitConfig{
+		MaxParams: cfg.Server.MaxQueryParams,
+		MaxLength: cfg.Server.MaxQueryLength,
+	}
+	if conf.MaxParams == 0 {
+		conf.MaxParams = defaultMaxQueryParams
+	}
+	if conf.MaxLength == 0 {
+		conf.MaxLength = defaultMaxQueryLength
+	}
+	return conf
+}
+
+/*
+QueryLimit 拒绝查询字符串过长或参数过多的请求，返回400
+应放在日志中间件之前，被拒绝的请求只记录参数个数和长度，不记录查询字符串本身；
+参数个数在解析之前按"&"计数，超出上限即停止，不会为超大的查询字符串分配解析结果
+*/
+func QueryLimit(conf QueryLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawQuery := c.Request.URL.RawQuery
+		if rawQuery == "" {
+			c.Next()
+			return
+		}
+
+		if conf.MaxLength > 0 && len(rawQuery) > conf.MaxLength {
+			rejectQuery(c, "查询字符串过长", zap.Int("length", len(rawQuery)), zap.Int("max_length", conf.MaxLength))
+			return
+		}
+		if conf.MaxParams > 0 && exceedsQueryParams(rawQuery, conf.MaxParams) {
+			rejectQuery(c, "查询参数过多", zap.Int("length", len(rawQuery)), zap.Int("max_params", conf.MaxParams))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// exceedsQueryParams 判断查询字符串中的参数个数是否超过上限，忽略空段（如 a=1&&b=2）
+func exceedsQueryParams(rawQuery string, maxParams int) bool {
+	count := 0
+	for rawQuery != "" {
+		var segment string
+		segment, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if segment == "" {
+			continue
+		}
+		if count++; count > maxParams {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectQuery 记录并拒绝查询字符串不合规的请求
+func rejectQuery(c *gin.Context, message string, fields ...zap.Field) {
+	fields = append(fields,
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("ip", c.ClientIP()),
+	)
+	utils.Warn("拒绝请求："+message, fields...)
+
+	c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveQuery(conf QueryLimitConfig, rawQuery string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(QueryLimit(conf))
+	r.GET("/res", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/res?"+rawQuery, nil))
+	return w
+}
+
+func TestQueryLimitRejectsTooManyParams(t *testing.T) {
+	conf := QueryLimitConfig{MaxParams: 3, MaxLength: -1}
+	cases := []struct {
+		query string
+		want  int
+	}{
+		{"a=1&b=2&c=3", http.StatusOK},
+		{"a=1&a=2&a=3&a=4", http.StatusBadRequest}, // 同名参数分别计数
+		{"a=1&&b=2&&c=3&", http.StatusOK},          // 空段不计数
+		{"a&b&c&d", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if w := serveQuery(conf, tc.query); w.Code != tc.want {
+			t.Errorf("query %q: status = %d, want %d", tc.query, w.Code, tc.want)
+		}
+	}
+}
+
+func TestQueryLimitRejectsLongQuery(t *testing.T) {
+	conf := QueryLimitConfig{MaxParams: -1, MaxLength: 16}
+	if w := serveQuery(conf, "q="+strings.Repeat("x", 14)); w.Code != http.StatusOK {
+		t.Fatalf("长度等于上限: status = %d, want 200", w.Code)
+	}
+	w := serveQuery(conf, "q="+strings.Repeat("x", 15))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("长度超过上限: status = %d, want 400", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "查询字符串过长") {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
+
+func TestQueryLimitNegativeDisablesCheck(t *testing.T) {
+	conf := QueryLimitConfig{MaxParams: -1, MaxLength: -1}
+	if w := serveQuery(conf, strings.Repeat("a=1&", 1000)); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestNewQueryLimitConfigDefaults(t *testing.T) {
+	conf := NewQueryLimitConfig(&config.Config{})
+	if conf.MaxParams != defaultMaxQueryParams || conf.MaxLength != defaultMaxQueryLength {
+		t.Fatalf("conf = %+v", conf)
+	}
+
+	var cfg config.Config
+	cfg.Server.MaxQueryParams = -1
+	cfg.Server.MaxQueryLength = 10
+	conf = NewQueryLimitConfig(&cfg)
+	if conf.MaxParams != -1 || conf.MaxLength != 10 {
+		t.Fatalf("conf = %+v", conf)
+	}
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 限流默认值，配置为0或负数时使用
+const (
+	defaultRateLimitRate  = 10 // 每秒补充的令牌数
+	defaultRateLimitBurst = 20 // 令牌桶容量，即允许的突发请求数
+)
+
+// RateLimitResult 一次取令牌的结果
+type RateLimitResult struct {
+	Allowed    bool            // 是否允许本次请求
+	Status     RateLimitStatus // 本次请求后的配额状态
+	RetryAfter time.Duration   // 被拒绝时距离下一个可用令牌的时间
+}
+
+// RateLimitStore 限流令牌桶存储
+// 多实例部署时应使用共享存储（如Redis实现），否则每个实例各自计数，实际配额为实例数倍
+type RateLimitStore interface {
+	// Take 从key对应的令牌桶中取出一个令牌，桶按 rate（每秒）补充令牌，容量为 burst
+	Take(key string, rate float64, burst int) (RateLimitResult, error)
+}
+
+// memoryRateLimitCleanupInterval 内存限流存储清理空闲令牌桶的最小间隔
+const memoryRateLimitCleanupInterval = time.Minute
+
+// tokenBucket 令牌桶状态
+type tokenBucket struct {
+	tokens float64   // 上次更新时的令牌数
+	last   time.Time // 上次更新时间
+	full   time.Time // 令牌桶补满的时间，之后的状态与新建的桶相同
+}
+
+// MemoryRateLimitStore 基于内存的令牌桶存储，仅在单实例部署时准确，重启后清空
+type MemoryRateLimitStore struct {
+	mu          sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// NewMemoryRateLimitStore 创建内存限流存储
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:     make(map[string]*tokenBucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Take 从令牌桶中取出一个令牌，令牌不足时返回拒绝结果和需要等待的时间
+func (s *MemoryRateLimitStore) Take(key string, rate float64, burst int) (RateLimitResult, error) {
+	now := time.Now()
+	capacity := float64(burst)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 已补满的令牌桶与新建的桶等价，可以直接删除
+	if now.Sub(s.lastCleanup) >= memoryRateLimitCleanupInterval {
+		for k, b := range s.buckets {
+			if !b.full.After(now) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Allowed: b.tokens >= 1}
+	if result.Allowed {
+		b.tokens--
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	b.full = now.Add(secondsToDuration((capacity - b.tokens) / rate))
+
+	result.Status = RateLimitStatus{
+		Limit:     burst,
+		Remaining: int(b.tokens),
+		Reset:     b.full,
+	}
+	return result, nil
+}
+
+// secondsToDuration 将浮点秒数转换为时间间隔
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enable bool    // 是否启用限流
+	Rate   float64 // 每个IP每秒补充的令牌数
+	Burst  int     // 每个IP的令牌桶容量，即允许的突发请求数
+	// 令牌桶存储；为nil时使用内存存储
+	Store RateLimitStore
+	// 不进行限流的路径
+	ExemptPaths []string
+}
+
+// NewRateLimitConfig 从应用配置创建限流配置，速率和容量未配置时使用默认值
+func NewRateLimitConfig(cfg *config.Config) RateLimitConfig {
+	conf := RateLimitConfig{
+		Enable:      cfg.RateLimit.Enable,
+		Rate:        cfg.RateLimit.Rate,
+		Burst:       cfg.RateLimit.Burst,
+		ExemptPaths: []string{"/ping", "/healthz", MetricsPath},
+	}
+	if conf.Rate <= 0 {
+		conf.Rate = defaultRateLimitRate
+	}
+	if conf.Burst <= 0 {
+		conf.Burst = defaultRateLimitBurst
+	}
+	return conf
+}
+
+/*
+RateLimit 按客户端IP限流的中间件
+每个IP一个令牌桶，每个请求消耗一个令牌，令牌按 Rate 匀速补充，最多累积 Burst 个；
+令牌不足时返回429和 Retry-After 响应头。所有经过限流的响应都带有 X-RateLimit-* 响应头。
+开启 WHITELIST_RATE_LIMIT_EXEMPT 时白名单IP不受限流；存储出错时放行请求并记录日志
+*/
+func RateLimit(conf RateLimitConfig) gin.HandlerFunc {
+	if !conf.Enable {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	store := conf.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	exempt := make(map[string]struct{}, len(conf.ExemptPaths))
+	for _, p := range conf.ExemptPaths {
+		exempt[p] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := exempt[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		if DefaultWhitelistConfig.IsRateLimitExempt(ip) {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take("ip:"+ip, conf.Rate, conf.Burst)
+		if err != nil {
+			utils.Warn("限流存储不可用，放行请求", zap.String("ip", ip), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		SetRateLimitHeaders(c, result.Status)
+		if !result.Allowed {
+			SetRetryAfterHeader(c, result.RetryAfter)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: "请求过于频繁，请稍后再试",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
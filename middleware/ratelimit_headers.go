@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限流相关响应头
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"     // 当前路由适用的配额
+	RateLimitRemainingHeader = "X-RateLimit-Remaining" // 剩余可用次数
+	RateLimitResetHeader     = "X-RateLimit-Reset"     // 配额完全恢复的时间（Unix秒）
+	RetryAfterHeader         = "Retry-After"           // 被限流时距离可再次请求的秒数
+)
+
+// RateLimitStatus 某个限流键在本次请求后的配额状态，由限流器根据令牌桶状态计算
+type RateLimitStatus struct {
+	Limit     int       // 配额（桶容量）
+	Remaining int       // 剩余可用次数
+	Reset     time.Time // 配额完全恢复的时间
+}
+
+/*
+SetRateLimitHeaders 在响应中写入限流配额信息
+限流中间件应在每个响应上调用（不仅是429），以便客户端据此控制请求节奏；
+各路由的配额不同时，传入该路由实际适用的状态
+*/
+func SetRateLimitHeaders(c *gin.Context, status RateLimitStatus) {
+	remaining := status.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	c.Header(RateLimitLimitHeader, strconv.Itoa(status.Limit))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(remaining))
+	c.Header(RateLimitResetHeader, strconv.FormatInt(status.Reset.Unix(), 10))
+}
+
+// SetRetryAfterHeader 写入 Retry-After 响应头，不足1秒按1秒计
+func SetRetryAfterHeader(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header(RetryAfterHeader, strconv.Itoa(seconds))
+}
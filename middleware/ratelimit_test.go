@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitEngine(conf RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit(conf))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/res", ok)
+	r.GET("/ping", ok)
+	return r
+}
+
+func serveFromIP(r *gin.Engine, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRejectsAfterBurst(t *testing.T) {
+	r := newRateLimitEngine(RateLimitConfig{Enable: true, Rate: 0.5, Burst: 3, ExemptPaths: []string{"/ping"}})
+
+	for i := 0; i < 3; i++ {
+		if w := serveFromIP(r, "/res", "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("第%d个请求: status = %d, want 200", i+1, w.Code)
+		}
+	}
+	w := serveFromIP(r, "/res", "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("超出突发数: status = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429响应缺少 Retry-After")
+	}
+
+	// 其他IP和豁免路径不受影响
+	if w := serveFromIP(r, "/res", "192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("其他IP: status = %d, want 200", w.Code)
+	}
+	if w := serveFromIP(r, "/ping", "192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("豁免路径: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	r := newRateLimitEngine(RateLimitConfig{Enable: false, Rate: 1, Burst: 1})
+	for i := 0; i < 5; i++ {
+		if w := serveFromIP(r, "/res", "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("未启用限流: status = %d, want 200", w.Code)
+		}
+	}
+}
+
+// failingRateLimitStore 总是返回错误的限流存储
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(key string, rate float64, burst int) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("存储不可用")
+}
+
+func TestRateLimitAllowsWhenStoreFails(t *testing.T) {
+	r := newRateLimitEngine(RateLimitConfig{Enable: true, Rate: 1, Burst: 1, Store: failingRateLimitStore{}})
+	for i := 0; i < 3; i++ {
+		if w := serveFromIP(r, "/res", "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("存储出错: status = %d, want 200", w.Code)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreRefills(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+
+	if res, _ := s.Take("k", 20, 1); !res.Allowed {
+		t.Fatal("第一个令牌应可用")
+	}
+	res, _ := s.Take("k", 20, 1)
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 50*time.Millisecond {
+		t.Fatalf("令牌用完: allowed = %v, retryAfter = %v", res.Allowed, res.RetryAfter)
+	}
+
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	if res, _ := s.Take("k", 20, 1); !res.Allowed {
+		t.Fatal("等待 RetryAfter 后应补充令牌")
+	}
+}
+
+func TestRateLimitExemptsWhitelistedIP(t *testing.T) {
+	saved := DefaultWhitelistConfig
+	t.Cleanup(func() { DefaultWhitelistConfig = saved })
+	DefaultWhitelistConfig = WhitelistConfig{
+		IPWhitelist:       []string{"10.0.0.0/8"},
+		EnableIPWhitelist: true,
+		ExemptRateLimit:   true,
+	}
+	DefaultWhitelistConfig.buildSets()
+
+	r := newRateLimitEngine(RateLimitConfig{Enable: true, Rate: 0.5, Burst: 2})
+	for i := 0; i < 10; i++ {
+		if w := serveFromIP(r, "/res", "10.1.2.3"); w.Code != http.StatusOK {
+			t.Fatalf("白名单网段内的IP第%d个请求: status = %d, want 200", i+1, w.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		serveFromIP(r, "/res", "192.0.2.1")
+	}
+	if w := serveFromIP(r, "/res", "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("非白名单IP: status = %d, want 429", w.Code)
+	}
+
+	// 未开启豁免时白名单IP同样限流
+	DefaultWhitelistConfig.ExemptRateLimit = false
+	r = newRateLimitEngine(RateLimitConfig{Enable: true, Rate: 0.5, Burst: 2})
+	for i := 0; i < 2; i++ {
+		serveFromIP(r, "/res", "10.1.2.3")
+	}
+	if w := serveFromIP(r, "/res", "10.1.2.3"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("未开启豁免: status = %d, want 429", w.Code)
+	}
+}
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	reset := time.Now().Add(time.Minute)
+	SetRateLimitHeaders(c, RateLimitStatus{Limit: 3, Remaining: -1, Reset: reset})
+	SetRetryAfterHeader(c, 100*time.Millisecond)
+
+	if got := w.Header().Get(RateLimitLimitHeader); got != "3" {
+		t.Fatalf("%s = %q, want 3", RateLimitLimitHeader, got)
+	}
+	// 剩余次数不为负数
+	if got := w.Header().Get(RateLimitRemainingHeader); got != "0" {
+		t.Fatalf("%s = %q, want 0", RateLimitRemainingHeader, got)
+	}
+	if got := w.Header().Get(RateLimitResetHeader); got != strconv.FormatInt(reset.Unix(), 10) {
+		t.Fatalf("%s = %q, want %d", RateLimitResetHeader, got, reset.Unix())
+	}
+	// 不足1秒按1秒计
+	if got := w.Header().Get(RetryAfterHeader); got != "1" {
+		t.Fatalf("%s = %q, want 1", RetryAfterHeader, got)
+	}
+}
+
+func TestRateLimitHeadersDecrementAndReset(t *testing.T) {
+	r := newRateLimitEngine(RateLimitConfig{Enable: true, Rate: 20, Burst: 3})
+
+	for _, want := range []string{"2", "1", "0"} {
+		w := serveFromIP(r, "/res", "192.0.2.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "3" {
+			t.Fatalf("%s = %q, want 3", RateLimitLimitHeader, got)
+		}
+		if got := w.Header().Get(RateLimitRemainingHeader); got != want {
+			t.Fatalf("%s = %q, want %s", RateLimitRemainingHeader, got, want)
+		}
+		if reset, err := strconv.ParseInt(w.Header().Get(RateLimitResetHeader), 10, 64); err != nil || reset < time.Now().Unix() {
+			t.Fatalf("%s = %q", RateLimitResetHeader, w.Header().Get(RateLimitResetHeader))
+		}
+	}
+	w := serveFromIP(r, "/res", "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get(RateLimitRemainingHeader) != "0" {
+		t.Fatalf("令牌用完: status = %d, remaining = %q", w.Code, w.Header().Get(RateLimitRemainingHeader))
+	}
+
+	// 令牌桶补满后剩余次数恢复
+	time.Sleep(200 * time.Millisecond)
+	if w := serveFromIP(r, "/res", "192.0.2.1"); w.Header().Get(RateLimitRemainingHeader) != "2" {
+		t.Fatalf("补满后: remaining = %q, want 2", w.Header().Get(RateLimitRemainingHeader))
+	}
+}
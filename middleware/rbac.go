@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-app/ctxkeys"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 角色校验中间件，当前用户的角色不在 roles 中时返回403
+// 需要放在认证中间件之后；通过API密钥认证的请求没有角色，无法访问受限路由
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := allowed[ctxkeys.UserRole(c)]; !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "权限不足",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireInteractiveAuth 要求通过登录态（JWT）认证，通过API密钥认证的请求返回403
+// 用于管理API密钥等凭证的路由，避免泄露的密钥被用来创建新的长期凭证
+func RequireInteractiveAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, viaAPIKey := ctxkeys.APIKeyScopes(c); viaAPIKey {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "不能使用API密钥访问该接口，请使用登录令牌",
+			})
+			return
+		}
+		c.Next()
+	}
+}
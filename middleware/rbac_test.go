@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/ctxkeys"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveWithRole(role string, roles ...string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if role != "" {
+			ctxkeys.SetUserRole(c, role)
+		}
+		c.Next()
+	})
+	r.GET("/admin", RequireRole(roles...), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	return w.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  string
+		roles []string
+		want  int
+	}{
+		{"允许的角色", "admin", []string{"admin"}, http.StatusOK},
+		{"多个允许的角色之一", "user", []string{"admin", "user"}, http.StatusOK},
+		{"角色不在列表中", "user", []string{"admin"}, http.StatusForbidden},
+		{"未设置角色（API密钥认证）", "", []string{"admin"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveWithRole(tt.role, tt.roles...); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireInteractiveAuthRejectsAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(JWTAuth(cfg, AuthOptions{APIKeyAuthenticator: stubAPIKeys{"gak_write": {"read", "write"}}}))
+	r.POST("/api-keys", RequireInteractiveAuth(), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	// 有写权限的API密钥也不能创建新的密钥
+	if w := serveAuthPath(r, "/api-keys", http.Header{APIKeyHeader: {"gak_write"}}); w.Code != http.StatusForbidden {
+		t.Fatalf("API密钥: status = %d, want 403", w.Code)
+	}
+
+	token, err := GenerateToken(7, "user", cfg.JWT.Secret, time.Hour)
+	if err != nil {
+		t.Fatalf("生成令牌失败: %v", err)
+	}
+	if w := serveAuthPath(r, "/api-keys", http.Header{"Authorization": {"Bearer " + token}}); w.Code != http.StatusCreated {
+		t.Fatalf("JWT: status = %d, want 201", w.Code)
+	}
+}
+
+func serveAuthPath(r *gin.Engine, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
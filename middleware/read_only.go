@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReadOnlyTogglePath 切换只读模式的管理接口路径，只读模式下仍然可以访问，以便关闭只读模式
+const ReadOnlyTogglePath = "/api/v1/admin/system/read-only"
+
+// ReadOnlyLoginPath 登录接口路径，只读模式下仍然可以登录，否则令牌过期的管理员无法取得新令牌来关闭只读模式
+const ReadOnlyLoginPath = "/api/v1/users/login"
+
+// ReadOnlySignatureVerifyPath 签名校验接口路径，只校验签名、不记录nonce，只读模式下仍然可以访问
+const ReadOnlySignatureVerifyPath = "/api/v1/signature/verify"
+
+// defaultReadOnlySyncInterval 默认的只读模式同步间隔
+const defaultReadOnlySyncInterval = 5 * time.Second
+
+// readOnlyMode 当前实例的只读模式状态
+var readOnlyMode atomic.Bool
+
+// SetReadOnly 设置当前实例的只读模式状态，其他实例在下一次同步时生效
+func SetReadOnly(enabled bool) {
+	if readOnlyMode.Swap(enabled) != enabled {
+		utils.Warn("只读模式状态变化", zap.Bool("enabled", enabled))
+	}
+}
+
+// IsReadOnly 判断当前实例是否处于只读模式
+func IsReadOnly() bool {
+	return readOnlyMode.Load()
+}
+
+// ReadOnlyLoadFunc 从共享存储读取只读模式状态的函数
+type ReadOnlyLoadFunc func(ctx context.Context) (bool, error)
+
+// ReadOnlyConfig 只读模式中间件配置
+type ReadOnlyConfig struct {
+	// 从共享存储同步状态的间隔
+	SyncInterval time.Duration
+	// 状态读取函数，为nil时只使用本实例通过 SetReadOnly 设置的状态
+	Load ReadOnlyLoadFunc
+	// 只读模式下仍允许写请求的路径
+	ExemptPaths []string
+}
+
+// NewReadOnlyConfig 创建只读模式中间件配置
+func NewReadOnlyConfig(load ReadOnlyLoadFunc) ReadOnlyConfig {
+	return ReadOnlyConfig{
+		SyncInterval: defaultReadOnlySyncInterval,
+		Load:         load,
+		ExemptPaths:  []string{ReadOnlyTogglePath, ReadOnlyLoginPath, ReadOnlySignatureVerifyPath},
+	}
+}
+
+/*
+ReadOnly 只读模式中间件
+用于故障处理时冻结写入：只读模式开启后，POST/PUT/PATCH/DELETE 请求返回503，GET/HEAD/OPTIONS 请求正常处理。
+切换只读模式的接口和登录接口不受限制（登录会照常记录失败次数、会话和审计日志），令牌校验接口为GET请求同样可用，
+保证管理员在只读期间能重新登录并关闭只读模式。签名校验接口不写入任何数据，同样不受限制。
+状态保存在共享存储中，后台按固定间隔同步，多实例部署时最迟一个同步间隔后在所有实例上生效；
+同步失败时保留上一次的状态
+*/
+func ReadOnly(conf ReadOnlyConfig) gin.HandlerFunc {
+	interval := conf.SyncInterval
+	if interval <= 0 {
+		interval = defaultReadOnlySyncInterval
+	}
+
+	exempt := make(map[string]struct{}, len(conf.ExemptPaths))
+	for _, p := range conf.ExemptPaths {
+		exempt[p] = struct{}{}
+	}
+
+	if conf.Load != nil {
+		refresh := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			defer cancel()
+
+			enabled, err := conf.Load(ctx)
+			if err != nil {
+				utils.Warn("同步只读模式状态失败", zap.Error(err))
+				return
+			}
+			SetReadOnly(enabled)
+		}
+
+		refresh()
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				refresh()
+			}
+		}()
+	}
+
+	return func(c *gin.Context) {
+		if !IsReadOnly() {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if _, ok := exempt[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "系统处于只读模式",
+		})
+	}
+}
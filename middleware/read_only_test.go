@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newReadOnlyEngine(t *testing.T, enabled bool) *gin.Engine {
+	t.Helper()
+	SetReadOnly(enabled)
+	t.Cleanup(func() { SetReadOnly(false) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ReadOnly(NewReadOnlyConfig(nil)))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/v1/users", ok)
+	r.HEAD("/api/v1/users", ok)
+	r.POST("/api/v1/users/register", ok)
+	r.PUT("/api/v1/users/profile", ok)
+	r.PATCH("/api/v1/users/profile", ok)
+	r.DELETE("/api/v1/users/1", ok)
+	r.POST(ReadOnlyLoginPath, ok)
+	r.GET("/api/v1/auth/validate", ok)
+	r.PUT(ReadOnlyTogglePath, ok)
+	r.POST(ReadOnlySignatureVerifyPath, ok)
+	return r
+}
+
+func serveReadOnly(r *gin.Engine, method, path string) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code
+}
+
+func TestReadOnlyBlocksWrites(t *testing.T) {
+	r := newReadOnlyEngine(t, true)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/users/register"},
+		{http.MethodPut, "/api/v1/users/profile"},
+		{http.MethodPatch, "/api/v1/users/profile"},
+		{http.MethodDelete, "/api/v1/users/1"},
+	} {
+		if got := serveReadOnly(r, req.method, req.path); got != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: status = %d, want 503", req.method, req.path, got)
+		}
+	}
+}
+
+func TestReadOnlyAllowsReadsAndExemptPaths(t *testing.T) {
+	r := newReadOnlyEngine(t, true)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/users"},
+		{http.MethodHead, "/api/v1/users"},
+		{http.MethodPost, ReadOnlyLoginPath},
+		{http.MethodGet, "/api/v1/auth/validate"},
+		{http.MethodPut, ReadOnlyTogglePath},
+		{http.MethodPost, ReadOnlySignatureVerifyPath},
+	} {
+		if got := serveReadOnly(r, req.method, req.path); got != http.StatusOK {
+			t.Errorf("%s %s: status = %d, want 200", req.method, req.path, got)
+		}
+	}
+}
+
+func TestReadOnlyDisabledAllowsWrites(t *testing.T) {
+	r := newReadOnlyEngine(t, false)
+
+	if got := serveReadOnly(r, http.MethodPost, "/api/v1/users/register"); got != http.StatusOK {
+		t.Fatalf("status = %d, want 200", got)
+	}
+}
+
+func TestReadOnlyLoadsSharedState(t *testing.T) {
+	t.Cleanup(func() { SetReadOnly(false) })
+
+	conf := NewReadOnlyConfig(func(ctx context.Context) (bool, error) { return true, nil })
+	conf.SyncInterval = time.Hour
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ReadOnly(conf))
+	r.POST("/api/v1/users/register", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	if got := serveReadOnly(r, http.MethodPost, "/api/v1/users/register"); got != http.StatusServiceUnavailable {
+		t.Fatalf("共享存储开启只读模式后: status = %d, want 503", got)
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultLagCheckInterval 复制延迟的默认检查间隔
+const defaultLagCheckInterval = 10 * time.Second
+
+// LagCheckFunc 查询当前复制延迟
+type LagCheckFunc func(ctx context.Context) (time.Duration, error)
+
+// ReplicationLagConfig 复制延迟保护配置
+type ReplicationLagConfig struct {
+	// 允许的最大复制延迟，超过后读请求返回503，0表示不启用
+	MaxLag time.Duration
+	// 检查间隔，检查结果在间隔内缓存，也作为 Retry-After 的值
+	CheckInterval time.Duration
+	// 复制延迟查询函数
+	Check LagCheckFunc
+	// 不受限制的路径（如健康检查）
+	ExemptPaths []string
+}
+
+// NewReplicationLagConfig 从应用配置创建复制延迟保护配置
+func NewReplicationLagConfig(cfg *config.Config, check LagCheckFunc) ReplicationLagConfig {
+	return ReplicationLagConfig{
+		MaxLag:        cfg.MongoDB.MaxReplicationLag,
+		CheckInterval: cfg.MongoDB.LagCheckInterval,
+		Check:         check,
+		ExemptPaths:   []string{"/ping", "/healthz", MetricsPath},
+	}
+}
+
+/*
+ReplicationLag 复制延迟保护中间件
+后台按固定间隔查询复制延迟并缓存，请求处理时只读取缓存值；延迟超过阈值时，
+GET/HEAD请求返回503并带上 Retry-After，写请求和豁免路径不受影响。查询失败时保留上一次的结果
+*/
+func ReplicationLag(conf ReplicationLagConfig) gin.HandlerFunc {
+	if conf.MaxLag <= 0 || conf.Check == nil {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	interval := conf.CheckInterval
+	if interval <= 0 {
+		interval = defaultLagCheckInterval
+	}
+
+	exempt := make(map[string]struct{}, len(conf.ExemptPaths))
+	for _, p := range conf.ExemptPaths {
+		exempt[p] = struct{}{}
+	}
+
+	var lag atomic.Int64
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+
+		current, err := conf.Check(ctx)
+		if err != nil {
+			utils.Warn("查询复制延迟失败", zap.Error(err))
+			return
+		}
+		if previous := time.Duration(lag.Swap(int64(current))); (previous > conf.MaxLag) != (current > conf.MaxLag) {
+			utils.Warn("复制延迟状态变化", zap.Duration("lag", current), zap.Duration("max_lag", conf.MaxLag))
+		}
+	}
+
+	refresh()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refresh()
+		}
+	}()
+
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			c.Next()
+			return
+		}
+		if _, ok := exempt[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		if time.Duration(lag.Load()) > conf.MaxLag {
+			SetRetryAfterHeader(c, interval)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+				Code:    http.StatusServiceUnavailable,
+				Message: "数据同步延迟过高，请稍后重试",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
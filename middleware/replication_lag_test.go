@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newLagEngine(conf ReplicationLagConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ReplicationLag(conf))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/users", ok)
+	r.POST("/users", ok)
+	r.GET("/ping", ok)
+	return r
+}
+
+func serveLag(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestReplicationLagBlocksReadsAboveThreshold(t *testing.T) {
+	r := newLagEngine(ReplicationLagConfig{
+		MaxLag:        time.Second,
+		CheckInterval: time.Hour,
+		Check:         func(ctx context.Context) (time.Duration, error) { return 5 * time.Second, nil },
+		ExemptPaths:   []string{"/ping"},
+	})
+
+	w := serveLag(r, http.MethodGet, "/users")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("读请求: status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serveLag(r, http.MethodPost, "/users"); w.Code != http.StatusOK {
+		t.Fatalf("写请求: status = %d, want 200", w.Code)
+	}
+	if w := serveLag(r, http.MethodGet, "/ping"); w.Code != http.StatusOK {
+		t.Fatalf("豁免路径: status = %d, want 200", w.Code)
+	}
+}
+
+func TestReplicationLagKeepsLastResultOnError(t *testing.T) {
+	var calls atomic.Int32
+	r := newLagEngine(ReplicationLagConfig{
+		MaxLag:        time.Second,
+		CheckInterval: 20 * time.Millisecond,
+		Check: func(ctx context.Context) (time.Duration, error) {
+			if calls.Add(1) == 1 {
+				return 5 * time.Second, nil
+			}
+			return 0, errors.New("replSetGetStatus失败")
+		},
+	})
+
+	time.Sleep(60 * time.Millisecond)
+	if calls.Load() < 2 {
+		t.Fatal("应在后台定期查询复制延迟")
+	}
+	// 之后的查询都失败，保留第一次查询到的过高延迟
+	if w := serveLag(r, http.MethodGet, "/users"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+}
+
+func TestReplicationLagDisabled(t *testing.T) {
+	r := newLagEngine(ReplicationLagConfig{
+		Check: func(ctx context.Context) (time.Duration, error) { return time.Hour, nil },
+	})
+	if w := serveLag(r, http.MethodGet, "/users"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
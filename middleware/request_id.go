@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"go-app/config"
+	"go-app/ctxkeys"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader 请求ID请求头/响应头名称
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入请求ID的最大长度，超出则重新生成
+const maxRequestIDLength = 128
+
+// 客户端重复使用请求ID时的处理方式
+const (
+	RequestIDDuplicateKeep       = "keep"       // 原样使用（默认）
+	RequestIDDuplicateSuffix     = "suffix"     // 在客户端的ID后追加随机后缀
+	RequestIDDuplicateRegenerate = "regenerate" // 生成新的ID
+)
+
+// defaultRequestIDDuplicateTTL 判断请求ID重复的默认时间窗口
+const defaultRequestIDDuplicateTTL = time.Minute
+
+// requestIDSuffixLength 追加到重复请求ID后的随机后缀长度
+const requestIDSuffixLength = 8
+
+// RequestIDConfig 请求ID中间件配置
+type RequestIDConfig struct {
+	// 客户端重复使用请求ID时的处理方式：keep、suffix 或 regenerate
+	DuplicateMode string
+	// 在该时间窗口内再次出现的客户端请求ID视为重复
+	DuplicateTTL time.Duration
+}
+
+// NewRequestIDConfig 从应用配置创建请求ID中间件配置，处理方式未知时按keep处理
+func NewRequestIDConfig(cfg *config.Config) RequestIDConfig {
+	conf := RequestIDConfig{
+		DuplicateMode: strings.ToLower(cfg.Server.RequestIDDuplicateMode),
+		DuplicateTTL:  cfg.Server.RequestIDDuplicateTTL,
+	}
+	switch conf.DuplicateMode {
+	case RequestIDDuplicateKeep, RequestIDDuplicateSuffix, RequestIDDuplicateRegenerate:
+	default:
+		if conf.DuplicateMode != "" {
+			utils.Warn("未知的重复请求ID处理方式，按keep处理", zap.String("mode", cfg.Server.RequestIDDuplicateMode))
+		}
+		conf.DuplicateMode = RequestIDDuplicateKeep
+	}
+	if conf.DuplicateTTL <= 0 {
+		conf.DuplicateTTL = defaultRequestIDDuplicateTTL
+	}
+	return conf
+}
+
+// RequestID 请求ID中间件
+// 优先使用客户端传入的 X-Request-ID，未传入或过长时生成新的ID，
+// 并写入上下文和响应头，便于日志关联
+func RequestID() gin.HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{DuplicateMode: RequestIDDuplicateKeep})
+}
+
+/*
+RequestIDWithConfig 使用指定配置的请求ID中间件
+DuplicateMode 不为keep时，记录最近一个时间窗口内出现过的客户端请求ID（仅保存在本实例内存中）；
+同一ID再次出现时追加随机后缀或重新生成，客户端传入的原始值保存为 client_request_id 并写入请求日志
+*/
+func RequestIDWithConfig(conf RequestIDConfig) gin.HandlerFunc {
+	var seen *MemoryNonceStore
+	if conf.DuplicateMode == RequestIDDuplicateSuffix || conf.DuplicateMode == RequestIDDuplicateRegenerate {
+		seen = NewMemoryNonceStore()
+	}
+	ttl := conf.DuplicateTTL
+	if ttl <= 0 {
+		ttl = defaultRequestIDDuplicateTTL
+	}
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = utils.GenerateRequestID()
+		} else if seen != nil {
+			if first, _ := seen.Remember(id, ttl); !first {
+				ctxkeys.SetClientRequestID(c, id)
+				if conf.DuplicateMode == RequestIDDuplicateSuffix {
+					id += "-" + utils.GenerateRequestID()[:requestIDSuffixLength]
+				} else {
+					id = utils.GenerateRequestID()
+				}
+			}
+		}
+
+		ctxkeys.SetRequestID(c, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID 从上下文中获取请求ID
+func GetRequestID(c *gin.Context) string {
+	return ctxkeys.RequestID(c)
+}
+
+// GetClientRequestID 从上下文中获取客户端传入的原始请求ID，请求ID未被替换时返回空字符串
+func GetClientRequestID(c *gin.Context) string {
+	return ctxkeys.ClientRequestID(c)
+}
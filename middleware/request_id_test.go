@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRequestIDRouter 处理器在响应头中返回客户端传入的原始请求ID
+func newRequestIDRouter(conf RequestIDConfig, extra ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDWithConfig(conf))
+	r.Use(extra...)
+	r.GET("/ids", func(c *gin.Context) {
+		c.Header("X-Client-Request-ID", GetClientRequestID(c))
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+// serveWithRequestID 发送带 X-Request-ID 的请求，返回生效的请求ID和客户端原始请求ID
+func serveWithRequestID(r *gin.Engine, id, ua string) (effective, client string) {
+	req := httptest.NewRequest(http.MethodGet, "/ids", nil)
+	req.Header.Set(RequestIDHeader, id)
+	if ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header().Get(RequestIDHeader), w.Header().Get("X-Client-Request-ID")
+}
+
+func TestRequestIDDuplicateSuffix(t *testing.T) {
+	r := newRequestIDRouter(RequestIDConfig{DuplicateMode: RequestIDDuplicateSuffix, DuplicateTTL: time.Minute})
+
+	first, client := serveWithRequestID(r, "dup-1", "")
+	if first != "dup-1" || client != "" {
+		t.Fatalf("首次出现: id = %q, client = %q", first, client)
+	}
+	second, client := serveWithRequestID(r, "dup-1", "")
+	if !strings.HasPrefix(second, "dup-1-") || len(second) != len("dup-1-")+requestIDSuffixLength {
+		t.Fatalf("重复出现: id = %q, want dup-1-<后缀>", second)
+	}
+	if client != "dup-1" {
+		t.Fatalf("重复出现: client = %q, want dup-1", client)
+	}
+	third, _ := serveWithRequestID(r, "dup-1", "")
+	if third == second {
+		t.Fatalf("每次重复都应生成不同的ID: %q", third)
+	}
+}
+
+func TestRequestIDDuplicateRegenerate(t *testing.T) {
+	r := newRequestIDRouter(RequestIDConfig{DuplicateMode: RequestIDDuplicateRegenerate, DuplicateTTL: time.Minute})
+
+	first, _ := serveWithRequestID(r, "dup-2", "")
+	second, client := serveWithRequestID(r, "dup-2", "")
+	if first != "dup-2" || second == "" || strings.Contains(second, "dup-2") {
+		t.Fatalf("first = %q, second = %q", first, second)
+	}
+	if client != "dup-2" {
+		t.Fatalf("client = %q, want dup-2", client)
+	}
+}
+
+func TestRequestIDDuplicateKeep(t *testing.T) {
+	r := newRequestIDRouter(RequestIDConfig{DuplicateMode: RequestIDDuplicateKeep})
+	for i := 0; i < 2; i++ {
+		if id, client := serveWithRequestID(r, "dup-3", ""); id != "dup-3" || client != "" {
+			t.Fatalf("第%d次: id = %q, client = %q", i+1, id, client)
+		}
+	}
+}
+
+func TestRequestIDDuplicateInRequestLog(t *testing.T) {
+	r := newRequestIDRouter(RequestIDConfig{DuplicateMode: RequestIDDuplicateSuffix, DuplicateTTL: time.Minute},
+		LoggerWithConfig(DefaultLoggerConfig))
+
+	ua := "request-id-dup-test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	first, _ := serveWithRequestID(r, "dup-log", ua)
+	second, _ := serveWithRequestID(r, "dup-log", ua)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		logs := readRequestLogs(t, ua)
+		if len(logs) >= 2 {
+			if logs[0].RequestID != first || logs[0].ClientRequestID != "" {
+				t.Fatalf("首次请求日志: request_id = %q, client_request_id = %q", logs[0].RequestID, logs[0].ClientRequestID)
+			}
+			if logs[1].RequestID != second || logs[1].ClientRequestID != "dup-log" {
+				t.Fatalf("重复请求日志: request_id = %q, client_request_id = %q", logs[1].RequestID, logs[1].ClientRequestID)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("等待请求日志超时, logs = %v", logs)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServerTimingHeader 耗时明细响应头
+const ServerTimingHeader = "Server-Timing"
+
+// ServerTimingEnabled 判断是否输出耗时明细：显式开启或运行在debug模式时输出
+func ServerTimingEnabled(cfg *config.Config) bool {
+	return cfg.Server.Timing || cfg.Server.Mode == gin.DebugMode
+}
+
+/*
+ServerTiming 耗时明细中间件
+在请求上下文中放入耗时记录，数据库等操作通过 utils.AddServerTiming 累加耗时，
+响应头写出前生成 Server-Timing 头（如 db;dur=12.3, total;dur=45.6），便于在浏览器开发者工具中查看耗时分布。
+耗时明细会暴露服务端内部信息，生产环境应关闭
+enable: 是否启用，为false时直接放行
+*/
+func ServerTiming(enable bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enable {
+			c.Next()
+			return
+		}
+
+		timing := utils.NewServerTiming()
+		c.Request = c.Request.WithContext(utils.WithServerTiming(c.Request.Context(), timing))
+		c.Writer = &serverTimingWriter{ResponseWriter: c.Writer, timing: timing, start: time.Now()}
+
+		c.Next()
+	}
+}
+
+// serverTimingWriter 在响应头实际写出前添加 Server-Timing 头
+// gin 的 WriteHeader 只记录状态码，响应头在 WriteHeaderNow 或首次 Write 时写出，此时的耗时即为处理总耗时
+type serverTimingWriter struct {
+	gin.ResponseWriter
+	timing  *utils.ServerTiming
+	start   time.Time
+	written bool
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.Header().Set(ServerTimingHeader, w.timing.Header(time.Since(w.start)))
+}
+
+// WriteHeaderNow 立即写出响应头前添加耗时明细
+func (w *serverTimingWriter) WriteHeaderNow() {
+	if !w.ResponseWriter.Written() {
+		w.setHeader()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 写出响应体前添加耗时明细
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	if !w.ResponseWriter.Written() {
+		w.setHeader()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写出响应体前添加耗时明细
+func (w *serverTimingWriter) WriteString(s string) (int, error) {
+	if !w.ResponseWriter.Written() {
+		w.setHeader()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
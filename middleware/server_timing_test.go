@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseServerTiming 解析 Server-Timing 头，返回各阶段的耗时（毫秒）
+func parseServerTiming(t *testing.T, header string) map[string]float64 {
+	t.Helper()
+	timings := make(map[string]float64)
+	for _, part := range strings.Split(header, ", ") {
+		name, dur, ok := strings.Cut(part, ";dur=")
+		if !ok {
+			t.Fatalf("无法解析 %q", part)
+		}
+		ms, err := strconv.ParseFloat(dur, 64)
+		if err != nil {
+			t.Fatalf("无法解析耗时 %q: %v", part, err)
+		}
+		timings[name] = ms
+	}
+	return timings
+}
+
+func newServerTimingEngine(enable bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ServerTiming(enable))
+	r.GET("/users", func(c *gin.Context) {
+		// 模拟一次数据库调用
+		done := utils.TrackServerTiming(c.Request.Context(), "db")
+		time.Sleep(5 * time.Millisecond)
+		done()
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	return r
+}
+
+func TestServerTimingIncludesDBAndTotal(t *testing.T) {
+	r := newServerTimingEngine(true)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	header := w.Header().Get(ServerTimingHeader)
+	timings := parseServerTiming(t, header)
+	db, ok := timings["db"]
+	if !ok || db < 5 {
+		t.Fatalf("%s = %q, 缺少db耗时或耗时过小", ServerTimingHeader, header)
+	}
+	total, ok := timings["total"]
+	if !ok || total < db {
+		t.Fatalf("%s = %q, total 应不小于 db", ServerTimingHeader, header)
+	}
+}
+
+func TestServerTimingDisabled(t *testing.T) {
+	r := newServerTimingEngine(false)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if got := w.Header().Get(ServerTimingHeader); got != "" {
+		t.Fatalf("未启用时不应输出 %s: %q", ServerTimingHeader, got)
+	}
+}
+
+func TestServerTimingEnabled(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.Mode = gin.ReleaseMode
+	if ServerTimingEnabled(cfg) {
+		t.Fatal("release模式下默认不应启用")
+	}
+	cfg.Server.Timing = true
+	if !ServerTimingEnabled(cfg) {
+		t.Fatal("显式开启时应启用")
+	}
+	cfg.Server.Timing = false
+	cfg.Server.Mode = gin.DebugMode
+	if !ServerTimingEnabled(cfg) {
+		t.Fatal("debug模式下应启用")
+	}
+}
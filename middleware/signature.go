@@ -1,22 +1,103 @@
 package middleware
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"log"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"go-app/config"
+	"go-app/ctxkeys"
+	"go-app/utils"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 请求头方式传递签名参数时使用的请求头
+const (
+	SignatureHeader          = "Signature"
+	SignatureAppKeyHeader    = "X-App-Key"
+	SignatureTimestampHeader = "X-Timestamp"
+	SignatureNonceHeader     = "X-Nonce"
 )
 
+// maxSignedBodySize 参与签名的请求体最大长度，超出时返回413
+const maxSignedBodySize = 10 << 20
+
+// ErrSignedBodyTooLarge 请求体超过参与签名的最大长度
+var ErrSignedBodyTooLarge = errors.New("请求体过大")
+
 // SignatureConfig 签名配置
 type SignatureConfig struct {
+	Enable    bool          // 是否启用签名验证
 	AppKey    string        // 应用key
 	AppSecret string        // 应用密钥
 	Expire    time.Duration // 签名有效期
+	// 签名算法：md5（默认，仅为兼容旧客户端）或 hmac-sha256（推荐）
+	Algorithm string
+	// nonce存储，用于拒绝重放请求；为nil时使用内存存储
+	NonceStore NonceStore
+	// 不进行签名验证的路径
+	ExemptPaths []string
+}
+
+// SignatureVerifyPath 签名调试接口的路径，该接口自行校验签名，不经过签名中间件
+const SignatureVerifyPath = "/api/v1/signature/verify"
+
+// NewSignatureConfig 从应用配置创建签名配置
+func NewSignatureConfig(cfg *config.Config) *SignatureConfig {
+	return &SignatureConfig{
+		Enable:    cfg.Signature.Enable,
+		AppKey:    cfg.Signature.AppKey,
+		AppSecret: cfg.Signature.AppSecret,
+		Expire:    cfg.Signature.Expire,
+		Algorithm: cfg.Signature.Algorithm,
+		// 签名调试接口需要接收错误的签名并返回校验结果；指标抓取方和负载均衡器的健康检查无法计算签名
+		ExemptPaths: []string{SignatureVerifyPath, MetricsPath, "/ping", "/healthz"},
+	}
+}
+
+/*
+ValidateSignature 校验签名配置
+算法配置错误时若退回默认算法，客户端按配置的算法生成的签名全部校验失败，且不易察觉；
+nonce存储配置错误时若退回内存存储，多实例部署下请求可以在另一个实例上重放，因此都应拒绝启动
+参数: cfg 应用配置
+返回: 错误
+*/
+func ValidateSignature(cfg *config.Config) error {
+	if !utils.IsSupportedSignatureAlgo(cfg.Signature.Algorithm) {
+		return fmt.Errorf("SIGNATURE_ALGORITHM配置无效: %s，可选值为 %s、%s",
+			cfg.Signature.Algorithm, utils.SignatureAlgoMD5, utils.SignatureAlgoHMACSHA256)
+	}
+	switch cfg.Signature.NonceStore {
+	case "", NonceStoreMemory, NonceStoreMongoDB:
+	default:
+		return fmt.Errorf("SIGNATURE_NONCE_STORE配置无效: %s，可选值为 %s、%s",
+			cfg.Signature.NonceStore, NonceStoreMemory, NonceStoreMongoDB)
+	}
+	return nil
+}
+
+// SignatureError 签名校验失败的原因，Message 可直接返回给客户端
+type SignatureError struct {
+	Message string
+	Err     error
+}
+
+// Error 返回校验失败的原因
+func (e *SignatureError) Error() string {
+	return e.Message
+}
+
+// Unwrap 返回底层错误
+func (e *SignatureError) Unwrap() error {
+	return e.Err
 }
 
 // SignatureParams 签名参数
@@ -27,104 +108,201 @@ type SignatureParams struct {
 	Sign      string `form:"sign"`
 }
 
-// Signature 签名验证中间件
+/*
+Signature 签名验证中间件
+签名参数可以通过请求头（Signature、X-App-Key、X-Timestamp、X-Nonce）或查询参数（sign、app_key、timestamp、nonce）传递，
+签名字符串包含查询参数、表单参数以及签名参数；非空请求体（不区分类型）以 body_hash（请求体SHA-256十六进制）参与签名，
+超过 maxSignedBodySize 的请求体返回413；nonce在签名有效期内只能使用一次，重复使用返回400
+config: 签名配置，Enable 为false时直接放行
+*/
 func Signature(config *SignatureConfig) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 临时禁用签名验证
-		c.Next()
-		return
+	nonceStore := config.NonceStore
+	if nonceStore == nil {
+		nonceStore = NewMemoryNonceStore()
+	}
+	// 时间戳允许前后各偏差 Expire，nonce至少需要保留整个窗口
+	nonceTTL := 2 * config.Expire
+
+	exempt := make(map[string]bool, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exempt[path] = true
+	}
 
-		// 调试信息
-		log.Printf("收到请求: %s %s", c.Request.Method, c.Request.URL.Path)
+	// 配置无效时panic，应用启动时应先调用 ValidateSignature 校验
+	if config.Enable && !utils.IsSupportedSignatureAlgo(config.Algorithm) {
+		panic(fmt.Errorf("不支持的签名算法: %s", config.Algorithm))
+	}
 
-		// OPTIONS请求直接放行
-		if c.Request.Method == "OPTIONS" {
+	return func(c *gin.Context) {
+		// 未启用、OPTIONS请求或豁免路径直接放行
+		if !config.Enable || c.Request.Method == http.MethodOptions || exempt[c.Request.URL.Path] {
 			c.Next()
 			return
 		}
 
-		// 从请求头中获取签名参数
-		sign := c.Request.Header.Get("signature")
-		if sign == "" {
-			// 尝试从查询参数获取
-			var params SignatureParams
-			if err := c.ShouldBindQuery(&params); err != nil {
-				log.Printf("签名验证失败: %v", err)
-				ErrorWrapper(c, http.StatusBadRequest, 400, "签名参数错误", err)
+		params, _, err := VerifySignature(c, config)
+		if err != nil {
+			if errors.Is(err, ErrSignedBodyTooLarge) {
+				ErrorWrapper(c, http.StatusRequestEntityTooLarge, 413, "请求体过大", nil)
 				return
 			}
-
-			// 验证AppKey
-			if params.AppKey != config.AppKey {
-				ErrorWrapper(c, http.StatusBadRequest, 400, "无效的AppKey", nil)
+			var sigErr *SignatureError
+			if errors.As(err, &sigErr) {
+				ErrorWrapper(c, http.StatusBadRequest, 400, sigErr.Message, sigErr.Err)
 				return
 			}
+			ErrorWrapper(c, http.StatusBadRequest, 400, "签名验证失败", err)
+			return
+		}
 
-			// 验证时间戳
-			now := time.Now().Unix()
-			if now-params.Timestamp > int64(config.Expire.Seconds()) {
-				ErrorWrapper(c, http.StatusBadRequest, 400, "签名已过期", nil)
-				return
-			}
+		// 签名通过后再记录nonce，避免伪造的请求占用存储
+		fresh, err := nonceStore.Remember(params.AppKey+":"+params.Nonce, nonceTTL)
+		if err != nil {
+			utils.Error("记录签名nonce失败", zap.Error(err))
+			ErrorWrapper(c, http.StatusInternalServerError, 500, "签名验证失败", nil)
+			return
+		}
+		if !fresh {
+			ErrorWrapper(c, http.StatusBadRequest, 400, "重复的请求", nil)
+			return
+		}
 
-			// 获取所有请求参数
-			queryParams := c.Request.URL.Query()
-			formParams := c.Request.PostForm
+		// 将参数存储到上下文中，以便后续使用
+		ctxkeys.SetSignatureParams(c, params)
 
-			// 合并所有参数
-			allParams := make(map[string]string)
-			for key, values := range queryParams {
-				if key != "sign" { // 排除签名参数
-					allParams[key] = values[0]
-				}
-			}
-			for key, values := range formParams {
-				if key != "sign" { // 排除签名参数
-					allParams[key] = values[0]
-				}
-			}
+		c.Next()
+	}
+}
 
-			// 按参数名排序
-			var keys []string
-			for k := range allParams {
-				keys = append(keys, k)
-			}
-			sort.Strings(keys)
-
-			// 构建签名字符串
-			var signStr strings.Builder
-			for _, k := range keys {
-				signStr.WriteString(k)
-				signStr.WriteString("=")
-				signStr.WriteString(allParams[k])
-				signStr.WriteString("&")
-			}
-			signStr.WriteString("app_secret=")
-			signStr.WriteString(config.AppSecret)
+/*
+VerifySignature 校验请求签名（AppKey、时间戳和签名值），不检查也不记录nonce
+config: 签名配置
+返回: 签名参数, 参与签名的参数, 错误（校验失败时为 *SignatureError）
+*/
+func VerifySignature(c *gin.Context, config *SignatureConfig) (*SignatureParams, map[string]string, error) {
+	params, err := signatureParams(c)
+	if err != nil {
+		return nil, nil, &SignatureError{Message: "签名参数错误", Err: err}
+	}
+	if params.Sign == "" {
+		return params, nil, &SignatureError{Message: "缺少签名"}
+	}
+	if params.Nonce == "" {
+		return params, nil, &SignatureError{Message: "缺少nonce"}
+	}
 
-			// 计算MD5签名
-			hash := md5.New()
-			hash.Write([]byte(signStr.String()))
-			calculatedSign := hex.EncodeToString(hash.Sum(nil))
+	// 验证AppKey
+	if params.AppKey != config.AppKey {
+		return params, nil, &SignatureError{Message: "无效的AppKey"}
+	}
 
-			// 验证签名
-			if calculatedSign != params.Sign {
-				ErrorWrapper(c, http.StatusBadRequest, 400, "签名验证失败", nil)
-				return
-			}
+	// 验证时间戳，同时拒绝过期和超前的时间戳
+	age := time.Now().Unix() - params.Timestamp
+	if age < 0 {
+		age = -age
+	}
+	if age > int64(config.Expire.Seconds()) {
+		return params, nil, &SignatureError{Message: "签名已过期"}
+	}
+
+	signParams, err := collectSignParams(c, params)
+	if errors.Is(err, ErrSignedBodyTooLarge) {
+		return params, nil, &SignatureError{Message: "请求体超过签名长度上限", Err: err}
+	}
+	if err != nil {
+		return params, nil, &SignatureError{Message: "读取请求体失败", Err: err}
+	}
 
-			// 将参数存储到上下文中，以便后续使用
-			c.Set("signatureParams", params)
+	// 验证签名
+	calculatedSign := utils.GenerateSignatureWithAlgo(signParams, config.AppSecret, config.Algorithm)
+	if subtle.ConstantTimeCompare([]byte(calculatedSign), []byte(strings.ToLower(params.Sign))) != 1 {
+		return params, signParams, &SignatureError{Message: "签名验证失败"}
+	}
+
+	return params, signParams, nil
+}
+
+// signatureParams 读取签名参数，请求头中带有签名时使用请求头，否则使用查询参数
+func signatureParams(c *gin.Context) (*SignatureParams, error) {
+	sign := c.GetHeader(SignatureHeader)
+	if sign == "" {
+		var params SignatureParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			return nil, err
 		}
+		return &params, nil
+	}
 
-		c.Next()
+	params := &SignatureParams{
+		AppKey: c.GetHeader(SignatureAppKeyHeader),
+		Nonce:  c.GetHeader(SignatureNonceHeader),
+		Sign:   sign,
+	}
+	if ts := c.GetHeader(SignatureTimestampHeader); ts != "" {
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		params.Timestamp = timestamp
 	}
+	return params, nil
+}
+
+/*
+collectSignParams 收集参与签名的参数
+包括查询参数、表单参数、签名参数（app_key、timestamp、nonce），非空请求体以 body_hash 参与签名，
+不区分请求体类型，避免通过修改 Content-Type 绕过对请求体的校验；
+读取请求体后会重新放回，后续处理器仍可正常绑定
+返回: 参与签名的参数, 错误（请求体超过 maxSignedBodySize 时为 ErrSignedBodyTooLarge）
+*/
+func collectSignParams(c *gin.Context, params *SignatureParams) (map[string]string, error) {
+	allParams := make(map[string]string)
+
+	// 先读取完整请求体再解析表单，表单请求的请求体同样参与签名
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodySize+1))
+		if err != nil {
+			return nil, err
+		}
+		// 截断后计算的摘要无法覆盖完整请求体，超出时直接拒绝
+		if len(body) > maxSignedBodySize {
+			return nil, ErrSignedBodyTooLarge
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	// Request.Form 包含查询参数和表单参数
+	for key, values := range c.Request.Form {
+		if key != "sign" && len(values) > 0 { // 排除签名参数
+			allParams[key] = values[0]
+		}
+	}
+
+	allParams["app_key"] = params.AppKey
+	allParams["timestamp"] = strconv.FormatInt(params.Timestamp, 10)
+	allParams["nonce"] = params.Nonce
+
+	if len(body) > 0 {
+		allParams[utils.SignatureBodyHashKey] = utils.SignatureBodyHash(body)
+	}
+
+	return allParams, nil
 }
 
 // GetSignatureParams 从上下文中获取签名参数
 func GetSignatureParams(c *gin.Context) *SignatureParams {
-	if params, exists := c.Get("signatureParams"); exists {
-		return params.(*SignatureParams)
+	if v, exists := ctxkeys.SignatureParams(c); exists {
+		if params, ok := v.(*SignatureParams); ok {
+			return params
+		}
 	}
 	return nil
 }
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAppKey    = "test-key"
+	testAppSecret = "test-secret"
+)
+
+func newSignatureEngine(cfg *SignatureConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Signature(cfg))
+	r.POST("/res", func(c *gin.Context) {
+		// 签名校验后处理器仍能读取表单
+		c.String(http.StatusOK, c.PostForm("name"))
+	})
+	return r
+}
+
+func testSignatureConfig() *SignatureConfig {
+	return &SignatureConfig{
+		Enable:    true,
+		AppKey:    testAppKey,
+		AppSecret: testAppSecret,
+		Expire:    time.Minute,
+	}
+}
+
+/*
+signedRequest 构造签名请求，签名参数通过查询参数传递
+signed: 客户端签名时使用的请求体，sent: 实际发送的请求体，二者不同时模拟请求体被篡改
+*/
+func signedRequest(contentType string, form map[string]string, signed, sent []byte) *http.Request {
+	params := make(map[string]string, len(form))
+	for k, v := range form {
+		params[k] = v
+	}
+	params = utils.GenerateAPIParamsWithBody(testAppKey, testAppSecret, params, signed)
+
+	query := url.Values{}
+	for k, v := range params {
+		if _, ok := form[k]; !ok {
+			query.Set(k, v)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/res?"+query.Encode(), bytes.NewReader(sent))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func serveSignature(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignatureAcceptsSignedBodies(t *testing.T) {
+	r := newSignatureEngine(testSignatureConfig())
+
+	body := []byte(`{"name":"alice"}`)
+	if w := serveSignature(r, signedRequest(gin.MIMEJSON, nil, body, body)); w.Code != http.StatusOK {
+		t.Fatalf("JSON请求体: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	form := []byte("name=alice")
+	w := serveSignature(r, signedRequest(gin.MIMEPOSTForm, map[string]string{"name": "alice"}, form, form))
+	if w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Fatalf("表单请求体: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSignatureRejectsTamperedBodyForAnyContentType(t *testing.T) {
+	r := newSignatureEngine(testSignatureConfig())
+
+	for _, contentType := range []string{gin.MIMEJSON, gin.MIMEPlain, "application/octet-stream", gin.MIMEXML} {
+		req := signedRequest(contentType, nil, []byte("original"), []byte("tampered"))
+		if w := serveSignature(r, req); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", contentType, w.Code)
+		}
+	}
+
+	// 表单参数未变，只修改了请求体中的其他字段
+	req := signedRequest(gin.MIMEPOSTForm, map[string]string{"name": "alice"}, []byte("name=alice"), []byte("name=alice&role=admin"))
+	if w := serveSignature(r, req); w.Code != http.StatusBadRequest {
+		t.Errorf("表单请求体: status = %d, want 400", w.Code)
+	}
+}
+
+func TestSignatureRejectsOversizedBody(t *testing.T) {
+	r := newSignatureEngine(testSignatureConfig())
+
+	body := bytes.Repeat([]byte("a"), maxSignedBodySize+1)
+	w := serveSignature(r, signedRequest(gin.MIMEPlain, nil, body, body))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "请求体过大") {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
+
+func TestSignatureHMACSHA256(t *testing.T) {
+	cfg := testSignatureConfig()
+	cfg.Algorithm = utils.SignatureAlgoHMACSHA256
+	r := newSignatureEngine(cfg)
+
+	params := map[string]string{
+		"app_key":   testAppKey,
+		"timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+		"nonce":     utils.GenerateNonce(),
+	}
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+
+	// 使用MD5签名的请求不能通过HMAC-SHA256校验
+	query.Set("sign", utils.GenerateSignature(params, testAppSecret))
+	req := httptest.NewRequest(http.MethodPost, "/res?"+query.Encode(), nil)
+	if w := serveSignature(r, req); w.Code != http.StatusBadRequest {
+		t.Fatalf("MD5签名: status = %d, want 400", w.Code)
+	}
+
+	query.Set("sign", utils.GenerateSignatureWithAlgo(params, testAppSecret, utils.SignatureAlgoHMACSHA256))
+	req = httptest.NewRequest(http.MethodPost, "/res?"+query.Encode(), nil)
+	if w := serveSignature(r, req); w.Code != http.StatusOK {
+		t.Fatalf("HMAC-SHA256签名: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestValidateSignatureAlgorithm(t *testing.T) {
+	for algo, valid := range map[string]bool{
+		"":                            true,
+		utils.SignatureAlgoMD5:        true,
+		utils.SignatureAlgoHMACSHA256: true,
+		"sha1":                        false,
+		"HMAC_SHA256":                 false,
+	} {
+		cfg := &config.Config{}
+		cfg.Signature.Algorithm = algo
+		if err := ValidateSignature(cfg); (err == nil) != valid {
+			t.Errorf("ValidateSignature(%q) = %v, valid = %v", algo, err, valid)
+		}
+	}
+}
+
+func TestSignatureRejectsReplayedNonce(t *testing.T) {
+	r := newSignatureEngine(testSignatureConfig())
+
+	body := []byte(`{"name":"alice"}`)
+	first := signedRequest(gin.MIMEJSON, nil, body, body)
+	replay := httptest.NewRequest(http.MethodPost, first.URL.String(), bytes.NewReader(body))
+	replay.Header.Set("Content-Type", gin.MIMEJSON)
+
+	if w := serveSignature(r, first); w.Code != http.StatusOK {
+		t.Fatalf("首次请求: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	w := serveSignature(r, replay)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "重复的请求") {
+		t.Fatalf("重放请求: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestValidateSignatureNonceStore(t *testing.T) {
+	for store, valid := range map[string]bool{
+		"":                true,
+		NonceStoreMemory:  true,
+		NonceStoreMongoDB: true,
+		"redis":           false,
+		"MongoDB":         false,
+	} {
+		cfg := &config.Config{}
+		cfg.Signature.NonceStore = store
+		if err := ValidateSignature(cfg); (err == nil) != valid {
+			t.Errorf("ValidateSignature(nonce store %q) = %v, valid = %v", store, err, valid)
+		}
+	}
+}
+
+func TestSignatureExemptsHealthChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Signature.Enable = true
+	cfg.Signature.AppKey = testAppKey
+	cfg.Signature.AppSecret = testAppSecret
+	cfg.Signature.Expire = time.Minute
+
+	r := gin.New()
+	r.Use(Signature(NewSignatureConfig(cfg)))
+	for _, path := range []string{"/ping", "/healthz", "/api/v1/users"} {
+		r.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	for _, path := range []string{"/ping", "/healthz"} {
+		if w := serveSignature(r, httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusOK {
+			t.Errorf("%s 未签名: status = %d, want 200", path, w.Code)
+		}
+	}
+	if w := serveSignature(r, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)); w.Code == http.StatusOK {
+		t.Error("未豁免的路径缺少签名时应被拒绝")
+	}
+}
@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+Timeout 处理器超时中间件，超过 timeout 仍未完成的请求立即返回504
+处理器在单独的goroutine中执行，响应先写入缓冲区，处理器完成后再一次性写出；
+到达截止时间时丢弃缓冲区并返回504，处理器之后的写入都会被忽略。
+请求上下文同时设置截止时间，数据库查询等依赖上下文的操作会被取消；
+不检查上下文的处理器（如纯计算）会继续执行到结束，但不再影响响应，
+中间件等它结束后才返回，因此同一请求的上下文不会被并发使用。
+处理器调用 Flush（如流式输出）后切换为直接写出，响应已经开始，此后不再返回504，
+只受 http.Server 的 WriteTimeout 约束。
+timeout<=0 时不做限制
+*/
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := c.Writer
+		tw := &timeoutWriter{ResponseWriter: w, header: make(http.Header)}
+		c.Writer = tw
+
+		done := make(chan struct{})
+		var panicValue interface{}
+		go func() {
+			defer close(done)
+			defer func() {
+				panicValue = recover()
+			}()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if tw.timeout() {
+				writeTimeoutResponse(w)
+			}
+			// 等待处理器结束，之后才能把上下文交还给gin
+			<-done
+		}
+
+		c.Writer = w
+		if panicValue != nil {
+			panic(panicValue)
+		}
+		if tw.timedOut {
+			c.Abort()
+			return
+		}
+		tw.commit()
+	}
+}
+
+// writeTimeoutResponse 直接向原始响应写出504，并立即发送给客户端
+func writeTimeoutResponse(w gin.ResponseWriter) {
+	body, _ := json.Marshal(ErrorResponse{
+		Code:    http.StatusGatewayTimeout,
+		Message: "请求处理超时",
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = w.Write(body)
+	w.Flush()
+}
+
+/*
+timeoutWriter 缓冲处理器的响应，到达截止时间后丢弃处理器的所有写入
+调用 Flush 后切换为直接写出，此时超时不再生效
+*/
+type timeoutWriter struct {
+	gin.ResponseWriter // 原始响应
+
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool // 已超时，写入全部丢弃
+	streaming   bool // 已调用 Flush，写入直接发送到原始响应
+}
+
+// timeout 标记为已超时；已开始流式输出时返回false，由调用方放弃写出504
+func (w *timeoutWriter) timeout() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return false
+	}
+	w.timedOut = true
+	return true
+}
+
+// commit 将缓冲的响应头、状态码和响应体写出到原始响应
+func (w *timeoutWriter) commit() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.commitLocked()
+}
+
+func (w *timeoutWriter) commitLocked() {
+	if w.streaming || w.timedOut {
+		return
+	}
+	// 处理器只设置了响应头而未写出时也要保留，后续中间件可能继续写响应
+	dst := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+	if !w.wroteHeader {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	w.buf.Reset()
+}
+
+// Header 流式输出前返回缓冲的响应头，超时时不会混入504响应
+func (w *timeoutWriter) Header() http.Header {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+	case w.streaming:
+		w.ResponseWriter.WriteHeader(code)
+	case !w.wroteHeader:
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+	case w.streaming:
+		w.ResponseWriter.WriteHeaderNow()
+	default:
+		w.writeHeaderLocked()
+	}
+}
+
+// writeHeaderLocked 标记响应头已写出，未设置状态码时为200
+func (w *timeoutWriter) writeHeaderLocked() {
+	if w.wroteHeader {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	w.writeHeaderLocked()
+	return w.buf.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if !w.wroteHeader {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.ResponseWriter.Written()
+	}
+	return w.wroteHeader
+}
+
+// Flush 写出已缓冲的内容并切换为直接写出，此后超时不再返回504
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	if !w.streaming {
+		w.writeHeaderLocked()
+		w.commitLocked()
+		w.streaming = true
+	}
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTimeoutServer(t *testing.T, timeout time.Duration, path string, handler gin.HandlerFunc) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Timeout(timeout))
+	r.GET(path, handler)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTimeoutRespondsBeforeSlowHandlerFinishes(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	srv := newTimeoutServer(t, 50*time.Millisecond, "/slow", func(c *gin.Context) {
+		defer close(finished)
+		// 不检查上下文的处理器
+		<-release
+		c.String(http.StatusOK, "late")
+	})
+
+	resp, err := http.Get(srv.URL + "/slow")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	select {
+	case <-finished:
+		t.Fatal("处理器结束前就应该收到响应")
+	default:
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", resp.StatusCode)
+	}
+	close(release)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) == "late" {
+		t.Fatal("超时后处理器的写入不应出现在响应中")
+	}
+	<-finished
+}
+
+func TestTimeoutOverridesHandlerErrorAfterDeadline(t *testing.T) {
+	srv := newTimeoutServer(t, 20*time.Millisecond, "/ctx", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": c.Request.Context().Err().Error()})
+	})
+
+	resp, err := http.Get(srv.URL + "/ctx")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", resp.StatusCode)
+	}
+}
+
+func TestTimeoutKeepsFlushedStreamAlive(t *testing.T) {
+	srv := newTimeoutServer(t, 30*time.Millisecond, "/stream", func(c *gin.Context) {
+		for i := 0; i < 3; i++ {
+			c.Writer.WriteString("chunk\n")
+			c.Writer.Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+	})
+
+	resp, err := http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if string(body) != "chunk\nchunk\nchunk\n" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestTimeoutPassesFastResponseThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Timeout(time.Second))
+	r.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Test", "1")
+		c.String(http.StatusCreated, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+	if w.Header().Get("X-Test") != "1" || w.Body.String() != "ok" {
+		t.Fatalf("header = %q, body = %q", w.Header().Get("X-Test"), w.Body.String())
+	}
+}
@@ -1,8 +1,14 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+
+	"go-app/ctxkeys"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -28,7 +34,64 @@ func ValidateJSON(model interface{}) gin.HandlerFunc {
 		}
 
 		// 将验证后的模型存储到上下文中，以便后续处理
-		c.Set("validatedData", modelValue)
+		ctxkeys.SetValidatedData(c, modelValue)
+		c.Next()
+	}
+}
+
+// IndexedError 批量请求中单个元素的校验错误
+type IndexedError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ValidateJSONSlice 数组请求体校验中间件，用于批量接口
+// 将JSON数组绑定为模型类型的切片，并逐个元素执行校验，返回带下标的错误信息
+// 用法示例: admin.POST("/users/batch", ValidateJSONSlice(&RegisterRequest{}), controller.BatchRegister)
+// 校验通过后，上下文中的 validatedData 为指向切片的指针（如 *[]RegisterRequest）
+func ValidateJSONSlice(model interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 创建模型类型的切片实例
+		modelType := reflect.TypeOf(model)
+		if modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		slicePtr := reflect.New(reflect.SliceOf(modelType))
+
+		// 解析请求体（此处只解析，不做校验）
+		if c.Request.Body == nil {
+			ErrorWrapper(c, http.StatusBadRequest, 400, "参数验证失败", errors.New("请求体不能为空"))
+			return
+		}
+		if err := json.NewDecoder(c.Request.Body).Decode(slicePtr.Interface()); err != nil {
+			ErrorWrapper(c, http.StatusBadRequest, 400, "参数验证失败", err)
+			return
+		}
+
+		slice := slicePtr.Elem()
+		if slice.Len() == 0 {
+			ErrorWrapper(c, http.StatusBadRequest, 400, "参数验证失败", errors.New("请求数组不能为空"))
+			return
+		}
+
+		// 逐个元素校验，收集所有元素的错误
+		var errs []IndexedError
+		for i := 0; i < slice.Len(); i++ {
+			if err := binding.Validator.ValidateStruct(slice.Index(i).Addr().Interface()); err != nil {
+				errs = append(errs, IndexedError{Index: i, Error: err.Error()})
+			}
+		}
+		if len(errs) > 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Code:    400,
+				Message: "参数验证失败",
+				Details: errs,
+			})
+			return
+		}
+
+		// 将验证后的切片存储到上下文中，以便后续处理
+		ctxkeys.SetValidatedData(c, slicePtr.Interface())
 		c.Next()
 	}
 }
@@ -51,7 +114,7 @@ func ValidateQuery(model interface{}) gin.HandlerFunc {
 		}
 
 		// 将验证后的模型存储到上下文中，以便后续处理
-		c.Set("validatedQuery", modelValue)
+		ctxkeys.SetValidatedQuery(c, modelValue)
 		c.Next()
 	}
 }
@@ -74,31 +137,128 @@ func ValidateParams(model interface{}) gin.HandlerFunc {
 		}
 
 		// 将验证后的模型存储到上下文中，以便后续处理
-		c.Set("validatedParams", modelValue)
+		ctxkeys.SetValidatedParams(c, modelValue)
 		c.Next()
 	}
 }
 
-// GetValidatedData 从上下文中获取验证后的数据
+// GetValidatedData 从上下文中获取验证后的数据，未经过校验中间件时返回nil
 func GetValidatedData(c *gin.Context) interface{} {
-	return c.MustGet("validatedData")
+	data, _ := ctxkeys.ValidatedData(c)
+	return data
 }
 
-// GetValidatedQuery 从上下文中获取验证后的查询参数
+// GetValidatedQuery 从上下文中获取验证后的查询参数，未经过校验中间件时返回nil
 func GetValidatedQuery(c *gin.Context) interface{} {
-	return c.MustGet("validatedQuery")
+	query, _ := ctxkeys.ValidatedQuery(c)
+	return query
 }
 
-// GetValidatedParams 从上下文中获取验证后的路径参数
+// GetValidatedParams 从上下文中获取验证后的路径参数，未经过校验中间件时返回nil
 func GetValidatedParams(c *gin.Context) interface{} {
-	return c.MustGet("validatedParams")
+	params, _ := ctxkeys.ValidatedParams(c)
+	return params
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段名，与请求JSON中的字段名一致
+	Rule    string `json:"rule"`    // 未通过的校验规则，如 required、email、password_strength
+	Message string `json:"message"` // 错误说明
+}
+
+/*
+ValidationDetails 将参数绑定错误转换为逐个字段的错误列表
+err: ShouldBindJSON 等返回的错误
+返回: 字段错误列表；err 不是字段校验错误（如JSON格式错误）时返回nil
+*/
+func ValidationDetails(err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	details := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		details = append(details, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+	return details
+}
+
+/*
+BindErrorResponse 根据参数绑定错误生成400响应
+字段校验错误在 details 中逐个列出，其他错误（如JSON格式错误）只返回错误信息
+*/
+func BindErrorResponse(err error) ErrorResponse {
+	if details := ValidationDetails(err); details != nil {
+		return ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "参数验证失败",
+			Details: details,
+		}
+	}
+	return ErrorResponse{
+		Code:    http.StatusBadRequest,
+		Message: "请求参数错误",
+		Error:   err.Error(),
+	}
+}
+
+// fieldErrorMessage 常用校验规则的错误说明
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "不能为空"
+	case "email":
+		return "邮箱格式不正确"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能少于%s个字符", fe.Param())
+		}
+		return "不能小于" + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能超过%s个字符", fe.Param())
+		}
+		return "不能大于" + fe.Param()
+	case "oneof":
+		return "取值必须是以下之一: " + fe.Param()
+	case "password_strength":
+		if s, ok := fe.Value().(string); ok {
+			if err := CheckPasswordStrength(s); err != nil {
+				return err.Error()
+			}
+		}
+		return "密码强度不足"
+	default:
+		return "校验规则 " + fe.Tag() + " 未通过"
+	}
+}
+
+// passwordStrength 密码强度校验规则，规则见 CheckPasswordStrength
+func passwordStrength(fl validator.FieldLevel) bool {
+	return CheckPasswordStrength(fl.Field().String()) == nil
+}
+
+// jsonFieldName 校验错误中使用JSON字段名，与客户端提交的字段一致
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" || name == "" {
+		return field.Name
+	}
+	return name
 }
 
 // 自定义验证器初始化
 func init() {
 	// 获取验证器实例
-	if _, ok := binding.Validator.Engine().(*validator.Validate); ok {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
 		// 注册自定义验证规则
-		// 例如: v.RegisterValidation("is_valid_name", isValidName)
+		_ = v.RegisterValidation("password_strength", passwordStrength)
 	}
 }
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchItem struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+func newSliceEngine(handled *[]batchItem) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/batch", ValidateJSONSlice(&batchItem{}), func(c *gin.Context) {
+		*handled = *GetValidatedData(c).(*[]batchItem)
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestValidateJSONSliceReportsInvalidIndex(t *testing.T) {
+	var handled []batchItem
+	r := newSliceEngine(&handled)
+
+	body := `[{"name": "a", "email": "a@example.com"}, {"name": "b", "email": "not-an-email"}]`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var resp struct {
+		Details []IndexedError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Details) != 1 || resp.Details[0].Index != 1 {
+		t.Fatalf("details = %+v, want a single error for index 1", resp.Details)
+	}
+	if handled != nil {
+		t.Error("handler ran although validation failed")
+	}
+}
+
+func TestValidateJSONSlicePassesValidArray(t *testing.T) {
+	var handled []batchItem
+	r := newSliceEngine(&handled)
+
+	body := `[{"name": "a", "email": "a@example.com"}, {"name": "b", "email": "b@example.com"}]`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if len(handled) != 2 || handled[1].Name != "b" {
+		t.Fatalf("handler received %+v", handled)
+	}
+}
+
+func TestValidateJSONSliceRejectsEmptyAndNonArray(t *testing.T) {
+	var handled []batchItem
+	r := newSliceEngine(&handled)
+
+	for _, body := range []string{`[]`, `{"name": "a"}`} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
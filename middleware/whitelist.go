@@ -1,26 +1,45 @@
 package middleware
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	pathpkg "path"
+	"strings"
+	"sync"
 
 	"go-app/config"
+	"go-app/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // WhitelistConfig 白名单配置
+// IPWhitelist 和 PathWhitelist 只是构建查找结构时的初始列表，运行时的增删只作用于查找结构；
+// 查找结构自带读写锁，可以在中间件处理请求的同时通过 Add/Remove 系列函数修改
 type WhitelistConfig struct {
-	// IP白名单列表
+	// IP白名单初始列表，当前生效的白名单请使用 ListIPWhitelist 获取
 	IPWhitelist []string
-	// 路径白名单列表（不需要验证的路径）
+	// 路径白名单初始列表（不需要验证的路径），以"*"结尾的条目按前缀匹配，如 /static/* 匹配 /static/img/logo.png
 	PathWhitelist []string
 	// 是否启用IP白名单
 	EnableIPWhitelist bool
 	// 是否启用路径白名单
 	EnablePathWhitelist bool
+	// 白名单IP是否豁免限流（账户级的登录锁定仍然生效）
+	ExemptRateLimit bool
+	// IP白名单最大条目数，0表示不限制
+	MaxEntries int
+
+	// IP白名单查找结构，由 IPWhitelist 构建
+	ips *ipSet
+	// 路径白名单查找结构，由 PathWhitelist 构建
+	paths *pathSet
 }
 
 // DefaultWhitelistConfig 默认白名单配置
+// 应用启动时（开始处理请求之前）替换为实际生效的配置，之后不再整体替换；Add/Remove 系列函数修改的是它的查找结构
 var DefaultWhitelistConfig = WhitelistConfig{
 	IPWhitelist:         []string{},
 	PathWhitelist:       []string{},
@@ -28,38 +47,78 @@ var DefaultWhitelistConfig = WhitelistConfig{
 	EnablePathWhitelist: false,
 }
 
-// NewWhitelistConfig 从应用配置创建白名单配置
+func init() {
+	DefaultWhitelistConfig.buildSets()
+}
+
+// NewWhitelistConfig 从应用配置创建白名单配置，并构建IP查找结构
 func NewWhitelistConfig(cfg *config.Config) WhitelistConfig {
-	return WhitelistConfig{
+	conf := WhitelistConfig{
 		IPWhitelist:         cfg.Whitelist.IPWhitelist,
 		PathWhitelist:       cfg.Whitelist.PathWhitelist,
 		EnableIPWhitelist:   cfg.Whitelist.EnableIPWhitelist,
 		EnablePathWhitelist: cfg.Whitelist.EnablePathWhitelist,
+		ExemptRateLimit:     cfg.Whitelist.ExemptRateLimit,
+		MaxEntries:          cfg.Whitelist.MaxEntries,
+	}
+	conf.buildSets()
+	return conf
+}
+
+// buildSets 根据白名单列表构建查找结构，超出最大条目数或格式错误的条目会被忽略并记录日志
+// 查找结构以指针形式共享，配置被复制后对白名单的修改仍然对所有副本生效
+func (w *WhitelistConfig) buildSets() {
+	ips, err := newIPSet(w.IPWhitelist, w.MaxEntries)
+	if err != nil {
+		utils.Warn("IP白名单加载不完整", zap.Error(err))
+	}
+	w.ips = ips
+	w.paths = newPathSet(w.PathWhitelist)
+}
+
+// ContainsIP 判断IP是否在白名单中（含CIDR网段）
+func (w WhitelistConfig) ContainsIP(ip string) bool {
+	if w.ips != nil {
+		return w.ips.Contains(ip)
+	}
+	return IsIPInWhitelist(ip, w.IPWhitelist)
+}
+
+// ContainsPath 判断路径是否在白名单中
+func (w WhitelistConfig) ContainsPath(path string) bool {
+	if w.paths != nil {
+		return w.paths.Contains(path)
 	}
+	return IsPathInWhitelist(path, w.PathWhitelist)
+}
+
+// IsRateLimitExempt 判断IP是否豁免限流
+// 仅当开启 ExemptRateLimit 时，白名单中的IP（含CIDR网段）才会豁免
+func (w WhitelistConfig) IsRateLimitExempt(ip string) bool {
+	return w.ExemptRateLimit && w.ContainsIP(ip)
 }
 
 // Whitelist 白名单中间件
 func Whitelist(config WhitelistConfig) gin.HandlerFunc {
+	// 查找结构只构建一次，避免每个请求线性扫描
+	if config.ips == nil || config.paths == nil {
+		config.buildSets()
+	}
+
 	return func(c *gin.Context) {
 		// 检查路径白名单
 		if config.EnablePathWhitelist {
-			path := c.Request.URL.Path
-			for _, whitelistPath := range config.PathWhitelist {
-				if path == whitelistPath {
-					c.Next()
-					return
-				}
+			if config.ContainsPath(c.Request.URL.Path) {
+				c.Next()
+				return
 			}
 		}
 
 		// 检查IP白名单
 		if config.EnableIPWhitelist {
-			clientIP := c.ClientIP()
-			for _, whitelistIP := range config.IPWhitelist {
-				if clientIP == whitelistIP {
-					c.Next()
-					return
-				}
+			if config.ContainsIP(c.ClientIP()) {
+				c.Next()
+				return
 			}
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"code":    403,
@@ -72,52 +131,212 @@ func Whitelist(config WhitelistConfig) gin.HandlerFunc {
 	}
 }
 
-// IsIPInWhitelist 检查IP是否在白名单中
+// WhitelistedIPOnly 仅允许白名单中的IP访问，无论是否启用全局IP白名单
+// 用于调试类接口，白名单为空时所有请求都被拒绝
+func WhitelistedIPOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !DefaultWhitelistConfig.ContainsIP(c.ClientIP()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "IP地址不在白名单中",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsIPInWhitelist 检查IP是否在白名单中，白名单条目支持单个IP和CIDR网段（如 10.0.0.0/8）
+// 该函数逐条扫描列表，适合临时判断；中间件中请使用 WhitelistConfig.ContainsIP
 func IsIPInWhitelist(ip string, whitelist []string) bool {
+	parsed := net.ParseIP(ip)
 	for _, whitelistIP := range whitelist {
 		if ip == whitelistIP {
 			return true
 		}
+		if parsed != nil && strings.Contains(whitelistIP, "/") {
+			if _, ipNet, err := net.ParseCIDR(whitelistIP); err == nil && ipNet.Contains(parsed) {
+				return true
+			}
+		}
 	}
 	return false
 }
 
-// IsPathInWhitelist 检查路径是否在白名单中
+// IsPathInWhitelist 检查路径是否在白名单中，优先精确匹配，再匹配以"*"结尾的前缀条目
+// 该函数逐条扫描列表，适合临时判断；中间件中请使用 WhitelistConfig.ContainsPath
 func IsPathInWhitelist(path string, whitelist []string) bool {
 	for _, whitelistPath := range whitelist {
 		if path == whitelistPath {
 			return true
 		}
 	}
+
+	cleaned := cleanRequestPath(path)
+	for _, whitelistPath := range whitelist {
+		if prefix, ok := pathPrefix(whitelistPath); ok && strings.HasPrefix(cleaned, prefix) {
+			return true
+		}
+	}
 	return false
 }
 
-// AddToIPWhitelist 添加IP到白名单
-func AddToIPWhitelist(ip string) {
-	DefaultWhitelistConfig.IPWhitelist = append(DefaultWhitelistConfig.IPWhitelist, ip)
+// pathPrefix 返回通配符条目的前缀，如 /static/* 返回 /static/；条目不以"*"结尾时返回false
+func pathPrefix(pattern string) (string, bool) {
+	if !strings.HasSuffix(pattern, "*") {
+		return "", false
+	}
+	return strings.TrimSuffix(pattern, "*"), true
+}
+
+// cleanRequestPath 规范化请求路径后再做前缀匹配，避免 /static/../admin 这类路径借通配符条目绕过IP白名单
+// 保留末尾的"/"，使 /static/ 仍能匹配 /static/*
+func cleanRequestPath(path string) string {
+	cleaned := pathpkg.Clean("/" + path)
+	if strings.HasSuffix(path, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// ListIPWhitelist 返回当前生效的IP白名单
+func ListIPWhitelist() []string {
+	return DefaultWhitelistConfig.ips.List()
+}
+
+// ListPathWhitelist 返回当前生效的路径白名单
+func ListPathWhitelist() []string {
+	return DefaultWhitelistConfig.paths.List()
+}
+
+// AddToIPWhitelist 添加IP或CIDR网段到白名单，超出最大条目数时返回错误
+// 可以与中间件并发调用：只修改带锁的查找结构，不修改 DefaultWhitelistConfig 的字段
+func AddToIPWhitelist(ip string) error {
+	return DefaultWhitelistConfig.ips.Add(ip)
 }
 
 // AddToPathWhitelist 添加路径到白名单
-func AddToPathWhitelist(path string) {
-	DefaultWhitelistConfig.PathWhitelist = append(DefaultWhitelistConfig.PathWhitelist, path)
+func AddToPathWhitelist(path string) error {
+	return DefaultWhitelistConfig.paths.Add(path)
+}
+
+// RemoveFromIPWhitelist 从白名单中移除IP，返回条目是否存在
+func RemoveFromIPWhitelist(ip string) bool {
+	return DefaultWhitelistConfig.ips.Remove(ip)
+}
+
+// RemoveFromPathWhitelist 从白名单中移除路径，返回条目是否存在
+func RemoveFromPathWhitelist(path string) bool {
+	return DefaultWhitelistConfig.paths.Remove(path)
+}
+
+// pathSet 路径集合，保留添加顺序便于展示
+// 普通条目精确匹配；以"*"结尾的条目按前缀匹配，精确匹配优先
+type pathSet struct {
+	mu       sync.RWMutex
+	index    map[string]struct{}
+	prefixes []string // 通配符条目去掉"*"后的前缀，按长度降序
+	items    []string
 }
 
-// RemoveFromIPWhitelist 从白名单中移除IP
-func RemoveFromIPWhitelist(ip string) {
-	for i, whitelistIP := range DefaultWhitelistConfig.IPWhitelist {
-		if whitelistIP == ip {
-			DefaultWhitelistConfig.IPWhitelist = append(DefaultWhitelistConfig.IPWhitelist[:i], DefaultWhitelistConfig.IPWhitelist[i+1:]...)
+// newPathSet 根据路径列表创建路径集合
+func newPathSet(paths []string) *pathSet {
+	s := &pathSet{index: make(map[string]struct{})}
+	for _, p := range paths {
+		_ = s.Add(p)
+	}
+	return s
+}
+
+// Add 添加路径，路径必须以"/"开头，通配符"*"只能出现在末尾
+func (s *pathSet) Add(path string) error {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("路径必须以/开头: %s", path)
+	}
+	if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+		return fmt.Errorf("通配符*只能出现在路径末尾: %s", path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[path]; ok {
+		return nil
+	}
+	s.index[path] = struct{}{}
+	s.items = append(s.items, path)
+	if prefix, ok := pathPrefix(path); ok {
+		s.addPrefix(prefix)
+	}
+	return nil
+}
+
+// addPrefix 按长度降序插入前缀，调用方需持有写锁
+func (s *pathSet) addPrefix(prefix string) {
+	i := len(s.prefixes)
+	for i > 0 && len(s.prefixes[i-1]) < len(prefix) {
+		i--
+	}
+	s.prefixes = append(s.prefixes, "")
+	copy(s.prefixes[i+1:], s.prefixes[i:])
+	s.prefixes[i] = prefix
+}
+
+// Remove 移除路径，返回路径是否存在
+func (s *pathSet) Remove(path string) bool {
+	path = strings.TrimSpace(path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[path]; !ok {
+		return false
+	}
+	delete(s.index, path)
+	for i, p := range s.items {
+		if p == path {
+			s.items = append(s.items[:i], s.items[i+1:]...)
 			break
 		}
 	}
+	if prefix, ok := pathPrefix(path); ok {
+		for i, p := range s.prefixes {
+			if p == prefix {
+				s.prefixes = append(s.prefixes[:i], s.prefixes[i+1:]...)
+				break
+			}
+		}
+	}
+	return true
 }
 
-// RemoveFromPathWhitelist 从白名单中移除路径
-func RemoveFromPathWhitelist(path string) {
-	for i, whitelistPath := range DefaultWhitelistConfig.PathWhitelist {
-		if whitelistPath == path {
-			DefaultWhitelistConfig.PathWhitelist = append(DefaultWhitelistConfig.PathWhitelist[:i], DefaultWhitelistConfig.PathWhitelist[i+1:]...)
-			break
+// Contains 判断路径是否在集合中，先精确匹配，再按最长前缀匹配通配符条目
+func (s *pathSet) Contains(path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.index[path]; ok {
+		return true
+	}
+	if len(s.prefixes) == 0 {
+		return false
+	}
+	cleaned := cleanRequestPath(path)
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(cleaned, prefix) {
+			return true
 		}
 	}
+	return false
+}
+
+// List 返回集合中的所有路径
+func (s *pathSet) List() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]string, len(s.items))
+	copy(result, s.items)
+	return result
 }
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pathMatchCases = []struct {
+	path string
+	want bool
+}{
+	{"/ping", true},
+	{"/ping/", false},
+	{"/pingx", false},
+	{"/static/img/logo.png", true},
+	{"/static/", true},
+	{"/static", false},
+	{"/staticfoo", false},
+	{"/static/../admin", false},
+	{"/static/./img/../css/app.css", true},
+	{"/api/v1/public/docs/index.html", true},
+	{"/api/v1/publicity", false},
+	{"/admin", false},
+}
+
+var pathMatchWhitelist = []string{"/ping", "/static/*", "/api/v1/public/*"}
+
+func TestIsPathInWhitelist(t *testing.T) {
+	for _, tc := range pathMatchCases {
+		if got := IsPathInWhitelist(tc.path, pathMatchWhitelist); got != tc.want {
+			t.Errorf("IsPathInWhitelist(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestContainsPathMatchesScan(t *testing.T) {
+	conf := WhitelistConfig{PathWhitelist: pathMatchWhitelist}
+	conf.buildSets()
+	for _, tc := range pathMatchCases {
+		if got := conf.ContainsPath(tc.path); got != tc.want {
+			t.Errorf("ContainsPath(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestContainsPathExactBeforeWildcard(t *testing.T) {
+	// 精确条目按原始路径匹配，不经过规范化
+	conf := WhitelistConfig{PathWhitelist: []string{"/files/../health", "/files/*"}}
+	conf.buildSets()
+	if !conf.ContainsPath("/files/../health") {
+		t.Fatal("精确条目应该直接命中")
+	}
+	if conf.ContainsPath("/files/../admin") {
+		t.Fatal("规范化后离开通配符前缀的路径不应命中")
+	}
+
+	// 移除通配符条目后，其下的路径不再命中，精确条目不受影响
+	conf.paths.Remove("/files/*")
+	if conf.ContainsPath("/files/a.txt") {
+		t.Fatal("移除通配符条目后不应再命中")
+	}
+	if !conf.ContainsPath("/files/../health") {
+		t.Fatal("移除通配符条目不应影响精确条目")
+	}
+}
+
+func TestPathSetRejectsInvalidEntries(t *testing.T) {
+	s := newPathSet(nil)
+	for _, p := range []string{"static/*", "/static/*/img", "/*/x"} {
+		if err := s.Add(p); err == nil {
+			t.Errorf("Add(%q) 应该返回错误", p)
+		}
+	}
+	if len(s.List()) != 0 {
+		t.Fatalf("List() = %v, want empty", s.List())
+	}
+}
+
+func TestWhitelistPathBypassesIPCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Whitelist(WhitelistConfig{
+		IPWhitelist:         []string{"10.0.0.0/8"},
+		PathWhitelist:       []string{"/ping", "/static/*"},
+		EnableIPWhitelist:   true,
+		EnablePathWhitelist: true,
+	}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/ping", ok)
+	r.GET("/static/*file", ok)
+	r.GET("/admin", ok)
+
+	cases := []struct {
+		path, remote string
+		want         int
+	}{
+		{"/ping", "192.0.2.1:1234", http.StatusOK},
+		{"/static/img/logo.png", "192.0.2.1:1234", http.StatusOK},
+		{"/admin", "192.0.2.1:1234", http.StatusForbidden},
+		{"/admin", "10.1.2.3:1234", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.RemoteAddr = tc.remote
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("GET %s from %s: status = %d, want %d", tc.path, tc.remote, w.Code, tc.want)
+		}
+	}
+}
+
+func TestWhitelistPathIgnoredWhenDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Whitelist(WhitelistConfig{
+		IPWhitelist:       []string{"10.0.0.1"},
+		PathWhitelist:     []string{"/static/*"},
+		EnableIPWhitelist: true,
+	}))
+	r.GET("/static/*file", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", w.Code)
+	}
+}
+
+// TestWhitelistConcurrentChanges 在中间件处理请求的同时增删白名单条目，使用 go test -race 运行
+func TestWhitelistConcurrentChanges(t *testing.T) {
+	saved := DefaultWhitelistConfig
+	t.Cleanup(func() { DefaultWhitelistConfig = saved })
+	DefaultWhitelistConfig = WhitelistConfig{
+		IPWhitelist:         []string{"10.0.0.1"},
+		PathWhitelist:       []string{"/ping"},
+		EnableIPWhitelist:   true,
+		EnablePathWhitelist: true,
+		ExemptRateLimit:     true,
+	}
+	DefaultWhitelistConfig.buildSets()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Whitelist(DefaultWhitelistConfig))
+	r.Use(RateLimit(RateLimitConfig{Enable: true, Rate: 1000, Burst: 1000}))
+	r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const rounds = 200
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			ip := "10.1.0." + strconv.Itoa(i%250)
+			if err := AddToIPWhitelist(ip); err != nil {
+				t.Errorf("AddToIPWhitelist(%s): %v", ip, err)
+				return
+			}
+			_ = AddToIPWhitelist("192.168.0.0/16")
+			ListIPWhitelist()
+			RemoveFromIPWhitelist(ip)
+			RemoveFromIPWhitelist("192.168.0.0/16")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			if err := AddToPathWhitelist("/public/*"); err != nil {
+				t.Errorf("AddToPathWhitelist: %v", err)
+				return
+			}
+			ListPathWhitelist()
+			RemoveFromPathWhitelist("/public/*")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			serveFromIP(r, "/public/a", "192.168.1.1")
+			serveFromIP(r, "/admin", "10.1.0."+strconv.Itoa(i%250))
+			if w := serveFromIP(r, "/admin", "10.0.0.1"); w.Code != http.StatusOK {
+				t.Errorf("初始白名单IP被拒绝: status = %d", w.Code)
+				return
+			}
+			if w := serveFromIP(r, "/ping", "203.0.113.1"); w.Code != http.StatusOK {
+				t.Errorf("初始白名单路径被拒绝: status = %d", w.Code)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	// 并发增删结束后只剩初始条目
+	if got := ListIPWhitelist(); len(got) != 1 || got[0] != "10.0.0.1" {
+		t.Fatalf("ListIPWhitelist() = %v, want [10.0.0.1]", got)
+	}
+	if got := ListPathWhitelist(); len(got) != 1 || got[0] != "/ping" {
+		t.Fatalf("ListPathWhitelist() = %v, want [/ping]", got)
+	}
+	if w := serveFromIP(r, "/admin", "10.1.0.1"); w.Code != http.StatusForbidden {
+		t.Fatalf("移除后的IP: status = %d, want 403", w.Code)
+	}
+}
+
+func TestIsRateLimitExempt(t *testing.T) {
+	conf := WhitelistConfig{
+		IPWhitelist:       []string{"10.0.0.0/8", "192.0.2.7"},
+		EnableIPWhitelist: true,
+		ExemptRateLimit:   true,
+	}
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.0.2.7", true},
+		{"192.0.2.1", false},
+		{"not-an-ip", false},
+	}
+	for _, tc := range cases {
+		if got := conf.IsRateLimitExempt(tc.ip); got != tc.want {
+			t.Errorf("IsRateLimitExempt(%q) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+
+	// 未开启豁免时白名单IP同样限流
+	conf.ExemptRateLimit = false
+	if conf.IsRateLimitExempt("10.1.2.3") {
+		t.Fatal("未开启豁免时不应豁免限流")
+	}
+}
@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API密钥权限范围
+const (
+	ScopeRead  = "read"  // 只读接口（GET、HEAD、OPTIONS）
+	ScopeWrite = "write" // 写接口（POST、PUT、PATCH、DELETE）
+)
+
+// KeyPrefix API密钥的固定前缀，便于识别和密钥扫描
+const KeyPrefix = "gak_"
+
+/*
+* API密钥实体
+* 只保存密钥的SHA-256哈希，明文仅在创建时返回一次
+ */
+type APIKey struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     uint               `json:"user_id" bson:"user_id"` // 所属用户ID
+	Name       string             `json:"name" bson:"name"`       // 密钥名称，便于区分用途
+	KeyHash    string             `json:"-" bson:"key_hash"`      // 密钥哈希
+	Prefix     string             `json:"prefix" bson:"prefix"`   // 密钥前几位，用于展示
+	Scopes     []string           `json:"scopes" bson:"scopes"`   // 权限范围
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+/*
+返回API密钥集合名称
+返回: 集合名称
+*/
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope 判断密钥是否拥有指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
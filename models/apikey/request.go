@@ -0,0 +1,7 @@
+package apikey
+
+// CreateRequest 创建API密钥请求
+type CreateRequest struct {
+	Name   string   `json:"name" binding:"required,max=50"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+}
@@ -0,0 +1,31 @@
+package apikey
+
+import "time"
+
+// Response API密钥响应，不包含密钥本身
+type Response struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CreateResponse 创建API密钥响应，Key 仅在创建时返回一次
+type CreateResponse struct {
+	*Response
+	Key string `json:"key"`
+}
+
+// ToResponse 将API密钥实体转换为响应
+func (k *APIKey) ToResponse() *Response {
+	return &Response{
+		ID:         k.ID.Hex(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 审计操作类型
+const (
+	ActionUserLogin          = "user.login"                // 登录成功
+	ActionPasswordChange     = "user.change_password"      // 修改密码
+	ActionPasswordReset      = "user.reset_password"       // 使用重置令牌设置新密码
+	ActionPasswordForceReset = "user.force_password_reset" // 管理员强制重置密码
+	ActionUserMerge          = "user.merge"                // 合并用户账户
+	ActionUserExport         = "user.export"               // 导出个人数据
+	ActionUserRestore        = "user.restore"              // 恢复已删除用户
+	ActionUserHardDelete     = "user.hard_delete"          // 永久删除用户
+	ActionUserBulkUpdate     = "user.bulk_update"          // 批量更新用户
+	ActionUserBulkVerify     = "user.bulk_verify"          // 批量标记邮箱已验证
+	ActionUserBatchRegister  = "user.batch_register"       // 批量创建用户
+	ActionWhitelistAdd       = "whitelist.add"             // 添加白名单条目
+	ActionWhitelistRemove    = "whitelist.remove"          // 移除白名单条目
+	ActionPasswordRehash     = "security.rehash_passwords" // 批量迁移明文和旧格式密码
+	ActionSystemReadOnly     = "system.read_only"          // 开启或关闭只读模式
+	ActionSystemLogLevel     = "system.log_level"          // 修改日志级别
+)
+
+/*
+* 审计日志实体
+* 记录用户或管理员执行的敏感操作
+ */
+type Entry struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	UserID    uint                   `json:"user_id" bson:"user_id"`                         // 审计记录所属用户
+	ActorID   uint                   `json:"actor_id" bson:"actor_id"`                       // 操作人ID
+	Action    string                 `json:"action" bson:"action"`                           // 操作类型
+	TargetID  uint                   `json:"target_id,omitempty" bson:"target_id,omitempty"` // 操作对象ID
+	Detail    map[string]interface{} `json:"detail,omitempty" bson:"detail,omitempty"`       // 操作详情
+	IP        string                 `json:"ip,omitempty" bson:"ip,omitempty"`               // 客户端IP
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+}
+
+/*
+返回审计日志集合名称
+返回: 集合名称
+*/
+func (Entry) TableName() string {
+	return "audit_logs"
+}
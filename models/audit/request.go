@@ -0,0 +1,9 @@
+package audit
+
+import "time"
+
+// ActivityFilter 个人操作记录的过滤条件，时间为nil表示不限制
+type ActivityFilter struct {
+	Since *time.Time // 开始时间（含）
+	Until *time.Time // 结束时间（不含）
+}
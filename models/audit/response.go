@@ -0,0 +1,21 @@
+package audit
+
+import "time"
+
+// ActivityResponse 用户查看自己操作记录时返回的审计日志，不包含操作详情、操作人ID等内部字段
+type ActivityResponse struct {
+	Action    string    `json:"action"`
+	IP        string    `json:"ip,omitempty"`
+	ByAdmin   bool      `json:"by_admin"` // 是否由管理员操作（如恢复账户、批量修改状态）
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToActivityResponse 将审计日志转换为个人操作记录
+func (e *Entry) ToActivityResponse() *ActivityResponse {
+	return &ActivityResponse{
+		Action:    e.Action,
+		IP:        e.IP,
+		ByAdmin:   e.ActorID != 0 && e.ActorID != e.UserID,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToActivityResponseOmitsInternalFields(t *testing.T) {
+	e := &Entry{
+		UserID:   1,
+		ActorID:  9,
+		Action:   ActionUserRestore,
+		TargetID: 1,
+		Detail:   map[string]interface{}{"secret": "x"},
+		IP:       "10.0.0.1",
+	}
+
+	resp := e.ToActivityResponse()
+	if !resp.ByAdmin {
+		t.Error("由其他人操作的记录应标记为 by_admin")
+	}
+	body, _ := json.Marshal(resp)
+	for _, field := range []string{"actor_id", "target_id", "detail", "user_id", "secret"} {
+		if strings.Contains(string(body), field) {
+			t.Errorf("响应不应包含 %s: %s", field, body)
+		}
+	}
+
+	own := &Entry{UserID: 1, ActorID: 1, Action: ActionUserLogin}
+	if own.ToActivityResponse().ByAdmin {
+		t.Error("本人操作的记录不应标记为 by_admin")
+	}
+}
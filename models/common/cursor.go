@@ -0,0 +1,56 @@
+package common
+
+import (
+	"errors"
+
+	"go-app/utils"
+)
+
+// cursorVersion 游标格式版本，格式变化时递增，旧版本游标会被拒绝
+const cursorVersion = 1
+
+// ErrInvalidCursor 游标无效
+var ErrInvalidCursor = utils.ErrInvalidCursor
+
+// Cursor 游标内容，记录上一页最后一条数据的位置
+// 对客户端不透明，客户端只应原样传回 NextCursor
+type Cursor struct {
+	Version   int         `json:"v"`
+	SortValue interface{} `json:"s,omitempty"` // 排序字段的值
+	ID        interface{} `json:"id"`          // 唯一字段的值，用于排序字段相同时定位
+}
+
+/*
+EncodeCursor 将游标编码为不透明的字符串，游标带有签名，客户端无法篡改
+sortValue: 最后一条数据排序字段的值
+id: 最后一条数据唯一字段的值
+返回: base64编码的游标, 错误
+*/
+func EncodeCursor(sortValue, id interface{}) (string, error) {
+	cursor := utils.EncodeCursor(Cursor{
+		Version:   cursorVersion,
+		SortValue: sortValue,
+		ID:        id,
+	})
+	if cursor == "" {
+		return "", errors.New("游标编码失败")
+	}
+	return cursor, nil
+}
+
+/*
+DecodeCursor 解码并校验游标
+cursor: EncodeCursor 生成的字符串
+返回: 游标内容, 错误（格式、签名、版本或字段不合法时返回 ErrInvalidCursor）
+*/
+func DecodeCursor(cursor string) (*Cursor, error) {
+	var c Cursor
+	if err := utils.DecodeCursor(cursor, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Version != cursorVersion || c.ID == nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
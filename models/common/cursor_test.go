@@ -0,0 +1,58 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-app/utils"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	s, err := EncodeCursor("2026-01-01T00:00:00Z", float64(42))
+	if err != nil {
+		t.Fatalf("EncodeCursor: %v", err)
+	}
+
+	c, err := DecodeCursor(s)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if c.SortValue != "2026-01-01T00:00:00Z" || fmt.Sprint(c.ID) != "42" {
+		t.Fatalf("cursor = %+v", c)
+	}
+}
+
+func TestCursorRejectsInvalid(t *testing.T) {
+	s, _ := EncodeCursor(nil, float64(42))
+	// 修改签名的第一个字符；最后一个字符含有解码时忽略的低位，修改后签名可能不变
+	i := strings.Index(s, ".") + 1
+	flipped := "A"
+	if s[i] == 'A' {
+		flipped = "B"
+	}
+	tampered := s[:i] + flipped + s[i+1:]
+	oldVersion := utils.EncodeCursor(Cursor{Version: cursorVersion + 1, ID: 1})
+	missingID := utils.EncodeCursor(Cursor{Version: cursorVersion})
+
+	cases := map[string]string{
+		"篡改签名":   tampered,
+		"非游标字符串": "42",
+		"版本不符":   oldVersion,
+		"缺少ID":   missingID,
+	}
+	for name, input := range cases {
+		if _, err := DecodeCursor(input); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestCursorPaginatedResponseJSON(t *testing.T) {
+	data, _ := json.Marshal(NewCursorPaginatedResponse([]int{1}, "", false))
+	if string(data) != `{"data":[1],"has_more":false}` {
+		t.Fatalf("JSON = %s", data)
+	}
+}
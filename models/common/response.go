@@ -2,9 +2,10 @@ package common
 
 // Response 通用响应结构
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 // NewResponse 创建新的响应
@@ -33,20 +34,49 @@ func ErrorResponse(code int, message string) *Response {
 	}
 }
 
+// WithRequestID 设置响应中的请求ID
+func (r *Response) WithRequestID(requestID string) *Response {
+	r.RequestID = requestID
+	return r
+}
+
 // PaginatedResponse 分页响应结构
 type PaginatedResponse struct {
-	Total    int64       `json:"total"`
-	Page     int         `json:"page"`
-	PageSize int         `json:"page_size"`
-	Data     interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	TotalPages int         `json:"total_pages"`
+	Data       interface{} `json:"data"`
 }
 
-// NewPaginatedResponse 创建新的分页响应
+// NewPaginatedResponse 创建新的分页响应，并根据总数和每页条数计算总页数
 func NewPaginatedResponse(total int64, page, pageSize int, data interface{}) *PaginatedResponse {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
 	return &PaginatedResponse{
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-		Data:     data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		Data:       data,
+	}
+}
+
+// CursorPaginatedResponse 游标分页响应结构
+type CursorPaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// NewCursorPaginatedResponse 创建新的游标分页响应
+func NewCursorPaginatedResponse(data interface{}, nextCursor string, hasMore bool) *CursorPaginatedResponse {
+	return &CursorPaginatedResponse{
+		Data:       data,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
 	}
 }
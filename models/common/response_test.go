@@ -0,0 +1,24 @@
+package common
+
+import "testing"
+
+func TestNewPaginatedResponseTotalPages(t *testing.T) {
+	tests := []struct {
+		total    int64
+		pageSize int
+		want     int
+	}{
+		{0, 10, 0},
+		{1, 10, 1},
+		{10, 10, 1},
+		{11, 10, 2},
+		{25, 10, 3},
+		{5, 0, 0},
+	}
+	for _, tt := range tests {
+		got := NewPaginatedResponse(tt.total, 1, tt.pageSize, nil)
+		if got.TotalPages != tt.want {
+			t.Errorf("total=%d pageSize=%d: TotalPages = %d, want %d", tt.total, tt.pageSize, got.TotalPages, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package health
+
+// 健康状态
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// 依赖名称
+const (
+	DependencyMongoDB    = "mongodb"     // MongoDB主节点，不可用时未就绪
+	DependencyMongoDBAny = "mongodb_any" // MongoDB任一成员，仅展示连通性，不影响就绪状态
+)
+
+// DependencyStatus 单个依赖的检查结果
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	Database  string  `json:"database,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessResponse 就绪检查结果，必需的依赖（MongoDB主节点）不可用时 Status 为 unavailable
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
@@ -0,0 +1,32 @@
+package security
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+* 登录失败事件实体
+* 用于安全监控，用户名只保存带密钥的哈希，不保存原始值
+ */
+type FailedLogin struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UsernameHash string             `json:"username_hash" bson:"username_hash"` // 用户名的HMAC-SHA256
+	IP           string             `json:"ip" bson:"ip"`                       // 客户端IP
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+/*
+返回登录失败事件集合名称
+返回: 集合名称
+*/
+func (FailedLogin) TableName() string {
+	return "failed_logins"
+}
+
+// 统计时间粒度
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
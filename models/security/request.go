@@ -0,0 +1,11 @@
+package security
+
+import "time"
+
+// FailedLoginQuery 登录失败统计查询参数
+// 时间使用RFC3339格式，默认统计最近24小时；按小时统计最长7天，按天统计最长366天
+type FailedLoginQuery struct {
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Bucket string    `form:"bucket" binding:"omitempty,oneof=hour day"`
+}
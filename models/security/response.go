@@ -0,0 +1,18 @@
+package security
+
+import "time"
+
+// FailedLoginBucket 单个时间段内的登录失败次数
+type FailedLoginBucket struct {
+	Start time.Time `json:"start" bson:"_id"`
+	Count int64     `json:"count" bson:"count"`
+}
+
+// FailedLoginStatsResponse 登录失败统计响应
+type FailedLoginStatsResponse struct {
+	From    time.Time           `json:"from"`
+	To      time.Time           `json:"to"`
+	Bucket  string              `json:"bucket"`
+	Total   int64               `json:"total"`
+	Buckets []FailedLoginBucket `json:"buckets"`
+}
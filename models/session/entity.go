@@ -0,0 +1,28 @@
+package session
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+* 登录会话实体
+* 每次登录创建一个会话，令牌的 jti 即会话的 TokenID；会话被吊销后对应的令牌立即失效
+ */
+type Session struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    uint               `json:"user_id" bson:"user_id"` // 所属用户ID
+	TokenID   string             `json:"-" bson:"token_id"`      // 令牌ID（jti）
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"` // 与令牌过期时间一致，过期后由TTL索引清理
+	RevokedAt *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+/*
+返回会话集合名称
+返回: 集合名称
+*/
+func (Session) TableName() string {
+	return "sessions"
+}
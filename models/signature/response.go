@@ -0,0 +1,10 @@
+package signature
+
+// VerifyResponse 签名校验结果
+type VerifyResponse struct {
+	Valid     bool   `json:"valid"`
+	Reason    string `json:"reason,omitempty"` // 校验失败的原因
+	Algorithm string `json:"algorithm"`        // 服务端使用的签名算法
+	// 服务端计算签名时使用的原始字符串（密钥已隐藏），仅在debug模式下返回
+	SignString string `json:"sign_string,omitempty"`
+}
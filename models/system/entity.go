@@ -0,0 +1,30 @@
+package system
+
+import (
+	"time"
+)
+
+// 系统设置ID
+const (
+	SettingReadOnly = "read_only" // 只读模式
+)
+
+/*
+* 只读模式设置实体
+* 保存在共享的系统设置集合中，所有实例定期同步，开启后拒绝所有写请求
+ */
+type ReadOnlySetting struct {
+	ID        string    `json:"-" bson:"_id"`
+	Enabled   bool      `json:"enabled" bson:"enabled"`                   // 是否开启只读模式
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"` // 开启原因
+	UpdatedBy uint      `json:"updated_by" bson:"updated_by"`             // 最后修改人ID
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+/*
+返回系统设置集合名称
+返回: 集合名称
+*/
+func (ReadOnlySetting) TableName() string {
+	return "system_settings"
+}
@@ -0,0 +1,12 @@
+package system
+
+// ReadOnlyRequest 切换只读模式请求
+type ReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason" binding:"max=200"`
+}
+
+// LogLevelRequest 修改日志级别请求
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error"`
+}
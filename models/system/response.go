@@ -0,0 +1,33 @@
+package system
+
+import "time"
+
+// ReadOnlyResponse 只读模式状态响应
+type ReadOnlyResponse struct {
+	Enabled   bool       `json:"enabled"`
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedBy uint       `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// LogLevelResponse 日志级别响应
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// ToResponse 转换为响应对象，从未设置过时只返回关闭状态
+func (s *ReadOnlySetting) ToResponse() *ReadOnlyResponse {
+	if s == nil {
+		return &ReadOnlyResponse{}
+	}
+	resp := &ReadOnlyResponse{
+		Enabled:   s.Enabled,
+		Reason:    s.Reason,
+		UpdatedBy: s.UpdatedBy,
+	}
+	if !s.UpdatedAt.IsZero() {
+		updatedAt := s.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
@@ -10,16 +10,65 @@ import (
 * 返回: 用户实体模型
  */
 type User struct {
-	ID        uint      `json:"id" bson:"id"`
-	Username  string    `json:"username" bson:"username"`
-	Email     string    `json:"email" bson:"email"`
+	ID       uint   `json:"id" bson:"id"`
+	Username string `json:"username" bson:"username"`
+	Email    string `json:"email" bson:"email"`
+	// 邮箱加密存储时用于精确匹配查询的哈希
+	EmailHash string    `json:"-" bson:"email_hash,omitempty"`
 	Password  string    `json:"-" bson:"password"`
 	Nickname  string    `json:"nickname" bson:"nickname"`
 	Avatar    string    `json:"avatar" bson:"avatar"`
 	Status    int       `json:"status" bson:"status"`
+	Role      string    `json:"role" bson:"role"` // 角色：user 或 admin
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 	Deleted   bool      `json:"-" bson:"deleted"`
+	// 软删除时间，未删除时为空
+	DeletedAt *time.Time `json:"-" bson:"deleted_at,omitempty"`
+	// 密码无法自动迁移（如旧系统的未知哈希），需要用户重置密码
+	PasswordResetRequired bool `json:"-" bson:"password_reset_required"`
+	// 密码最近一次修改或被管理员强制失效的时间，此前签发的令牌一律失效
+	PasswordChangedAt *time.Time `json:"-" bson:"password_changed_at,omitempty"`
+	// 密码重置令牌的SHA-256哈希，令牌明文只出现在重置邮件或管理员的响应中
+	PasswordResetTokenHash string `json:"-" bson:"password_reset_token_hash,omitempty"`
+	// 密码重置令牌的过期时间
+	PasswordResetExpiresAt *time.Time `json:"-" bson:"password_reset_expires_at,omitempty"`
+	// 连续登录失败次数，登录成功或账户被锁定后清零
+	FailedLoginCount int `json:"-" bson:"failed_login_count"`
+	// 账户锁定截止时间，未锁定时为空
+	LockedUntil *time.Time `json:"-" bson:"locked_until,omitempty"`
+	// 邮箱验证时间，未验证时为空
+	EmailVerifiedAt *time.Time `json:"-" bson:"email_verified_at,omitempty"`
+	// 邮箱验证令牌的SHA-256哈希，令牌明文只出现在验证邮件中
+	EmailVerificationTokenHash string `json:"-" bson:"email_verification_token_hash,omitempty"`
+	// 邮箱验证令牌的过期时间
+	EmailVerificationExpiresAt *time.Time `json:"-" bson:"email_verification_expires_at,omitempty"`
+	// 最近一次发送验证邮件的时间，用于限制重发频率
+	EmailVerificationSentAt *time.Time `json:"-" bson:"email_verification_sent_at,omitempty"`
+}
+
+// IsLocked 判断账户在指定时间是否处于锁定状态
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// 用户角色
+const (
+	RoleUser  = "user"  // 普通用户（默认）
+	RoleAdmin = "admin" // 管理员
+)
+
+// IsValidRole 判断角色是否为已知角色
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// EffectiveRole 返回用户的角色，未设置角色的历史用户视为普通用户
+func (u *User) EffectiveRole() string {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
 }
 
 /*
@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -10,18 +12,120 @@ type LoginRequest struct {
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required,password_strength"` // 8到72字节，至少包含一个字母和一个数字
 	Nickname string `json:"nickname"`
 }
 
-// UpdateProfileRequest 更新用户资料请求
+// ListFilter 用户列表过滤条件，零值字段表示不过滤
+type ListFilter struct {
+	Keyword string   // 关键词，匹配用户名、邮箱和昵称
+	Search  string   // 全文搜索词，使用全文索引，结果按相关度排序
+	Status  int      // 状态，0表示不过滤
+	Roles   []string // 角色，匹配其中任意一个
+	// 是否已验证邮箱，nil表示不过滤
+	EmailVerified *bool
+	// 排序字段（created_at、updated_at、username）和方向（asc、desc），只用于页码分页
+	// 均为空时按创建时间倒序，全文搜索时按相关度排序
+	SortBy string
+	Order  string
+}
+
+// RegistrationStatsQuery 每日注册数统计查询参数
+// 时间使用RFC3339格式，默认统计最近30天，最长366天
+type RegistrationStatsQuery struct {
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// UpdateProfileRequest 更新用户资料请求（PUT，整体替换）
+// 未提供的字段会被重置为空值
 type UpdateProfileRequest struct {
 	Nickname string `json:"nickname"`
 	Avatar   string `json:"avatar"`
 }
 
+// PatchProfileRequest 部分更新用户资料请求（PATCH）
+// 字段为nil表示不修改，显式传入空字符串表示清空
+type PatchProfileRequest struct {
+	Nickname *string `json:"nickname"`
+	Avatar   *string `json:"avatar"`
+}
+
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,password_strength"`
+}
+
+// ResetPasswordRequest 使用重置令牌设置新密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,password_strength"`
+}
+
+// 合并账户时的资料冲突处理策略
+const (
+	MergeKeepTarget   = "keep_target"   // 双方均有值时保留目标账户的值
+	MergePreferSource = "prefer_source" // 双方均有值时使用源账户的值
+)
+
+// MergeUsersRequest 合并用户账户请求
+type MergeUsersRequest struct {
+	SourceID uint   `json:"source_id" binding:"required"`
+	TargetID uint   `json:"target_id" binding:"required"`
+	Strategy string `json:"strategy" binding:"omitempty,oneof=keep_target prefer_source"`
+}
+
+// BulkUpdateRequest 批量更新用户请求
+// 过滤条件和修改内容都只支持下列字段；过滤条件为空时会匹配所有用户，必须将 confirm 设为true
+type BulkUpdateRequest struct {
+	Filter  BulkUpdateFilter `json:"filter"`
+	Patch   BulkUpdatePatch  `json:"patch"`
+	DryRun  bool             `json:"dry_run"` // 只返回匹配的用户数，不修改数据
+	Confirm bool             `json:"confirm"` // 确认过滤条件为空时更新所有用户
+}
+
+// BulkUpdateFilter 批量更新的过滤条件，多个条件同时满足，零值字段表示不过滤
+type BulkUpdateFilter struct {
+	IDs           []uint     `json:"ids"`
+	Status        *int       `json:"status"`
+	Roles         []string   `json:"roles"`
+	EmailVerified *bool      `json:"email_verified"`
+	CreatedBefore *time.Time `json:"created_before"`
+	CreatedAfter  *time.Time `json:"created_after"`
+}
+
+// IsEmpty 判断过滤条件是否为空（匹配所有用户）
+func (f BulkUpdateFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.Status == nil && len(f.Roles) == 0 &&
+		f.EmailVerified == nil && f.CreatedBefore == nil && f.CreatedAfter == nil
+}
+
+// BulkUpdatePatch 批量更新的修改内容，字段为nil表示不修改
+type BulkUpdatePatch struct {
+	Status *int    `json:"status" binding:"omitempty,oneof=0 1"` // 1为正常，0为禁用
+	Role   *string `json:"role"`
+}
+
+// IsEmpty 判断修改内容是否为空
+func (p BulkUpdatePatch) IsEmpty() bool {
+	return p.Status == nil && p.Role == nil
+}
+
+// BulkVerifyRequest 批量标记邮箱已验证请求
+// 过滤条件与批量更新相同，只会修改其中邮箱尚未验证的用户；过滤条件为空时必须将 confirm 设为true
+type BulkVerifyRequest struct {
+	Filter  BulkUpdateFilter `json:"filter"`
+	DryRun  bool             `json:"dry_run"` // 只返回匹配的用户数，不修改数据
+	Confirm bool             `json:"confirm"` // 确认过滤条件为空时验证所有用户
+}
+
+// ForcePasswordResetRequest 强制重置密码请求
+// 过滤条件与批量更新相同，过滤条件为空时会匹配所有用户，必须将 confirm 设为true
+type ForcePasswordResetRequest struct {
+	Filter      BulkUpdateFilter `json:"filter"`
+	SendEmail   bool             `json:"send_email"`    // 通过邮件发送重置令牌；未发送的令牌在响应中返回
+	KeepAPIKeys bool             `json:"keep_api_keys"` // 保留用户的API密钥，默认全部吊销
+	DryRun      bool             `json:"dry_run"`       // 只返回匹配的用户数，不修改数据
+	Confirm     bool             `json:"confirm"`       // 确认过滤条件为空时重置所有用户
 }
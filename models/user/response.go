@@ -1,6 +1,12 @@
 package user
 
-import "time"
+import (
+	"time"
+
+	"go-app/models/apikey"
+	"go-app/models/audit"
+	"go-app/models/session"
+)
 
 // Response 用户响应
 type Response struct {
@@ -10,8 +16,11 @@ type Response struct {
 	Nickname  string    `json:"nickname"`
 	Avatar    string    `json:"avatar"`
 	Status    int       `json:"status"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// 是否已验证邮箱
+	EmailVerified bool `json:"email_verified"`
 }
 
 // ProfileResponse 用户简要资料响应
@@ -31,6 +40,112 @@ type TokenResponse struct {
 	ExpiresIn   int    `json:"expires_in"`
 }
 
+// TokenValidationResponse 令牌校验响应
+type TokenValidationResponse struct {
+	User      *ProfileResponse `json:"user"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	ExpiresIn int              `json:"expires_in"` // 剩余有效秒数
+}
+
+// MergeResponse 合并用户账户响应
+type MergeResponse struct {
+	User             *Response `json:"user"`
+	Conflicts        []string  `json:"conflicts"`
+	ReassignedAudits int64     `json:"reassigned_audits"`
+	RevokedSessions  int64     `json:"revoked_sessions"`
+}
+
+// ExportResponse 个人数据导出
+// 包含系统保存的该用户的全部数据，不包含密码哈希、令牌ID和API密钥哈希等凭证
+type ExportResponse struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Profile    *Response          `json:"profile"`
+	AuditLogs  []*audit.Entry     `json:"audit_logs"`
+	Sessions   []*session.Session `json:"sessions"`
+	APIKeys    []*apikey.APIKey   `json:"api_keys"`
+}
+
+// DistinctResponse 字段不重复取值响应
+type DistinctResponse struct {
+	Field  string        `json:"field"`
+	Values []interface{} `json:"values"`
+}
+
+// BatchRegisterItem 批量创建中单个用户的结果，成功时 user 不为空，失败时 error 不为空
+type BatchRegisterItem struct {
+	Index int              `json:"index"` // 在请求数组中的下标
+	User  *ProfileResponse `json:"user,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+// BatchRegisterResponse 批量创建用户结果
+type BatchRegisterResponse struct {
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Results []BatchRegisterItem `json:"results"`
+}
+
+// BulkUpdateResponse 批量更新结果
+type BulkUpdateResponse struct {
+	Matched  int64 `json:"matched"`  // 匹配的用户数
+	Modified int64 `json:"modified"` // 实际修改的用户数（原值已相同的用户不计入），试运行时为0
+	DryRun   bool  `json:"dry_run"`
+}
+
+// 密码批量迁移任务的状态
+const (
+	RehashRunning   = "running"   // 执行中
+	RehashCompleted = "completed" // 已完成
+	RehashFailed    = "failed"    // 遍历用户失败而中止，已处理的用户不受影响
+)
+
+// RehashPasswordsResponse 密码批量迁移任务的状态和进度，执行中的计数随处理进度增加
+type RehashPasswordsResponse struct {
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"` // 中止的原因
+	Scanned    int        `json:"scanned"`         // 扫描的用户数
+	Migrated   int        `json:"migrated"`        // 明文密码已哈希的用户数
+	Flagged    int        `json:"flagged"`         // 无法识别的哈希，已标记需要重置密码的用户数
+	LowCost    int        `json:"low_cost"`        // 强度较低的bcrypt哈希，将在用户下次登录时升级
+	Skipped    int        `json:"skipped"`         // 读取后密码已被修改（如用户改密、管理员重置），未覆盖的用户数
+	Failed     int        `json:"failed"`          // 更新失败的用户数
+}
+
+// ForcePasswordResetResponse 强制重置密码结果
+type ForcePasswordResetResponse struct {
+	Matched int64 `json:"matched"` // 匹配的用户数
+	Reset   int   `json:"reset"`   // 密码已失效并生成重置令牌的用户数
+	Emailed int   `json:"emailed"` // 重置邮件发送成功的用户数
+	Failed  int   `json:"failed"`  // 更新失败的用户数
+	DryRun  bool  `json:"dry_run"`
+	// 吊销的API密钥数，请求中 keep_api_keys 为true时为0
+	APIKeysRevoked int64 `json:"api_keys_revoked"`
+	// 未通过邮件发送（未要求发送或发送失败）的重置令牌，需由管理员转交给用户
+	Tokens []PasswordResetToken `json:"tokens,omitempty"`
+}
+
+// DailyCount 单日的统计数
+type DailyCount struct {
+	Date  string `json:"date"` // 日期（UTC），格式为 2006-01-02
+	Count int64  `json:"count"`
+}
+
+// RegistrationStatsResponse 每日注册数统计响应
+type RegistrationStatsResponse struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Total int64        `json:"total"`
+	Days  []DailyCount `json:"days"`
+}
+
+// PasswordResetToken 用户的密码重置令牌
+type PasswordResetToken struct {
+	UserID uint   `json:"user_id"`
+	Token  string `json:"token"`
+}
+
 // ToResponse 将用户实体转换为用户响应
 func (u *User) ToResponse() *Response {
 	return &Response{
@@ -40,8 +155,11 @@ func (u *User) ToResponse() *Response {
 		Nickname:  u.Nickname,
 		Avatar:    u.Avatar,
 		Status:    u.Status,
+		Role:      u.EffectiveRole(),
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
+		// 已验证邮箱的用户 email_verified_at 不为空
+		EmailVerified: u.EmailVerifiedAt != nil,
 	}
 }
 
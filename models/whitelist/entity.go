@@ -0,0 +1,33 @@
+package whitelist
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 白名单条目类型
+const (
+	TypeIP   = "ip"   // IP或CIDR网段
+	TypePath = "path" // 请求路径
+)
+
+/*
+* 白名单条目实体
+* 通过管理接口动态添加的白名单条目，持久化后在重启时重新加载
+ */
+type Entry struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type      string             `json:"type" bson:"type"`             // 条目类型：ip/path
+	Value     string             `json:"value" bson:"value"`           // IP、CIDR网段或路径
+	CreatedBy uint               `json:"created_by" bson:"created_by"` // 添加人ID
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+/*
+返回白名单集合名称
+返回: 集合名称
+*/
+func (Entry) TableName() string {
+	return "whitelist_entries"
+}
@@ -0,0 +1,7 @@
+package whitelist
+
+// EntryRequest 添加或移除白名单条目请求
+// 添加时通过JSON请求体传入，移除时通过查询参数传入
+type EntryRequest struct {
+	Value string `json:"value" form:"value" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package whitelist
+
+// ListResponse 白名单列表响应
+type ListResponse struct {
+	Type    string   `json:"type"`
+	Entries []string `json:"entries"`
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"go-app/controller/security"
+	"go-app/controller/system"
+	"go-app/controller/user"
+	"go-app/controller/whitelist"
+	"go-app/middleware"
+	userModel "go-app/models/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes 设置管理员相关路由，仅管理员角色可访问
+func SetupAdminRoutes(userController *user.Controller, whitelistController *whitelist.Controller, securityController *security.Controller, systemController *system.Controller, authorized *gin.RouterGroup) {
+	admin := authorized.Group("/admin", middleware.RequireRole(userModel.RoleAdmin))
+	{
+		// 批量创建用户，请求体为数组，逐个元素校验
+		admin.POST("/users/batch", middleware.ValidateJSONSlice(&userModel.RegisterRequest{}), userController.BatchRegister)
+		// 合并用户账户
+		admin.POST("/users/merge", userController.MergeUsers)
+		// 按过滤条件批量更新用户（状态、角色）
+		admin.POST("/users/bulk-update", userController.BulkUpdateUsers)
+		// 按过滤条件批量标记邮箱已验证，不发送邮件
+		admin.POST("/users/bulk-verify", userController.BulkVerifyUsers)
+		// 按过滤条件强制用户重置密码
+		admin.POST("/users/force-password-reset", userController.ForcePasswordReset)
+		// 按天统计注册用户数
+		admin.GET("/users/registrations", userController.RegistrationStats)
+		// 获取字段的不重复取值（status、email_domain）
+		admin.GET("/users/distinct/:field", userController.GetDistinctValues)
+		// 恢复已删除的用户
+		admin.POST("/users/:id/restore", userController.RestoreUser)
+		// 永久删除用户
+		admin.DELETE("/users/:id", userController.HardDeleteUser)
+		// 批量迁移明文和旧格式密码
+		admin.POST("/security/rehash-passwords", userController.RehashPasswords)
+		admin.GET("/security/rehash-passwords", userController.RehashPasswordsStatus)
+		// 按时间段统计登录失败次数
+		admin.GET("/security/failed-logins", securityController.FailedLogins)
+
+		// 白名单管理，修改立即生效并持久化
+		admin.GET("/whitelist/ip", whitelistController.ListIPs)
+		admin.POST("/whitelist/ip", whitelistController.AddIP)
+		admin.DELETE("/whitelist/ip", whitelistController.RemoveIP)
+		admin.GET("/whitelist/path", whitelistController.ListPaths)
+		admin.POST("/whitelist/path", whitelistController.AddPath)
+		admin.DELETE("/whitelist/path", whitelistController.RemovePath)
+
+		// 只读模式，开启后拒绝所有写请求（该接口除外），对所有实例生效
+		admin.GET("/system/read-only", systemController.GetReadOnly)
+		admin.PUT("/system/read-only", systemController.SetReadOnly)
+
+		// 运行时日志级别，只作用于处理请求的实例，重启后恢复为配置值
+		admin.GET("/loglevel", systemController.GetLogLevel)
+		admin.PUT("/loglevel", systemController.SetLogLevel)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"go-app/controller/apikey"
+	"go-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAPIKeyRoutes 设置API密钥相关路由
+func SetupAPIKeyRoutes(controller *apikey.Controller, authorized *gin.RouterGroup) {
+	// API密钥只能通过登录态（JWT）管理，避免泄露的密钥被用来创建新的密钥
+	apiKeys := authorized.Group("/api-keys", middleware.RequireInteractiveAuth())
+	{
+		// 获取当前用户的API密钥
+		apiKeys.GET("", controller.List)
+		// 创建API密钥
+		apiKeys.POST("", controller.Create)
+		// 吊销API密钥
+		apiKeys.DELETE("/:id", controller.Revoke)
+	}
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"go-app/controller/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuthRoutes 设置认证相关路由
+func SetupAuthRoutes(controller *user.Controller, authorized *gin.RouterGroup) {
+	auth := authorized.Group("/auth")
+	{
+		// 校验当前令牌
+		auth.GET("/validate", controller.ValidateToken)
+	}
+}
@@ -0,0 +1,151 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 尾部斜杠处理方式
+const (
+	TrailingSlashNotFound = "404"      // 不重定向，/users 与 /users/ 视为不同路径，未注册的返回404（默认）
+	TrailingSlashRedirect = "redirect" // 对应的另一种写法已注册时返回308重定向，保留请求方法和请求体
+)
+
+// trustedPlatforms 支持的部署平台及其携带真实客户端IP的请求头
+var trustedPlatforms = map[string]string{
+	"cloudflare":        gin.PlatformCloudflare,      // CF-Connecting-IP
+	"google-app-engine": gin.PlatformGoogleAppEngine, // X-Appengine-Remote-Addr
+	"flyio":             gin.PlatformFlyIO,           // Fly-Client-IP
+}
+
+/*
+configureEngine 根据配置设置gin引擎的路由行为
+gin默认会对尾部斜杠不匹配的请求做重定向（GET为301，其余方法为307），
+客户端看到的状态码因方法而异，因此关闭gin的自动重定向和路径修正，
+统一由 trailingSlashRedirect 处理：默认返回404，配置为 redirect 时统一返回308
+*/
+func configureEngine(r *gin.Engine, cfg *config.Config) {
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+	// 未注册的路由和不支持的请求方法统一返回JSON
+	r.HandleMethodNotAllowed = true
+
+	// 部署在云平台时从平台请求头读取客户端IP，日志、白名单、限流使用的 c.ClientIP() 随之生效
+	// 配置无效时panic，应用启动时应先调用 ValidateTrustedPlatform 校验
+	platform, err := resolveTrustedPlatform(cfg.Server.TrustedPlatform)
+	if err != nil {
+		panic(err)
+	}
+	r.TrustedPlatform = platform
+}
+
+/*
+ValidateTrustedPlatform 校验 SERVER_TRUSTED_PLATFORM 配置
+配置错误时若忽略该配置，客户端IP会退回为代理的地址，白名单和限流随之失效，因此应拒绝启动
+参数: cfg 应用配置
+返回: 错误
+*/
+func ValidateTrustedPlatform(cfg *config.Config) error {
+	if _, err := resolveTrustedPlatform(cfg.Server.TrustedPlatform); err != nil {
+		return fmt.Errorf("SERVER_TRUSTED_PLATFORM配置无效: %w", err)
+	}
+	return nil
+}
+
+/*
+resolveTrustedPlatform 将配置的平台名称转换为gin使用的请求头
+参数: value 平台名称（cloudflare/google-app-engine/flyio），不区分大小写，也可直接填写对应的请求头；为空表示不使用平台请求头
+返回: 请求头名称, 错误
+*/
+func resolveTrustedPlatform(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if header, ok := trustedPlatforms[strings.ToLower(value)]; ok {
+		return header, nil
+	}
+	for _, header := range trustedPlatforms {
+		if strings.EqualFold(value, header) {
+			return header, nil
+		}
+	}
+
+	return "", fmt.Errorf("不支持的平台: %s，可选值为 cloudflare、google-app-engine、flyio", value)
+}
+
+/*
+trailingSlashRedirect 尾部斜杠不匹配时尝试重定向
+仅当配置为 redirect 且去掉或补上尾部斜杠后的路径已注册时，返回308并中止请求
+返回: 是否已重定向
+*/
+func trailingSlashRedirect(c *gin.Context, engine *gin.Engine, cfg *config.Config) bool {
+	if cfg.Server.TrailingSlash != TrailingSlashRedirect {
+		return false
+	}
+
+	path := c.Request.URL.Path
+	if path == "/" {
+		return false
+	}
+
+	var target string
+	if strings.HasSuffix(path, "/") {
+		target = strings.TrimSuffix(path, "/")
+	} else {
+		target = path + "/"
+	}
+
+	if !routeExists(engine, c.Request.Method, target) {
+		return false
+	}
+
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusPermanentRedirect, target)
+	c.Abort()
+	return true
+}
+
+// routeExists 判断指定方法和路径是否命中已注册的路由，支持 :param 和 *catchAll
+func routeExists(engine *gin.Engine, method, path string) bool {
+	for _, route := range engine.Routes() {
+		if route.Method == method && matchRoute(route.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute 按路径段匹配路由模板
+func matchRoute(pattern, path string) bool {
+	patternSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(path, "/")
+
+	for i, seg := range patternSegs {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(pathSegs) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			if pathSegs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != pathSegs[i] {
+			return false
+		}
+	}
+
+	return len(patternSegs) == len(pathSegs)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-app/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedPlatformClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Server.TrustedPlatform = "Cloudflare"
+
+	r := gin.New()
+	configureEngine(r, cfg)
+	r.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("CF-Connecting-IP", "203.0.113.7")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "203.0.113.7" {
+		t.Fatalf("ClientIP = %q, want 203.0.113.7", w.Body.String())
+	}
+}
+
+func TestValidateTrustedPlatform(t *testing.T) {
+	cases := map[string]bool{
+		"":                        true,
+		"flyio":                   true,
+		"X-Appengine-Remote-Addr": true,
+		"cloudfront":              false,
+	}
+	for value, valid := range cases {
+		cfg := &config.Config{}
+		cfg.Server.TrustedPlatform = value
+		if err := ValidateTrustedPlatform(cfg); (err == nil) != valid {
+			t.Errorf("ValidateTrustedPlatform(%q) = %v, valid = %v", value, err, valid)
+		}
+	}
+}
+
+func TestTrailingSlashDefaultNotFound(t *testing.T) {
+	r := newTestRouter(t, &config.Config{})
+
+	cases := []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/users/register/"},
+		{http.MethodGet, "/ping/"},
+	}
+	for _, tc := range cases {
+		if w := serveRouter(r, tc.method, tc.path); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: status = %d, want 404", tc.method, tc.path, w.Code)
+		}
+	}
+	if w := serveRouter(r, http.MethodGet, "/ping"); w.Code != http.StatusOK {
+		t.Fatalf("GET /ping: status = %d, want 200", w.Code)
+	}
+}
+
+func TestTrailingSlashRedirect(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.TrailingSlash = TrailingSlashRedirect
+	r := newTestRouter(t, cfg)
+
+	cases := []struct {
+		method, path, location string
+	}{
+		{http.MethodPost, "/api/v1/users/register/", "/api/v1/users/register"},
+		{http.MethodGet, "/api/v1/users/?page=2", "/api/v1/users?page=2"},
+		{http.MethodGet, "/api/v1/users/42/", "/api/v1/users/42"},
+	}
+	for _, tc := range cases {
+		w := serveRouter(r, tc.method, tc.path)
+		if w.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s %s: status = %d, want 308", tc.method, tc.path, w.Code)
+			continue
+		}
+		if got := w.Header().Get("Location"); got != tc.location {
+			t.Errorf("%s %s: Location = %q, want %q", tc.method, tc.path, got, tc.location)
+		}
+	}
+
+	// 另一种写法未注册时仍返回404
+	if w := serveRouter(r, http.MethodGet, "/api/v1/nope/"); w.Code != http.StatusNotFound {
+		t.Fatalf("未注册的路径: status = %d, want 404", w.Code)
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api/v1/users/:id", "/api/v1/users/42", true},
+		{"/api/v1/users/:id", "/api/v1/users/", false},
+		{"/api/v1/users/:id", "/api/v1/users/42/x", false},
+		{"/static/*file", "/static/a/b.css", true},
+		{"/ping", "/ping/", false},
+	}
+	for _, tc := range cases {
+		if got := matchRoute(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchRoute(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
@@ -5,29 +5,48 @@ import (
 	"go-app/controller"
 	"go-app/database/repositories"
 	"go-app/middleware"
+	"go-app/models/common"
+	"go-app/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Setup 初始化所有路由
-func Setup(r *gin.Engine, cfg *config.Config, repoManager *repositories.RepositoryManager) {
-	// 初始化控制器管理器
-	controllerManager := controller.NewManager(cfg, repoManager)
+func Setup(r *gin.Engine, cfg *config.Config, controllerManager *controller.Manager) {
+	// 设置引擎的路由行为（尾部斜杠、405等）
+	configureEngine(r, cfg)
 
-	// 设置健康检查
+	// 未注册的路由统一返回JSON，尾部斜杠不匹配时按配置决定是否重定向
+	r.NoRoute(func(c *gin.Context) {
+		if trailingSlashRedirect(c, r, cfg) {
+			return
+		}
+		c.JSON(http.StatusNotFound, common.ErrorResponse(404, "接口不存在").WithRequestID(middleware.GetRequestID(c)))
+	})
+	r.NoMethod(func(c *gin.Context) {
+		c.JSON(http.StatusMethodNotAllowed, common.ErrorResponse(405, "请求方法不允许").WithRequestID(middleware.GetRequestID(c)))
+	})
+
+	// 设置存活检查，不检查依赖
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "pong",
 		})
 	})
+	// 设置就绪检查，MongoDB不可用时返回503
+	r.GET("/healthz", controllerManager.Health.Readiness)
+	// 设置 Prometheus 指标抓取接口，可通过IP白名单限制访问
+	r.GET(middleware.MetricsPath, middleware.MetricsHandler())
 
 	// API路由组
 	api := r.Group("/api/v1")
 	{
 		// 添加重定向，将/api/v1/login重定向到/api/v1/users/login
+		// 重定向目标统一经过 SafeRedirect 校验，避免开放重定向
 		api.Any("/login", func(c *gin.Context) {
-			c.Redirect(http.StatusMovedPermanently, "/api/v1/users/login")
+			target, _ := utils.SafeRedirect("/api/v1/users/login", cfg.Server.RedirectAllowlist)
+			c.Redirect(http.StatusMovedPermanently, target)
 		})
 
 		// 公开路由组
@@ -35,10 +54,22 @@ func Setup(r *gin.Engine, cfg *config.Config, repoManager *repositories.Reposito
 		// 需要认证的路由组
 		authorized := api.Group("")
 		// 添加JWT认证
-		middleware.SetupAuthMiddleware(authorized, cfg)
+		middleware.SetupAuthMiddleware(authorized, cfg, controllerManager.Auth)
 
 		// 设置用户路由
 		SetupUserRoutes(controllerManager.User, public, authorized)
+
+		// 设置认证路由
+		SetupAuthRoutes(controllerManager.User, authorized)
+
+		// 设置API密钥路由
+		SetupAPIKeyRoutes(controllerManager.APIKey, authorized)
+
+		// 设置签名调试路由
+		SetupSignatureRoutes(controllerManager.Signature, public)
+
+		// 设置管理员路由
+		SetupAdminRoutes(controllerManager.User, controllerManager.Whitelist, controllerManager.Security, controllerManager.System, authorized)
 	}
 }
 
@@ -46,11 +77,12 @@ func Setup(r *gin.Engine, cfg *config.Config, repoManager *repositories.Reposito
 func SetupRouter(cfg *config.Config, repoManager *repositories.RepositoryManager) *gin.Engine {
 	r := gin.Default()
 
-	// 使用白名单中间件
-	r.Use(middleware.Whitelist(middleware.NewWhitelistConfig(cfg)))
+	// 使用白名单中间件，管理接口的修改作用于该配置
+	middleware.DefaultWhitelistConfig = middleware.NewWhitelistConfig(cfg)
+	r.Use(middleware.Whitelist(middleware.DefaultWhitelistConfig))
 
 	// 初始化路由
-	Setup(r, cfg, repoManager)
+	Setup(r, cfg, controller.NewManager(cfg, repoManager))
 
 	return r
 }
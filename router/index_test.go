@@ -0,0 +1,65 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-app/config"
+	"go-app/controller"
+	"go-app/database/repositories"
+	"go-app/middleware"
+	"go-app/models/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter 使用空存储库创建完整路由，不依赖MongoDB
+func newTestRouter(t *testing.T, cfg *config.Config) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if cfg.JWT.Secret == "" {
+		cfg.JWT.Secret = "test-secret"
+	}
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	Setup(r, cfg, controller.NewManager(cfg, repositories.NewRepositoryManager(nil)))
+	return r
+}
+
+func serveRouter(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestUnknownRoutesReturnJSON(t *testing.T) {
+	r := newTestRouter(t, &config.Config{})
+
+	cases := []struct {
+		name, method, path string
+		status             int
+	}{
+		{"未注册的路径", http.MethodGet, "/api/v1/nope", http.StatusNotFound},
+		{"已注册路径的错误方法", http.MethodDelete, "/ping", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		w := serveRouter(r, tc.method, tc.path)
+		if w.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.status)
+			continue
+		}
+		var resp common.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: 响应不是JSON: %q", tc.name, w.Body.String())
+			continue
+		}
+		if resp.Code != tc.status || resp.Message == "" {
+			t.Errorf("%s: 响应 = %+v", tc.name, resp)
+		}
+		if resp.RequestID == "" || resp.RequestID != w.Header().Get(middleware.RequestIDHeader) {
+			t.Errorf("%s: request_id = %q, 响应头 = %q", tc.name, resp.RequestID, w.Header().Get(middleware.RequestIDHeader))
+		}
+	}
+}
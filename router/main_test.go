@@ -0,0 +1,24 @@
+package router
+
+import (
+	"os"
+	"testing"
+
+	"go-app/utils"
+)
+
+// TestMain 将测试期间的日志写入临时目录，避免在源码目录下生成日志文件
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "router-test-logs")
+	if err != nil {
+		panic(err)
+	}
+	utils.InitLoggerWithConfig(utils.LogConfig{
+		LogDir:      dir,
+		LogFileName: "test.log",
+		MaxSize:     1,
+	})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"go-app/controller/signature"
+	"go-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSignatureRoutes 设置签名调试路由，仅白名单IP可访问
+func SetupSignatureRoutes(controller *signature.Controller, public *gin.RouterGroup) {
+	signatures := public.Group("/signature", middleware.WhitelistedIPOnly())
+	{
+		// 校验签名是否正确，不执行任何操作
+		signatures.POST("/verify", controller.Verify)
+	}
+}
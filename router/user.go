@@ -2,6 +2,8 @@ package router
 
 import (
 	"go-app/controller/user"
+	"go-app/middleware"
+	userModel "go-app/models/user"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,22 +17,34 @@ func SetupUserRoutes(controller *user.Controller, public, authorized *gin.Router
 		users.POST("/register", controller.Register)
 		// 登录
 		users.POST("/login", controller.Login)
+		// 使用重置令牌设置新密码
+		users.POST("/reset-password", controller.ResetPassword)
 	}
 
 	// 需要认证的路由
 	authUsers := authorized.Group("/users")
 	{
-		// 获取用户列表
-		authUsers.GET("", controller.GetUsers)
+		// 获取用户列表（管理员）
+		authUsers.GET("", middleware.RequireRole(userModel.RoleAdmin), controller.GetUsers)
+		// 统计用户数（管理员）
+		authUsers.GET("/count", middleware.RequireRole(userModel.RoleAdmin), controller.CountUsers)
 		// 获取用户详情
 		authUsers.GET("/:id", controller.GetUser)
-		// 删除用户
-		authUsers.DELETE("/:id", controller.DeleteUser)
+		// 删除用户（管理员）
+		authUsers.DELETE("/:id", middleware.RequireRole(userModel.RoleAdmin), controller.DeleteUser)
 		// 获取个人资料
 		authUsers.GET("/profile", controller.GetProfile)
-		// 更新个人资料
+		// 整体更新个人资料（未提供的字段会被清空）
 		authUsers.PUT("/profile", controller.UpdateProfile)
+		// 部分更新个人资料
+		authUsers.PATCH("/profile", controller.PatchProfile)
 		// 修改密码
 		authUsers.POST("/change-password", controller.ChangePassword)
+		// 重新发送邮箱验证邮件
+		authUsers.POST("/resend-verification", controller.ResendVerification)
+		// 导出个人数据
+		authUsers.GET("/me/export", controller.ExportData)
+		// 查看个人操作记录
+		authUsers.GET("/me/activity", controller.GetActivity)
 	}
 }
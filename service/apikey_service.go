@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-app/database/repositories"
+	"go-app/models/apikey"
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// lastUsedInterval 最后使用时间的更新间隔，避免每个请求都写库
+const lastUsedInterval = time.Minute
+
+// ErrInvalidAPIKey API密钥无效
+var ErrInvalidAPIKey = errors.New("API密钥无效")
+
+// APIKeyService API密钥服务接口
+type APIKeyService interface {
+	Create(userID uint, req *apikey.CreateRequest) (*apikey.APIKey, string, error)
+	List(userID uint) ([]*apikey.APIKey, error)
+	Revoke(userID uint, id string) error
+	AuthenticateAPIKey(ctx context.Context, key string) (uint, []string, error)
+}
+
+// APIKeyServiceImpl API密钥服务实现
+type APIKeyServiceImpl struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+}
+
+// NewAPIKeyService 创建API密钥服务
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository) APIKeyService {
+	return &APIKeyServiceImpl{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Create 为用户创建API密钥，返回的明文密钥只在此时可见
+func (s *APIKeyServiceImpl) Create(userID uint, req *apikey.CreateRequest) (*apikey.APIKey, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	key := apikey.KeyPrefix + hex.EncodeToString(raw)
+
+	entity := &apikey.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		KeyHash: hashAPIKey(key),
+		Prefix:  key[:len(apikey.KeyPrefix)+6],
+		Scopes:  uniqueStrings(req.Scopes),
+	}
+	if err := s.apiKeyRepo.Create(entity); err != nil {
+		return nil, "", err
+	}
+
+	return entity, key, nil
+}
+
+// List 返回用户未吊销的API密钥
+func (s *APIKeyServiceImpl) List(userID uint) ([]*apikey.APIKey, error) {
+	return s.apiKeyRepo.FindByUser(userID)
+}
+
+// Revoke 吊销用户的API密钥
+func (s *APIKeyServiceImpl) Revoke(userID uint, id string) error {
+	return s.apiKeyRepo.Revoke(id, userID)
+}
+
+/*
+AuthenticateAPIKey 校验API密钥，实现 middleware.APIKeyAuthenticator
+key: 请求头中的明文密钥
+返回: 密钥所属用户ID, 密钥的权限范围, 错误
+*/
+func (s *APIKeyServiceImpl) AuthenticateAPIKey(ctx context.Context, key string) (uint, []string, error) {
+	entity, err := s.apiKeyRepo.FindByHash(hashAPIKey(key))
+	if err != nil {
+		return 0, nil, ErrInvalidAPIKey
+	}
+
+	// 密钥所属用户被删除或禁用后，密钥随之失效
+	u, err := s.userRepo.FindByID(ctx, entity.UserID)
+	if err != nil || u.Deleted || u.Status != 1 {
+		return 0, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if entity.LastUsedAt == nil || now.Sub(*entity.LastUsedAt) > lastUsedInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(entity.ID, now); err != nil {
+			utils.Warn("更新API密钥使用时间失败", zap.String("key_id", entity.ID.Hex()), zap.Error(err))
+		}
+	}
+
+	return entity.UserID, entity.Scopes, nil
+}
+
+// hashAPIKey 计算API密钥的哈希
+// 密钥本身是高熵随机值，使用SHA-256即可，无需bcrypt等慢哈希，也便于按哈希直接查询
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// uniqueStrings 去除重复的字符串，保留原有顺序
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]struct{}, len(list))
+	result := make([]string, 0, len(list))
+	for _, v := range list {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
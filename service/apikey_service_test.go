@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-app/models/apikey"
+	"go-app/models/user"
+)
+
+func newTestAPIKeyService(users ...*user.User) (*APIKeyServiceImpl, *fakeAPIKeyRepo, *fakeUserRepo) {
+	keys := &fakeAPIKeyRepo{}
+	userRepo := newFakeUserRepo(users...)
+	return NewAPIKeyService(keys, userRepo).(*APIKeyServiceImpl), keys, userRepo
+}
+
+func TestAPIKeyCreateAndAuthenticate(t *testing.T) {
+	svc, keys, _ := newTestAPIKeyService(&user.User{ID: 1, Username: "bot", Status: 1})
+	entity, key, err := svc.Create(1, &apikey.CreateRequest{Name: "ci", Scopes: []string{"read", "read", "write"}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+	if !strings.HasPrefix(key, apikey.KeyPrefix) || !strings.HasPrefix(key, entity.Prefix) {
+		t.Fatalf("key = %q, prefix = %q", key, entity.Prefix)
+	}
+	if entity.KeyHash == key || strings.Contains(entity.KeyHash, key) {
+		t.Fatal("存储库中不应保存明文密钥")
+	}
+	if len(entity.Scopes) != 2 {
+		t.Fatalf("scopes = %v, 重复的权限应被去除", entity.Scopes)
+	}
+
+	userID, scopes, err := svc.AuthenticateAPIKey(context.Background(), key)
+	if err != nil || userID != 1 || len(scopes) != 2 {
+		t.Fatalf("AuthenticateAPIKey = %d, %v, %v", userID, scopes, err)
+	}
+	if keys.keys[0].LastUsedAt == nil {
+		t.Fatal("认证成功后应记录最后使用时间")
+	}
+}
+
+func TestAPIKeyRejectedAfterRevoke(t *testing.T) {
+	svc, _, _ := newTestAPIKeyService(&user.User{ID: 1, Username: "bot", Status: 1})
+	entity, key, err := svc.Create(1, &apikey.CreateRequest{Name: "ci", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+
+	// 其他用户不能吊销
+	if err := svc.Revoke(2, entity.ID.Hex()); err == nil {
+		t.Fatal("不应吊销其他用户的密钥")
+	}
+	if err := svc.Revoke(1, entity.ID.Hex()); err != nil {
+		t.Fatalf("吊销失败: %v", err)
+	}
+	if _, _, err := svc.AuthenticateAPIKey(context.Background(), key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("err = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestAPIKeyRejectedForDisabledOrDeletedUser(t *testing.T) {
+	svc, _, users := newTestAPIKeyService(&user.User{ID: 1, Username: "bot", Status: 1})
+	ctx := context.Background()
+
+	_, key, err := svc.Create(1, &apikey.CreateRequest{Name: "ci", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+
+	users.users[1].Status = 0
+	if _, _, err := svc.AuthenticateAPIKey(ctx, key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("禁用用户: err = %v, want ErrInvalidAPIKey", err)
+	}
+
+	users.users[1].Status = 1
+	users.users[1].Deleted = true
+	if _, _, err := svc.AuthenticateAPIKey(ctx, key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("已删除用户: err = %v, want ErrInvalidAPIKey", err)
+	}
+
+	if _, _, err := svc.AuthenticateAPIKey(ctx, apikey.KeyPrefix+"unknown"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("未知密钥: err = %v, want ErrInvalidAPIKey", err)
+	}
+}
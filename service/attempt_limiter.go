@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// staleKeyThreshold 记录的键数量超过该值时，在记录尝试时顺带清理过期的键
+const staleKeyThreshold = 1024
+
+// attemptLimiter 基于滑动窗口的尝试次数限制器（进程内存储）
+// 同一个键在窗口期内记录的次数达到上限后被限制，窗口期过后自动解除
+type attemptLimiter struct {
+	mu          sync.Mutex
+	maxAttempts int
+	window      time.Duration
+	failures    map[string][]time.Time // 各个键在窗口期内的尝试时间
+}
+
+// newAttemptLimiter 创建尝试次数限制器
+func newAttemptLimiter(maxAttempts int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		maxAttempts: maxAttempts,
+		window:      window,
+		failures:    make(map[string][]time.Time),
+	}
+}
+
+/*
+Blocked 判断键是否已被限制
+key: 限制对象，如用户ID
+返回: 是否被限制, 距离解除限制的剩余时间
+*/
+func (l *attemptLimiter) Blocked(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	failures := l.prune(key, now)
+	if len(failures) < l.maxAttempts {
+		return false, 0
+	}
+
+	// 最早的一次尝试移出窗口后即可再次尝试
+	return true, failures[0].Add(l.window).Sub(now)
+}
+
+// Record 记录一次计入限制的尝试（如一次失败）
+func (l *attemptLimiter) Record(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.failures[key] = append(l.prune(key, now), now)
+
+	if len(l.failures) > staleKeyThreshold {
+		for k := range l.failures {
+			l.prune(k, now)
+		}
+	}
+}
+
+// Reset 清除键的尝试记录
+func (l *attemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, key)
+}
+
+// prune 移除窗口期之外的尝试记录，调用方需持有锁
+func (l *attemptLimiter) prune(key string, now time.Time) []time.Time {
+	failures := l.failures[key]
+	cutoff := now.Add(-l.window)
+
+	i := 0
+	for i < len(failures) && !failures[i].After(cutoff) {
+		i++
+	}
+	failures = failures[i:]
+
+	if len(failures) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = failures
+	return failures
+}
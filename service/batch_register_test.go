@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-app/models/audit"
+	"go-app/models/user"
+)
+
+func TestBatchRegisterReportsPerItemResults(t *testing.T) {
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "taken", Email: "taken@example.com"})
+	ctx := context.Background()
+
+	audits := &fakeAuditRepo{}
+	s := newTestUserService(users, audits, &fakeSessionRepo{}, nil)
+
+	reqs := []user.RegisterRequest{
+		{Username: "bob", Email: "bob@example.com", Password: "password1"},
+		{Username: "taken", Email: "other@example.com", Password: "password1"},
+		{Username: "carol", Email: "carol@example.com", Password: "password1"},
+	}
+	result, err := s.BatchRegister(ctx, reqs, 1)
+	if err != nil {
+		t.Fatalf("BatchRegister: %v", err)
+	}
+
+	if result.Created != 2 || result.Failed != 1 {
+		t.Fatalf("created/failed = %d/%d, want 2/1", result.Created, result.Failed)
+	}
+	for i, item := range result.Results {
+		if item.Index != i {
+			t.Errorf("results[%d].Index = %d", i, item.Index)
+		}
+	}
+	if result.Results[1].Error == "" || result.Results[1].User != nil {
+		t.Errorf("duplicate username was not reported as failed: %+v", result.Results[1])
+	}
+	if _, err := users.FindByUsername(ctx, "carol"); err != nil {
+		t.Error("the element after the failed one was not created")
+	}
+	if got := audits.actions(); len(got) != 1 || got[0] != audit.ActionUserBatchRegister {
+		t.Errorf("audit actions = %v", got)
+	}
+}
+
+func TestBatchRegisterRejectsTooManyUsers(t *testing.T) {
+	s := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	reqs := make([]user.RegisterRequest, maxBatchRegisterUsers+1)
+	for i := range reqs {
+		reqs[i] = user.RegisterRequest{Username: fmt.Sprintf("u%d", i), Email: fmt.Sprintf("u%d@example.com", i), Password: "password1"}
+	}
+	if _, err := s.BatchRegister(context.Background(), reqs, 1); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("err = %v, want ErrBatchTooLarge", err)
+	}
+}
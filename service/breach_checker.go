@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-app/config"
+	"go-app/utils"
+)
+
+// ErrPasswordBreached 密码已出现在公开泄露的数据中
+var ErrPasswordBreached = errors.New("该密码已出现在公开泄露的数据中，请更换其他密码")
+
+// 泄露密码检查的默认值
+const (
+	defaultBreachCheckURL     = "https://api.pwnedpasswords.com/range/"
+	defaultBreachCheckTimeout = 3 * time.Second
+)
+
+// BreachChecker 泄露密码检查接口
+type BreachChecker interface {
+	// IsBreached 判断密码是否出现在泄露数据中
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// NoopBreachChecker 不做任何检查的实现，未开启泄露密码检查时使用
+type NoopBreachChecker struct{}
+
+// IsBreached 始终返回未泄露
+func (NoopBreachChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	return false, nil
+}
+
+/*
+HIBPBreachChecker 基于 HaveIBeenPwned k-匿名接口的实现
+只发送密码SHA-1哈希的前5个字符，在返回的后缀列表中本地比对，密码和完整哈希都不会离开本机
+*/
+type HIBPBreachChecker struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHIBPBreachChecker 创建 HaveIBeenPwned 泄露密码检查器
+func NewHIBPBreachChecker(endpoint string, timeout time.Duration) *HIBPBreachChecker {
+	if endpoint == "" {
+		endpoint = defaultBreachCheckURL
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	if timeout <= 0 {
+		timeout = defaultBreachCheckTimeout
+	}
+
+	return &HIBPBreachChecker{
+		endpoint: endpoint,
+		client:   utils.NewOutboundClient(timeout),
+	}
+}
+
+// IsBreached 查询哈希前缀对应的后缀列表，判断密码是否泄露
+func (c *HIBPBreachChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// 要求接口填充返回结果，避免通过响应长度推断前缀
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("请求泄露密码接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("泄露密码接口返回异常状态码: %d", resp.StatusCode)
+	}
+
+	// 每行格式为 "后缀:出现次数"，填充行的出现次数为0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], suffix) {
+			continue
+		}
+		return strings.TrimSpace(parts[1]) != "0", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("读取泄露密码接口响应失败: %w", err)
+	}
+
+	return false, nil
+}
+
+// NewBreachChecker 根据配置创建泄露密码检查器，未开启时返回不做检查的实现
+func NewBreachChecker(cfg *config.Config) BreachChecker {
+	if !cfg.Security.BreachCheckEnable {
+		return NoopBreachChecker{}
+	}
+	return NewHIBPBreachChecker(cfg.Security.BreachCheckURL, cfg.Security.BreachCheckTimeout)
+}
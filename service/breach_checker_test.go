@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-app/models/user"
+)
+
+// fakeBreachChecker 返回预设结果的泄露密码检查器
+type fakeBreachChecker struct {
+	breached bool
+	err      error
+}
+
+func (c fakeBreachChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	return c.breached, c.err
+}
+
+func registerWithChecker(checker BreachChecker) error {
+	svc := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	svc.SetBreachChecker(checker)
+	_, err := svc.Register(context.Background(), &user.RegisterRequest{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Password: "Passw0rd123",
+	})
+	return err
+}
+
+func TestRegisterRejectsBreachedPassword(t *testing.T) {
+	if err := registerWithChecker(fakeBreachChecker{breached: true}); !errors.Is(err, ErrPasswordBreached) {
+		t.Fatalf("err = %v, want ErrPasswordBreached", err)
+	}
+	if err := registerWithChecker(fakeBreachChecker{}); err != nil {
+		t.Fatalf("未泄露的密码: %v", err)
+	}
+	// 检查失败（如接口超时）时放行
+	if err := registerWithChecker(fakeBreachChecker{err: context.DeadlineExceeded}); err != nil {
+		t.Fatalf("检查失败时应放行: %v", err)
+	}
+}
+
+func TestChangePasswordRejectsBreachedPassword(t *testing.T) {
+	svc := newPasswordChangeTestService(t, 5)
+	svc.SetBreachChecker(fakeBreachChecker{breached: true})
+
+	if err := changePassword(svc, lockoutTestPassword, "N3w!Passw0rd"); !errors.Is(err, ErrPasswordBreached) {
+		t.Fatalf("err = %v, want ErrPasswordBreached", err)
+	}
+}
+
+func TestHIBPBreachCheckerSendsOnlyPrefix(t *testing.T) {
+	sum := sha1.Sum([]byte("password"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		// 目标后缀和一条填充行
+		fmt.Fprintf(w, "%s:3\r\n0000000000000000000000000000000000A:0\r\n", hash[5:])
+	}))
+	defer srv.Close()
+
+	checker := NewHIBPBreachChecker(srv.URL, time.Second)
+	breached, err := checker.IsBreached(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("IsBreached: %v", err)
+	}
+	if !breached {
+		t.Fatal("密码应被判定为已泄露")
+	}
+	if gotPath != "/"+hash[:5] {
+		t.Fatalf("请求路径 = %q, want /%s", gotPath, hash[:5])
+	}
+
+	breached, err = checker.IsBreached(context.Background(), "another-password")
+	if err != nil || breached {
+		t.Fatalf("未出现在列表中的密码: breached = %v, err = %v", breached, err)
+	}
+}
+
+func TestHIBPBreachCheckerReportsServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := NewHIBPBreachChecker(srv.URL, time.Second).IsBreached(context.Background(), "password"); err == nil {
+		t.Fatal("接口返回异常状态码时应返回错误")
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/models/session"
+	"go-app/models/user"
+)
+
+const bulkOperatorID = 1000000
+
+func newBulkUpdateTestService(n int) (*UserServiceImpl, *fakeUserRepo, *fakeAuditRepo, *fakeSessionRepo) {
+	users := newFakeUserRepo(&user.User{ID: bulkOperatorID, Username: "admin", Role: user.RoleAdmin, Status: 1})
+	for i := 1; i <= n; i++ {
+		users.users[uint(i)] = &user.User{ID: uint(i), Role: user.RoleUser, Status: 1}
+	}
+	audits := &fakeAuditRepo{}
+	sessions := &fakeSessionRepo{}
+	return newTestUserService(users, audits, sessions, nil), users, audits, sessions
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestBulkUpdateDryRun(t *testing.T) {
+	svc, users, audits, _ := newBulkUpdateTestService(3)
+
+	resp, err := svc.BulkUpdateUsers(context.Background(), &user.BulkUpdateRequest{
+		Filter: user.BulkUpdateFilter{Roles: []string{user.RoleUser}},
+		Patch:  user.BulkUpdatePatch{Status: intPtr(0)},
+		DryRun: true,
+	}, bulkOperatorID)
+	if err != nil {
+		t.Fatalf("试运行失败: %v", err)
+	}
+	if !resp.DryRun || resp.Matched != 3 || resp.Modified != 0 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	for i := uint(1); i <= 3; i++ {
+		if users.get(i).Status != 1 {
+			t.Fatal("试运行不应修改数据")
+		}
+	}
+	if len(audits.entries) != 0 {
+		t.Fatal("试运行不应记录审计日志")
+	}
+}
+
+func TestBulkUpdateEmptyFilterRequiresConfirm(t *testing.T) {
+	svc, users, _, _ := newBulkUpdateTestService(2)
+	ctx := context.Background()
+	req := &user.BulkUpdateRequest{Patch: user.BulkUpdatePatch{Status: intPtr(0)}}
+
+	if _, err := svc.BulkUpdateUsers(ctx, req, bulkOperatorID); !errors.Is(err, ErrBulkUpdateUnconfirmed) {
+		t.Fatalf("err = %v, want ErrBulkUpdateUnconfirmed", err)
+	}
+	if users.get(1).Status != 1 {
+		t.Fatal("未确认时不应修改数据")
+	}
+
+	req.Confirm = true
+	resp, err := svc.BulkUpdateUsers(ctx, req, bulkOperatorID)
+	if err != nil {
+		t.Fatalf("确认后更新失败: %v", err)
+	}
+	if resp.Matched != 2 || resp.Modified != 2 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if users.get(bulkOperatorID).Status != 1 {
+		t.Fatal("操作人自己的账户不应被修改")
+	}
+}
+
+func TestBulkUpdateDisableRevokesSessions(t *testing.T) {
+	svc, users, audits, sessions := newBulkUpdateTestService(3)
+	ctx := context.Background()
+	for _, id := range []uint{1, 2, 3} {
+		_ = sessions.Create(ctx, &session.Session{UserID: id, TokenID: "t", ExpiresAt: time.Now().Add(time.Hour)})
+	}
+
+	resp, err := svc.BulkUpdateUsers(ctx, &user.BulkUpdateRequest{
+		Filter: user.BulkUpdateFilter{IDs: []uint{1, 2}},
+		Patch:  user.BulkUpdatePatch{Status: intPtr(0)},
+	}, bulkOperatorID)
+	if err != nil {
+		t.Fatalf("批量更新失败: %v", err)
+	}
+	if resp.Matched != 2 || resp.Modified != 2 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if users.get(1).Status != 0 || users.get(2).Status != 0 || users.get(3).Status != 1 {
+		t.Fatal("只有过滤条件匹配的用户应被禁用")
+	}
+	if sessions.activeCount(1) != 0 || sessions.activeCount(2) != 0 || sessions.activeCount(3) != 1 {
+		t.Fatal("被禁用用户的会话应被吊销，其他用户不受影响")
+	}
+	if actions := audits.actions(); len(actions) != 1 || actions[0] != "user.bulk_update" {
+		t.Fatalf("审计日志 = %v", actions)
+	}
+}
+
+func TestBulkUpdateRejectsOverCap(t *testing.T) {
+	svc, users, _, _ := newBulkUpdateTestService(maxBulkUpdateUsers + 1)
+
+	_, err := svc.BulkUpdateUsers(context.Background(), &user.BulkUpdateRequest{
+		Filter: user.BulkUpdateFilter{Roles: []string{user.RoleUser}},
+		Patch:  user.BulkUpdatePatch{Status: intPtr(0)},
+	}, bulkOperatorID)
+	if !errors.Is(err, ErrBulkUpdateTooLarge) {
+		t.Fatalf("err = %v, want ErrBulkUpdateTooLarge", err)
+	}
+	if users.get(1).Status != 1 {
+		t.Fatal("超过上限时不应修改任何用户")
+	}
+}
+
+// growingUserRepo 在取出ID之后插入新的匹配用户，模拟统计与更新之间的并发写入
+type growingUserRepo struct {
+	*fakeUserRepo
+}
+
+func (r growingUserRepo) FindIDs(ctx context.Context, conditions map[string]interface{}, limit int) ([]uint, error) {
+	ids, err := r.fakeUserRepo.FindIDs(ctx, conditions, limit)
+	r.mu.Lock()
+	r.users[50] = &user.User{ID: 50, Role: user.RoleUser, Status: 1}
+	r.mu.Unlock()
+	return ids, err
+}
+
+func TestBulkUpdateOnlyTouchesCollectedIDs(t *testing.T) {
+	_, users, audits, sessions := newBulkUpdateTestService(2)
+	svc := newTestUserService(users, audits, sessions, nil)
+	svc.userRepo = growingUserRepo{users}
+
+	resp, err := svc.BulkUpdateUsers(context.Background(), &user.BulkUpdateRequest{
+		Filter: user.BulkUpdateFilter{Roles: []string{user.RoleUser}},
+		Patch:  user.BulkUpdatePatch{Status: intPtr(0)},
+	}, bulkOperatorID)
+	if err != nil {
+		t.Fatalf("批量更新失败: %v", err)
+	}
+	if resp.Matched != 2 {
+		t.Fatalf("Matched = %d, want 2", resp.Matched)
+	}
+	if users.get(50).Status != 1 {
+		t.Fatal("取出ID之后新增的用户不应被更新")
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/models/audit"
+	"go-app/models/user"
+)
+
+func TestBulkVerifyMarksTargetedUsers(t *testing.T) {
+	svc, users, audits, _ := newBulkUpdateTestService(4)
+	earlier := time.Now().Add(-24 * time.Hour)
+	users.users[3].EmailVerifiedAt = &earlier
+
+	resp, err := svc.BulkVerifyUsers(context.Background(), &user.BulkVerifyRequest{
+		Filter: user.BulkUpdateFilter{IDs: []uint{1, 2, 3}},
+	}, bulkOperatorID)
+	if err != nil {
+		t.Fatalf("批量验证失败: %v", err)
+	}
+	// 已验证的用户不计入匹配数
+	if resp.Matched != 2 || resp.Modified != 2 {
+		t.Fatalf("resp = %+v, want matched=2 modified=2", resp)
+	}
+	for _, id := range []uint{1, 2} {
+		if users.get(id).EmailVerifiedAt == nil {
+			t.Fatalf("用户 %d 应被标记为已验证", id)
+		}
+	}
+	if got := users.get(3).EmailVerifiedAt; got == nil || !got.Equal(earlier) {
+		t.Fatalf("已验证用户的验证时间不应改变: %v", got)
+	}
+	if users.get(4).EmailVerifiedAt != nil {
+		t.Fatal("不在过滤条件内的用户不应被修改")
+	}
+
+	if len(audits.entries) != 1 || audits.entries[0].Action != audit.ActionUserBulkVerify {
+		t.Fatalf("audit entries = %+v", audits.entries)
+	}
+	ids, _ := audits.entries[0].Detail["user_ids"].([]uint)
+	if len(ids) != 2 {
+		t.Fatalf("审计日志中的用户ID = %v", audits.entries[0].Detail["user_ids"])
+	}
+}
+
+func TestBulkVerifyEmptyFilterRequiresConfirm(t *testing.T) {
+	svc, users, _, _ := newBulkUpdateTestService(2)
+	ctx := context.Background()
+
+	if _, err := svc.BulkVerifyUsers(ctx, &user.BulkVerifyRequest{}, bulkOperatorID); !errors.Is(err, ErrBulkUpdateUnconfirmed) {
+		t.Fatalf("err = %v, want ErrBulkUpdateUnconfirmed", err)
+	}
+	if users.get(1).EmailVerifiedAt != nil {
+		t.Fatal("未确认时不应修改数据")
+	}
+
+	resp, err := svc.BulkVerifyUsers(ctx, &user.BulkVerifyRequest{DryRun: true}, bulkOperatorID)
+	if err != nil || !resp.DryRun || resp.Matched != 3 {
+		t.Fatalf("试运行: resp = %+v, err = %v", resp, err)
+	}
+	if users.get(1).EmailVerifiedAt != nil {
+		t.Fatal("试运行不应修改数据")
+	}
+
+	// 确认后验证所有用户（包括操作者本人）
+	resp, err = svc.BulkVerifyUsers(ctx, &user.BulkVerifyRequest{Confirm: true}, bulkOperatorID)
+	if err != nil || resp.Modified != 3 {
+		t.Fatalf("确认后: resp = %+v, err = %v", resp, err)
+	}
+}
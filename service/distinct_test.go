@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go-app/models/user"
+)
+
+func newDistinctTestService() *UserServiceImpl {
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Email: "a@example.com", Status: 1},
+		&user.User{ID: 2, Email: "b@Example.com", Status: 0},
+		&user.User{ID: 3, Email: "c@test.org", Status: 1},
+		&user.User{ID: 4, Email: "d@deleted.org", Status: 2, Deleted: true},
+	)
+	return newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+}
+
+func TestDistinctValues(t *testing.T) {
+	svc := newDistinctTestService()
+	ctx := context.Background()
+
+	statuses, err := svc.DistinctValues(ctx, "status")
+	if err != nil {
+		t.Fatalf("DistinctValues(status): %v", err)
+	}
+	if want := []interface{}{1, 0}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("status = %v, want %v", statuses, want)
+	}
+
+	// 邮箱只返回域名，大小写不同的域名视为同一个
+	domains, err := svc.DistinctValues(ctx, "email_domain")
+	if err != nil {
+		t.Fatalf("DistinctValues(email_domain): %v", err)
+	}
+	if want := []interface{}{"example.com", "test.org"}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("email_domain = %v, want %v", domains, want)
+	}
+}
+
+func TestDistinctValuesRejectsUnlistedField(t *testing.T) {
+	svc := newDistinctTestService()
+	for _, field := range []string{"email", "username", "password", ""} {
+		if _, err := svc.DistinctValues(context.Background(), field); err == nil {
+			t.Errorf("DistinctValues(%q) 应该返回错误", field)
+		}
+	}
+}
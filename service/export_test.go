@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-app/models/apikey"
+	"go-app/models/audit"
+	"go-app/models/session"
+	"go-app/models/user"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExportUserDataOmitsPassword(t *testing.T) {
+	const hashed = "$2a$10$abcdefghijklmnopqrstuuM6hUv3fYqJbV2oQ1s8iO3tqJ5nLr9aW"
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hashed, Status: 1})
+	audits := &fakeAuditRepo{}
+	_ = audits.Create(&audit.Entry{UserID: 1, ActorID: 1, Action: audit.ActionUserLogin})
+	_ = audits.Create(&audit.Entry{UserID: 2, ActorID: 2, Action: audit.ActionUserLogin})
+	sessions := &fakeSessionRepo{}
+	alice := &session.Session{UserID: 1, TokenID: "jti-alice", CreatedAt: time.Now()}
+	_ = sessions.Create(context.Background(), alice)
+	_ = sessions.Create(context.Background(), &session.Session{UserID: 2, TokenID: "jti-bob", CreatedAt: time.Now()})
+	_ = sessions.Revoke(context.Background(), []primitive.ObjectID{alice.ID})
+	svc := newTestUserService(users, audits, sessions, nil)
+	keys := svc.apiKeyRepo.(*fakeAPIKeyRepo)
+	_ = keys.Create(&apikey.APIKey{UserID: 1, Name: "ci", KeyHash: "alice-key-hash", Prefix: "gak_abcd", Scopes: []string{apikey.ScopeRead}})
+	_ = keys.Create(&apikey.APIKey{UserID: 2, Name: "bob", KeyHash: "bob-key-hash"})
+
+	result, err := svc.ExportUserData(context.Background(), 1, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+	if result.Profile.Username != "alice" || result.Profile.Email != "alice@example.com" {
+		t.Fatalf("profile = %+v", result.Profile)
+	}
+	if len(result.AuditLogs) != 1 || result.AuditLogs[0].Action != audit.ActionUserLogin {
+		t.Fatalf("只应包含本人的审计日志: %+v", result.AuditLogs)
+	}
+	// 已吊销的会话仍由系统保存，同样需要导出
+	if len(result.Sessions) != 1 || result.Sessions[0].UserID != 1 || result.Sessions[0].RevokedAt == nil {
+		t.Fatalf("只应包含本人的会话: %+v", result.Sessions)
+	}
+	if len(result.APIKeys) != 1 || result.APIKeys[0].Name != "ci" || result.APIKeys[0].Prefix != "gak_abcd" {
+		t.Fatalf("只应包含本人的API密钥: %+v", result.APIKeys)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	if strings.Contains(string(data), hashed) || strings.Contains(strings.ToLower(string(data)), `"password"`) {
+		t.Fatalf("导出数据包含密码: %s", data)
+	}
+	for _, secret := range []string{"jti-alice", "alice-key-hash", `"token_id"`, `"key_hash"`} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("导出数据包含凭证 %s: %s", secret, data)
+		}
+	}
+	for _, field := range []string{`"sessions"`, `"api_keys"`} {
+		if !strings.Contains(string(data), field) {
+			t.Fatalf("导出数据缺少 %s: %s", field, data)
+		}
+	}
+
+	// 导出操作本身记录到审计日志
+	if got := audits.actions(); got[len(got)-1] != audit.ActionUserExport {
+		t.Fatalf("审计日志 = %v", got)
+	}
+}
+
+func TestExportUserDataRateLimited(t *testing.T) {
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Status: 1})
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	ctx := context.Background()
+
+	for i := 0; i < exportMaxAttempts; i++ {
+		if _, err := svc.ExportUserData(ctx, 1, "10.0.0.1"); err != nil {
+			t.Fatalf("第%d次导出: %v", i+1, err)
+		}
+	}
+	if _, err := svc.ExportUserData(ctx, 1, "10.0.0.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("超出次数: err = %v, want ErrTooManyAttempts", err)
+	}
+}
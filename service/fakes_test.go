@@ -0,0 +1,715 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go-app/config"
+	"go-app/database/repositories"
+	"go-app/models/apikey"
+	"go-app/models/audit"
+	"go-app/models/session"
+	"go-app/models/user"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeUserRepo 基于内存的用户存储库，只实现测试用到的方法
+type fakeUserRepo struct {
+	repositories.NullUserRepository
+	mu    sync.Mutex
+	users map[uint]*user.User
+}
+
+func newFakeUserRepo(users ...*user.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[uint]*user.User)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+// get 返回用户的副本，不存在时返回nil
+func (r *fakeUserRepo) get(id uint) *user.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	cp := *u
+	return &cp
+}
+
+// snapshot 复制全部用户，供 fakeTransactions 回滚
+func (r *fakeUserRepo) snapshot() map[uint]*user.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := make(map[uint]*user.User, len(r.users))
+	for id, u := range r.users {
+		c := *u
+		cp[id] = &c
+	}
+	return cp
+}
+
+func (r *fakeUserRepo) restore(users map[uint]*user.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users = users
+}
+
+func (r *fakeUserRepo) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	if u := r.get(id); u != nil {
+		return u, nil
+	}
+	return nil, errors.New("用户不存在")
+}
+
+func (r *fakeUserRepo) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Username == username && !u.Deleted {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, errors.New("用户不存在")
+}
+
+func (r *fakeUserRepo) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email && !u.Deleted {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, errors.New("用户不存在")
+}
+
+// Create 按顺序分配ID，用户名或邮箱与未删除的用户重复时返回 ErrDuplicateUser
+func (r *fakeUserRepo) Create(ctx context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var maxID uint
+	for id, existing := range r.users {
+		if !existing.Deleted && (existing.Username == u.Username || existing.Email == u.Email) {
+			return repositories.ErrDuplicateUser
+		}
+		if id > maxID {
+			maxID = id
+		}
+	}
+	u.ID = maxID + 1
+	cp := *u
+	r.users[u.ID] = &cp
+	return nil
+}
+
+// Update 与MongoDB实现一致，只写入资料字段，并把最新状态写回 u
+func (r *fakeUserRepo) Update(ctx context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return errors.New("用户不存在")
+	}
+	stored.Nickname = u.Nickname
+	stored.Avatar = u.Avatar
+	stored.UpdatedAt = time.Now()
+	*u = *stored
+	return nil
+}
+
+func (r *fakeUserRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Deleted {
+		return errors.New("用户不存在")
+	}
+	u.Deleted = true
+	return nil
+}
+
+// Restore 恢复已删除的用户，用户名或邮箱与未删除的用户重复时返回 ErrDuplicateUser
+func (r *fakeUserRepo) Restore(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || !u.Deleted {
+		return errors.New("用户不存在或未被删除")
+	}
+	for otherID, other := range r.users {
+		if otherID != id && !other.Deleted && (other.Username == u.Username || other.Email == u.Email) {
+			return repositories.ErrDuplicateUser
+		}
+	}
+	u.Deleted = false
+	return nil
+}
+
+// ForEach 按ID顺序遍历用户的副本
+func (r *fakeUserRepo) ForEach(ctx context.Context, fn func(u *user.User) error) error {
+	r.mu.Lock()
+	ids := make([]uint, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	r.mu.Unlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		if u := r.get(id); u != nil {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) ForcePasswordReset(ctx context.Context, id uint, tokenHash string, expiresAt, changedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Deleted {
+		return errors.New("用户不存在")
+	}
+	u.PasswordResetRequired = true
+	u.PasswordChangedAt = &changedAt
+	u.PasswordResetTokenHash = tokenHash
+	u.PasswordResetExpiresAt = &expiresAt
+	return nil
+}
+
+// SetEmailVerificationToken 与Mongo实现一致：邮箱未验证且不在冷却期内时才更新
+func (r *fakeUserRepo) SetEmailVerificationToken(ctx context.Context, id uint, tokenHash string, expiresAt, sentAt, cooldownStart time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Deleted || u.EmailVerifiedAt != nil {
+		return false, nil
+	}
+	if u.EmailVerificationSentAt != nil && !u.EmailVerificationSentAt.Before(cooldownStart) {
+		return false, nil
+	}
+	u.EmailVerificationTokenHash = tokenHash
+	u.EmailVerificationExpiresAt = &expiresAt
+	u.EmailVerificationSentAt = &sentAt
+	return true, nil
+}
+
+func (r *fakeUserRepo) ReplacePassword(ctx context.Context, id uint, oldPassword, newPassword string, resetRequired bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Password != oldPassword {
+		return false, nil
+	}
+	u.Password = newPassword
+	if resetRequired {
+		u.PasswordResetRequired = true
+	}
+	return true, nil
+}
+
+// matches 判断用户是否符合批量操作的条件，只支持测试用到的条件
+func matches(u *user.User, conditions map[string]interface{}) bool {
+	if u.Deleted {
+		return false
+	}
+	for k, v := range conditions {
+		switch k {
+		case "ids":
+			found := false
+			for _, id := range v.([]uint) {
+				found = found || id == u.ID
+			}
+			if !found {
+				return false
+			}
+		case "exclude_id":
+			if u.ID == v.(uint) {
+				return false
+			}
+		case "status":
+			if u.Status != v.(int) {
+				return false
+			}
+		case "role":
+			found := false
+			for _, role := range v.([]string) {
+				found = found || role == u.Role
+			}
+			if !found {
+				return false
+			}
+		case "email_verified":
+			if (u.EmailVerifiedAt != nil) != v.(bool) {
+				return false
+			}
+		default:
+			panic("fakeUserRepo 不支持的条件: " + k)
+		}
+	}
+	return true
+}
+
+func (r *fakeUserRepo) Count(ctx context.Context, conditions map[string]interface{}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, u := range r.users {
+		if matches(u, conditions) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// FindAll 忽略排序参数，按ID升序分页
+func (r *fakeUserRepo) FindAll(ctx context.Context, page, pageSize int, conditions map[string]interface{}, _ bson.D) ([]user.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []user.User
+	for _, u := range r.users {
+		if matches(u, conditions) {
+			all = append(all, *u)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	total := int64(len(all))
+	start := (page - 1) * pageSize
+	if start >= len(all) {
+		return nil, total, nil
+	}
+	end := start + pageSize
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[start:end], total, nil
+}
+
+// FindAfter 与Mongo实现一致：按创建时间倒序、相同时按ID倒序
+func (r *fakeUserRepo) FindAfter(ctx context.Context, lastCreatedAt time.Time, lastID uint, limit int, conditions map[string]interface{}) ([]user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []user.User
+	for _, u := range r.users {
+		if !matches(u, conditions) {
+			continue
+		}
+		if !lastCreatedAt.IsZero() && !u.CreatedAt.Before(lastCreatedAt) &&
+			!(u.CreatedAt.Equal(lastCreatedAt) && u.ID < lastID) {
+			continue
+		}
+		all = append(all, *u)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// Distinct 只支持 status 和 email 字段，按ID升序去重
+func (r *fakeUserRepo) Distinct(ctx context.Context, field string) ([]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]uint, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	seen := make(map[interface{}]bool)
+	values := make([]interface{}, 0)
+	for _, id := range ids {
+		u := r.users[id]
+		if u.Deleted {
+			continue
+		}
+		var v interface{}
+		switch field {
+		case "status":
+			v = u.Status
+		case "email":
+			v = u.Email
+		default:
+			return nil, errors.New("不支持的字段: " + field)
+		}
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (r *fakeUserRepo) FindIDs(ctx context.Context, conditions map[string]interface{}, limit int) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uint
+	for id, u := range r.users {
+		if matches(u, conditions) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+// UpdateMany 支持 status 和 role 字段
+func (r *fakeUserRepo) UpdateMany(ctx context.Context, conditions map[string]interface{}, fields map[string]interface{}) (int64, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched, modified int64
+	for _, u := range r.users {
+		if !matches(u, conditions) {
+			continue
+		}
+		matched++
+		before := *u
+		for k, v := range fields {
+			switch k {
+			case "status":
+				u.Status = v.(int)
+			case "role":
+				u.Role = v.(string)
+			case "email_verified_at":
+				at := v.(time.Time)
+				u.EmailVerifiedAt = &at
+			default:
+				panic("fakeUserRepo 不支持的字段: " + k)
+			}
+		}
+		if before.Status != u.Status || before.Role != u.Role || before.EmailVerifiedAt != u.EmailVerifiedAt {
+			modified++
+		}
+	}
+	return matched, modified, nil
+}
+
+func (r *fakeUserRepo) IncrementFailedLogins(ctx context.Context, id uint, now time.Time) (int, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Deleted {
+		return 0, false, errors.New("用户不存在")
+	}
+	if u.IsLocked(now) {
+		return 0, true, nil
+	}
+	u.FailedLoginCount++
+	return u.FailedLoginCount, false, nil
+}
+
+func (r *fakeUserRepo) LockUntil(ctx context.Context, id uint, attempts int, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.Deleted || u.FailedLoginCount < attempts {
+		return false, nil
+	}
+	u.LockedUntil = &until
+	u.FailedLoginCount = 0
+	return true, nil
+}
+
+func (r *fakeUserRepo) ResetFailedLogins(ctx context.Context, id uint, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.IsLocked(now) {
+		return false, nil
+	}
+	u.FailedLoginCount = 0
+	u.LockedUntil = nil
+	return true, nil
+}
+
+// fakeAuditRepo 记录写入的审计日志
+type fakeAuditRepo struct {
+	repositories.NullAuditRepository
+	mu      sync.Mutex
+	entries []*audit.Entry
+}
+
+func (r *fakeAuditRepo) Create(entry *audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeAuditRepo) ReassignUser(ctx context.Context, fromUserID, toUserID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, e := range r.entries {
+		if e.UserID == fromUserID {
+			e.UserID = toUserID
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeAuditRepo) FindByUser(userID uint) ([]*audit.Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []*audit.Entry
+	for _, e := range r.entries {
+		if e.UserID == userID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// actions 返回已写入的审计日志的操作类型
+func (r *fakeAuditRepo) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var actions []string
+	for _, e := range r.entries {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+// fakeSessionRepo 基于内存的会话存储库
+type fakeSessionRepo struct {
+	repositories.NullSessionRepository
+	mu       sync.Mutex
+	sessions []*session.Session
+	// 不为nil时 RevokeByUser 返回该错误
+	revokeErr error
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, s *session.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.ID = primitive.NewObjectID()
+	r.sessions = append(r.sessions, s)
+	return nil
+}
+
+func (r *fakeSessionRepo) FindActiveByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*session.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeSessionRepo) FindByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*session.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) IsActive(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.TokenID == tokenID {
+			return s.RevokedAt == nil, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, ids []primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, s := range r.sessions {
+		for _, id := range ids {
+			if s.ID == id && s.RevokedAt == nil {
+				s.RevokedAt = &now
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	if r.revokeErr != nil {
+		return 0, r.revokeErr
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var n int64
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeSessionRepo) RevokeByUsers(ctx context.Context, userIDs []uint) (int64, error) {
+	var n int64
+	for _, id := range userIDs {
+		revoked, err := r.RevokeByUser(ctx, id)
+		if err != nil {
+			return n, err
+		}
+		n += revoked
+	}
+	return n, nil
+}
+
+// activeCount 返回用户未吊销的会话数
+func (r *fakeSessionRepo) activeCount(userID uint) int {
+	active, _ := r.FindActiveByUser(context.Background(), userID)
+	return len(active)
+}
+
+// fakeAPIKeyRepo 基于内存的API密钥存储库
+type fakeAPIKeyRepo struct {
+	repositories.NullAPIKeyRepository
+	mu   sync.Mutex
+	keys []*apikey.APIKey
+}
+
+func (r *fakeAPIKeyRepo) Create(key *apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now()
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) FindByHash(keyHash string) (*apikey.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash && k.RevokedAt == nil {
+			cp := *k
+			return &cp, nil
+		}
+	}
+	return nil, errors.New("API密钥不存在")
+}
+
+func (r *fakeAPIKeyRepo) FindByUser(userID uint) ([]*apikey.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []*apikey.APIKey
+	for _, k := range r.keys {
+		if k.UserID == userID && k.RevokedAt == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) FindAllByUser(ctx context.Context, userID uint) ([]*apikey.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []*apikey.APIKey
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(id string, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, k := range r.keys {
+		if k.ID.Hex() == id && k.UserID == userID && k.RevokedAt == nil {
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return errors.New("API密钥不存在")
+}
+
+func (r *fakeAPIKeyRepo) RevokeByUser(ctx context.Context, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var n int64
+	for _, k := range r.keys {
+		if k.UserID == userID && k.RevokedAt == nil {
+			k.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.ID == id {
+			k.LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+// fakeTransactions 模拟事务：fn 返回错误时把用户存储库恢复到执行前的状态
+type fakeTransactions struct {
+	users *fakeUserRepo
+}
+
+func (t fakeTransactions) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	var before map[uint]*user.User
+	if t.users != nil {
+		before = t.users.snapshot()
+	}
+	sessCtx := mongo.NewSessionContext(ctx, nil)
+	if err := fn(sessCtx); err != nil {
+		if t.users != nil {
+			t.users.restore(before)
+		}
+		return err
+	}
+	return nil
+}
+
+// newTestUserService 使用内存存储库创建用户服务
+func newTestUserService(users *fakeUserRepo, audits *fakeAuditRepo, sessions *fakeSessionRepo, cfg *config.Config) *UserServiceImpl {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return NewUserService(users, audits, sessions, &fakeAPIKeyRepo{}, fakeTransactions{users: users}, cfg).(*UserServiceImpl)
+}
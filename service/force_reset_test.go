@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/apikey"
+	"go-app/models/user"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const forceResetOperatorID = 99
+
+func newForceResetTestService(t *testing.T, users *fakeUserRepo) (*UserServiceImpl, APIKeyService) {
+	t.Helper()
+	keys := &fakeAPIKeyRepo{}
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.Expire = time.Hour
+	svc := NewUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, keys, fakeTransactions{users: users}, cfg).(*UserServiceImpl)
+	return svc, NewAPIKeyService(keys, users)
+}
+
+func newForceResetUser(t *testing.T) *fakeUserRepo {
+	t.Helper()
+	hashed, err := middleware.HashPassword(lockoutTestPassword)
+	if err != nil {
+		t.Fatalf("密码哈希失败: %v", err)
+	}
+	return newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hashed, Status: 1})
+}
+
+func forceReset(t *testing.T, svc *UserServiceImpl, keepAPIKeys bool) *user.ForcePasswordResetResponse {
+	t.Helper()
+	result, err := svc.ForcePasswordReset(context.Background(), &user.ForcePasswordResetRequest{
+		Filter:      user.BulkUpdateFilter{IDs: []uint{1}},
+		KeepAPIKeys: keepAPIKeys,
+	}, forceResetOperatorID)
+	if err != nil {
+		t.Fatalf("ForcePasswordReset: %v", err)
+	}
+	if result.Reset != 1 || len(result.Tokens) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	return result
+}
+
+func TestForcePasswordResetRevokesCredentials(t *testing.T) {
+	users := newForceResetUser(t)
+	svc, keys := newForceResetTestService(t, users)
+	ctx := context.Background()
+
+	_, token, err := svc.Login(ctx, &user.LoginRequest{Username: "alice", Password: lockoutTestPassword}, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	_, key, err := keys.Create(1, &apikey.CreateRequest{Name: "ci", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+
+	// 令牌签发时间精确到秒，同一秒内签发的令牌视为重置之后，等到下一秒再重置
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	result := forceReset(t, svc, false)
+	if result.APIKeysRevoked != 1 {
+		t.Fatalf("APIKeysRevoked = %d, want 1", result.APIKeysRevoked)
+	}
+
+	if _, _, err := svc.ValidateToken(ctx, token); err == nil {
+		t.Fatal("重置前签发的令牌应该失效")
+	}
+	if _, _, err := keys.AuthenticateAPIKey(ctx, key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("API密钥: err = %v, want ErrInvalidAPIKey", err)
+	}
+	if err := login(svc, lockoutTestPassword); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("原密码登录: err = %v, want ErrPasswordResetRequired", err)
+	}
+}
+
+func TestForcePasswordResetCanKeepAPIKeys(t *testing.T) {
+	users := newForceResetUser(t)
+	svc, keys := newForceResetTestService(t, users)
+
+	_, key, err := keys.Create(1, &apikey.CreateRequest{Name: "ci", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+	if result := forceReset(t, svc, true); result.APIKeysRevoked != 0 {
+		t.Fatalf("APIKeysRevoked = %d, want 0", result.APIKeysRevoked)
+	}
+	if _, _, err := keys.AuthenticateAPIKey(context.Background(), key); err != nil {
+		t.Fatalf("keep_api_keys 时API密钥应保持有效: %v", err)
+	}
+}
+
+// resetRacingUserRepo 在读取用户之后、写入之前强制重置密码，模拟与强制重置并发的请求
+type resetRacingUserRepo struct {
+	*fakeUserRepo
+}
+
+func (r resetRacingUserRepo) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	u, err := r.fakeUserRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cp := *u
+	_ = r.fakeUserRepo.ForcePasswordReset(ctx, id, "hash", time.Now().Add(time.Hour), time.Now())
+	return &cp, nil
+}
+
+func (r resetRacingUserRepo) ResetFailedLogins(ctx context.Context, id uint, now time.Time) (bool, error) {
+	_ = r.fakeUserRepo.ForcePasswordReset(ctx, id, "hash", now.Add(time.Hour), now)
+	return r.fakeUserRepo.ResetFailedLogins(ctx, id, now)
+}
+
+func TestProfileUpdateKeepsConcurrentForcedReset(t *testing.T) {
+	users := newForceResetUser(t)
+	svc := NewUserService(resetRacingUserRepo{users}, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{users: users}, &config.Config{})
+
+	nickname := "Alice"
+	u, err := svc.PatchProfile(context.Background(), 1, &user.PatchProfileRequest{Nickname: &nickname})
+	if err != nil {
+		t.Fatalf("PatchProfile: %v", err)
+	}
+	if u.Nickname != "Alice" {
+		t.Fatalf("Nickname = %q", u.Nickname)
+	}
+	if !users.get(1).PasswordResetRequired {
+		t.Fatal("资料更新不应清除并发设置的重置标记")
+	}
+}
+
+func TestLoginRehashKeepsConcurrentForcedReset(t *testing.T) {
+	weak, err := bcrypt.GenerateFromPassword([]byte(lockoutTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("密码哈希失败: %v", err)
+	}
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: string(weak), Status: 1})
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.Expire = time.Hour
+	svc := NewUserService(resetRacingUserRepo{users}, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{users: users}, cfg).(*UserServiceImpl)
+
+	_ = login(svc, lockoutTestPassword)
+	got := users.get(1)
+	if !got.PasswordResetRequired {
+		t.Fatal("登录时升级密码哈希不应清除并发设置的重置标记")
+	}
+	if middleware.PasswordNeedsRehash(got.Password) {
+		t.Fatal("密码哈希应已升级")
+	}
+}
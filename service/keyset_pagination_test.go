@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/models/common"
+	"go-app/models/user"
+)
+
+func TestGetUsersAfterFollowsNextCursor(t *testing.T) {
+	// 每三个用户共享同一个创建时间，翻页边界会落在创建时间相同的用户之间
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := newFakeUserRepo()
+	for i := 1; i <= 25; i++ {
+		users.users[uint(i)] = &user.User{ID: uint(i), Username: "u", CreatedAt: base.Add(time.Duration(i/3) * time.Minute)}
+	}
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+
+	seen := map[uint]bool{}
+	var order []user.User
+	cursor := ""
+	for page := 1; ; page++ {
+		list, next, err := svc.GetUsersAfter(context.Background(), cursor, 10, user.ListFilter{})
+		if err != nil {
+			t.Fatalf("第%d页: %v", page, err)
+		}
+		for _, u := range list {
+			if seen[u.ID] {
+				t.Fatalf("第%d页重复返回用户 %d", page, u.ID)
+			}
+			seen[u.ID] = true
+			order = append(order, u)
+		}
+		if next == "" {
+			break
+		}
+		if page > 3 {
+			t.Fatal("翻页未结束")
+		}
+		cursor = next
+	}
+
+	if len(seen) != 25 {
+		t.Fatalf("共返回 %d 个用户, want 25", len(seen))
+	}
+	for i := 1; i < len(order); i++ {
+		prev, cur := order[i-1], order[i]
+		if cur.CreatedAt.After(prev.CreatedAt) || (cur.CreatedAt.Equal(prev.CreatedAt) && cur.ID > prev.ID) {
+			t.Fatalf("顺序错误: %d 之后是 %d", prev.ID, cur.ID)
+		}
+	}
+}
+
+func TestGetUsersAfterRejectsInvalidCursor(t *testing.T) {
+	svc := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	forged, err := common.EncodeCursor("not-a-time", 1)
+	if err != nil {
+		t.Fatalf("EncodeCursor: %v", err)
+	}
+	for _, cursor := range []string{"garbage", forged} {
+		if _, _, err := svc.GetUsersAfter(context.Background(), cursor, 10, user.ListFilter{}); !errors.Is(err, common.ErrInvalidCursor) {
+			t.Fatalf("GetUsersAfter(%q): err = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
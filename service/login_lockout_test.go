@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/user"
+)
+
+const lockoutTestPassword = "Str0ng!Passw0rd"
+
+func newLockoutTestService(t *testing.T, maxAttempts int) (*UserServiceImpl, *fakeUserRepo) {
+	t.Helper()
+	hashed, err := middleware.HashPassword(lockoutTestPassword)
+	if err != nil {
+		t.Fatalf("密码哈希失败: %v", err)
+	}
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hashed, Status: 1})
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.Expire = time.Hour
+	cfg.Security.LoginMaxAttempts = maxAttempts
+	cfg.Security.LoginLockoutDuration = time.Minute
+	return newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, cfg), users
+}
+
+func login(svc *UserServiceImpl, password string) error {
+	_, _, err := svc.Login(context.Background(), &user.LoginRequest{Username: "alice", Password: password}, "10.0.0.1")
+	return err
+}
+
+func TestLoginLocksAccountAfterMaxAttempts(t *testing.T) {
+	svc, users := newLockoutTestService(t, 3)
+
+	for i := 1; i < 3; i++ {
+		if err := login(svc, "wrong"); err == nil || errors.Is(err, ErrAccountLocked) {
+			t.Fatalf("第%d次失败: err = %v, want 用户名或密码错误", i, err)
+		}
+	}
+	if err := login(svc, "wrong"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("达到上限: err = %v, want ErrAccountLocked", err)
+	}
+
+	// 锁定期间正确的密码也被拒绝，失败请求不再计数
+	if err := login(svc, lockoutTestPassword); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("锁定期间: err = %v, want ErrAccountLocked", err)
+	}
+	if err := login(svc, "wrong"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("锁定期间: err = %v, want ErrAccountLocked", err)
+	}
+	if u := users.get(1); u.FailedLoginCount != 0 {
+		t.Fatalf("锁定期间失败次数 = %d, want 0", u.FailedLoginCount)
+	}
+
+	// 锁定过期后可以登录，失败次数和锁定时间被清除
+	past := time.Now().Add(-time.Second)
+	users.users[1].LockedUntil = &past
+	if err := login(svc, lockoutTestPassword); err != nil {
+		t.Fatalf("锁定过期后登录失败: %v", err)
+	}
+	if u := users.get(1); u.LockedUntil != nil || u.FailedLoginCount != 0 {
+		t.Fatalf("登录成功后 LockedUntil = %v, FailedLoginCount = %d", u.LockedUntil, u.FailedLoginCount)
+	}
+}
+
+func TestLoginSuccessResetsFailedCount(t *testing.T) {
+	svc, users := newLockoutTestService(t, 3)
+
+	_ = login(svc, "wrong")
+	_ = login(svc, "wrong")
+	if err := login(svc, lockoutTestPassword); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if u := users.get(1); u.FailedLoginCount != 0 {
+		t.Fatalf("FailedLoginCount = %d, want 0", u.FailedLoginCount)
+	}
+	// 清零后重新计数，不会因为之前的失败提前锁定
+	_ = login(svc, "wrong")
+	if err := login(svc, "wrong"); errors.Is(err, ErrAccountLocked) {
+		t.Fatal("清零后不应提前锁定")
+	}
+}
+
+// staleUserRepo 模拟读取用户之后账户才被并发请求锁定：查询返回的是锁定前的状态
+type staleUserRepo struct {
+	*fakeUserRepo
+}
+
+func (r staleUserRepo) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	u, err := r.fakeUserRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	u.LockedUntil = nil
+	u.FailedLoginCount = 0
+	return u, nil
+}
+
+func TestLoginRejectsCorrectPasswordWhenLockedConcurrently(t *testing.T) {
+	_, users := newLockoutTestService(t, 3)
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	svc := NewUserService(staleUserRepo{users}, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{users: users}, cfg).(*UserServiceImpl)
+
+	until := time.Now().Add(time.Minute)
+	users.users[1].LockedUntil = &until
+
+	if err := login(svc, lockoutTestPassword); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("正确密码: err = %v, want ErrAccountLocked", err)
+	}
+	if err := login(svc, "wrong"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("错误密码: err = %v, want ErrAccountLocked", err)
+	}
+	if u := users.get(1); u.FailedLoginCount != 0 || u.LockedUntil == nil {
+		t.Fatalf("锁定状态被修改: LockedUntil = %v, FailedLoginCount = %d", u.LockedUntil, u.FailedLoginCount)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// Send 发送纯文本邮件
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+/*
+LogMailer 只把邮件写入应用日志的实现，未接入邮件服务时使用
+邮件正文中可能包含验证令牌等敏感信息，只适合本地开发，生产环境应通过 SetMailer 接入实际的邮件服务
+*/
+type LogMailer struct{}
+
+// Send 将邮件内容记录到日志
+func (LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	utils.Info("发送邮件（未配置邮件服务，仅记录日志）",
+		zap.String("to", to),
+		zap.String("subject", subject),
+		zap.String("body", body),
+	)
+	return nil
+}
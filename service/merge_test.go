@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-app/models/audit"
+	"go-app/models/session"
+	"go-app/models/user"
+)
+
+func newMergeFixture() (*fakeUserRepo, *fakeAuditRepo, *fakeSessionRepo) {
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Nickname: "Alice"},
+		&user.User{ID: 2, Username: "alice2", Email: "alice2@example.com", Avatar: "a.png"},
+	)
+	audits := &fakeAuditRepo{entries: []*audit.Entry{
+		{UserID: 2, Action: audit.ActionUserLogin},
+		{UserID: 2, Action: audit.ActionUserLogin},
+		{UserID: 1, Action: audit.ActionUserLogin},
+	}}
+	sessions := &fakeSessionRepo{sessions: []*session.Session{
+		{UserID: 2, TokenID: "s1"},
+		{UserID: 2, TokenID: "s2"},
+		{UserID: 1, TokenID: "t1"},
+	}}
+	return users, audits, sessions
+}
+
+func TestMergeUsersReassignsAuditsAndRevokesSourceSessions(t *testing.T) {
+	users, audits, sessions := newMergeFixture()
+	s := newTestUserService(users, audits, sessions, nil)
+
+	result, err := s.MergeUsers(context.Background(), &user.MergeUsersRequest{SourceID: 2, TargetID: 1}, 99)
+	if err != nil {
+		t.Fatalf("MergeUsers: %v", err)
+	}
+
+	if result.ReassignedAudits != 2 {
+		t.Errorf("ReassignedAudits = %d, want 2", result.ReassignedAudits)
+	}
+	if result.RevokedSessions != 2 {
+		t.Errorf("RevokedSessions = %d, want 2", result.RevokedSessions)
+	}
+	if n := sessions.activeCount(2); n != 0 {
+		t.Errorf("source still has %d active sessions", n)
+	}
+	if n := sessions.activeCount(1); n != 1 {
+		t.Errorf("target has %d active sessions, want 1", n)
+	}
+	if !users.get(2).Deleted {
+		t.Error("source account was not deleted")
+	}
+	if got := users.get(1); got.Avatar != "a.png" || got.Nickname != "Alice" {
+		t.Errorf("target profile = %q/%q, want Alice/a.png", got.Nickname, got.Avatar)
+	}
+	if got := result.Conflicts; len(got) != 2 || got[0] != "username" || got[1] != "email" {
+		t.Errorf("Conflicts = %v, want [username email]", got)
+	}
+	if actions := audits.actions(); actions[len(actions)-1] != audit.ActionUserMerge {
+		t.Errorf("audit actions = %v, want the merge recorded last", actions)
+	}
+}
+
+func TestMergeUsersRollsBackOnFailure(t *testing.T) {
+	users, audits, sessions := newMergeFixture()
+	sessions.revokeErr = errors.New("boom")
+	s := newTestUserService(users, audits, sessions, nil)
+
+	if _, err := s.MergeUsers(context.Background(), &user.MergeUsersRequest{SourceID: 2, TargetID: 1}, 99); err == nil {
+		t.Fatal("MergeUsers succeeded, want error")
+	}
+
+	if users.get(2).Deleted {
+		t.Error("source account deleted although the merge failed")
+	}
+	if got := users.get(1); got.Avatar != "" {
+		t.Errorf("target avatar = %q, want the update rolled back", got.Avatar)
+	}
+	for _, a := range audits.actions() {
+		if a == audit.ActionUserMerge {
+			t.Error("merge audit entry written although the merge failed")
+		}
+	}
+}
+
+func TestMergeUsersRejectsSameAccount(t *testing.T) {
+	users, audits, sessions := newMergeFixture()
+	s := newTestUserService(users, audits, sessions, nil)
+
+	if _, err := s.MergeUsers(context.Background(), &user.MergeUsersRequest{SourceID: 1, TargetID: 1}, 99); err == nil {
+		t.Fatal("MergeUsers merged an account into itself")
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/user"
+)
+
+func newPasswordChangeTestService(t *testing.T, maxAttempts int) *UserServiceImpl {
+	t.Helper()
+	hashed, err := middleware.HashPassword(lockoutTestPassword)
+	if err != nil {
+		t.Fatalf("密码哈希失败: %v", err)
+	}
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Password: hashed, Status: 1})
+	cfg := &config.Config{}
+	cfg.Security.PasswordChangeMaxAttempts = maxAttempts
+	cfg.Security.PasswordChangeWindow = time.Minute
+	return newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, cfg)
+}
+
+func changePassword(svc *UserServiceImpl, oldPassword, newPassword string) error {
+	return svc.ChangePassword(context.Background(), 1, &user.ChangePasswordRequest{OldPassword: oldPassword, NewPassword: newPassword}, "10.0.0.1")
+}
+
+func TestChangePasswordThrottlesWrongOldPassword(t *testing.T) {
+	svc := newPasswordChangeTestService(t, 3)
+
+	for i := 1; i <= 3; i++ {
+		if err := changePassword(svc, "wrong", "N3w!Passw0rd"); err == nil || errors.Is(err, ErrTooManyAttempts) {
+			t.Fatalf("第%d次失败: err = %v, want 原密码错误", i, err)
+		}
+	}
+	// 达到上限后正确的原密码也被拒绝
+	if err := changePassword(svc, lockoutTestPassword, "N3w!Passw0rd"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("达到上限: err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestChangePasswordSuccessResetsCounter(t *testing.T) {
+	svc := newPasswordChangeTestService(t, 3)
+
+	for i := 0; i < 2; i++ {
+		_ = changePassword(svc, "wrong", "N3w!Passw0rd")
+	}
+	if err := changePassword(svc, lockoutTestPassword, "N3w!Passw0rd"); err != nil {
+		t.Fatalf("修改密码: %v", err)
+	}
+
+	// 成功后重新计数，再失败两次不会触发限制
+	for i := 0; i < 2; i++ {
+		if err := changePassword(svc, "wrong", "An0ther!Passw0rd"); errors.Is(err, ErrTooManyAttempts) {
+			t.Fatalf("成功修改后第%d次失败被限制", i+1)
+		}
+	}
+	if err := changePassword(svc, "N3w!Passw0rd", "An0ther!Passw0rd"); err != nil {
+		t.Fatalf("修改密码: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go-app/models/user"
+)
+
+func newProfileTestService() (*UserServiceImpl, *fakeUserRepo) {
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Nickname: "Alice", Avatar: "https://cdn.example.com/a.png", Status: 1})
+	return newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil), users
+}
+
+func TestUpdateProfileReplacesAllFields(t *testing.T) {
+	svc, users := newProfileTestService()
+	// PUT 未提供的头像重置为空值
+	if _, err := svc.UpdateProfile(context.Background(), 1, &user.UpdateProfileRequest{Nickname: "Al"}); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	u := users.get(1)
+	if u.Nickname != "Al" || u.Avatar != "" {
+		t.Fatalf("nickname = %q, avatar = %q, want Al 和空值", u.Nickname, u.Avatar)
+	}
+}
+
+func TestPatchProfileUpdatesOnlyProvidedFields(t *testing.T) {
+	svc, users := newProfileTestService()
+	ctx := context.Background()
+
+	var req user.PatchProfileRequest
+	if err := json.Unmarshal([]byte(`{"nickname":"Al"}`), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+	if _, err := svc.PatchProfile(ctx, 1, &req); err != nil {
+		t.Fatalf("PatchProfile: %v", err)
+	}
+	u := users.get(1)
+	if u.Nickname != "Al" || u.Avatar != "https://cdn.example.com/a.png" {
+		t.Fatalf("nickname = %q, avatar = %q, 未提供的头像不应修改", u.Nickname, u.Avatar)
+	}
+
+	// 显式传入空字符串表示清空
+	req = user.PatchProfileRequest{}
+	if err := json.Unmarshal([]byte(`{"avatar":""}`), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+	if _, err := svc.PatchProfile(ctx, 1, &req); err != nil {
+		t.Fatalf("PatchProfile: %v", err)
+	}
+	u = users.get(1)
+	if u.Nickname != "Al" || u.Avatar != "" {
+		t.Fatalf("nickname = %q, avatar = %q, want Al 和空值", u.Nickname, u.Avatar)
+	}
+}
+
+func TestProfileUpdateUnknownUser(t *testing.T) {
+	svc, _ := newProfileTestService()
+	ctx := context.Background()
+
+	if _, err := svc.UpdateProfile(ctx, 2, &user.UpdateProfileRequest{}); err == nil {
+		t.Fatal("UpdateProfile 不存在的用户应返回错误")
+	}
+	if _, err := svc.PatchProfile(ctx, 2, &user.PatchProfileRequest{}); err == nil {
+		t.Fatal("PatchProfile 不存在的用户应返回错误")
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go-app/config"
+	"go-app/models/user"
+)
+
+// barrierUserRepo 所有注册请求都通过邮箱检查后才放行，确保检查与插入之间的并发窗口一定出现
+type barrierUserRepo struct {
+	*fakeUserRepo
+	checked sync.WaitGroup
+}
+
+func (r *barrierUserRepo) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	u, err := r.fakeUserRepo.FindByEmail(ctx, email)
+	r.checked.Done()
+	r.checked.Wait()
+	return u, err
+}
+
+func TestConcurrentRegisterSameEmail(t *testing.T) {
+	const n = 8
+	users := &barrierUserRepo{fakeUserRepo: newFakeUserRepo()}
+	users.checked.Add(n)
+	svc := NewUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{users: users.fakeUserRepo}, &config.Config{})
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.Register(context.Background(), &user.RegisterRequest{
+				Username: fmt.Sprintf("user%d", i),
+				Email:    "same@example.com",
+				Password: "Str0ng!Passw0rd",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrUserExists):
+			t.Errorf("第%d个注册: err = %v, want ErrUserExists", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("成功注册 %d 个, want 1", succeeded)
+	}
+	if got := len(users.snapshot()); got != 1 {
+		t.Fatalf("用户数 = %d, want 1", got)
+	}
+}
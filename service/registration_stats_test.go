@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/models/user"
+)
+
+// dailyCountUserRepo 返回预设的每日注册数，并记录查询的时间范围
+type dailyCountUserRepo struct {
+	*fakeUserRepo
+	counts   map[string]int64
+	from, to time.Time
+}
+
+func (r *dailyCountUserRepo) CountCreatedByDay(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	r.from, r.to = from, to
+	return r.counts, nil
+}
+
+func TestRegistrationStatsFillsMissingDays(t *testing.T) {
+	users := &dailyCountUserRepo{fakeUserRepo: newFakeUserRepo(), counts: map[string]int64{"2026-03-01": 2, "2026-03-03": 5}}
+	svc := NewUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{}, &config.Config{})
+
+	from := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	stats, err := svc.RegistrationStats(context.Background(), &user.RegistrationStatsQuery{From: from, To: to})
+	if err != nil {
+		t.Fatalf("RegistrationStats: %v", err)
+	}
+
+	// 开始时间取整到当天0点
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !users.from.Equal(want) || !stats.From.Equal(want) {
+		t.Fatalf("from = %s, want %s", users.from, want)
+	}
+	wantDays := []user.DailyCount{{Date: "2026-03-01", Count: 2}, {Date: "2026-03-02", Count: 0}, {Date: "2026-03-03", Count: 5}}
+	if len(stats.Days) != len(wantDays) {
+		t.Fatalf("days = %v", stats.Days)
+	}
+	for i, d := range wantDays {
+		if stats.Days[i] != d {
+			t.Fatalf("days[%d] = %v, want %v", i, stats.Days[i], d)
+		}
+	}
+	if stats.Total != 7 {
+		t.Fatalf("total = %d, want 7", stats.Total)
+	}
+}
+
+func TestRegistrationStatsRejectsInvalidRange(t *testing.T) {
+	svc := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	now := time.Now()
+
+	if _, err := svc.RegistrationStats(context.Background(), &user.RegistrationStatsQuery{From: now, To: now.Add(-time.Hour)}); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Fatalf("err = %v, want ErrInvalidTimeRange", err)
+	}
+	if _, err := svc.RegistrationStats(context.Background(), &user.RegistrationStatsQuery{From: now.AddDate(-2, 0, 0), To: now}); !errors.Is(err, ErrStatsRangeTooLarge) {
+		t.Fatalf("err = %v, want ErrStatsRangeTooLarge", err)
+	}
+}
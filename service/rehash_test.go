@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/user"
+)
+
+// waitRehash 等待后台迁移任务结束并返回最终状态
+func waitRehash(t *testing.T, svc *UserServiceImpl) *user.RehashPasswordsResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := svc.RehashPasswordsStatus()
+		if err != nil {
+			t.Fatalf("查询任务状态失败: %v", err)
+		}
+		if job.Status != user.RehashRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("迁移任务未在预期时间内结束")
+	return nil
+}
+
+func TestRehashPasswordsMigratesPlaintextInBackground(t *testing.T) {
+	hashed, err := middleware.HashPassword("already-hashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Username: "plain", Password: "secret123"},
+		&user.User{ID: 2, Username: "hashed", Password: hashed},
+		&user.User{ID: 3, Username: "md5", Password: "5f4dcc3b5aa765d61d8327deb882cf99"},
+	)
+	audits := &fakeAuditRepo{}
+	svc := newTestUserService(users, audits, &fakeSessionRepo{}, nil)
+
+	if _, err := svc.RehashPasswordsStatus(); !errors.Is(err, ErrRehashNotStarted) {
+		t.Fatalf("未执行时: err = %v, want ErrRehashNotStarted", err)
+	}
+
+	job, err := svc.StartRehashPasswords(9)
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	if job.Status != user.RehashRunning {
+		t.Fatalf("status = %q, want running", job.Status)
+	}
+	if _, err := svc.StartRehashPasswords(9); !errors.Is(err, ErrRehashRunning) {
+		t.Fatalf("重复启动: err = %v, want ErrRehashRunning", err)
+	}
+
+	job = waitRehash(t, svc)
+	if job.Status != user.RehashCompleted || job.FinishedAt == nil {
+		t.Fatalf("job = %+v", job)
+	}
+	if job.Scanned != 3 || job.Migrated != 1 || job.Flagged != 1 || job.Failed != 0 || job.Skipped != 0 {
+		t.Fatalf("job = %+v", job)
+	}
+
+	if u := users.get(1); !middleware.CheckPasswordHash("secret123", u.Password) {
+		t.Fatalf("明文密码未被哈希: %q", u.Password)
+	}
+	if u := users.get(2); u.Password != hashed || u.PasswordResetRequired {
+		t.Fatal("已哈希的密码不应被修改")
+	}
+	if u := users.get(3); !u.PasswordResetRequired {
+		t.Fatal("无法识别的哈希应标记为需要重置密码")
+	}
+	if actions := audits.actions(); len(actions) != 1 {
+		t.Fatalf("audit actions = %v", actions)
+	}
+}
+
+// racingUserRepo 在替换密码前修改已存储的密码，模拟用户在读取之后修改了密码
+type racingUserRepo struct {
+	*fakeUserRepo
+}
+
+func (r racingUserRepo) ReplacePassword(ctx context.Context, id uint, oldPassword, newPassword string, resetRequired bool) (bool, error) {
+	r.mu.Lock()
+	r.users[id].Password = "changed-by-user"
+	r.mu.Unlock()
+	return r.fakeUserRepo.ReplacePassword(ctx, id, oldPassword, newPassword, resetRequired)
+}
+
+func TestRehashPasswordsDoesNotOverwriteConcurrentChange(t *testing.T) {
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "plain", Password: "secret123"})
+	svc := NewUserService(racingUserRepo{users}, &fakeAuditRepo{}, &fakeSessionRepo{}, &fakeAPIKeyRepo{}, fakeTransactions{users: users}, &config.Config{}).(*UserServiceImpl)
+
+	if _, err := svc.StartRehashPasswords(9); err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	job := waitRehash(t, svc)
+	if job.Skipped != 1 || job.Migrated != 0 {
+		t.Fatalf("job = %+v", job)
+	}
+	if u := users.get(1); u.Password != "changed-by-user" {
+		t.Fatalf("并发修改的密码被覆盖: %q", u.Password)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/models/user"
+)
+
+// fakeMailer 记录发送的邮件
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []string // 收件人
+	body []string
+}
+
+func (m *fakeMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, to)
+	m.body = append(m.body, body)
+	return nil
+}
+
+func newResendTestService(t *testing.T, u *user.User) (*UserServiceImpl, *fakeUserRepo, *fakeMailer) {
+	t.Helper()
+	users := newFakeUserRepo(u)
+	cfg := &config.Config{}
+	cfg.Security.VerificationResendCooldown = time.Minute
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, cfg)
+	mailer := &fakeMailer{}
+	svc.SetMailer(mailer)
+	return svc, users, mailer
+}
+
+func TestResendVerificationCooldown(t *testing.T) {
+	svc, users, mailer := newResendTestService(t, &user.User{ID: 1, Username: "alice", Email: "alice@example.com"})
+	ctx := context.Background()
+
+	if verified, err := svc.ResendVerification(ctx, 1); err != nil || verified {
+		t.Fatalf("首次发送: verified = %v, err = %v", verified, err)
+	}
+	first := users.get(1)
+	if first.EmailVerificationTokenHash == "" || first.EmailVerificationSentAt == nil {
+		t.Fatalf("未保存验证令牌: %+v", first)
+	}
+	if !strings.Contains(mailer.body[0], "alice") {
+		t.Fatalf("邮件正文 = %q", mailer.body[0])
+	}
+
+	// 冷却期内再次请求被拒绝，不发送邮件，令牌不变
+	if _, err := svc.ResendVerification(ctx, 1); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("冷却期内: err = %v, want ErrTooManyAttempts", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("冷却期内不应发送邮件, sent = %v", mailer.sent)
+	}
+	if users.get(1).EmailVerificationTokenHash != first.EmailVerificationTokenHash {
+		t.Fatal("冷却期内不应替换验证令牌")
+	}
+
+	// 冷却期过后可以再次发送，之前的令牌失效
+	past := time.Now().Add(-2 * time.Minute)
+	users.mu.Lock()
+	users.users[1].EmailVerificationSentAt = &past
+	users.mu.Unlock()
+	if _, err := svc.ResendVerification(ctx, 1); err != nil {
+		t.Fatalf("冷却期后: %v", err)
+	}
+	if len(mailer.sent) != 2 || mailer.sent[1] != "alice@example.com" {
+		t.Fatalf("sent = %v", mailer.sent)
+	}
+	if users.get(1).EmailVerificationTokenHash == first.EmailVerificationTokenHash {
+		t.Fatal("重新发送后应生成新的验证令牌")
+	}
+}
+
+func TestResendVerificationAlreadyVerified(t *testing.T) {
+	verifiedAt := time.Now()
+	svc, users, mailer := newResendTestService(t, &user.User{ID: 1, Username: "alice", Email: "alice@example.com", EmailVerifiedAt: &verifiedAt})
+
+	verified, err := svc.ResendVerification(context.Background(), 1)
+	if err != nil || !verified {
+		t.Fatalf("verified = %v, err = %v, want true, nil", verified, err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("已验证的用户不应收到邮件, sent = %v", mailer.sent)
+	}
+	if users.get(1).EmailVerificationTokenHash != "" {
+		t.Fatal("已验证的用户不应生成验证令牌")
+	}
+}
+
+func TestResendVerificationUnknownUser(t *testing.T) {
+	svc, _, _ := newResendTestService(t, &user.User{ID: 1, Username: "alice"})
+	if _, err := svc.ResendVerification(context.Background(), 2); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go-app/config"
+	"go-app/database/repositories"
+	"go-app/models/security"
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// 登录失败统计的默认时间范围和各粒度允许的最长范围
+const (
+	defaultFailedLoginRange = 24 * time.Hour
+	maxHourlyRange          = 7 * 24 * time.Hour
+	maxDailyRange           = 366 * 24 * time.Hour
+)
+
+// SecurityService 安全监控服务接口
+type SecurityService interface {
+	RecordFailedLogin(username, ip string)
+	FailedLoginStats(query *security.FailedLoginQuery) (*security.FailedLoginStatsResponse, error)
+}
+
+// SecurityServiceImpl 安全监控服务实现
+type SecurityServiceImpl struct {
+	loginEventRepo repositories.LoginEventRepository
+	cfg            *config.Config
+	usernameKey    []byte // 用户名哈希的密钥
+}
+
+// loginHashKeyInfo 由JWT密钥派生用户名哈希密钥时使用的HKDF标签，派生出的密钥与JWT签名密钥相互独立
+const loginHashKeyInfo = "go-app failed-login username hash"
+
+// NewSecurityService 创建安全监控服务
+func NewSecurityService(loginEventRepo repositories.LoginEventRepository, cfg *config.Config) SecurityService {
+	return &SecurityServiceImpl{
+		loginEventRepo: loginEventRepo,
+		cfg:            cfg,
+		usernameKey:    loginHashKey(cfg),
+	}
+}
+
+// loginHashKey 返回用户名哈希的密钥：优先使用 SECURITY_LOGIN_HASH_SECRET，未配置时由JWT密钥经HKDF派生
+func loginHashKey(cfg *config.Config) []byte {
+	if cfg.Security.LoginHashSecret != "" {
+		return []byte(cfg.Security.LoginHashSecret)
+	}
+	key, err := hkdf.Key(sha256.New, []byte(cfg.JWT.Secret), nil, loginHashKeyInfo, sha256.Size)
+	if err != nil {
+		// 只有请求的长度超出HKDF上限时才会出错，固定长度不会发生
+		panic(err)
+	}
+	return key
+}
+
+// RecordFailedLogin 记录登录失败事件，用户名只保存哈希；记录失败只写日志，不影响登录流程
+func (s *SecurityServiceImpl) RecordFailedLogin(username, ip string) {
+	event := &security.FailedLogin{
+		UsernameHash: s.hashUsername(username),
+		IP:           ip,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.loginEventRepo.CreateFailure(event); err != nil {
+		utils.Warn("记录登录失败事件失败", zap.String("ip", ip), zap.Error(err))
+	}
+}
+
+/*
+FailedLoginStats 按时间段统计登录失败次数
+没有记录的时间段补0，便于直接绘制时间序列
+query: 查询参数，未指定时统计最近24小时，按小时统计
+返回: 统计结果, 错误
+*/
+func (s *SecurityServiceImpl) FailedLoginStats(query *security.FailedLoginQuery) (*security.FailedLoginStatsResponse, error) {
+	bucket := query.Bucket
+	if bucket == "" {
+		bucket = security.BucketHour
+	}
+
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-defaultFailedLoginRange)
+	}
+	from, to = from.UTC(), to.UTC()
+
+	if !from.Before(to) {
+		return nil, errors.New("开始时间必须早于结束时间")
+	}
+	maxRange := maxHourlyRange
+	if bucket == security.BucketDay {
+		maxRange = maxDailyRange
+	}
+	if to.Sub(from) > maxRange {
+		return nil, errors.New("统计时间范围过大")
+	}
+
+	counts, err := s.loginEventRepo.CountFailures(from, to, bucket)
+	if err != nil {
+		return nil, err
+	}
+	byStart := make(map[time.Time]int64, len(counts))
+	for _, c := range counts {
+		byStart[c.Start.UTC()] = c.Count
+	}
+
+	step := time.Hour
+	start := from.Truncate(time.Hour)
+	if bucket == security.BucketDay {
+		step = 24 * time.Hour
+		start = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	result := &security.FailedLoginStatsResponse{
+		From:    from,
+		To:      to,
+		Bucket:  bucket,
+		Buckets: make([]security.FailedLoginBucket, 0),
+	}
+	for t := start; t.Before(to); t = t.Add(step) {
+		count := byStart[t]
+		result.Total += count
+		result.Buckets = append(result.Buckets, security.FailedLoginBucket{Start: t, Count: count})
+	}
+
+	return result, nil
+}
+
+// hashUsername 计算用户名的HMAC-SHA256，用户名不区分大小写
+// 使用独立的哈希密钥，避免通过常见用户名字典反推，日志泄露时也不暴露JWT密钥的任何输出
+func (s *SecurityServiceImpl) hashUsername(username string) string {
+	mac := hmac.New(sha256.New, s.usernameKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(username))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/models/security"
+)
+
+// fakeLoginEventRepo 基于内存的登录事件存储库，按UTC时间段统计
+type fakeLoginEventRepo struct {
+	mu     sync.Mutex
+	events []security.FailedLogin
+}
+
+func (r *fakeLoginEventRepo) CreateFailure(event *security.FailedLogin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeLoginEventRepo) CountFailures(from, to time.Time, bucket string) ([]security.FailedLoginBucket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[time.Time]int64)
+	var order []time.Time
+	for _, e := range r.events {
+		t := e.CreatedAt.UTC()
+		if t.Before(from) || !t.Before(to) {
+			continue
+		}
+		start := t.Truncate(time.Hour)
+		if bucket == security.BucketDay {
+			start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if _, ok := counts[start]; !ok {
+			order = append(order, start)
+		}
+		counts[start]++
+	}
+	buckets := make([]security.FailedLoginBucket, 0, len(order))
+	for _, start := range order {
+		buckets = append(buckets, security.FailedLoginBucket{Start: start, Count: counts[start]})
+	}
+	return buckets, nil
+}
+
+func newTestSecurityService(cfg *config.Config) (*SecurityServiceImpl, *fakeLoginEventRepo) {
+	repo := &fakeLoginEventRepo{}
+	return NewSecurityService(repo, cfg).(*SecurityServiceImpl), repo
+}
+
+func TestFailedLoginStatsFillsEmptyBuckets(t *testing.T) {
+	s, repo := newTestSecurityService(&config.Config{})
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{5 * time.Minute, 20 * time.Minute, 2*time.Hour + time.Minute, 26 * time.Hour} {
+		repo.events = append(repo.events, security.FailedLogin{CreatedAt: base.Add(offset)})
+	}
+
+	hourly, err := s.FailedLoginStats(&security.FailedLoginQuery{From: base, To: base.Add(4 * time.Hour)})
+	if err != nil {
+		t.Fatalf("按小时统计失败: %v", err)
+	}
+	want := []int64{2, 0, 1, 0}
+	if len(hourly.Buckets) != len(want) {
+		t.Fatalf("时间段数量 = %d, want %d", len(hourly.Buckets), len(want))
+	}
+	for i, b := range hourly.Buckets {
+		if !b.Start.Equal(base.Add(time.Duration(i)*time.Hour)) || b.Count != want[i] {
+			t.Errorf("buckets[%d] = %v %d, want %v %d", i, b.Start, b.Count, base.Add(time.Duration(i)*time.Hour), want[i])
+		}
+	}
+	if hourly.Total != 3 {
+		t.Errorf("Total = %d, want 3", hourly.Total)
+	}
+
+	daily, err := s.FailedLoginStats(&security.FailedLoginQuery{From: base, To: base.Add(48 * time.Hour), Bucket: security.BucketDay})
+	if err != nil {
+		t.Fatalf("按天统计失败: %v", err)
+	}
+	wantDaily := []int64{3, 1, 0}
+	if len(daily.Buckets) != len(wantDaily) {
+		t.Fatalf("时间段数量 = %d, want %d", len(daily.Buckets), len(wantDaily))
+	}
+	for i, b := range daily.Buckets {
+		if b.Count != wantDaily[i] {
+			t.Errorf("buckets[%d].Count = %d, want %d", i, b.Count, wantDaily[i])
+		}
+	}
+}
+
+func TestFailedLoginStatsRejectsInvalidRange(t *testing.T) {
+	s, _ := newTestSecurityService(&config.Config{})
+	now := time.Now()
+	if _, err := s.FailedLoginStats(&security.FailedLoginQuery{From: now, To: now.Add(-time.Hour)}); err == nil {
+		t.Error("开始时间晚于结束时间时应返回错误")
+	}
+	if _, err := s.FailedLoginStats(&security.FailedLoginQuery{From: now.Add(-8 * 24 * time.Hour), To: now}); err == nil {
+		t.Error("按小时统计超过7天时应返回错误")
+	}
+}
+
+func TestHashUsernameDoesNotUseJWTSecretDirectly(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "jwt-secret"
+	s, _ := newTestSecurityService(cfg)
+
+	mac := hmac.New(sha256.New, []byte(cfg.JWT.Secret))
+	mac.Write([]byte("alice"))
+	if s.hashUsername("alice") == hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatal("用户名哈希不应直接使用JWT密钥")
+	}
+	if s.hashUsername(" Alice ") != s.hashUsername("alice") {
+		t.Error("用户名哈希应不区分大小写并忽略首尾空白")
+	}
+
+	cfg2 := &config.Config{}
+	cfg2.JWT.Secret = "jwt-secret"
+	cfg2.Security.LoginHashSecret = "login-hash-secret"
+	s2, _ := newTestSecurityService(cfg2)
+	if s2.hashUsername("alice") == s.hashUsername("alice") {
+		t.Error("配置 SECURITY_LOGIN_HASH_SECRET 后应使用该密钥")
+	}
+}
+
+func TestRecordFailedLoginStoresOnlyHash(t *testing.T) {
+	s, repo := newTestSecurityService(&config.Config{})
+	s.RecordFailedLogin("alice", "10.0.0.1")
+
+	if len(repo.events) != 1 {
+		t.Fatalf("记录数 = %d, want 1", len(repo.events))
+	}
+	e := repo.events[0]
+	if e.UsernameHash != s.hashUsername("alice") || e.IP != "10.0.0.1" || e.CreatedAt.IsZero() {
+		t.Fatalf("event = %+v", e)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/user"
+)
+
+func newSessionLimitTestService(t *testing.T, maxSessions int, policy string) (*UserServiceImpl, *fakeSessionRepo) {
+	t.Helper()
+	hashed, err := middleware.HashPassword(lockoutTestPassword)
+	if err != nil {
+		t.Fatalf("密码哈希失败: %v", err)
+	}
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hashed, Status: 1})
+	sessions := &fakeSessionRepo{}
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.Expire = time.Hour
+	cfg.JWT.MaxSessions = maxSessions
+	cfg.JWT.SessionLimitPolicy = policy
+	return newTestUserService(users, &fakeAuditRepo{}, sessions, cfg), sessions
+}
+
+// loginToken 登录并返回令牌中的会话ID
+func loginToken(t *testing.T, svc *UserServiceImpl) (string, error) {
+	t.Helper()
+	_, token, err := svc.Login(context.Background(), &user.LoginRequest{Username: "alice", Password: lockoutTestPassword}, "10.0.0.1")
+	if err != nil {
+		return "", err
+	}
+	claims, err := middleware.ParseToken(token, "test-secret")
+	if err != nil {
+		t.Fatalf("解析令牌失败: %v", err)
+	}
+	return claims.ID, nil
+}
+
+func TestSessionLimitEvictsOldest(t *testing.T) {
+	svc, sessions := newSessionLimitTestService(t, 2, SessionLimitEvictOldest)
+
+	first, err := loginToken(t, svc)
+	if err != nil {
+		t.Fatalf("第一次登录失败: %v", err)
+	}
+	second, _ := loginToken(t, svc)
+	third, err := loginToken(t, svc)
+	if err != nil {
+		t.Fatalf("超出上限时应吊销最早的会话: %v", err)
+	}
+
+	if n := sessions.activeCount(1); n != 2 {
+		t.Fatalf("活跃会话数 = %d, want 2", n)
+	}
+	ctx := context.Background()
+	if active, _ := sessions.IsActive(ctx, first); active {
+		t.Error("最早的会话应被吊销")
+	}
+	for _, id := range []string{second, third} {
+		if active, _ := sessions.IsActive(ctx, id); !active {
+			t.Errorf("会话 %s 应保持有效", id)
+		}
+	}
+}
+
+func TestSessionLimitRejectsNewLogin(t *testing.T) {
+	svc, sessions := newSessionLimitTestService(t, 2, SessionLimitReject)
+
+	first, _ := loginToken(t, svc)
+	if _, err := loginToken(t, svc); err != nil {
+		t.Fatalf("未达上限时登录失败: %v", err)
+	}
+	if _, err := loginToken(t, svc); !errors.Is(err, ErrTooManySessions) {
+		t.Fatalf("达到上限: err = %v, want ErrTooManySessions", err)
+	}
+
+	if n := sessions.activeCount(1); n != 2 {
+		t.Fatalf("活跃会话数 = %d, want 2", n)
+	}
+	if active, _ := sessions.IsActive(context.Background(), first); !active {
+		t.Error("拒绝新登录时不应吊销已有会话")
+	}
+}
+
+func TestSessionLimitDisabled(t *testing.T) {
+	svc, sessions := newSessionLimitTestService(t, 0, "")
+	for i := 0; i < 3; i++ {
+		if _, err := loginToken(t, svc); err != nil {
+			t.Fatalf("登录失败: %v", err)
+		}
+	}
+	if n := sessions.activeCount(1); n != 0 {
+		t.Fatalf("未限制会话数时不应创建会话: %d", n)
+	}
+}
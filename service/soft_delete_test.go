@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-app/models/user"
+)
+
+func TestRestoreUserConflictsWithReusedUsername(t *testing.T) {
+	users := newFakeUserRepo(&user.User{ID: 1, Username: "alice", Email: "alice@example.com", Status: 1})
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	ctx := context.Background()
+
+	if err := svc.DeleteUser(ctx, 1); err != nil {
+		t.Fatalf("删除用户失败: %v", err)
+	}
+	if _, err := svc.Register(ctx, &user.RegisterRequest{Username: "alice", Email: "new@example.com", Password: "Str0ng!Passw0rd"}); err != nil {
+		t.Fatalf("删除后重新注册失败: %v", err)
+	}
+
+	if err := svc.RestoreUser(ctx, 1, 9); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("err = %v, want ErrUserExists", err)
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go-app/database/repositories"
+	"go-app/middleware"
+	"go-app/models/audit"
+	"go-app/models/system"
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// SystemService 系统设置服务接口
+type SystemService interface {
+	ReadOnly(ctx context.Context) (*system.ReadOnlySetting, error)
+	IsReadOnly(ctx context.Context) (bool, error)
+	SetReadOnly(ctx context.Context, enabled bool, reason string, operatorID uint, clientIP string) (*system.ReadOnlySetting, error)
+	LogLevel() string
+	SetLogLevel(level string, operatorID uint, clientIP string) error
+}
+
+// SystemServiceImpl 系统设置服务实现
+type SystemServiceImpl struct {
+	settingRepo repositories.SettingRepository
+	auditRepo   repositories.AuditRepository
+}
+
+// NewSystemService 创建系统设置服务
+func NewSystemService(settingRepo repositories.SettingRepository, auditRepo repositories.AuditRepository) SystemService {
+	return &SystemServiceImpl{
+		settingRepo: settingRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// ReadOnly 查询持久化的只读模式设置，从未设置过时返回nil
+func (s *SystemServiceImpl) ReadOnly(ctx context.Context) (*system.ReadOnlySetting, error) {
+	return s.settingRepo.GetReadOnly(ctx)
+}
+
+// IsReadOnly 查询是否开启了只读模式，供只读模式中间件同步状态
+func (s *SystemServiceImpl) IsReadOnly(ctx context.Context) (bool, error) {
+	setting, err := s.settingRepo.GetReadOnly(ctx)
+	if err != nil {
+		return false, err
+	}
+	return setting != nil && setting.Enabled, nil
+}
+
+/*
+SetReadOnly 开启或关闭只读模式
+设置先持久化到共享存储，再立即作用于当前实例，其他实例在下一次同步时生效
+enabled: 是否开启
+reason: 开启原因，关闭时忽略
+operatorID: 操作人ID
+clientIP: 客户端IP，记录在审计日志中
+*/
+func (s *SystemServiceImpl) SetReadOnly(ctx context.Context, enabled bool, reason string, operatorID uint, clientIP string) (*system.ReadOnlySetting, error) {
+	if !enabled {
+		reason = ""
+	}
+
+	setting := &system.ReadOnlySetting{
+		Enabled:   enabled,
+		Reason:    reason,
+		UpdatedBy: operatorID,
+		UpdatedAt: time.Now(),
+	}
+	if err := s.settingRepo.SaveReadOnly(ctx, setting); err != nil {
+		return nil, err
+	}
+	middleware.SetReadOnly(enabled)
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionSystemReadOnly,
+		Detail: map[string]interface{}{
+			"enabled": enabled,
+			"reason":  reason,
+		},
+		IP: clientIP,
+	}); err != nil {
+		utils.Warn("记录只读模式变更审计日志失败", zap.Bool("enabled", enabled), zap.Error(err))
+	}
+
+	return setting, nil
+}
+
+// LogLevel 返回当前实例的日志级别
+func (s *SystemServiceImpl) LogLevel() string {
+	return utils.GetLogLevel()
+}
+
+/*
+SetLogLevel 修改当前实例的日志级别，立即生效，重启后恢复为 LOGGER_LEVEL
+多实例部署时只作用于处理该请求的实例
+level: 日志级别（debug/info/warn/error）
+operatorID: 操作人ID
+clientIP: 客户端IP，记录在审计日志中
+*/
+func (s *SystemServiceImpl) SetLogLevel(level string, operatorID uint, clientIP string) error {
+	previous := utils.GetLogLevel()
+	if err := utils.SetLogLevel(level); err != nil {
+		return err
+	}
+
+	utils.Warn("日志级别已修改", zap.String("from", previous), zap.String("to", utils.GetLogLevel()), zap.Uint("operator_id", operatorID))
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionSystemLogLevel,
+		Detail: map[string]interface{}{
+			"from": previous,
+			"to":   utils.GetLogLevel(),
+		},
+		IP: clientIP,
+	}); err != nil {
+		utils.Warn("记录日志级别变更审计日志失败", zap.String("level", level), zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-app/models/user"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserListSort(t *testing.T) {
+	cases := []struct {
+		sortBy, order string
+		want          bson.D
+	}{
+		{"", "", nil},
+		{"", "asc", bson.D{{Key: "created_at", Value: 1}}},
+		{"username", "", bson.D{{Key: "username", Value: -1}}},
+		{"username", "ASC", bson.D{{Key: "username", Value: 1}}},
+		{"updated_at", "desc", bson.D{{Key: "updated_at", Value: -1}}},
+	}
+	for _, tc := range cases {
+		got, err := userListSort(tc.sortBy, tc.order)
+		if err != nil {
+			t.Errorf("userListSort(%q, %q): %v", tc.sortBy, tc.order, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("userListSort(%q, %q) = %v, want %v", tc.sortBy, tc.order, got, tc.want)
+		}
+	}
+}
+
+func TestUserListSortRejectsInvalid(t *testing.T) {
+	if _, err := userListSort("password", ""); !errors.Is(err, ErrInvalidSortField) {
+		t.Fatalf("err = %v, want ErrInvalidSortField", err)
+	}
+	if _, err := userListSort("username", "up"); !errors.Is(err, ErrInvalidSortOrder) {
+		t.Fatalf("err = %v, want ErrInvalidSortOrder", err)
+	}
+}
+
+func TestGetUsersAfterRejectsSort(t *testing.T) {
+	svc := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	_, _, err := svc.GetUsersAfter(context.Background(), "", 10, user.ListFilter{SortBy: "username"})
+	if !errors.Is(err, ErrSortWithCursor) {
+		t.Fatalf("err = %v, want ErrSortWithCursor", err)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-app/models/user"
+)
+
+func TestGetUsersFiltersByRole(t *testing.T) {
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Username: "admin", Role: user.RoleAdmin},
+		&user.User{ID: 2, Username: "alice", Role: user.RoleUser},
+		&user.User{ID: 3, Username: "bob", Role: user.RoleAdmin},
+		&user.User{ID: 4, Username: "carol", Role: user.RoleUser},
+	)
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+
+	cases := []struct {
+		roles []string
+		want  []uint
+	}{
+		{[]string{user.RoleAdmin}, []uint{1, 3}},
+		{[]string{user.RoleUser}, []uint{2, 4}},
+		{[]string{user.RoleUser, user.RoleAdmin}, []uint{1, 2, 3, 4}},
+	}
+	for _, tc := range cases {
+		list, total, err := svc.GetUsers(context.Background(), 1, 10, user.ListFilter{Roles: tc.roles})
+		if err != nil {
+			t.Fatalf("GetUsers(%v): %v", tc.roles, err)
+		}
+		if total != int64(len(tc.want)) || len(list) != len(tc.want) {
+			t.Fatalf("GetUsers(%v): total = %d, len = %d, want %d", tc.roles, total, len(list), len(tc.want))
+		}
+		for i, u := range list {
+			if u.ID != tc.want[i] {
+				t.Fatalf("GetUsers(%v)[%d].ID = %d, want %d", tc.roles, i, u.ID, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestGetUsersRejectsUnknownRole(t *testing.T) {
+	svc := newTestUserService(newFakeUserRepo(), &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+	_, _, err := svc.GetUsers(context.Background(), 1, 10, user.ListFilter{Roles: []string{user.RoleUser, "root"}})
+	if !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("err = %v, want ErrInvalidRole", err)
+	}
+}
@@ -1,53 +1,306 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go-app/config"
+	"go-app/database"
 	"go-app/database/repositories"
 	"go-app/middleware"
+	"go-app/models/audit"
+	"go-app/models/common"
+	"go-app/models/session"
 	"go-app/models/user"
+	"go-app/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
 )
 
 // UserService 用户服务接口
 type UserService interface {
-	Register(req *user.RegisterRequest) (*user.User, error)
-	Login(req *user.LoginRequest) (*user.User, string, error)
-	GetUserByID(id uint) (*user.User, error)
-	GetUsers(page, pageSize int, keyword string, status int) ([]user.User, int64, error)
-	UpdateProfile(id uint, req *user.UpdateProfileRequest) (*user.User, error)
-	ChangePassword(id uint, req *user.ChangePasswordRequest) error
-	DeleteUser(id uint) error
+	Register(ctx context.Context, req *user.RegisterRequest) (*user.User, error)
+	BatchRegister(ctx context.Context, reqs []user.RegisterRequest, operatorID uint) (*user.BatchRegisterResponse, error)
+	Login(ctx context.Context, req *user.LoginRequest, clientIP string) (*user.User, string, error)
+	ValidateToken(ctx context.Context, token string) (*user.User, time.Time, error)
+	GetUserByID(ctx context.Context, id uint) (*user.User, error)
+	GetUsers(ctx context.Context, page, pageSize int, filter user.ListFilter) ([]user.User, int64, error)
+	GetUsersAfter(ctx context.Context, cursor string, pageSize int, filter user.ListFilter) ([]user.User, string, error)
+	CountUsers(ctx context.Context, filter user.ListFilter) (int64, error)
+	RegistrationStats(ctx context.Context, query *user.RegistrationStatsQuery) (*user.RegistrationStatsResponse, error)
+	UpdateProfile(ctx context.Context, id uint, req *user.UpdateProfileRequest) (*user.User, error)
+	PatchProfile(ctx context.Context, id uint, req *user.PatchProfileRequest) (*user.User, error)
+	ChangePassword(ctx context.Context, id uint, req *user.ChangePasswordRequest, clientIP string) error
+	DeleteUser(ctx context.Context, id uint) error
+	RestoreUser(ctx context.Context, id uint, operatorID uint) error
+	HardDeleteUser(ctx context.Context, id uint, operatorID uint) error
+	MergeUsers(ctx context.Context, req *user.MergeUsersRequest, operatorID uint) (*MergeResult, error)
+	ExportUserData(ctx context.Context, id uint, clientIP string) (*user.ExportResponse, error)
+	DistinctValues(ctx context.Context, field string) ([]interface{}, error)
+	StartRehashPasswords(operatorID uint) (*user.RehashPasswordsResponse, error)
+	RehashPasswordsStatus() (*user.RehashPasswordsResponse, error)
+	ResendVerification(ctx context.Context, id uint) (bool, error)
+	BulkUpdateUsers(ctx context.Context, req *user.BulkUpdateRequest, operatorID uint) (*user.BulkUpdateResponse, error)
+	BulkVerifyUsers(ctx context.Context, req *user.BulkVerifyRequest, operatorID uint) (*user.BulkUpdateResponse, error)
+	GetActivity(ctx context.Context, userID uint, page, pageSize int, filter audit.ActivityFilter) ([]*audit.Entry, int64, error)
+	ForcePasswordReset(ctx context.Context, req *user.ForcePasswordResetRequest, operatorID uint) (*user.ForcePasswordResetResponse, error)
+	ResetPassword(ctx context.Context, req *user.ResetPasswordRequest, clientIP string) error
+}
+
+// 服务层通用错误，控制器据此确定HTTP状态码
+var (
+	ErrUserNotFound    = errors.New("用户不存在")
+	ErrUserExists      = errors.New("用户名或邮箱已被使用")
+	ErrTooManyAttempts = errors.New("尝试次数过多，请稍后再试")
+	ErrAccountLocked   = errors.New("账户已锁定，请稍后再试")
+	ErrInvalidRole     = errors.New("无效的角色")
+	ErrTooManySessions = errors.New("登录设备数已达上限，请先在其他设备退出登录")
+	// 批量更新的校验错误
+	ErrBulkUpdateUnconfirmed = errors.New("过滤条件为空将更新所有用户，请将 confirm 设为true确认")
+	ErrBulkUpdateEmptyPatch  = errors.New("未指定要修改的字段")
+	ErrBulkUpdateTooLarge    = errors.New("匹配的用户数超过批量更新上限，请缩小过滤条件")
+	ErrInvalidTimeRange      = errors.New("开始时间必须早于结束时间")
+	ErrSearchWithCursor      = errors.New("全文搜索按相关度排序，不支持游标分页，请使用页码分页")
+	ErrSortWithCursor        = errors.New("游标分页按创建时间倒序，不支持自定义排序，请使用页码分页")
+	ErrInvalidSortField      = errors.New("不支持的排序字段")
+	ErrInvalidSortOrder      = errors.New("排序方向只能是 asc 或 desc")
+	ErrStatsRangeTooLarge    = errors.New("统计时间范围过大")
+	// 密码重置
+	ErrPasswordResetRequired = errors.New("密码已失效，请使用重置令牌设置新密码")
+	ErrInvalidResetToken     = errors.New("重置令牌无效或已过期")
+	// 密码批量迁移
+	ErrRehashRunning    = errors.New("密码迁移任务正在执行，请等待完成")
+	ErrRehashNotStarted = errors.New("密码迁移任务尚未执行")
+)
+
+// 达到会话数上限时的处理方式
+const (
+	SessionLimitEvictOldest = "evict_oldest" // 吊销最早的会话（默认）
+	SessionLimitReject      = "reject"       // 拒绝新的登录
+)
+
+// 修改密码失败限制的默认值
+const (
+	defaultPasswordChangeMaxAttempts = 5
+	defaultPasswordChangeWindow      = 15 * time.Minute
+)
+
+// 登录失败锁定的默认值
+const (
+	defaultLoginMaxAttempts     = 5
+	defaultLoginLockoutDuration = 15 * time.Minute
+)
+
+// 个人数据导出的频率限制：每个用户每小时最多导出3次
+const (
+	exportMaxAttempts = 3
+	exportWindow      = time.Hour
+)
+
+// 邮箱验证邮件的默认值
+const (
+	defaultVerificationResendCooldown = time.Minute
+	verificationTokenTTL              = 24 * time.Hour
+)
+
+// 密码重置令牌的有效期
+const passwordResetTokenTTL = 24 * time.Hour
+
+// 强制重置密码时每秒最多处理的用户数，每个用户需要写入数据库并可能发送邮件
+const forcePasswordResetPerSecond = 20
+
+// 每日注册数统计的默认时间范围
+const defaultRegistrationStatsRange = 30 * 24 * time.Hour
+
+// 单次批量更新最多修改的用户数
+const maxBulkUpdateUsers = 1000
+
+// 批量迁移密码时每秒最多写入的用户数，避免对数据库造成压力
+const rehashWritesPerSecond = 50
+
+// distinctFields 允许查询不重复取值的字段，键为对外暴露的名称，值为数据库字段
+// 用户名、邮箱等可识别个人身份的字段不在此列
+var distinctFields = map[string]string{
+	"status":       "status",
+	"email_domain": "email",
+}
+
+// sortableFields 用户列表允许排序的字段，键为对外暴露的名称，值为数据库字段
+// 只开放有索引或数据量可控的字段，避免按任意字段排序
+var sortableFields = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"username":   "username",
+}
+
+// MergeResult 账户合并结果
+type MergeResult struct {
+	Target           *user.User // 合并后的目标账户
+	Conflicts        []string   // 双方均有值且不一致的字段
+	ReassignedAudits int64      // 转移到目标账户的审计日志条数
+	RevokedSessions  int64      // 吊销的源账户会话数
+}
+
+// TransactionRunner 在事务中执行多个存储库操作，由 database.TransactionManager 实现
+type TransactionRunner interface {
+	WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error
 }
 
+// ErrBatchTooLarge 单次批量创建的用户数超过上限
+var ErrBatchTooLarge = errors.New("单次批量创建的用户数超过上限")
+
+// 单次批量创建最多创建的用户数，每个用户都需要计算bcrypt哈希
+const maxBatchRegisterUsers = 100
+
 // UserServiceImpl 用户服务实现
 type UserServiceImpl struct {
-	userRepo repositories.UserRepository
-	cfg      *config.Config
+	userRepo    repositories.UserRepository
+	auditRepo   repositories.AuditRepository
+	sessionRepo repositories.SessionRepository
+	apiKeyRepo  repositories.APIKeyRepository
+	// 多个写操作需要同时成功或失败时使用（如账户合并）
+	transactions TransactionRunner
+	cfg          *config.Config
+	// 修改密码时原密码错误的次数限制，按用户统计
+	passwordChangeLimiter *attemptLimiter
+	// 个人数据导出次数限制，按用户统计
+	exportLimiter *attemptLimiter
+	// 连续登录失败多少次后锁定账户，以及锁定时长
+	loginMaxAttempts     int
+	loginLockoutDuration time.Duration
+	// 泄露密码检查
+	breachChecker BreachChecker
+	// 同一用户两次发送验证邮件的最小间隔
+	verificationResendCooldown time.Duration
+	// 邮件发送
+	mailer Mailer
+	// 每个用户同时有效的会话数上限（0表示不限制），以及达到上限时的处理方式
+	maxSessions        int
+	sessionLimitPolicy string
+	// 本实例最近一次密码批量迁移任务的状态，为nil表示尚未执行
+	rehashMu  sync.Mutex
+	rehashJob *user.RehashPasswordsResponse
 }
 
 // NewUserService 创建用户服务
-func NewUserService(userRepo repositories.UserRepository, cfg *config.Config) UserService {
+func NewUserService(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, sessionRepo repositories.SessionRepository, apiKeyRepo repositories.APIKeyRepository, transactions TransactionRunner, cfg *config.Config) UserService {
+	maxAttempts := defaultPasswordChangeMaxAttempts
+	if cfg.Security.PasswordChangeMaxAttempts > 0 {
+		maxAttempts = cfg.Security.PasswordChangeMaxAttempts
+	}
+	window := defaultPasswordChangeWindow
+	if cfg.Security.PasswordChangeWindow > 0 {
+		window = cfg.Security.PasswordChangeWindow
+	}
+	loginMaxAttempts := defaultLoginMaxAttempts
+	if cfg.Security.LoginMaxAttempts > 0 {
+		loginMaxAttempts = cfg.Security.LoginMaxAttempts
+	}
+	loginLockoutDuration := defaultLoginLockoutDuration
+	if cfg.Security.LoginLockoutDuration > 0 {
+		loginLockoutDuration = cfg.Security.LoginLockoutDuration
+	}
+	resendCooldown := defaultVerificationResendCooldown
+	if cfg.Security.VerificationResendCooldown > 0 {
+		resendCooldown = cfg.Security.VerificationResendCooldown
+	}
+	sessionLimitPolicy := strings.ToLower(cfg.JWT.SessionLimitPolicy)
+	switch sessionLimitPolicy {
+	case SessionLimitEvictOldest, SessionLimitReject:
+	default:
+		if sessionLimitPolicy != "" {
+			utils.Warn("未知的会话数上限处理方式，使用evict_oldest", zap.String("policy", cfg.JWT.SessionLimitPolicy))
+		}
+		sessionLimitPolicy = SessionLimitEvictOldest
+	}
+
 	return &UserServiceImpl{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:                   userRepo,
+		auditRepo:                  auditRepo,
+		sessionRepo:                sessionRepo,
+		apiKeyRepo:                 apiKeyRepo,
+		transactions:               transactions,
+		cfg:                        cfg,
+		passwordChangeLimiter:      newAttemptLimiter(maxAttempts, window),
+		exportLimiter:              newAttemptLimiter(exportMaxAttempts, exportWindow),
+		loginMaxAttempts:           loginMaxAttempts,
+		loginLockoutDuration:       loginLockoutDuration,
+		breachChecker:              NewBreachChecker(cfg),
+		verificationResendCooldown: resendCooldown,
+		mailer:                     LogMailer{},
+		maxSessions:                cfg.JWT.MaxSessions,
+		sessionLimitPolicy:         sessionLimitPolicy,
+	}
+}
+
+// SetBreachChecker 替换泄露密码检查器，可用于接入自建的泄露密码库
+func (s *UserServiceImpl) SetBreachChecker(checker BreachChecker) {
+	if checker == nil {
+		checker = NoopBreachChecker{}
 	}
+	s.breachChecker = checker
+}
+
+// SetMailer 替换邮件发送实现，默认只记录日志
+func (s *UserServiceImpl) SetMailer(mailer Mailer) {
+	if mailer == nil {
+		mailer = LogMailer{}
+	}
+	s.mailer = mailer
+}
+
+// checkPasswordBreach 检查密码是否已泄露
+// 检查接口超时或出错时放行并记录警告，避免外部服务故障导致无法注册或修改密码
+// 检查超时从请求上下文派生，请求剩余时间更短时以剩余时间为准
+func (s *UserServiceImpl) checkPasswordBreach(ctx context.Context, password string) error {
+	timeout := defaultBreachCheckTimeout
+	if s.cfg.Security.BreachCheckTimeout > 0 {
+		timeout = s.cfg.Security.BreachCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	breached, err := s.breachChecker.IsBreached(ctx, password)
+	if err != nil {
+		utils.Warn("泄露密码检查失败，已放行", zap.Error(err))
+		return nil
+	}
+	if breached {
+		return ErrPasswordBreached
+	}
+	return nil
 }
 
 // Register 用户注册
-func (s *UserServiceImpl) Register(req *user.RegisterRequest) (*user.User, error) {
+func (s *UserServiceImpl) Register(ctx context.Context, req *user.RegisterRequest) (*user.User, error) {
 	// 检查用户名是否存在
-	if _, err := s.userRepo.FindByUsername(req.Username); err == nil {
+	if _, err := s.userRepo.FindByUsername(ctx, req.Username); err == nil {
 		return nil, errors.New("用户名已被使用")
 	}
 
 	// 检查邮箱是否存在
-	if _, err := s.userRepo.FindByEmail(req.Email); err == nil {
+	if _, err := s.userRepo.FindByEmail(ctx, req.Email); err == nil {
 		return nil, errors.New("邮箱已被使用")
 	}
 
+	// 检查密码是否已泄露
+	if err := s.checkPasswordBreach(ctx, req.Password); err != nil {
+		return nil, err
+	}
+
 	// 创建新用户
 	hashedPassword, err := middleware.HashPassword(req.Password)
 	if err != nil {
@@ -60,66 +313,268 @@ func (s *UserServiceImpl) Register(req *user.RegisterRequest) (*user.User, error
 		Password:  hashedPassword,
 		Nickname:  req.Nickname,
 		Status:    1, // 正常状态
+		Role:      user.RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	if err := s.userRepo.Create(newUser); err != nil {
-		return nil, errors.New("创建用户失败: " + err.Error())
+	// 上面的检查与插入之间存在并发窗口，同名的并发注册由唯一索引拒绝
+	if err := s.userRepo.Create(ctx, newUser); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateUser) {
+			return nil, ErrUserExists
+		}
+		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 
 	return newUser, nil
 }
 
-// Login 用户登录
-func (s *UserServiceImpl) Login(req *user.LoginRequest) (*user.User, string, error) {
-	// 调试信息
-	fmt.Printf("尝试登录用户: %s\n", req.Username)
+/*
+BatchRegister 批量创建用户（管理员），如从其他系统导入账户
+每个用户按 Register 的规则单独创建，某个用户失败不影响其他用户，结果按请求顺序返回
+reqs: 已通过校验的注册请求，最多 maxBatchRegisterUsers 个
+operatorID: 操作人ID，记录到审计日志
+返回: 每个用户的创建结果, 错误（超过上限时为 ErrBatchTooLarge）
+*/
+func (s *UserServiceImpl) BatchRegister(ctx context.Context, reqs []user.RegisterRequest, operatorID uint) (*user.BatchRegisterResponse, error) {
+	if len(reqs) > maxBatchRegisterUsers {
+		return nil, fmt.Errorf("%w（%d个，上限%d个）", ErrBatchTooLarge, len(reqs), maxBatchRegisterUsers)
+	}
+
+	result := &user.BatchRegisterResponse{Results: make([]user.BatchRegisterItem, 0, len(reqs))}
+	var created []uint
+	for i := range reqs {
+		item := user.BatchRegisterItem{Index: i}
+		u, err := s.Register(ctx, &reqs[i])
+		if err != nil {
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			item.User = u.ToProfileResponse()
+			result.Created++
+			created = append(created, u.ID)
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionUserBatchRegister,
+		Detail: map[string]interface{}{
+			"created": created,
+			"failed":  result.Failed,
+		},
+	}); err != nil {
+		utils.Warn("记录批量创建用户审计日志失败", zap.Uint("operator_id", operatorID), zap.Error(err))
+	}
+
+	return result, nil
+}
 
+// Login 用户登录，登录成功记录到审计日志
+func (s *UserServiceImpl) Login(ctx context.Context, req *user.LoginRequest, clientIP string) (*user.User, string, error) {
 	// 根据用户名查找用户
-	u, err := s.userRepo.FindByUsername(req.Username)
+	u, err := s.userRepo.FindByUsername(ctx, req.Username)
 	if err != nil {
-		fmt.Printf("用户查找失败: %v\n", err)
 		return nil, "", errors.New("用户名或密码错误")
 	}
 
-	// 输出调试信息
-	fmt.Printf("找到用户: %s, ID: %d, 状态: %d\n", u.Username, u.ID, u.Status)
-	fmt.Printf("数据库密码: %s\n", u.Password)
-
 	// 检查用户状态
 	if u.Status != 1 {
-		fmt.Printf("用户状态异常: %d\n", u.Status)
 		return nil, "", errors.New("用户已被禁用")
 	}
 
-	// 验证密码 - 先检查密码哈希，如果失败则检查明文密码
-	passwordMatch := middleware.CheckPasswordHash(req.Password, u.Password)
+	// 锁定期间不再校验密码，避免继续猜测
+	now := time.Now()
+	if u.IsLocked(now) {
+		return nil, "", ErrAccountLocked
+	}
 
-	// 如果哈希验证失败，尝试直接比较明文密码（临时解决方案）
-	if !passwordMatch && u.Password == req.Password {
-		passwordMatch = true
-		fmt.Println("警告：使用明文密码匹配成功，应更新为哈希密码")
+	// 验证密码，仅接受哈希密码；历史明文密码由迁移统一哈希
+	if !middleware.CheckPasswordHash(req.Password, u.Password) {
+		return nil, "", s.recordLoginFailure(ctx, u, now)
 	}
 
-	fmt.Printf("密码匹配结果: %v\n", passwordMatch)
+	// 管理员强制重置后原密码不再可用，需先通过重置令牌设置新密码
+	if u.PasswordResetRequired {
+		return nil, "", ErrPasswordResetRequired
+	}
 
-	if !passwordMatch {
-		return nil, "", errors.New("用户名或密码错误")
+	// 清零失败次数，同时确认账户仍未锁定：读取用户后并发的失败请求可能已锁定账户，此时密码正确也拒绝登录
+	unlocked, err := s.userRepo.ResetFailedLogins(ctx, u.ID, now)
+	if err != nil {
+		utils.Warn("重置登录失败次数失败", zap.Uint("user_id", u.ID), zap.Error(err))
+		return nil, "", errors.New("登录失败，请稍后再试")
+	}
+	if !unlocked {
+		return nil, "", ErrAccountLocked
+	}
+	u.FailedLoginCount = 0
+	u.LockedUntil = nil
+
+	// 强度较低的密码在登录成功后升级为当前强度的哈希，失败不影响登录；
+	// 只在密码仍为读取时的值时替换，不会覆盖并发修改的密码，也不会清除并发设置的重置标记
+	if middleware.PasswordNeedsRehash(u.Password) {
+		if hashed, err := middleware.HashPassword(req.Password); err == nil {
+			if updated, err := s.userRepo.ReplacePassword(ctx, u.ID, u.Password, hashed, false); err != nil {
+				utils.Warn("登录时升级密码哈希失败", zap.Uint("user_id", u.ID), zap.Error(err))
+			} else if updated {
+				u.Password = hashed
+			}
+		}
+	}
+
+	// 限制会话数时为本次登录创建会话，令牌通过 jti 关联会话
+	var tokenID string
+	if s.maxSessions > 0 {
+		if tokenID, err = s.startSession(ctx, u.ID); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// 生成JWT令牌
-	token, err := middleware.GenerateToken(u.ID, s.cfg.JWT.Secret, s.cfg.JWT.Expire)
+	token, err := middleware.GenerateSessionToken(u.ID, u.EffectiveRole(), s.cfg.JWT.Secret, s.cfg.JWT.Expire, tokenID)
 	if err != nil {
 		return nil, "", errors.New("生成令牌失败: " + err.Error())
 	}
 
+	s.recordUserAction(u.ID, audit.ActionUserLogin, clientIP)
 	return u, token, nil
 }
 
+/*
+startSession 为新的登录创建会话
+活跃会话数已达上限时，按配置吊销最早的会话或返回 ErrTooManySessions。
+并发登录时检查和创建不是原子的，会话数可能短暂超出上限，下次登录时会被纠正
+返回: 新会话的令牌ID, 错误
+*/
+func (s *UserServiceImpl) startSession(ctx context.Context, userID uint) (string, error) {
+	active, err := s.sessionRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if excess := len(active) - s.maxSessions + 1; excess > 0 {
+		if s.sessionLimitPolicy == SessionLimitReject {
+			return "", ErrTooManySessions
+		}
+
+		ids := make([]primitive.ObjectID, 0, excess)
+		for _, old := range active[:excess] {
+			ids = append(ids, old.ID)
+		}
+		if err := s.sessionRepo.Revoke(ctx, ids); err != nil {
+			return "", err
+		}
+		utils.Info("会话数达到上限，已吊销最早的会话", zap.Uint("user_id", userID), zap.Int("revoked", len(ids)))
+	}
+
+	tokenID, err := newSessionTokenID()
+	if err != nil {
+		return "", fmt.Errorf("生成会话ID失败: %w", err)
+	}
+	now := time.Now()
+	if err := s.sessionRepo.Create(ctx, &session.Session{
+		UserID:    userID,
+		TokenID:   tokenID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.JWT.Expire),
+	}); err != nil {
+		return "", err
+	}
+
+	return tokenID, nil
+}
+
+// newSessionTokenID 生成随机的会话令牌ID
+func newSessionTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// recordLoginFailure 记录一次密码错误，达到次数上限时锁定账户
+// 读取用户后账户已被并发的请求锁定时不再计数，直接返回锁定错误
+// 返回: 本次登录应返回的错误
+func (s *UserServiceImpl) recordLoginFailure(ctx context.Context, u *user.User, now time.Time) error {
+	count, locked, err := s.userRepo.IncrementFailedLogins(ctx, u.ID, now)
+	if err != nil {
+		utils.Warn("记录登录失败次数失败", zap.Uint("user_id", u.ID), zap.Error(err))
+		return errors.New("用户名或密码错误")
+	}
+	if locked {
+		return ErrAccountLocked
+	}
+	if count < s.loginMaxAttempts {
+		return errors.New("用户名或密码错误")
+	}
+
+	until := now.Add(s.loginLockoutDuration)
+	locked, err = s.userRepo.LockUntil(ctx, u.ID, s.loginMaxAttempts, until)
+	if err != nil {
+		utils.Warn("锁定账户失败", zap.Uint("user_id", u.ID), zap.Error(err))
+		return errors.New("用户名或密码错误")
+	}
+	if !locked {
+		// 期间有一次成功登录清零了失败次数
+		return errors.New("用户名或密码错误")
+	}
+	utils.Warn("连续登录失败，账户已锁定",
+		zap.Uint("user_id", u.ID),
+		zap.Int("attempts", count),
+		zap.Time("locked_until", until),
+	)
+	return ErrAccountLocked
+}
+
+// ValidateToken 校验令牌并返回对应的用户及令牌过期时间
+// 除令牌本身的签名和有效期外，还要求用户存在、未删除且状态正常
+func (s *UserServiceImpl) ValidateToken(ctx context.Context, token string) (*user.User, time.Time, error) {
+	claims, err := middleware.ParseTokenWithOptions(token, s.cfg.JWT.Secret, middleware.NewTokenOptions(s.cfg))
+	if err != nil {
+		return nil, time.Time{}, errors.New("令牌无效: " + err.Error())
+	}
+
+	// 关联会话的令牌在会话被吊销（如超出会话数上限）后失效
+	if claims.ID != "" {
+		active, err := s.sessionRepo.IsActive(ctx, claims.ID)
+		if err != nil {
+			return nil, time.Time{}, errors.New("会话校验失败")
+		}
+		if !active {
+			return nil, time.Time{}, errors.New("会话已失效，请重新登录")
+		}
+	}
+
+	u, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil || u.Deleted {
+		return nil, time.Time{}, errors.New("用户不存在")
+	}
+
+	if u.Status != 1 {
+		return nil, time.Time{}, errors.New("用户已被禁用")
+	}
+
+	// 密码被重置前签发的令牌失效；令牌签发时间精确到秒，同一秒内签发的令牌视为重置之后
+	if u.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(u.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, time.Time{}, errors.New("密码已重置，请重新登录")
+	}
+
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return u, expiresAt, nil
+}
+
 // GetUserByID 根据ID获取用户
-func (s *UserServiceImpl) GetUserByID(id uint) (*user.User, error) {
-	u, err := s.userRepo.FindByID(id)
+func (s *UserServiceImpl) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
+	u, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, errors.New("用户不存在")
 	}
@@ -127,7 +582,7 @@ func (s *UserServiceImpl) GetUserByID(id uint) (*user.User, error) {
 }
 
 // GetUsers 获取用户列表
-func (s *UserServiceImpl) GetUsers(page, pageSize int, keyword string, status int) ([]user.User, int64, error) {
+func (s *UserServiceImpl) GetUsers(ctx context.Context, page, pageSize int, filter user.ListFilter) ([]user.User, int64, error) {
 	// 设置默认值
 	if page <= 0 {
 		page = 1
@@ -137,77 +592,1085 @@ func (s *UserServiceImpl) GetUsers(page, pageSize int, keyword string, status in
 	}
 
 	// 创建过滤条件
-	filter := map[string]interface{}{}
-	if status != 0 {
-		filter["status"] = status
+	conditions, err := userListConditions(filter)
+	if err != nil {
+		return nil, 0, err
 	}
-	if keyword != "" {
-		filter["keyword"] = keyword
+	sort, err := userListSort(filter.SortBy, filter.Order)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// 获取用户列表
-	return s.userRepo.FindAll(page, pageSize, filter)
+	return s.userRepo.FindAll(ctx, page, pageSize, conditions, sort)
+}
+
+/*
+userListSort 将排序参数转换为排序条件，字段必须在 sortableFields 中
+sortBy: 排序字段，为空时使用默认排序
+order: 排序方向 asc 或 desc，为空时为 desc
+返回: 排序条件（使用默认排序时为nil）, 错误（ErrInvalidSortField 或 ErrInvalidSortOrder）
+*/
+func userListSort(sortBy, order string) (bson.D, error) {
+	direction := -1
+	switch strings.ToLower(order) {
+	case "", "desc":
+	case "asc":
+		direction = 1
+	default:
+		return nil, ErrInvalidSortOrder
+	}
+
+	if sortBy == "" {
+		if order != "" {
+			return bson.D{{Key: "created_at", Value: direction}}, nil
+		}
+		return nil, nil
+	}
+	field, ok := sortableFields[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSortField, sortBy)
+	}
+	return bson.D{{Key: field, Value: direction}}, nil
+}
+
+// CountUsers 统计符合过滤条件的用户数，过滤条件与 GetUsers 相同，只统计不查询用户
+func (s *UserServiceImpl) CountUsers(ctx context.Context, filter user.ListFilter) (int64, error) {
+	conditions, err := userListConditions(filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.userRepo.Count(ctx, conditions)
+}
+
+/*
+RegistrationStats 按天统计注册用户数（不含已删除的用户），日期按UTC划分
+开始时间向前取整到当天0点；没有注册的日期补0，便于直接绘制时间序列
+query: 查询参数，未指定时统计最近30天
+返回: 统计结果, 错误（开始时间不早于结束时间时为 ErrInvalidTimeRange，超过366天时为 ErrStatsRangeTooLarge）
+*/
+func (s *UserServiceImpl) RegistrationStats(ctx context.Context, query *user.RegistrationStatsQuery) (*user.RegistrationStatsResponse, error) {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-defaultRegistrationStatsRange)
+	}
+	from, to = from.UTC(), to.UTC()
+
+	if !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+	if to.Sub(from) > maxDailyRange {
+		return nil, ErrStatsRangeTooLarge
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	counts, err := s.userRepo.CountCreatedByDay(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &user.RegistrationStatsResponse{
+		From: from,
+		To:   to,
+		Days: make([]user.DailyCount, 0),
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(database.DayLayout)
+		count := counts[date]
+		result.Total += count
+		result.Days = append(result.Days, user.DailyCount{Date: date, Count: count})
+	}
+
+	return result, nil
+}
+
+/*
+GetUsersAfter 使用游标获取用户列表（按创建时间倒序）
+适合深度翻页和遍历大量数据，不返回总数；需要跳转到指定页码时使用 GetUsers
+cursor: 上一页返回的游标，为空时返回第一页
+pageSize: 每页数量，默认10，最大100
+filter: 过滤条件
+返回: 用户列表, 下一页游标（没有更多数据时为空）, 错误（游标无效时为 common.ErrInvalidCursor，使用全文搜索时为 ErrSearchWithCursor）
+*/
+func (s *UserServiceImpl) GetUsersAfter(ctx context.Context, cursor string, pageSize int, filter user.ListFilter) ([]user.User, string, error) {
+	if filter.Search != "" {
+		return nil, "", ErrSearchWithCursor
+	}
+	if filter.SortBy != "" || filter.Order != "" {
+		return nil, "", ErrSortWithCursor
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	var lastCreatedAt time.Time
+	var lastID uint
+	if cursor != "" {
+		c, err := common.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// 游标经过JSON编码，时间为RFC3339字符串，ID解码为 json.Number
+		sortValue, _ := c.SortValue.(string)
+		number, _ := c.ID.(json.Number)
+		id, idErr := number.Int64()
+		lastCreatedAt, err = time.Parse(time.RFC3339Nano, sortValue)
+		if err != nil || idErr != nil || id <= 0 {
+			return nil, "", common.ErrInvalidCursor
+		}
+		lastID = uint(id)
+	}
+
+	conditions, err := userListConditions(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 多取一条用于判断是否还有下一页
+	users, err := s.userRepo.FindAfter(ctx, lastCreatedAt, lastID, pageSize+1, conditions)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) <= pageSize {
+		return users, "", nil
+	}
+
+	users = users[:pageSize]
+	last := users[len(users)-1]
+	next, err := common.EncodeCursor(last.CreatedAt, last.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return users, next, nil
 }
 
-// UpdateProfile 更新用户资料
-func (s *UserServiceImpl) UpdateProfile(id uint, req *user.UpdateProfileRequest) (*user.User, error) {
+// userListConditions 构建用户列表的过滤条件，角色不在已知范围内时返回 ErrInvalidRole
+func userListConditions(filter user.ListFilter) (map[string]interface{}, error) {
+	conditions := map[string]interface{}{}
+	if filter.Status != 0 {
+		conditions["status"] = filter.Status
+	}
+	if filter.Keyword != "" {
+		conditions["keyword"] = filter.Keyword
+	}
+	if filter.Search != "" {
+		conditions["text"] = filter.Search
+	}
+	if len(filter.Roles) > 0 {
+		for _, role := range filter.Roles {
+			if !user.IsValidRole(role) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRole, role)
+			}
+		}
+		conditions["role"] = filter.Roles
+	}
+	if filter.EmailVerified != nil {
+		conditions["email_verified"] = *filter.EmailVerified
+	}
+	return conditions, nil
+}
+
+// UpdateProfile 整体替换用户资料，未提供的字段重置为空值
+func (s *UserServiceImpl) UpdateProfile(ctx context.Context, id uint, req *user.UpdateProfileRequest) (*user.User, error) {
 	// 获取用户
-	u, err := s.userRepo.FindByID(id)
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	// 替换字段
+	u.Nickname = req.Nickname
+	u.Avatar = req.Avatar
+	u.UpdatedAt = time.Now()
+
+	// 更新用户
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("更新用户资料失败: %w", err)
+	}
+
+	return u, nil
+}
+
+// PatchProfile 部分更新用户资料，仅修改请求中提供的字段
+func (s *UserServiceImpl) PatchProfile(ctx context.Context, id uint, req *user.PatchProfileRequest) (*user.User, error) {
+	// 获取用户
+	u, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, errors.New("用户不存在")
 	}
 
 	// 更新字段
-	if req.Nickname != "" {
-		u.Nickname = req.Nickname
+	if req.Nickname != nil {
+		u.Nickname = *req.Nickname
 	}
-	if req.Avatar != "" {
-		u.Avatar = req.Avatar
+	if req.Avatar != nil {
+		u.Avatar = *req.Avatar
 	}
 	u.UpdatedAt = time.Now()
 
 	// 更新用户
-	if err := s.userRepo.Update(u); err != nil {
-		return nil, errors.New("更新用户资料失败: " + err.Error())
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("更新用户资料失败: %w", err)
 	}
 
 	return u, nil
 }
 
 // ChangePassword 修改密码
-func (s *UserServiceImpl) ChangePassword(id uint, req *user.ChangePasswordRequest) error {
+// 窗口期内原密码错误次数达到上限后返回 ErrTooManyAttempts，修改成功后清除失败记录并记录到审计日志
+func (s *UserServiceImpl) ChangePassword(ctx context.Context, id uint, req *user.ChangePasswordRequest, clientIP string) error {
+	limiterKey := strconv.FormatUint(uint64(id), 10)
+	if blocked, retryAfter := s.passwordChangeLimiter.Blocked(limiterKey); blocked {
+		return fmt.Errorf("%w（%d秒后可重试）", ErrTooManyAttempts, int(retryAfter.Seconds())+1)
+	}
+
 	// 获取用户
-	u, err := s.userRepo.FindByID(id)
+	u, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("用户不存在")
 	}
+	// 被强制重置的密码只能通过重置令牌修改
+	if u.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
 
 	// 验证旧密码
 	if !middleware.CheckPasswordHash(req.OldPassword, u.Password) {
+		s.passwordChangeLimiter.Record(limiterKey)
 		return errors.New("原密码错误")
 	}
 
+	// 检查新密码是否已泄露
+	if err := s.checkPasswordBreach(ctx, req.NewPassword); err != nil {
+		return err
+	}
+
 	// 更新密码
 	hashedPassword, err := middleware.HashPassword(req.NewPassword)
 	if err != nil {
 		return errors.New("密码加密失败: " + err.Error())
 	}
 
-	u.Password = hashedPassword
-	u.UpdatedAt = time.Now()
-
-	// 更新用户
-	if err := s.userRepo.Update(u); err != nil {
-		return errors.New("更新密码失败: " + err.Error())
+	// 只在密码仍为验证时的值时更新，验证之后密码被修改或被强制重置时不覆盖
+	updated, err := s.userRepo.ReplacePassword(ctx, id, u.Password, hashedPassword, false)
+	if err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	if !updated {
+		return errors.New("密码已被修改，请重试")
 	}
+	s.passwordChangeLimiter.Reset(limiterKey)
+	s.recordUserAction(id, audit.ActionPasswordChange, clientIP)
 
 	return nil
 }
 
 // DeleteUser 删除用户
-func (s *UserServiceImpl) DeleteUser(id uint) error {
-	if err := s.userRepo.Delete(id); err != nil {
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, id uint) error {
+	if err := s.userRepo.Delete(ctx, id); err != nil {
 		return errors.New("删除用户失败: " + err.Error())
 	}
 	return nil
 }
+
+// RestoreUser 恢复已删除的用户（管理员）
+func (s *UserServiceImpl) RestoreUser(ctx context.Context, id uint, operatorID uint) error {
+	if err := s.userRepo.Restore(ctx, id); err != nil {
+		// 删除后用户名或邮箱已被新用户注册
+		if errors.Is(err, repositories.ErrDuplicateUser) {
+			return fmt.Errorf("恢复用户失败: %w", ErrUserExists)
+		}
+		return fmt.Errorf("恢复用户失败: %w", err)
+	}
+
+	s.recordAudit(id, operatorID, audit.ActionUserRestore)
+	return nil
+}
+
+// HardDeleteUser 永久删除用户（管理员），删除后无法恢复
+func (s *UserServiceImpl) HardDeleteUser(ctx context.Context, id uint, operatorID uint) error {
+	if err := s.userRepo.HardDelete(ctx, id); err != nil {
+		return fmt.Errorf("永久删除用户失败: %w", err)
+	}
+
+	s.recordAudit(id, operatorID, audit.ActionUserHardDelete)
+	return nil
+}
+
+// recordAudit 记录管理员对用户的操作，失败只记录日志
+func (s *UserServiceImpl) recordAudit(userID, operatorID uint, action string) {
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:   userID,
+		ActorID:  operatorID,
+		Action:   action,
+		TargetID: userID,
+	}); err != nil {
+		utils.Warn("记录审计日志失败", zap.String("action", action), zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// recordUserAction 记录用户本人的操作，失败只记录日志
+func (s *UserServiceImpl) recordUserAction(userID uint, action, clientIP string) {
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  userID,
+		ActorID: userID,
+		Action:  action,
+		IP:      clientIP,
+	}); err != nil {
+		utils.Warn("记录审计日志失败", zap.String("action", action), zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+/*
+GetActivity 分页查询用户本人的操作记录（审计日志），按时间倒序
+只返回审计记录所属用户为 userID 的记录；page 默认1，pageSize 默认10
+返回: 审计日志, 总数, 错误（开始时间不早于结束时间时为 ErrInvalidTimeRange）
+*/
+func (s *UserServiceImpl) GetActivity(ctx context.Context, userID uint, page, pageSize int, filter audit.ActivityFilter) ([]*audit.Entry, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return nil, 0, ErrInvalidTimeRange
+	}
+
+	return s.auditRepo.FindPageByUser(ctx, userID, filter, page, pageSize)
+}
+
+// MergeUsers 将源账户合并到目标账户
+// 源账户的审计日志转移到目标账户，源账户被软删除，合并操作记录到审计日志。
+// 用户名和邮箱属于账户标识，冲突时始终保留目标账户的值；
+// 昵称、头像等资料字段按 req.Strategy 处理，目标账户为空时直接使用源账户的值。
+func (s *UserServiceImpl) MergeUsers(ctx context.Context, req *user.MergeUsersRequest, operatorID uint) (*MergeResult, error) {
+	if req.SourceID == req.TargetID {
+		return nil, errors.New("源账户与目标账户不能相同")
+	}
+
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = user.MergeKeepTarget
+	}
+
+	// 读取、更新目标账户、转移审计日志、吊销源账户会话和删除源账户在同一事务中执行，任一步失败全部回滚；
+	// 事务冲突时驱动会重新执行整个函数，因此每次都重新读取两个账户
+	var source, target *user.User
+	var conflicts []string
+	var reassigned, revoked int64
+	err := s.transactions.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		var err error
+		source, err = s.userRepo.FindByID(sessCtx, req.SourceID)
+		if err != nil || source.Deleted {
+			return errors.New("源账户不存在")
+		}
+		target, err = s.userRepo.FindByID(sessCtx, req.TargetID)
+		if err != nil || target.Deleted {
+			return errors.New("目标账户不存在")
+		}
+
+		// 账户标识冲突：保留目标账户
+		conflicts = nil
+		if source.Username != target.Username {
+			conflicts = append(conflicts, "username")
+		}
+		if source.Email != target.Email {
+			conflicts = append(conflicts, "email")
+		}
+
+		// 资料字段冲突：按策略合并
+		mergeField := func(name string, src string, dst *string) {
+			switch {
+			case src == "" || src == *dst:
+			case *dst == "":
+				*dst = src
+			default:
+				conflicts = append(conflicts, name)
+				if strategy == user.MergePreferSource {
+					*dst = src
+				}
+			}
+		}
+		mergeField("nickname", source.Nickname, &target.Nickname)
+		mergeField("avatar", source.Avatar, &target.Avatar)
+
+		target.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(sessCtx, target); err != nil {
+			return fmt.Errorf("更新目标账户失败: %w", err)
+		}
+
+		// 转移源账户拥有的审计日志
+		reassigned, err = s.auditRepo.ReassignUser(sessCtx, source.ID, target.ID)
+		if err != nil {
+			return fmt.Errorf("转移审计日志失败: %w", err)
+		}
+
+		// 吊销源账户的会话，源账户登录的设备不会因合并而获得目标账户的权限
+		revoked, err = s.sessionRepo.RevokeByUser(sessCtx, source.ID)
+		if err != nil {
+			return fmt.Errorf("吊销源账户会话失败: %w", err)
+		}
+
+		// 软删除源账户
+		if err := s.userRepo.Delete(sessCtx, source.ID); err != nil {
+			return fmt.Errorf("删除源账户失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录合并审计日志
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:   target.ID,
+		ActorID:  operatorID,
+		Action:   audit.ActionUserMerge,
+		TargetID: source.ID,
+		Detail: map[string]interface{}{
+			"source_id": source.ID,
+			"target_id": target.ID,
+			"strategy":  strategy,
+			"conflicts": conflicts,
+			"revoked":   revoked,
+		},
+	}); err != nil {
+		utils.Warn("记录账户合并审计日志失败", zap.Uint("source_id", source.ID), zap.Uint("target_id", target.ID), zap.Error(err))
+	}
+
+	return &MergeResult{
+		Target:           target,
+		Conflicts:        conflicts,
+		ReassignedAudits: reassigned,
+		RevokedSessions:  revoked,
+	}, nil
+}
+
+// ExportUserData 导出用户的个人数据（用户资料、审计日志、登录会话和API密钥元数据），导出操作本身记录到审计日志
+func (s *UserServiceImpl) ExportUserData(ctx context.Context, id uint, clientIP string) (*user.ExportResponse, error) {
+	limiterKey := strconv.FormatUint(uint64(id), 10)
+	if blocked, retryAfter := s.exportLimiter.Blocked(limiterKey); blocked {
+		return nil, fmt.Errorf("%w（%d秒后可重试）", ErrTooManyAttempts, int(retryAfter.Seconds())+1)
+	}
+
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil || u.Deleted {
+		return nil, ErrUserNotFound
+	}
+
+	auditLogs, err := s.auditRepo.FindByUser(id)
+	if err != nil {
+		return nil, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+
+	sessions, err := s.sessionRepo.FindByUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询登录会话失败: %w", err)
+	}
+
+	apiKeys, err := s.apiKeyRepo.FindAllByUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+
+	s.exportLimiter.Record(limiterKey)
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  id,
+		ActorID: id,
+		Action:  audit.ActionUserExport,
+		IP:      clientIP,
+	}); err != nil {
+		utils.Warn("记录数据导出审计日志失败", zap.Uint("user_id", id), zap.Error(err))
+	}
+
+	return &user.ExportResponse{
+		ExportedAt: time.Now(),
+		Profile:    u.ToResponse(),
+		AuditLogs:  auditLogs,
+		Sessions:   sessions,
+		APIKeys:    apiKeys,
+	}, nil
+}
+
+/*
+ResendVerification 重新生成邮箱验证令牌并发送验证邮件
+同一用户在冷却期内只能发送一次，过早请求返回 ErrTooManyAttempts；之前发送的令牌随之失效。
+邮件发送失败时本次发送仍计入冷却期
+返回: 邮箱是否已经验证（已验证时不发送邮件）, 错误
+*/
+func (s *UserServiceImpl) ResendVerification(ctx context.Context, id uint) (bool, error) {
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil || u.Deleted {
+		return false, ErrUserNotFound
+	}
+	if u.EmailVerifiedAt != nil {
+		return true, nil
+	}
+
+	now := time.Now()
+	if u.EmailVerificationSentAt != nil {
+		if wait := u.EmailVerificationSentAt.Add(s.verificationResendCooldown).Sub(now); wait > 0 {
+			return false, fmt.Errorf("%w（%d秒后可重试）", ErrTooManyAttempts, int(wait.Seconds())+1)
+		}
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return false, fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+	updated, err := s.userRepo.SetEmailVerificationToken(ctx, id, hashVerificationToken(token),
+		now.Add(verificationTokenTTL), now, now.Add(-s.verificationResendCooldown))
+	if err != nil {
+		return false, err
+	}
+	if !updated {
+		// 并发请求已经发送过验证邮件
+		return false, fmt.Errorf("%w（%d秒后可重试）", ErrTooManyAttempts, int(s.verificationResendCooldown.Seconds()))
+	}
+
+	if err := s.mailer.Send(ctx, u.Email, "请验证您的邮箱", s.verificationEmailBody(u, token)); err != nil {
+		utils.Warn("发送验证邮件失败", zap.Uint("user_id", id), zap.Error(err))
+		return false, fmt.Errorf("发送验证邮件失败: %w", err)
+	}
+	return false, nil
+}
+
+// verificationEmailBody 生成验证邮件正文，配置了验证链接时附带链接
+func (s *UserServiceImpl) verificationEmailBody(u *user.User, token string) string {
+	hours := int(verificationTokenTTL.Hours())
+	if s.cfg.Security.VerificationURL == "" {
+		return fmt.Sprintf("%s，您好：\n\n您的邮箱验证令牌为 %s，%d小时内有效。", u.Username, token, hours)
+	}
+
+	link := linkWithToken(s.cfg.Security.VerificationURL, token)
+	return fmt.Sprintf("%s，您好：\n\n请在%d小时内打开以下链接完成邮箱验证：\n%s", u.Username, hours, link)
+}
+
+// linkWithToken 将令牌以 token 查询参数附加到链接地址后
+func linkWithToken(base, token string) string {
+	if strings.Contains(base, "?") {
+		base += "&"
+	} else {
+		base += "?"
+	}
+	return base + "token=" + url.QueryEscape(token)
+}
+
+// newVerificationToken 生成随机的邮箱验证令牌
+func newVerificationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashVerificationToken 计算验证令牌的哈希，数据库中只保存哈希
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DistinctValues 查询字段的不重复取值，仅支持 distinctFields 中的字段
+// email_domain 由邮箱的不重复取值计算得到，只返回域名部分
+func (s *UserServiceImpl) DistinctValues(ctx context.Context, field string) ([]interface{}, error) {
+	dbField, ok := distinctFields[field]
+	if !ok {
+		return nil, errors.New("不支持查询该字段: " + field)
+	}
+
+	values, err := s.userRepo.Distinct(ctx, dbField)
+	if err != nil {
+		return nil, err
+	}
+
+	if field != "email_domain" {
+		return values, nil
+	}
+
+	seen := make(map[string]struct{})
+	domains := make([]interface{}, 0)
+	for _, v := range values {
+		email, ok := v.(string)
+		if !ok {
+			continue
+		}
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			continue
+		}
+		domain := strings.ToLower(email[at+1:])
+		if _, dup := seen[domain]; dup || domain == "" {
+			continue
+		}
+		seen[domain] = struct{}{}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+/*
+BulkUpdateUsers 按过滤条件批量更新用户（管理员）
+过滤条件和可修改的字段都是固定的（见 user.BulkUpdateRequest），操作人自己的账户不会被修改，避免管理员禁用或降级自己。
+过滤条件为空时必须显式确认；匹配的用户数超过 maxBulkUpdateUsers 时拒绝更新。
+状态改为禁用时同时吊销这些用户的全部会话，已签发的令牌立即失效。
+试运行只返回匹配的用户数，不修改数据也不记录审计日志
+*/
+func (s *UserServiceImpl) BulkUpdateUsers(ctx context.Context, req *user.BulkUpdateRequest, operatorID uint) (*user.BulkUpdateResponse, error) {
+	fields := make(map[string]interface{})
+	if req.Patch.Status != nil {
+		fields["status"] = *req.Patch.Status
+	}
+	if req.Patch.Role != nil {
+		if !user.IsValidRole(*req.Patch.Role) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, *req.Patch.Role)
+		}
+		fields["role"] = *req.Patch.Role
+	}
+	if len(fields) == 0 && !req.DryRun {
+		return nil, ErrBulkUpdateEmptyPatch
+	}
+	if req.Filter.IsEmpty() && !req.Confirm && !req.DryRun {
+		return nil, ErrBulkUpdateUnconfirmed
+	}
+
+	conditions, err := bulkUpdateConditions(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	conditions["exclude_id"] = operatorID
+
+	if req.DryRun {
+		matched, err := s.userRepo.Count(ctx, conditions)
+		if err != nil {
+			return nil, err
+		}
+		return &user.BulkUpdateResponse{Matched: matched, DryRun: true}, nil
+	}
+
+	ids, matched, modified, err := s.updateUsersByIDs(ctx, conditions, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// 禁用的用户立即吊销会话；更新已经完成，吊销失败只记录日志
+	var revoked int64
+	if req.Patch.Status != nil && *req.Patch.Status == 0 && len(ids) > 0 {
+		if revoked, err = s.sessionRepo.RevokeByUsers(ctx, ids); err != nil {
+			utils.Warn("吊销被禁用用户的会话失败", zap.Uint("operator_id", operatorID), zap.Int("users", len(ids)), zap.Error(err))
+		}
+	}
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionUserBulkUpdate,
+		Detail: map[string]interface{}{
+			"filter":           conditions,
+			"patch":            fields,
+			"user_ids":         ids,
+			"matched":          matched,
+			"modified":         modified,
+			"revoked_sessions": revoked,
+		},
+	}); err != nil {
+		utils.Warn("记录批量更新审计日志失败", zap.Uint("operator_id", operatorID), zap.Error(err))
+	}
+
+	return &user.BulkUpdateResponse{Matched: matched, Modified: modified}, nil
+}
+
+/*
+BulkVerifyUsers 按过滤条件批量将用户标记为邮箱已验证（管理员），不发送邮件，用于导入用户等场景
+只修改邮箱尚未验证的用户，已验证用户的验证时间保持不变；过滤条件为空时必须显式确认，
+匹配的用户数超过 maxBulkUpdateUsers 时拒绝更新。试运行只返回匹配的用户数，不修改数据也不记录审计日志
+*/
+func (s *UserServiceImpl) BulkVerifyUsers(ctx context.Context, req *user.BulkVerifyRequest, operatorID uint) (*user.BulkUpdateResponse, error) {
+	if req.Filter.IsEmpty() && !req.Confirm && !req.DryRun {
+		return nil, ErrBulkUpdateUnconfirmed
+	}
+
+	conditions, err := bulkUpdateConditions(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	conditions["email_verified"] = false
+
+	if req.DryRun {
+		matched, err := s.userRepo.Count(ctx, conditions)
+		if err != nil {
+			return nil, err
+		}
+		return &user.BulkUpdateResponse{Matched: matched, DryRun: true}, nil
+	}
+
+	ids, matched, modified, err := s.updateUsersByIDs(ctx, conditions, map[string]interface{}{
+		"email_verified_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionUserBulkVerify,
+		Detail: map[string]interface{}{
+			"filter":   conditions,
+			"user_ids": ids,
+			"matched":  matched,
+			"modified": modified,
+		},
+	}); err != nil {
+		utils.Warn("记录批量验证审计日志失败", zap.Uint("operator_id", operatorID), zap.Error(err))
+	}
+
+	return &user.BulkUpdateResponse{Matched: matched, Modified: modified}, nil
+}
+
+/*
+updateUsersByIDs 批量更新符合条件的用户，最多 maxBulkUpdateUsers 个
+先取出最多 maxBulkUpdateUsers+1 个匹配用户的ID，超过上限时拒绝；再按这些ID（同时保留原条件）更新，
+取ID之后才满足条件的用户不会被更新，实际更新的用户数不会超过上限
+返回: 取出的用户ID, 匹配的用户数, 实际修改的用户数, 错误
+*/
+func (s *UserServiceImpl) updateUsersByIDs(ctx context.Context, conditions, fields map[string]interface{}) ([]uint, int64, int64, error) {
+	ids, err := s.userRepo.FindIDs(ctx, conditions, maxBulkUpdateUsers+1)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(ids) > maxBulkUpdateUsers {
+		return nil, 0, 0, fmt.Errorf("%w（匹配超过%d个）", ErrBulkUpdateTooLarge, maxBulkUpdateUsers)
+	}
+	// 没有匹配的用户时不能执行更新：ids 为空的条件不限制用户ID
+	if len(ids) == 0 {
+		return ids, 0, 0, nil
+	}
+
+	byID := make(map[string]interface{}, len(conditions)+1)
+	for k, v := range conditions {
+		byID[k] = v
+	}
+	byID["ids"] = ids
+	matched, modified, err := s.userRepo.UpdateMany(ctx, byID, fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return ids, matched, modified, nil
+}
+
+// bulkUpdateConditions 将批量更新的过滤条件转换为存储库的查询条件
+func bulkUpdateConditions(filter user.BulkUpdateFilter) (map[string]interface{}, error) {
+	conditions, err := userListConditions(user.ListFilter{
+		Roles:         filter.Roles,
+		EmailVerified: filter.EmailVerified,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filter.IDs) > 0 {
+		conditions["ids"] = filter.IDs
+	}
+	if filter.Status != nil {
+		conditions["status"] = *filter.Status
+	}
+	if filter.CreatedBefore != nil {
+		conditions["created_before"] = *filter.CreatedBefore
+	}
+	if filter.CreatedAfter != nil {
+		conditions["created_after"] = *filter.CreatedAfter
+	}
+	return conditions, nil
+}
+
+/*
+StartRehashPasswords 在后台启动密码批量迁移任务
+遍历全部用户并按固定速率写入，用户量大时耗时较长，不能在请求内完成（会被处理器超时中断），
+因此在后台执行，通过 RehashPasswordsStatus 查询进度。任务状态保存在本实例内存中，
+同一实例同时只能执行一个任务；进程退出时任务中断，已处理的用户不受影响，重新执行即可继续
+operatorID: 操作人ID
+返回: 任务的初始状态, 错误（已有任务在执行时返回 ErrRehashRunning）
+*/
+func (s *UserServiceImpl) StartRehashPasswords(operatorID uint) (*user.RehashPasswordsResponse, error) {
+	s.rehashMu.Lock()
+	if s.rehashJob != nil && s.rehashJob.Status == user.RehashRunning {
+		s.rehashMu.Unlock()
+		return nil, ErrRehashRunning
+	}
+	s.rehashJob = &user.RehashPasswordsResponse{Status: user.RehashRunning, StartedAt: time.Now()}
+	s.rehashMu.Unlock()
+
+	// 任务不随请求结束而取消
+	go s.rehashPasswords(context.Background(), operatorID)
+
+	return s.RehashPasswordsStatus()
+}
+
+/*
+RehashPasswordsStatus 返回本实例最近一次密码批量迁移任务的状态和进度
+返回: 任务状态的副本, 错误（尚未执行过任务时返回 ErrRehashNotStarted）
+*/
+func (s *UserServiceImpl) RehashPasswordsStatus() (*user.RehashPasswordsResponse, error) {
+	s.rehashMu.Lock()
+	defer s.rehashMu.Unlock()
+	if s.rehashJob == nil {
+		return nil, ErrRehashNotStarted
+	}
+	job := *s.rehashJob
+	return &job, nil
+}
+
+// updateRehashJob 在锁内更新迁移任务的状态
+func (s *UserServiceImpl) updateRehashJob(fn func(job *user.RehashPasswordsResponse)) {
+	s.rehashMu.Lock()
+	defer s.rehashMu.Unlock()
+	fn(s.rehashJob)
+}
+
+/*
+rehashPasswords 批量迁移明文和旧格式密码
+明文密码直接哈希后写回；无法识别的哈希没有原密码无法迁移，标记为需要重置密码；
+强度较低的bcrypt哈希会在用户下次登录时自动升级，这里只做统计。写入按固定速率进行，
+只在密码仍为读取时的值时更新，遍历期间用户修改过密码的跳过
+operatorID: 操作人ID
+*/
+func (s *UserServiceImpl) rehashPasswords(ctx context.Context, operatorID uint) {
+	throttle := time.NewTicker(time.Second / rehashWritesPerSecond)
+	defer throttle.Stop()
+
+	// replace 替换密码并记录结果
+	replace := func(u *user.User, password string, resetRequired bool, onSuccess func(job *user.RehashPasswordsResponse)) {
+		<-throttle.C
+		updated, err := s.userRepo.ReplacePassword(ctx, u.ID, u.Password, password, resetRequired)
+		s.updateRehashJob(func(job *user.RehashPasswordsResponse) {
+			switch {
+			case err != nil:
+				job.Failed++
+			case !updated:
+				job.Skipped++
+			default:
+				onSuccess(job)
+			}
+		})
+		if err != nil {
+			utils.Warn("迁移用户密码失败", zap.Uint("user_id", u.ID), zap.Error(err))
+		}
+	}
+
+	err := s.userRepo.ForEach(ctx, func(u *user.User) error {
+		s.updateRehashJob(func(job *user.RehashPasswordsResponse) { job.Scanned++ })
+
+		switch middleware.ClassifyStoredPassword(u.Password) {
+		case middleware.PasswordPlaintext:
+			hashed, err := middleware.HashPassword(u.Password)
+			if err != nil {
+				s.updateRehashJob(func(job *user.RehashPasswordsResponse) { job.Failed++ })
+				return nil
+			}
+			replace(u, hashed, false, func(job *user.RehashPasswordsResponse) { job.Migrated++ })
+		case middleware.PasswordHashUnknown:
+			if u.PasswordResetRequired {
+				s.updateRehashJob(func(job *user.RehashPasswordsResponse) { job.Flagged++ })
+				return nil
+			}
+			replace(u, u.Password, true, func(job *user.RehashPasswordsResponse) { job.Flagged++ })
+		case middleware.PasswordHashLowCost:
+			s.updateRehashJob(func(job *user.RehashPasswordsResponse) { job.LowCost++ })
+		}
+		return nil
+	})
+
+	status := user.RehashCompleted
+	if err != nil {
+		status = user.RehashFailed
+		utils.Error("密码批量迁移中止", zap.Uint("operator_id", operatorID), zap.Error(err))
+	}
+
+	// 先记录审计日志再更新任务状态，查询到任务结束时审计日志已经写入
+	result, _ := s.RehashPasswordsStatus()
+	if auditErr := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  audit.ActionPasswordRehash,
+		Detail: map[string]interface{}{
+			"status":   status,
+			"scanned":  result.Scanned,
+			"migrated": result.Migrated,
+			"flagged":  result.Flagged,
+			"low_cost": result.LowCost,
+			"skipped":  result.Skipped,
+			"failed":   result.Failed,
+		},
+	}); auditErr != nil {
+		utils.Warn("记录密码迁移审计日志失败", zap.Uint("operator_id", operatorID), zap.Error(auditErr))
+	}
+
+	finishedAt := time.Now()
+	s.updateRehashJob(func(job *user.RehashPasswordsResponse) {
+		job.Status = status
+		job.FinishedAt = &finishedAt
+		if err != nil {
+			job.Error = fmt.Sprintf("遍历用户失败: %v", err)
+		}
+	})
+}
+
+/*
+ForcePasswordReset 按过滤条件强制用户重置密码（管理员）
+匹配用户的当前密码和已签发的令牌立即失效，API密钥全部吊销（keep_api_keys 为true时保留），
+并为每个用户生成新的重置令牌；要求发送邮件时通过邮件发送，
+未发送或发送失败的令牌在响应中返回，由管理员转交。过滤条件、确认和数量上限的规则与 BulkUpdateUsers 相同，
+操作人自己的账户不会被重置。写入按固定速率进行，每个用户记录一条审计日志；
+请求中途取消时停止处理，已处理的用户不会回滚
+返回: 重置结果, 错误
+*/
+func (s *UserServiceImpl) ForcePasswordReset(ctx context.Context, req *user.ForcePasswordResetRequest, operatorID uint) (*user.ForcePasswordResetResponse, error) {
+	if req.Filter.IsEmpty() && !req.Confirm && !req.DryRun {
+		return nil, ErrBulkUpdateUnconfirmed
+	}
+
+	conditions, err := bulkUpdateConditions(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	conditions["exclude_id"] = operatorID
+
+	matched, err := s.userRepo.Count(ctx, conditions)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return &user.ForcePasswordResetResponse{Matched: matched, DryRun: true}, nil
+	}
+	if matched > maxBulkUpdateUsers {
+		return nil, fmt.Errorf("%w（匹配%d个，上限%d个）", ErrBulkUpdateTooLarge, matched, maxBulkUpdateUsers)
+	}
+
+	users, _, err := s.userRepo.FindAll(ctx, 1, maxBulkUpdateUsers, conditions, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &user.ForcePasswordResetResponse{Matched: int64(len(users))}
+
+	throttle := time.NewTicker(time.Second / forcePasswordResetPerSecond)
+	defer throttle.Stop()
+
+	for i := range users {
+		u := &users[i]
+		select {
+		case <-ctx.Done():
+			utils.Warn("强制重置密码被取消", zap.Uint("operator_id", operatorID), zap.Int("reset", result.Reset))
+			return result, ctx.Err()
+		case <-throttle.C:
+		}
+
+		token, err := newVerificationToken()
+		if err != nil {
+			result.Failed++
+			continue
+		}
+
+		// 先吊销API密钥再使密码失效，吊销失败时跳过该用户，重新执行即可补齐
+		var revokedKeys int64
+		if !req.KeepAPIKeys {
+			if revokedKeys, err = s.apiKeyRepo.RevokeByUser(ctx, u.ID); err != nil {
+				utils.Warn("强制重置密码时吊销API密钥失败", zap.Uint("user_id", u.ID), zap.Error(err))
+				result.Failed++
+				continue
+			}
+			result.APIKeysRevoked += revokedKeys
+		}
+
+		now := time.Now()
+		if err := s.userRepo.ForcePasswordReset(ctx, u.ID, hashVerificationToken(token), now.Add(passwordResetTokenTTL), now); err != nil {
+			utils.Warn("强制重置密码失败", zap.Uint("user_id", u.ID), zap.Error(err))
+			result.Failed++
+			continue
+		}
+		result.Reset++
+
+		emailed := false
+		if req.SendEmail {
+			if err := s.mailer.Send(ctx, u.Email, "请重置您的密码", s.passwordResetEmailBody(u, token)); err != nil {
+				utils.Warn("发送密码重置邮件失败", zap.Uint("user_id", u.ID), zap.Error(err))
+			} else {
+				emailed = true
+				result.Emailed++
+			}
+		}
+		if !emailed {
+			result.Tokens = append(result.Tokens, user.PasswordResetToken{UserID: u.ID, Token: token})
+		}
+
+		if err := s.auditRepo.Create(&audit.Entry{
+			UserID:   u.ID,
+			ActorID:  operatorID,
+			Action:   audit.ActionPasswordForceReset,
+			TargetID: u.ID,
+			Detail:   map[string]interface{}{"emailed": emailed, "api_keys_revoked": revokedKeys},
+		}); err != nil {
+			utils.Warn("记录审计日志失败", zap.String("action", audit.ActionPasswordForceReset), zap.Uint("user_id", u.ID), zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// passwordResetEmailBody 生成密码重置邮件正文，配置了重置链接时附带链接
+func (s *UserServiceImpl) passwordResetEmailBody(u *user.User, token string) string {
+	hours := int(passwordResetTokenTTL.Hours())
+	if s.cfg.Security.PasswordResetURL == "" {
+		return fmt.Sprintf("%s，您好：\n\n管理员已重置您的密码，原密码不再可用。您的密码重置令牌为 %s，%d小时内有效。", u.Username, token, hours)
+	}
+
+	link := linkWithToken(s.cfg.Security.PasswordResetURL, token)
+	return fmt.Sprintf("%s，您好：\n\n管理员已重置您的密码，原密码不再可用。请在%d小时内打开以下链接设置新密码：\n%s", u.Username, hours, link)
+}
+
+/*
+ResetPassword 使用重置令牌设置新密码
+令牌只能使用一次，过期、已使用或被新令牌替换时返回 ErrInvalidResetToken；
+设置成功后清除重置标记，此前签发的令牌全部失效，并记录到审计日志
+*/
+func (s *UserServiceImpl) ResetPassword(ctx context.Context, req *user.ResetPasswordRequest, clientIP string) error {
+	tokenHash := hashVerificationToken(req.Token)
+	u, err := s.userRepo.FindByPasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if u.PasswordResetExpiresAt == nil || time.Now().After(*u.PasswordResetExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	// 检查新密码是否已泄露
+	if err := s.checkPasswordBreach(ctx, req.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := middleware.HashPassword(req.NewPassword)
+	if err != nil {
+		return errors.New("密码加密失败: " + err.Error())
+	}
+
+	updated, err := s.userRepo.CompletePasswordReset(ctx, u.ID, tokenHash, hashedPassword, time.Now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		// 并发请求已经使用了该令牌
+		return ErrInvalidResetToken
+	}
+
+	s.recordUserAction(u.ID, audit.ActionPasswordReset, clientIP)
+	return nil
+}
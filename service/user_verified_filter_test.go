@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-app/models/user"
+)
+
+func TestGetUsersFiltersByEmailVerified(t *testing.T) {
+	verifiedAt := time.Now()
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Username: "alice", EmailVerifiedAt: &verifiedAt},
+		&user.User{ID: 2, Username: "bob"},
+		&user.User{ID: 3, Username: "carol", EmailVerifiedAt: &verifiedAt},
+		&user.User{ID: 4, Username: "dave"},
+	)
+	svc := newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, nil)
+
+	cases := []struct {
+		verified *bool
+		want     []uint
+	}{
+		{boolPtr(true), []uint{1, 3}},
+		{boolPtr(false), []uint{2, 4}},
+		{nil, []uint{1, 2, 3, 4}},
+	}
+	for _, tc := range cases {
+		list, total, err := svc.GetUsers(context.Background(), 1, 10, user.ListFilter{EmailVerified: tc.verified})
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		if total != int64(len(tc.want)) || len(list) != len(tc.want) {
+			t.Fatalf("total = %d, len = %d, want %d", total, len(list), len(tc.want))
+		}
+		for i, u := range list {
+			if u.ID != tc.want[i] {
+				t.Fatalf("list[%d].ID = %d, want %d", i, u.ID, tc.want[i])
+			}
+		}
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/user"
+)
+
+func newValidateTokenTestService(users *fakeUserRepo) *UserServiceImpl {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	return newTestUserService(users, &fakeAuditRepo{}, &fakeSessionRepo{}, cfg)
+}
+
+func TestValidateTokenReturnsUserAndExpiry(t *testing.T) {
+	svc := newValidateTokenTestService(newFakeUserRepo(&user.User{ID: 1, Username: "alice", Status: 1}))
+	token, err := middleware.GenerateToken(1, user.RoleUser, "test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	u, expiresAt, err := svc.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if u.ID != 1 {
+		t.Fatalf("user.ID = %d, want 1", u.ID)
+	}
+	if remaining := time.Until(expiresAt); remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Fatalf("剩余有效期 = %s, want 约1小时", remaining)
+	}
+}
+
+func TestValidateTokenRejectsInvalidTokens(t *testing.T) {
+	users := newFakeUserRepo(
+		&user.User{ID: 1, Username: "alice", Status: 1},
+		&user.User{ID: 2, Username: "bob", Status: 0},
+	)
+	svc := newValidateTokenTestService(users)
+
+	expired, _ := middleware.GenerateToken(1, user.RoleUser, "test-secret", -time.Minute)
+	wrongSecret, _ := middleware.GenerateToken(1, user.RoleUser, "other-secret", time.Hour)
+	disabled, _ := middleware.GenerateToken(2, user.RoleUser, "test-secret", time.Hour)
+	missing, _ := middleware.GenerateToken(3, user.RoleUser, "test-secret", time.Hour)
+
+	cases := map[string]string{
+		"已过期":   expired,
+		"签名不匹配": wrongSecret,
+		"用户已禁用": disabled,
+		"用户不存在": missing,
+	}
+	for name, token := range cases {
+		if _, _, err := svc.ValidateToken(context.Background(), token); err == nil {
+			t.Errorf("%s: 令牌应该被拒绝", name)
+		}
+	}
+}
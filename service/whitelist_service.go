@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"go-app/database/repositories"
+	"go-app/middleware"
+	"go-app/models/audit"
+	"go-app/models/whitelist"
+	"go-app/utils"
+
+	"go.uber.org/zap"
+)
+
+// WhitelistService 白名单服务接口
+// 修改直接作用于运行中的白名单（middleware.DefaultWhitelistConfig），并持久化以便重启后恢复
+type WhitelistService interface {
+	Load() error
+	List(entryType string) ([]string, error)
+	Add(entryType, value string, operatorID uint, clientIP string) error
+	Remove(entryType, value string, operatorID uint, clientIP string) error
+}
+
+// WhitelistServiceImpl 白名单服务实现
+type WhitelistServiceImpl struct {
+	whitelistRepo repositories.WhitelistRepository
+	auditRepo     repositories.AuditRepository
+}
+
+// NewWhitelistService 创建白名单服务
+func NewWhitelistService(whitelistRepo repositories.WhitelistRepository, auditRepo repositories.AuditRepository) WhitelistService {
+	return &WhitelistServiceImpl{
+		whitelistRepo: whitelistRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// Load 将持久化的白名单条目加载到运行中的白名单
+func (s *WhitelistServiceImpl) Load() error {
+	entries, err := s.whitelistRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var addErr error
+		switch entry.Type {
+		case whitelist.TypeIP:
+			addErr = middleware.AddToIPWhitelist(entry.Value)
+		case whitelist.TypePath:
+			addErr = middleware.AddToPathWhitelist(entry.Value)
+		default:
+			addErr = errors.New("未知的白名单类型")
+		}
+		if addErr != nil {
+			utils.Warn("加载白名单条目失败", zap.String("type", entry.Type), zap.String("value", entry.Value), zap.Error(addErr))
+		}
+	}
+
+	return nil
+}
+
+// List 返回运行中的白名单条目
+func (s *WhitelistServiceImpl) List(entryType string) ([]string, error) {
+	switch entryType {
+	case whitelist.TypeIP:
+		return middleware.ListIPWhitelist(), nil
+	case whitelist.TypePath:
+		return middleware.ListPathWhitelist(), nil
+	}
+	return nil, errors.New("未知的白名单类型")
+}
+
+// Add 添加白名单条目，立即生效并持久化
+func (s *WhitelistServiceImpl) Add(entryType, value string, operatorID uint, clientIP string) error {
+	value, err := normalizeWhitelistValue(entryType, value)
+	if err != nil {
+		return err
+	}
+
+	current, _ := s.List(entryType)
+	existed := containsString(current, value)
+
+	// 先修改运行中的白名单，以便在持久化之前发现数量上限等错误
+	if entryType == whitelist.TypeIP {
+		err = middleware.AddToIPWhitelist(value)
+	} else {
+		err = middleware.AddToPathWhitelist(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.whitelistRepo.Add(&whitelist.Entry{
+		Type:      entryType,
+		Value:     value,
+		CreatedBy: operatorID,
+	}); err != nil {
+		// 持久化失败时回滚，保证运行状态与存储一致
+		if !existed {
+			s.removeLive(entryType, value)
+		}
+		return err
+	}
+
+	s.audit(audit.ActionWhitelistAdd, entryType, value, operatorID, clientIP)
+	return nil
+}
+
+// Remove 移除白名单条目，立即生效并从存储中删除
+// 配置文件中的条目会在重启后重新加载，如需永久移除请同时修改配置
+func (s *WhitelistServiceImpl) Remove(entryType, value string, operatorID uint, clientIP string) error {
+	value, err := normalizeWhitelistValue(entryType, value)
+	if err != nil {
+		return err
+	}
+
+	current, _ := s.List(entryType)
+	if !containsString(current, value) {
+		return errors.New("白名单条目不存在")
+	}
+
+	if err := s.whitelistRepo.Remove(entryType, value); err != nil {
+		return err
+	}
+	s.removeLive(entryType, value)
+
+	s.audit(audit.ActionWhitelistRemove, entryType, value, operatorID, clientIP)
+	return nil
+}
+
+// removeLive 从运行中的白名单移除条目
+func (s *WhitelistServiceImpl) removeLive(entryType, value string) {
+	if entryType == whitelist.TypeIP {
+		middleware.RemoveFromIPWhitelist(value)
+	} else {
+		middleware.RemoveFromPathWhitelist(value)
+	}
+}
+
+// audit 记录白名单变更审计日志，失败时仅记录警告
+func (s *WhitelistServiceImpl) audit(action, entryType, value string, operatorID uint, clientIP string) {
+	if err := s.auditRepo.Create(&audit.Entry{
+		UserID:  operatorID,
+		ActorID: operatorID,
+		Action:  action,
+		Detail: map[string]interface{}{
+			"type":  entryType,
+			"value": value,
+		},
+		IP: clientIP,
+	}); err != nil {
+		utils.Warn("记录白名单变更审计日志失败", zap.String("action", action), zap.String("value", value), zap.Error(err))
+	}
+}
+
+// normalizeWhitelistValue 校验并规范化白名单条目
+// IP和CIDR网段统一转换为标准格式，避免同一地址以不同写法重复出现
+func normalizeWhitelistValue(entryType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("白名单条目不能为空")
+	}
+
+	switch entryType {
+	case whitelist.TypeIP:
+		if strings.Contains(value, "/") {
+			_, ipNet, err := net.ParseCIDR(value)
+			if err != nil {
+				return "", errors.New("无效的CIDR网段: " + value)
+			}
+			return ipNet.String(), nil
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", errors.New("无效的IP地址: " + value)
+		}
+		return ip.String(), nil
+	case whitelist.TypePath:
+		if !strings.HasPrefix(value, "/") {
+			return "", errors.New("路径必须以/开头: " + value)
+		}
+		return value, nil
+	}
+
+	return "", errors.New("未知的白名单类型")
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
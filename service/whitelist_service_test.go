@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go-app/config"
+	"go-app/middleware"
+	"go-app/models/audit"
+	"go-app/models/whitelist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeWhitelistRepo 内存中的白名单存储
+type fakeWhitelistRepo struct {
+	mu      sync.Mutex
+	entries []*whitelist.Entry
+	addErr  error
+}
+
+func (r *fakeWhitelistRepo) FindAll() ([]*whitelist.Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*whitelist.Entry(nil), r.entries...), nil
+}
+
+func (r *fakeWhitelistRepo) Add(entry *whitelist.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.addErr != nil {
+		return r.addErr
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeWhitelistRepo) Remove(entryType, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.entries {
+		if e.Type == entryType && e.Value == value {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// useTestWhitelist 将运行中的白名单替换为只允许 10.0.0.1 的配置，测试结束后恢复
+func useTestWhitelist(t *testing.T) *gin.Engine {
+	t.Helper()
+	saved := middleware.DefaultWhitelistConfig
+	t.Cleanup(func() { middleware.DefaultWhitelistConfig = saved })
+
+	cfg := &config.Config{}
+	cfg.Whitelist.IPWhitelist = []string{"10.0.0.1"}
+	cfg.Whitelist.EnableIPWhitelist = true
+	middleware.DefaultWhitelistConfig = middleware.NewWhitelistConfig(cfg)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Whitelist(middleware.DefaultWhitelistConfig))
+	r.GET("/res", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func serveWhitelistFrom(r *gin.Engine, ip string) int {
+	req := httptest.NewRequest(http.MethodGet, "/res", nil)
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestWhitelistAddAllowsRequest(t *testing.T) {
+	r := useTestWhitelist(t)
+	repo := &fakeWhitelistRepo{}
+	audits := &fakeAuditRepo{}
+	svc := NewWhitelistService(repo, audits)
+
+	if code := serveWhitelistFrom(r, "192.0.2.7"); code != http.StatusForbidden {
+		t.Fatalf("添加前: status = %d, want 403", code)
+	}
+	if err := svc.Add(whitelist.TypeIP, " 192.0.2.0/24 ", 1, "10.0.0.1"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if code := serveWhitelistFrom(r, "192.0.2.7"); code != http.StatusOK {
+		t.Fatalf("添加后: status = %d, want 200", code)
+	}
+	if entries, _ := repo.FindAll(); len(entries) != 1 || entries[0].Value != "192.0.2.0/24" {
+		t.Fatalf("持久化的条目 = %v", entries)
+	}
+
+	if err := svc.Remove(whitelist.TypeIP, "192.0.2.0/24", 1, "10.0.0.1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if code := serveWhitelistFrom(r, "192.0.2.7"); code != http.StatusForbidden {
+		t.Fatalf("移除后: status = %d, want 403", code)
+	}
+
+	got := audits.actions()
+	if len(got) != 2 || got[0] != audit.ActionWhitelistAdd || got[1] != audit.ActionWhitelistRemove {
+		t.Fatalf("审计日志 = %v", got)
+	}
+}
+
+func TestWhitelistAddRollsBackWhenPersistFails(t *testing.T) {
+	r := useTestWhitelist(t)
+	svc := NewWhitelistService(&fakeWhitelistRepo{addErr: errors.New("写入失败")}, &fakeAuditRepo{})
+
+	if err := svc.Add(whitelist.TypeIP, "192.0.2.7", 1, "10.0.0.1"); err == nil {
+		t.Fatal("持久化失败时应返回错误")
+	}
+	if code := serveWhitelistFrom(r, "192.0.2.7"); code != http.StatusForbidden {
+		t.Fatalf("持久化失败后: status = %d, want 403", code)
+	}
+}
+
+func TestWhitelistLoadRestoresPersistedEntries(t *testing.T) {
+	r := useTestWhitelist(t)
+	repo := &fakeWhitelistRepo{entries: []*whitelist.Entry{{Type: whitelist.TypeIP, Value: "192.0.2.7"}}}
+
+	if err := NewWhitelistService(repo, &fakeAuditRepo{}).Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if code := serveWhitelistFrom(r, "192.0.2.7"); code != http.StatusOK {
+		t.Fatalf("加载后: status = %d, want 200", code)
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix 加密字段的前缀，用于区分密文和加密启用前写入的明文
+const encryptedPrefix = "enc:v1:"
+
+// Crypter 字段加密接口
+type Crypter interface {
+	// Encrypt 加密明文，相同明文每次得到不同的密文
+	Encrypt(plaintext string) (string, error)
+	// Decrypt 解密密文，不是密文的值（加密启用前写入的明文）原样返回
+	Decrypt(ciphertext string) (string, error)
+	// Hash 计算确定性的带密钥哈希，用于加密字段的精确匹配查询
+	Hash(value string) string
+}
+
+// AESGCMCrypter 基于AES-256-GCM的字段加密实现
+type AESGCMCrypter struct {
+	aead    cipher.AEAD
+	hashKey []byte
+}
+
+/*
+NewAESGCMCrypter 创建AES-GCM字段加密器
+key: base64编码的32字节密钥；哈希密钥由该密钥派生，与加密密钥不同
+返回: 加密器, 错误
+*/
+func NewAESGCMCrypter(key string) (*AESGCMCrypter, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("加密密钥不是有效的base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, errors.New("加密密钥长度必须为32字节")
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, raw)
+	mac.Write([]byte("field-hash"))
+
+	return &AESGCMCrypter{aead: aead, hashKey: mac.Sum(nil)}, nil
+}
+
+// Encrypt 加密明文，密文格式为 enc:v1:base64(nonce|密文)
+func (c *AESGCMCrypter) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文，没有加密前缀的值原样返回
+func (c *AESGCMCrypter) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, encryptedPrefix) {
+		return ciphertext, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("密文格式错误")
+	}
+	nonce, data := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", errors.New("解密失败")
+	}
+	return string(plaintext), nil
+}
+
+// Hash 计算HMAC-SHA256十六进制摘要
+func (c *AESGCMCrypter) Hash(value string) string {
+	mac := hmac.New(sha256.New, c.hashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func newTestCrypter(t *testing.T) *AESGCMCrypter {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewAESGCMCrypter(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+	return c
+}
+
+func TestAESGCMCrypterRoundTrip(t *testing.T) {
+	c := newTestCrypter(t)
+
+	a, err := c.Encrypt("alice@example.com")
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	b, _ := c.Encrypt("alice@example.com")
+	if a == b {
+		t.Fatal("相同明文每次加密的密文应不同")
+	}
+	if strings.Contains(a, "alice") {
+		t.Fatalf("密文中包含明文: %s", a)
+	}
+
+	got, err := c.Decrypt(a)
+	if err != nil || got != "alice@example.com" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	// 加密启用前写入的明文原样返回
+	if got, err := c.Decrypt("legacy@example.com"); err != nil || got != "legacy@example.com" {
+		t.Fatalf("Decrypt(明文) = %q, %v", got, err)
+	}
+}
+
+func TestAESGCMCrypterRejectsTamperedCiphertext(t *testing.T) {
+	c := newTestCrypter(t)
+	ciphertext, _ := c.Encrypt("alice@example.com")
+
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedPrefix))
+	sealed[len(sealed)-1] ^= 0xff
+	tampered := encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+	if _, err := c.Decrypt(tampered); err == nil {
+		t.Fatal("被篡改的密文应解密失败")
+	}
+
+	// 其他密钥加密的密文无法解密
+	if _, err := newTestCrypter(t).Decrypt(ciphertext); err == nil {
+		t.Fatal("其他密钥的密文应解密失败")
+	}
+}
+
+func TestAESGCMCrypterHash(t *testing.T) {
+	c := newTestCrypter(t)
+	if c.Hash("alice@example.com") != c.Hash("alice@example.com") {
+		t.Fatal("哈希应是确定性的")
+	}
+	if c.Hash("alice@example.com") == newTestCrypter(t).Hash("alice@example.com") {
+		t.Fatal("不同密钥的哈希应不同")
+	}
+}
+
+func TestNewAESGCMCrypterValidatesKey(t *testing.T) {
+	for _, key := range []string{"not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := NewAESGCMCrypter(key); err == nil {
+			t.Errorf("NewAESGCMCrypter(%q) 应返回错误", key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// maxCursorLength 游标字符串的最大长度，避免解析超大的输入
+const maxCursorLength = 1024
+
+// ErrInvalidCursor 游标无效（格式错误或签名不匹配）
+var ErrInvalidCursor = errors.New("无效的游标")
+
+var (
+	cursorSecret   []byte
+	cursorSecretMu sync.RWMutex
+)
+
+func init() {
+	// 未配置密钥时使用随机密钥，游标在重启后失效
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err == nil {
+		cursorSecret = secret
+	}
+}
+
+// SetCursorSecret 设置游标签名密钥，多实例部署时各实例必须一致，为空时保持当前密钥
+func SetCursorSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+	cursorSecret = []byte(secret)
+}
+
+/*
+EncodeCursor 将游标内容编码为不透明的字符串
+内容序列化为JSON后附加HMAC-SHA256签名，客户端无法篡改
+v: 游标内容
+返回: base64编码的游标，序列化失败时返回空字符串
+*/
+func EncodeCursor(v interface{}) string {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(cursorMAC(payload))
+}
+
+/*
+DecodeCursor 校验签名并解码游标
+s: EncodeCursor 生成的字符串
+dst: 解码目标，必须为指针；interface{} 字段中的数字解码为 json.Number
+返回: 错误（格式错误、签名不匹配或包含未知字段时返回 ErrInvalidCursor）
+*/
+func DecodeCursor(s string, dst interface{}) error {
+	if s == "" || len(s) > maxCursorLength {
+		return ErrInvalidCursor
+	}
+
+	encodedPayload, encodedMAC, ok := strings.Cut(s, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, cursorMAC(payload)) {
+		return ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(dst); err != nil || decoder.More() {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+// cursorMAC 计算游标内容的签名
+func cursorMAC(payload []byte) []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
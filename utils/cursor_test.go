@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testCursor struct {
+	ID   uint   `json:"id"`
+	Sort string `json:"sort"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	s := EncodeCursor(testCursor{ID: 42, Sort: "created_at"})
+
+	var got testCursor
+	if err := DecodeCursor(s, &got); err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if got.ID != 42 || got.Sort != "created_at" {
+		t.Fatalf("got = %+v", got)
+	}
+}
+
+func TestCursorRejectsTampering(t *testing.T) {
+	s := EncodeCursor(testCursor{ID: 42})
+	_, mac, _ := strings.Cut(s, ".")
+
+	// 替换内容但保留原签名
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"id":1}`)) + "." + mac
+	// 未知字段
+	unknown := EncodeCursor(map[string]interface{}{"id": 1, "admin": true})
+
+	cases := map[string]string{
+		"空字符串": "",
+		"缺少签名": strings.Split(s, ".")[0],
+		"篡改内容": forged,
+		"未知字段": unknown,
+		"超长":   strings.Repeat("a", maxCursorLength+1),
+	}
+	for name, input := range cases {
+		var got testCursor
+		if err := DecodeCursor(input, &got); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
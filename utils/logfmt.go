@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 请求日志输出格式
+const (
+	RequestLogFormatJSON   = "json"
+	RequestLogFormatLogfmt = "logfmt"
+)
+
+// IsSupportedRequestLogFormat 判断请求日志格式是否受支持，空值按json处理
+func IsSupportedRequestLogFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "", RequestLogFormatJSON, RequestLogFormatLogfmt:
+		return true
+	}
+	return false
+}
+
+// EncodeRequestLog 按指定格式序列化请求日志（不含换行符），未知格式按json输出
+func EncodeRequestLog(reqLog RequestLog, format string) ([]byte, error) {
+	if strings.ToLower(format) == RequestLogFormatLogfmt {
+		return reqLog.MarshalLogfmt(), nil
+	}
+	return json.Marshal(reqLog)
+}
+
+/*
+MarshalLogfmt 将请求日志序列化为logfmt格式
+字段名与JSON格式一致，空的可选字段同样省略；params、headers、extra_info 展开为
+params.id=1 形式的字段并按名称排序。包含空格、等号、引号或控制字符的值加双引号并转义
+*/
+func (l RequestLog) MarshalLogfmt() []byte {
+	var b strings.Builder
+
+	writeLogfmtPair(&b, "time", l.Time.Format(time.RFC3339Nano))
+	writeLogfmtPair(&b, "method", l.Method)
+	writeLogfmtPair(&b, "path", l.Path)
+	writeLogfmtPair(&b, "query", l.Query)
+	writeLogfmtPair(&b, "status", strconv.Itoa(l.Status))
+	writeLogfmtPair(&b, "ip", l.IP)
+	writeLogfmtPair(&b, "user_agent", l.UserAgent)
+	writeLogfmtPair(&b, "latency_ms", strconv.FormatFloat(l.LatencyMs, 'f', -1, 64))
+	writeLogfmtPair(&b, "request_bytes", strconv.FormatInt(l.RequestBytes, 10))
+	writeLogfmtPair(&b, "response_bytes", strconv.FormatInt(l.ResponseBytes, 10))
+	if l.RequestID != "" {
+		writeLogfmtPair(&b, "request_id", l.RequestID)
+	}
+	if l.ClientRequestID != "" {
+		writeLogfmtPair(&b, "client_request_id", l.ClientRequestID)
+	}
+	if l.Error != "" {
+		writeLogfmtPair(&b, "error", l.Error)
+	}
+
+	for _, k := range sortedKeys(l.Params) {
+		writeLogfmtPair(&b, "params."+k, l.Params[k])
+	}
+	for _, k := range sortedKeys(l.Headers) {
+		writeLogfmtPair(&b, "headers."+k, l.Headers[k])
+	}
+	for _, k := range sortedKeys(l.ExtraInfo) {
+		writeLogfmtPair(&b, "extra_info."+k, logfmtValue(l.ExtraInfo[k]))
+	}
+
+	return []byte(b.String())
+}
+
+// sortedKeys 返回按名称排序的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// logfmtValue 将任意值转换为字符串，字符串原样返回，其他类型按JSON表示
+func logfmtValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case fmt.Stringer:
+		return val.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// writeLogfmtPair 写入一个 key=value 字段，字段之间以空格分隔
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	if logfmtNeedsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+		return
+	}
+	b.WriteString(value)
+}
+
+// logfmtKey 替换键中不允许出现的字符（空格、等号、引号和控制字符）
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtNeedsQuote 判断值是否需要加引号：空值或包含空格、等号、引号、反斜杠和控制字符时需要
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == unicode.ReplacementChar || unicode.IsControl(r) || unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
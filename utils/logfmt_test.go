@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseLogfmt 解析一行logfmt，带引号的值按Go字符串字面量反转义
+func parseLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for line != "" {
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			t.Fatalf("缺少等号: %q", line)
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				t.Fatalf("无法解析带引号的值 %q: %v", rest, err)
+			}
+			if value, err = strconv.Unquote(quoted); err != nil {
+				t.Fatalf("反转义失败 %q: %v", quoted, err)
+			}
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+			rest = " " + rest
+		}
+		if _, dup := fields[key]; dup {
+			t.Fatalf("字段重复: %s", key)
+		}
+		fields[key] = value
+		line = strings.TrimPrefix(rest, " ")
+	}
+	return fields
+}
+
+// flattenJSONLog 将JSON格式的请求日志展开为与logfmt相同的 key=value 形式
+func flattenJSONLog(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("解析JSON失败: %v", err)
+	}
+
+	fields := make(map[string]string)
+	for k, v := range doc {
+		if nested, ok := v.(map[string]interface{}); ok {
+			for nk, nv := range nested {
+				fields[k+"."+nk] = logfmtValue(nv)
+			}
+			continue
+		}
+		fields[k] = logfmtValue(v)
+	}
+	return fields
+}
+
+func TestRequestLogFormatsRoundTrip(t *testing.T) {
+	reqLog := RequestLog{
+		Time:      time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC),
+		Method:    "POST",
+		Path:      "/api/v1/users",
+		Query:     "q=a b&x=\"y\"",
+		Status:    400,
+		IP:        "192.0.2.1",
+		UserAgent: "Mozilla/5.0 (X11; Linux)",
+		LatencyMs: 12.5,
+		RequestID: "req-1",
+		Error:     "line1\nline2\t\\ 中文",
+		Params:    map[string]string{"id": "42", "name": "a=b"},
+		Headers:   map[string]string{"Content-Type": "application/json", "X-Empty": ""},
+		ExtraInfo: map[string]interface{}{"user_id": json.Number("7"), "note": "with \"quotes\""},
+
+		RequestBytes:    128,
+		ResponseBytes:   64,
+		ClientRequestID: "client id",
+	}
+
+	jsonData, err := EncodeRequestLog(reqLog, RequestLogFormatJSON)
+	if err != nil {
+		t.Fatalf("json序列化失败: %v", err)
+	}
+	logfmtData, err := EncodeRequestLog(reqLog, RequestLogFormatLogfmt)
+	if err != nil {
+		t.Fatalf("logfmt序列化失败: %v", err)
+	}
+	if bytes.ContainsAny(logfmtData, "\n\t") {
+		t.Fatalf("logfmt输出应为单行且控制字符已转义: %s", logfmtData)
+	}
+
+	fromJSON := flattenJSONLog(t, jsonData)
+	fromLogfmt := parseLogfmt(t, string(logfmtData))
+	if !reflect.DeepEqual(fromJSON, fromLogfmt) {
+		t.Fatalf("两种格式的字段不一致\njson:   %v\nlogfmt: %v", fromJSON, fromLogfmt)
+	}
+	if fromLogfmt["error"] != reqLog.Error || fromLogfmt["params.name"] != "a=b" {
+		t.Fatalf("特殊字符未正确还原: %v", fromLogfmt)
+	}
+}
+
+func TestRequestLogFormatsOmitEmptyOptionalFields(t *testing.T) {
+	reqLog := RequestLog{Time: time.Unix(0, 0).UTC(), Method: "GET", Path: "/ping", Status: 200}
+
+	jsonData, _ := EncodeRequestLog(reqLog, "")
+	logfmtData, _ := EncodeRequestLog(reqLog, "LOGFMT")
+	fromJSON := flattenJSONLog(t, jsonData)
+	fromLogfmt := parseLogfmt(t, string(logfmtData))
+	if !reflect.DeepEqual(fromJSON, fromLogfmt) {
+		t.Fatalf("两种格式的字段不一致\njson:   %v\nlogfmt: %v", fromJSON, fromLogfmt)
+	}
+	for _, k := range []string{"request_id", "error", "client_request_id"} {
+		if _, ok := fromLogfmt[k]; ok {
+			t.Errorf("空的可选字段 %s 不应输出", k)
+		}
+	}
+}
+
+func TestIsSupportedRequestLogFormat(t *testing.T) {
+	for format, want := range map[string]bool{"": true, "json": true, "LogFmt": true, "xml": false} {
+		if got := IsSupportedRequestLogFormat(format); got != want {
+			t.Errorf("IsSupportedRequestLogFormat(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,6 +19,10 @@ var (
 	logger      *zap.Logger
 	sugarLogger *zap.SugaredLogger
 	once        sync.Once
+	// 日志文件写入器，收到重新打开信号时关闭，下次写入时按原文件名重新创建
+	logFiles []*lumberjack.Logger
+	// 当前日志级别，控制台和文件输出共用，可在运行时修改
+	logLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 )
 
 // LogConfig 日志配置
@@ -28,6 +35,13 @@ type LogConfig struct {
 	Compress      bool   // 是否压缩旧日志文件
 	ConsoleOutput bool   // 是否输出到控制台
 	RotateDaily   bool   // 是否按天轮转
+	// 自动附加堆栈信息的最低日志级别：error（默认）、dpanic、panic、fatal，none表示不自动附加
+	// 预期内的客户端错误（4xx）以warn级别记录，不会附加堆栈
+	StacktraceLevel string
+	// 最低日志级别：debug（默认）、info、warn、error，运行时可通过 SetLogLevel 修改
+	Level string
+	// 请求日志格式：json（默认）或 logfmt，仅对请求日志生效
+	RequestLogFormat string
 }
 
 // 默认日志配置
@@ -50,9 +64,14 @@ func InitLogger() {
 // InitLoggerWithConfig 使用自定义配置初始化日志
 func InitLoggerWithConfig(config LogConfig) {
 	once.Do(func() {
-		// 确保日志目录存在
+		// 确保日志目录存在；目录不可用时，若开启了控制台输出则仅输出到控制台，保证至少有一个输出
+		fileOutput := true
 		if err := os.MkdirAll(config.LogDir, 0755); err != nil {
-			panic("无法创建日志目录: " + err.Error())
+			if !config.ConsoleOutput {
+				panic("无法创建日志目录: " + err.Error())
+			}
+			fmt.Fprintf(os.Stderr, "无法创建日志目录，仅输出到控制台: %v\n", err)
+			fileOutput = false
 		}
 
 		// 配置编码器
@@ -74,12 +93,17 @@ func InitLoggerWithConfig(config LogConfig) {
 		// 创建JSON编码器
 		jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
 
-		// 日志级别
+		// 日志级别，error及以上写入错误日志，其余写入常规日志；两者都受运行时日志级别控制
+		if config.Level != "" {
+			if err := SetLogLevel(config.Level); err != nil {
+				fmt.Fprintf(os.Stderr, "日志级别无效，使用debug: %v\n", err)
+			}
+		}
 		highPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			return lvl >= zapcore.ErrorLevel
+			return lvl >= zapcore.ErrorLevel && logLevel.Enabled(lvl)
 		})
 		lowPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			return lvl < zapcore.ErrorLevel
+			return lvl < zapcore.ErrorLevel && logLevel.Enabled(lvl)
 		})
 
 		// 获取当前日期
@@ -113,37 +137,25 @@ func InitLoggerWithConfig(config LogConfig) {
 		}
 
 		// 将文件WriteSyncer包装成zapcore.WriteSyncer
-		infoFileWriter := zapcore.AddSync(infoLogFile)
-		errorFileWriter := zapcore.AddSync(errorLogFile)
-
-		// 构建日志核心
-		var cores []zapcore.Core
-
-		// 文件日志输出
-		cores = append(cores,
-			zapcore.NewCore(jsonEncoder, errorFileWriter, highPriority),
-			zapcore.NewCore(jsonEncoder, infoFileWriter, lowPriority),
-		)
-
-		// 控制台日志输出(可选)
-		if config.ConsoleOutput {
-			consoleDebugging := zapcore.Lock(os.Stdout)
-			consoleErrors := zapcore.Lock(os.Stderr)
-			cores = append(cores,
-				zapcore.NewCore(jsonEncoder, consoleErrors, highPriority),
-				zapcore.NewCore(jsonEncoder, consoleDebugging, lowPriority),
-			)
+		files := logWriters{
+			errors: zapcore.AddSync(errorLogFile),
+			info:   zapcore.AddSync(infoLogFile),
 		}
+		logFiles = []*lumberjack.Logger{infoLogFile, errorLogFile}
 
 		// 合并所有日志输出
-		core := zapcore.NewTee(cores...)
+		core := zapcore.NewTee(buildLogCores(config, fileOutput, files, jsonEncoder, highPriority, lowPriority)...)
 
 		// 创建日志记录器，添加调用信息
-		logger = zap.New(core,
+		opts := []zap.Option{
 			zap.AddCaller(),
 			zap.AddCallerSkip(1),
-			zap.AddStacktrace(zapcore.ErrorLevel),
-		)
+		}
+		stacktraceLevel, stacktraceEnabled := parseStacktraceLevel(config.StacktraceLevel)
+		if stacktraceEnabled {
+			opts = append(opts, zap.AddStacktrace(stacktraceLevel))
+		}
+		logger = zap.New(core, opts...)
 
 		// 创建糖化记录器
 		sugarLogger = logger.Sugar()
@@ -153,10 +165,85 @@ func InitLoggerWithConfig(config LogConfig) {
 			zap.String("日志目录", config.LogDir),
 			zap.String("日志文件名", config.LogFileName),
 			zap.Bool("按天轮转", config.RotateDaily),
+			zap.String("日志级别", logLevel.String()),
 		)
 	})
 }
 
+// logWriters 一个输出目标的错误日志和常规日志写入器
+type logWriters struct {
+	errors zapcore.WriteSyncer
+	info   zapcore.WriteSyncer
+}
+
+// consoleWriters 控制台输出，错误日志写入标准错误，其余写入标准输出
+var consoleWriters = logWriters{
+	errors: zapcore.Lock(os.Stderr),
+	info:   zapcore.Lock(os.Stdout),
+}
+
+/*
+buildLogCores 构建日志核心，每个输出目标的error及以上写入 errors，其余写入 info
+参数: config 日志配置，ConsoleOutput 为false时不创建控制台核心；fileOutput 是否输出到文件；files 文件输出
+返回: 日志核心
+*/
+func buildLogCores(config LogConfig, fileOutput bool, files logWriters, encoder zapcore.Encoder, high, low zapcore.LevelEnabler) []zapcore.Core {
+	var outputs []logWriters
+	// 文件日志输出
+	if fileOutput {
+		outputs = append(outputs, files)
+	}
+	// 控制台日志输出(可选)
+	if config.ConsoleOutput {
+		outputs = append(outputs, consoleWriters)
+	}
+
+	cores := make([]zapcore.Core, 0, 2*len(outputs))
+	for _, out := range outputs {
+		cores = append(cores,
+			zapcore.NewCore(encoder, out.errors, high),
+			zapcore.NewCore(encoder, out.info, low),
+		)
+	}
+	return cores
+}
+
+// SetLogLevel 修改运行时日志级别，立即对控制台和文件输出生效
+// level: debug、info、warn、error 等zap级别名称，不区分大小写
+func SetLogLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(strings.ToLower(strings.TrimSpace(level)))
+	if err != nil {
+		return fmt.Errorf("无效的日志级别: %s", level)
+	}
+	logLevel.SetLevel(parsed)
+	return nil
+}
+
+// GetLogLevel 返回当前的日志级别名称
+func GetLogLevel() string {
+	return logLevel.String()
+}
+
+/*
+parseStacktraceLevel 解析自动附加堆栈信息的最低日志级别
+level: 级别名称，为空时使用error，无法识别时同样使用error
+返回: 日志级别, 是否启用自动堆栈
+*/
+func parseStacktraceLevel(level string) (zapcore.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "none", "off":
+		return zapcore.InvalidLevel, false
+	case "dpanic":
+		return zapcore.DPanicLevel, true
+	case "panic":
+		return zapcore.PanicLevel, true
+	case "fatal":
+		return zapcore.FatalLevel, true
+	default:
+		return zapcore.ErrorLevel, true
+	}
+}
+
 // GetLogger 获取日志记录器
 func GetLogger() *zap.Logger {
 	if logger == nil {
@@ -198,10 +285,60 @@ func Fatal(msg string, fields ...zap.Field) {
 	GetLogger().Fatal(msg, fields...)
 }
 
-// Sync 同步日志缓冲区到文件
+/*
+ReopenLogFiles 重新打开所有日志文件（应用日志和请求日志）
+配合外部 logrotate 使用：logrotate 重命名日志文件后发送 SIGHUP，
+关闭当前文件句柄，下次写入时会按原文件名创建新文件，而不是继续写入已重命名的文件
+返回: 关闭文件时遇到的第一个错误
+*/
+func ReopenLogFiles() error {
+	var firstErr error
+	for _, f := range logFiles {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if requestLogger != nil {
+		if err := requestLogger.reopen(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+/*
+Sync 同步日志缓冲区到文件
+标准输出/标准错误为终端或管道时，部分平台的fsync会返回 EINVAL 或 ENOTTY，这类错误没有实际影响，会被忽略
+返回: 日志文件同步失败的错误
+*/
 func Sync() error {
 	if logger != nil {
-		return logger.Sync()
+		return filterSyncError(logger.Sync())
 	}
 	return nil
 }
+
+// filterSyncError 去掉同步标准输出/标准错误时的已知错误，保留其余错误
+// zap 同步多个输出时会合并错误，需要逐个判断
+func filterSyncError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		var kept []error
+		for _, e := range multi.Unwrap() {
+			if e = filterSyncError(e); e != nil {
+				kept = append(kept, e)
+			}
+		}
+		return errors.Join(kept...)
+	}
+
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) && (pathErr.Path == os.Stdout.Name() || pathErr.Path == os.Stderr.Name()) &&
+		(errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY)) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFilterSyncError(t *testing.T) {
+	stdoutErr := &os.PathError{Op: "sync", Path: os.Stdout.Name(), Err: syscall.EINVAL}
+	stderrErr := &os.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.ENOTTY}
+	fileErr := &os.PathError{Op: "sync", Path: "logs/app.log", Err: syscall.EIO}
+
+	if err := filterSyncError(stdoutErr); err != nil {
+		t.Errorf("标准输出的EINVAL应被忽略: %v", err)
+	}
+	if err := filterSyncError(stderrErr); err != nil {
+		t.Errorf("标准错误的ENOTTY应被忽略: %v", err)
+	}
+	if err := filterSyncError(errors.Join(stdoutErr, stderrErr)); err != nil {
+		t.Errorf("合并的已知错误应全部忽略: %v", err)
+	}
+
+	err := filterSyncError(errors.Join(stdoutErr, fileErr))
+	if !errors.Is(err, syscall.EIO) {
+		t.Errorf("日志文件同步错误应保留: %v", err)
+	}
+	if errors.Is(err, syscall.EINVAL) {
+		t.Errorf("合并错误中的已知错误应去掉: %v", err)
+	}
+
+	otherStdout := &os.PathError{Op: "sync", Path: os.Stdout.Name(), Err: syscall.EIO}
+	if filterSyncError(otherStdout) == nil {
+		t.Error("标准输出的其他错误应保留")
+	}
+}
+
+// bufferSyncer 记录写入内容的 WriteSyncer
+type bufferSyncer struct {
+	bytes.Buffer
+}
+
+func (b *bufferSyncer) Sync() error { return nil }
+
+func TestBuildLogCoresConsoleOutput(t *testing.T) {
+	saved := consoleWriters
+	t.Cleanup(func() { consoleWriters = saved })
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	high := zap.LevelEnablerFunc(func(l zapcore.Level) bool { return l >= zapcore.ErrorLevel })
+	low := zap.LevelEnablerFunc(func(l zapcore.Level) bool { return l < zapcore.ErrorLevel })
+
+	cases := []struct {
+		name          string
+		console, file bool
+	}{
+		{"仅文件", false, true},
+		{"文件和控制台", true, true},
+		{"仅控制台", true, false},
+	}
+	for _, tc := range cases {
+		consoleErr, consoleInfo := &bufferSyncer{}, &bufferSyncer{}
+		fileErr, fileInfo := &bufferSyncer{}, &bufferSyncer{}
+		consoleWriters = logWriters{errors: consoleErr, info: consoleInfo}
+
+		cores := buildLogCores(LogConfig{ConsoleOutput: tc.console}, tc.file, logWriters{errors: fileErr, info: fileInfo}, encoder, high, low)
+		log := zap.New(zapcore.NewTee(cores...))
+		log.Info("info")
+		log.Error("error")
+
+		if got, want := len(cores), 2*(btoi(tc.console)+btoi(tc.file)); got != want {
+			t.Errorf("%s: len(cores) = %d, want %d", tc.name, got, want)
+		}
+		if wrote := consoleInfo.Len() > 0 && consoleErr.Len() > 0; wrote != tc.console {
+			t.Errorf("%s: 控制台输出 = %v, want %v", tc.name, wrote, tc.console)
+		}
+		if wrote := fileInfo.Len() > 0 && fileErr.Len() > 0; wrote != tc.file {
+			t.Errorf("%s: 文件输出 = %v, want %v", tc.name, wrote, tc.file)
+		}
+		if strings.Contains(fileInfo.String(), `"msg":"error"`) {
+			t.Errorf("%s: error日志不应写入常规日志", tc.name)
+		}
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestStacktraceOnlyForErrors(t *testing.T) {
+	cases := []struct {
+		level               string
+		warnStack, errStack bool
+	}{
+		{"", false, true},
+		{"error", false, true},
+		{"dpanic", false, false},
+		{"warn", false, true}, // 不支持低于error的级别，按error处理
+		{"none", false, false},
+		{"invalid", false, true},
+	}
+	for _, tc := range cases {
+		core, logs := observer.New(zapcore.DebugLevel)
+		var opts []zap.Option
+		if level, ok := parseStacktraceLevel(tc.level); ok {
+			opts = append(opts, zap.AddStacktrace(level))
+		}
+		log := zap.New(core, opts...)
+
+		// 日志中间件以warn记录4xx，以error记录5xx
+		log.Warn("[GIN] 404 GET /missing")
+		log.Error("[GIN] 500 GET /boom")
+
+		entries := logs.All()
+		if got := entries[0].Stack != ""; got != tc.warnStack {
+			t.Errorf("StacktraceLevel=%q: 4xx warn日志带堆栈 = %v, want %v", tc.level, got, tc.warnStack)
+		}
+		if got := entries[1].Stack != ""; got != tc.errStack {
+			t.Errorf("StacktraceLevel=%q: 5xx error日志带堆栈 = %v, want %v", tc.level, got, tc.errStack)
+		}
+	}
+}
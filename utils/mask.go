@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maskChar 脱敏使用的替换字符
+const maskChar = "*"
+
+// maskFullLength 不超过该长度的值全部替换，避免短值被首尾字符猜出
+const maskFullLength = 4
+
+// SensitiveKeys 默认需要脱敏的字段名（不区分大小写），用于查询参数、路径参数等日志输出
+var SensitiveKeys = []string{
+	"password", "old_password", "new_password",
+	"token", "access_token", "refresh_token",
+	"secret", "app_secret", "api_key", "apikey",
+}
+
+/*
+MaskSecret 对敏感值进行脱敏，用于日志和调试输出
+保留首尾各一个字符，中间按原长度替换为"*"；长度不超过4个字符的值全部替换。按字符（而非字节）计算长度
+s: 原始值
+返回: 脱敏后的值，空字符串原样返回
+*/
+func MaskSecret(s string) string {
+	runes := []rune(s)
+	n := len(runes)
+	if n == 0 {
+		return ""
+	}
+	if n <= maskFullLength {
+		return strings.Repeat(maskChar, n)
+	}
+	return string(runes[0]) + strings.Repeat(maskChar, n-2) + string(runes[n-1])
+}
+
+/*
+MaskMap 返回脱敏后的map副本，键名与 keys 中任意一项匹配（不区分大小写）时值经过 MaskSecret 处理
+原map不会被修改
+m: 原始map
+keys: 需要脱敏的键名
+*/
+func MaskMap(m map[string]string, keys []string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if isSensitiveKey(k, keys) {
+			v = MaskSecret(v)
+		}
+		result[k] = v
+	}
+	return result
+}
+
+/*
+MaskQuery 对URL查询字符串中的敏感参数脱敏
+参数顺序和其他参数的原始编码保持不变，只替换敏感参数的值
+rawQuery: 原始查询字符串（不含"?"）
+keys: 需要脱敏的参数名
+*/
+func MaskQuery(rawQuery string, keys []string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !isSensitiveKey(key, keys) {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		// "*" 在查询字符串中无需转义，保持可读
+		masked := strings.ReplaceAll(url.QueryEscape(MaskSecret(value)), "%2A", maskChar)
+		pairs[i] = pair[:strings.Index(pair, "=")+1] + masked
+	}
+	return strings.Join(pairs, "&")
+}
+
+// isSensitiveKey 判断键名是否需要脱敏
+func isSensitiveKey(key string, keys []string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(key, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskSecret(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"a", "*"},
+		{"ab", "**"},
+		{"abcd", "****"},
+		{"abcde", "a***e"},
+		{"password123", "p*********3"},
+		{"密码很重要啊", "密****啊"},
+	}
+	for _, tc := range cases {
+		if got := MaskSecret(tc.in); got != tc.want {
+			t.Errorf("MaskSecret(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMaskMap(t *testing.T) {
+	m := map[string]string{"Password": "hunter22", "token": "abc", "name": "alice"}
+	got := MaskMap(m, SensitiveKeys)
+	want := map[string]string{"Password": "h******2", "token": "***", "name": "alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MaskMap() = %v, want %v", got, want)
+	}
+	if m["Password"] != "hunter22" {
+		t.Fatal("MaskMap 不应修改原map")
+	}
+	if MaskMap(nil, SensitiveKeys) != nil {
+		t.Fatal("MaskMap(nil) 应返回nil")
+	}
+}
+
+func TestMaskQuery(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"page=1&size=10", "page=1&size=10"},
+		{"token=abcdefgh&page=1", "token=a******h&page=1"},
+		{"q=a%20b&PASSWORD=p%40ssword", "q=a%20b&PASSWORD=p******d"},
+		{"api_key=xy&flag", "api_key=**&flag"},
+	}
+	for _, tc := range cases {
+		if got := MaskQuery(tc.in, SensitiveKeys); got != tc.want {
+			t.Errorf("MaskQuery(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader 出站请求中携带剩余处理时间的请求头，值为毫秒数
+// 下游服务可据此决定是否还有必要处理请求，以及为自身的依赖调用分配多少时间
+const TimeoutHeader = "X-Timeout"
+
+/*
+DeadlineTransport 传递截止时间的出站 http.RoundTripper
+请求上下文带有截止时间时（如经过 Timeout 中间件的入站请求上下文），在出站请求上设置 X-Timeout 头，
+值为剩余的毫秒数；上下文没有截止时间时不设置。调用方已设置该请求头时保留原值。
+http.Client 的 Timeout 同样体现在请求上下文中，因此传递的是入站剩余时间与客户端超时中较短的一个
+*/
+type DeadlineTransport struct {
+	// 实际发送请求的 RoundTripper，为nil时使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// RoundTrip 设置剩余时间请求头后发送请求，不修改调用方传入的请求
+func (t *DeadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	deadline, ok := req.Context().Deadline()
+	if !ok || req.Header.Get(TimeoutHeader) != "" {
+		return base.RoundTrip(req)
+	}
+
+	// 剩余不足1毫秒时按1毫秒传递，已超时的请求交给底层传输返回上下文错误
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 1 {
+		remaining = 1
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(TimeoutHeader, strconv.FormatInt(remaining, 10))
+	return base.RoundTrip(req)
+}
+
+// NewOutboundClient 创建出站HTTP客户端，请求会携带上下文的剩余截止时间
+// timeout: 单次请求的最长时间，<=0 表示不限制
+func NewOutboundClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &DeadlineTransport{},
+	}
+}
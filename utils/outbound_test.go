@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTimeoutEchoServer 返回收到的 X-Timeout 请求头
+func newTimeoutEchoServer(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+	got := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get(TimeoutHeader)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func doOutbound(ctx context.Context, t *testing.T, client *http.Client, url string, header string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+	if header != "" {
+		req.Header.Set(TimeoutHeader, header)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if req.Header.Get(TimeoutHeader) != header {
+		t.Fatal("不应修改调用方传入的请求")
+	}
+}
+
+func TestOutboundClientSendsRemainingDeadline(t *testing.T) {
+	srv, got := newTimeoutEchoServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	doOutbound(ctx, t, NewOutboundClient(0), srv.URL, "")
+	ms, err := strconv.ParseInt(<-got, 10, 64)
+	if err != nil {
+		t.Fatalf("%s 不是毫秒数: %v", TimeoutHeader, err)
+	}
+	if ms <= 0 || ms > 500 {
+		t.Fatalf("%s = %d, want (0, 500]", TimeoutHeader, ms)
+	}
+}
+
+func TestOutboundClientUsesShorterClientTimeout(t *testing.T) {
+	srv, got := newTimeoutEchoServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	doOutbound(ctx, t, NewOutboundClient(time.Second), srv.URL, "")
+	ms, err := strconv.ParseInt(<-got, 10, 64)
+	if err != nil || ms <= 0 || ms > 1000 {
+		t.Fatalf("%s = %d (%v), want (0, 1000]", TimeoutHeader, ms, err)
+	}
+}
+
+func TestOutboundClientWithoutDeadline(t *testing.T) {
+	srv, got := newTimeoutEchoServer(t)
+	doOutbound(context.Background(), t, NewOutboundClient(0), srv.URL, "")
+	if h := <-got; h != "" {
+		t.Fatalf("没有截止时间时不应设置 %s: %q", TimeoutHeader, h)
+	}
+
+	// 调用方已设置时保留原值
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	doOutbound(ctx, t, NewOutboundClient(0), srv.URL, "250")
+	if h := <-got; h != "250" {
+		t.Fatalf("%s = %q, want 250", TimeoutHeader, h)
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultRedirectTarget 重定向目标不合法时使用的安全默认地址
+const DefaultRedirectTarget = "/"
+
+// SafeRedirect 校验重定向目标，防止开放重定向
+// allowlist 中以"/"开头的条目表示允许的站内路径前缀，其余条目表示允许的主机名，
+// 主机名支持"*.example.com"形式的子域名通配。
+// 站内相对路径在 allowlist 未配置路径前缀时默认允许；绝对地址仅允许 http/https 且主机在 allowlist 中。
+// 返回: 可安全使用的重定向地址，以及原始目标是否通过校验（未通过时返回 DefaultRedirectTarget）
+func SafeRedirect(target string, allowlist []string) (string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "" || strings.ContainsAny(target, "\\\r\n\t") {
+		return DefaultRedirectTarget, false
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return DefaultRedirectTarget, false
+	}
+
+	var hosts, paths []string
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "/") {
+			paths = append(paths, entry)
+		} else {
+			hosts = append(hosts, strings.ToLower(entry))
+		}
+	}
+
+	// 站内相对路径，"//host" 形式属于协议相对地址，不视为站内路径
+	if u.Scheme == "" && u.Host == "" {
+		if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") {
+			return DefaultRedirectTarget, false
+		}
+		if len(paths) == 0 {
+			return target, true
+		}
+		for _, p := range paths {
+			if matchPathPrefix(u.Path, p) {
+				return target, true
+			}
+		}
+		return DefaultRedirectTarget, false
+	}
+
+	// 绝对地址
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return DefaultRedirectTarget, false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == h {
+			return target, true
+		}
+		if strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:]) {
+			return target, true
+		}
+	}
+
+	return DefaultRedirectTarget, false
+}
+
+// matchPathPrefix 判断路径是否位于前缀之下，按路径段匹配（/app 匹配 /app 和 /app/x，不匹配 /apple）
+func matchPathPrefix(path, prefix string) bool {
+	if path == prefix || strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix) {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
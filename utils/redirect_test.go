@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestSafeRedirect(t *testing.T) {
+	allowlist := []string{"app.example.com", "*.example.org", "/dashboard"}
+	cases := []struct {
+		name, target string
+		ok           bool
+	}{
+		{"允许的主机", "https://app.example.com/home", true},
+		{"主机名不区分大小写", "https://APP.example.com/home", true},
+		{"允许的子域名", "https://a.example.org/x", true},
+		{"通配不匹配根域名", "https://example.org/x", false},
+		{"不允许的主机", "https://evil.com/home", false},
+		{"主机名后缀相似", "https://app.example.com.evil.com/", false},
+		{"非http协议", "javascript:alert(1)", false},
+		{"允许的站内路径", "/dashboard/stats?x=1", true},
+		{"路径前缀按段匹配", "/dashboardx", false},
+		{"不允许的站内路径", "/admin", false},
+		{"协议相对地址", "//evil.com/x", false},
+		{"反斜杠", "/\\evil.com", false},
+		{"不以斜杠开头的相对路径", "dashboard", false},
+		{"空目标", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := SafeRedirect(tc.target, allowlist)
+		if ok != tc.ok {
+			t.Errorf("%s: SafeRedirect(%q) ok = %v, want %v", tc.name, tc.target, ok, tc.ok)
+			continue
+		}
+		if ok && got != tc.target {
+			t.Errorf("%s: 通过校验时应返回原始目标, got %q", tc.name, got)
+		}
+		if !ok && got != DefaultRedirectTarget {
+			t.Errorf("%s: 未通过校验时应返回默认地址, got %q", tc.name, got)
+		}
+	}
+}
+
+func TestSafeRedirectRelativeWithoutPathAllowlist(t *testing.T) {
+	// 未配置路径前缀时站内相对路径默认允许
+	if got, ok := SafeRedirect("/api/v1/users/login", []string{"app.example.com"}); !ok || got != "/api/v1/users/login" {
+		t.Fatalf("SafeRedirect = %q, %v", got, ok)
+	}
+	if _, ok := SafeRedirect("https://app.example.com/", nil); ok {
+		t.Fatal("未配置主机时绝对地址应被拒绝")
+	}
+}
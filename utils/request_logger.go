@@ -1,13 +1,16 @@
 package utils
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -15,13 +18,41 @@ import (
 var (
 	requestLogger *RequestLogger
 	reqLogOnce    sync.Once
+
+	// requestLogFailures 请求日志写入失败的条目数，result 为 fallback（已改写入应用日志）或 dropped（丢弃）
+	requestLogFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "request_log_write_failures_total",
+		Help: "请求日志写入失败的条目数",
+	}, []string{"result"})
+)
+
+// 请求日志写入失败时的重试次数和初始退避时间，每次重试退避时间翻倍
+const (
+	requestLogWriteAttempts = 3
+	requestLogRetryBackoff  = 50 * time.Millisecond
+)
+
+// 请求日志批量写入参数：缓冲区容量、每批最多条目数和最长等待时间
+const (
+	requestLogBufferSize    = 4096
+	requestLogBatchSize     = 100
+	requestLogFlushInterval = 200 * time.Millisecond
 )
 
 // RequestLogger 专门用于记录HTTP请求的日志器
+// 请求日志先进入缓冲通道，由单个写入goroutine批量写入文件
 type RequestLogger struct {
 	config LogConfig
 	writer *lumberjack.Logger
 	mutex  sync.Mutex
+
+	// 待写入的请求日志
+	entries chan RequestLog
+	// 写入goroutine退出时关闭
+	done chan struct{}
+	// 保护 closed，关闭后不再向 entries 发送
+	closeMu sync.RWMutex
+	closed  bool
 }
 
 // RequestLog 请求日志结构
@@ -39,6 +70,11 @@ type RequestLog struct {
 	Params    map[string]string      `json:"params,omitempty"`
 	Headers   map[string]string      `json:"headers,omitempty"`
 	ExtraInfo map[string]interface{} `json:"extra_info,omitempty"`
+	// 请求体和响应体字节数，请求体长度未知时为处理器实际读取的字节数
+	RequestBytes  int64 `json:"request_bytes"`
+	ResponseBytes int64 `json:"response_bytes"`
+	// 客户端重复使用请求ID、请求ID被替换时客户端传入的原始值
+	ClientRequestID string `json:"client_request_id,omitempty"`
 }
 
 // InitRequestLogger 初始化请求日志记录器
@@ -57,10 +93,17 @@ func InitRequestLogger(config LogConfig) {
 			return
 		}
 
+		if !IsSupportedRequestLogFormat(config.RequestLogFormat) {
+			Warn("不支持的请求日志格式，使用json格式", zap.String("format", config.RequestLogFormat))
+			config.RequestLogFormat = RequestLogFormatJSON
+		}
+
 		// 初始化请求日志记录器
 		requestLogger = &RequestLogger{
-			config: config,
-			mutex:  sync.Mutex{},
+			config:  config,
+			mutex:   sync.Mutex{},
+			entries: make(chan RequestLog, requestLogBufferSize),
+			done:    make(chan struct{}),
 		}
 
 		// 启动一个goroutine，每天更新日志文件名
@@ -83,9 +126,13 @@ func InitRequestLogger(config LogConfig) {
 			requestLogger.updateWriter()
 		}
 
+		// 启动写入goroutine
+		go requestLogger.run()
+
 		Info("请求日志系统初始化成功",
 			zap.String("日志目录", logDir),
 			zap.Bool("按天轮转", config.RotateDaily),
+			zap.String("格式", config.RequestLogFormat),
 		)
 	})
 }
@@ -95,6 +142,11 @@ func (rl *RequestLogger) updateWriter() {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
+	rl.updateWriterLocked()
+}
+
+// updateWriterLocked 更新日志写入器，调用方需持有锁
+func (rl *RequestLogger) updateWriterLocked() {
 	// 获取当前日期
 	var logFilename string
 
@@ -106,7 +158,10 @@ func (rl *RequestLogger) updateWriter() {
 		logFilename = filepath.Join(rl.config.LogDir, "requests", "requests.log")
 	}
 
-	// 创建或更新写入器
+	// 关闭旧文件后创建新的写入器
+	if rl.writer != nil {
+		_ = rl.writer.Close()
+	}
 	rl.writer = &lumberjack.Logger{
 		Filename:   logFilename,
 		MaxSize:    rl.config.MaxSize,
@@ -116,34 +171,181 @@ func (rl *RequestLogger) updateWriter() {
 	}
 }
 
-// LogRequest 记录请求日志
+// reopen 关闭当前日志文件，下次写入时重新打开
+func (rl *RequestLogger) reopen() error {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if rl.writer == nil {
+		return nil
+	}
+	return rl.writer.Close()
+}
+
+/*
+write 写入一批请求日志
+写入失败时关闭当前文件（下次写入时重新打开，目录被删除时会重新创建），按退避时间重试，
+最多尝试 requestLogWriteAttempts 次；重试等待期间不持有锁
+*/
+func (rl *RequestLogger) write(data []byte) error {
+	var err error
+	backoff := requestLogRetryBackoff
+	for attempt := 1; attempt <= requestLogWriteAttempts; attempt++ {
+		if err = rl.writeOnce(data); err == nil {
+			return nil
+		}
+		if attempt < requestLogWriteAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// writeOnce 写入一次，失败时关闭当前文件以便下次重新打开
+func (rl *RequestLogger) writeOnce(data []byte) error {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	// 确保writer已初始化
+	if rl.writer == nil {
+		rl.updateWriterLocked()
+	}
+
+	if _, err := rl.writer.Write(data); err != nil {
+		_ = rl.writer.Close()
+		return err
+	}
+	return nil
+}
+
+/*
+run 写入goroutine，从缓冲通道读取请求日志并批量写入
+累计 requestLogBatchSize 条或距上次写入超过 requestLogFlushInterval 时写入一次；
+通道关闭后写入剩余条目并退出
+*/
+func (rl *RequestLogger) run() {
+	defer close(rl.done)
+
+	ticker := time.NewTicker(requestLogFlushInterval)
+	defer ticker.Stop()
+
+	var buf []byte
+	var lines [][]byte
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		if err := rl.write(buf); err != nil {
+			for _, line := range lines {
+				fallbackRequestLog(line, err)
+			}
+		}
+		buf = buf[:0]
+		lines = lines[:0]
+	}
+
+	for {
+		select {
+		case reqLog, ok := <-rl.entries:
+			if !ok {
+				flush()
+				return
+			}
+			data, err := EncodeRequestLog(reqLog, rl.config.RequestLogFormat)
+			if err != nil {
+				requestLogFailures.WithLabelValues("dropped").Inc()
+				Error("请求日志序列化失败", zap.Error(err))
+				continue
+			}
+			lines = append(lines, data)
+			buf = append(append(buf, data...), '\n')
+			if len(lines) >= requestLogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// enqueue 将请求日志放入缓冲通道，不阻塞；已关闭或缓冲区已满时返回false
+func (rl *RequestLogger) enqueue(reqLog RequestLog) bool {
+	rl.closeMu.RLock()
+	defer rl.closeMu.RUnlock()
+
+	if rl.closed {
+		return false
+	}
+	select {
+	case rl.entries <- reqLog:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 停止接收请求日志，等待缓冲区写完并关闭日志文件
+func (rl *RequestLogger) close(ctx context.Context) error {
+	rl.closeMu.Lock()
+	if !rl.closed {
+		rl.closed = true
+		close(rl.entries)
+	}
+	rl.closeMu.Unlock()
+
+	select {
+	case <-rl.done:
+	case <-ctx.Done():
+		return fmt.Errorf("等待请求日志写入超时: %w", ctx.Err())
+	}
+
+	return rl.reopen()
+}
+
+/*
+LogRequest 记录请求日志
+请求日志放入缓冲区后立即返回，由写入goroutine批量写入文件，可在请求处理中直接调用。
+缓冲区已满或请求日志记录器已关闭时条目被丢弃；文件不可写时改为写入应用日志，
+两种情况都计入 request_log_write_failures_total
+*/
 func LogRequest(reqLog RequestLog) {
 	if requestLogger == nil {
 		// 如果请求日志器未初始化，使用默认配置初始化
 		InitRequestLogger(defaultLogConfig)
 	}
 
-	// 序列化为JSON
-	jsonData, err := json.Marshal(reqLog)
-	if err != nil {
-		Error("请求日志序列化失败", zap.Error(err))
+	// 请求日志目录无法创建时初始化失败，直接写入应用日志
+	if requestLogger == nil {
+		data, err := EncodeRequestLog(reqLog, RequestLogFormatJSON)
+		if err != nil {
+			requestLogFailures.WithLabelValues("dropped").Inc()
+			return
+		}
+		fallbackRequestLog(data, errors.New("请求日志记录器未初始化"))
 		return
 	}
 
-	// 添加换行符
-	jsonData = append(jsonData, '\n')
-
-	// 写入日志
-	requestLogger.mutex.Lock()
-	defer requestLogger.mutex.Unlock()
-
-	// 确保writer已初始化
-	if requestLogger.writer == nil {
-		requestLogger.updateWriter()
+	if !requestLogger.enqueue(reqLog) {
+		requestLogFailures.WithLabelValues("dropped").Inc()
 	}
+}
 
-	// 写入日志数据
-	if _, err := requestLogger.writer.Write(jsonData); err != nil {
-		Error("请求日志写入失败", zap.Error(err))
+/*
+CloseRequestLogger 关闭请求日志记录器，在服务退出前调用
+停止接收新的请求日志，等待缓冲区中的条目写入文件后关闭文件
+ctx: 等待写入的截止时间
+返回: 等待超时或关闭文件失败的错误
+*/
+func CloseRequestLogger(ctx context.Context) error {
+	if requestLogger == nil {
+		return nil
 	}
+	return requestLogger.close(ctx)
+}
+
+// fallbackRequestLog 将请求日志写入应用日志
+func fallbackRequestLog(data []byte, cause error) {
+	requestLogFailures.WithLabelValues("fallback").Inc()
+	Warn("请求日志写入失败，已改为写入应用日志", zap.ByteString("request_log", data), zap.Error(cause))
 }
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestLoggerReopenWritesToFreshFile(t *testing.T) {
+	dir := t.TempDir()
+	rl := &RequestLogger{config: LogConfig{LogDir: dir, MaxSize: 1}}
+	rl.updateWriter()
+	t.Cleanup(func() { _ = rl.reopen() })
+
+	if err := writeString(rl, "before\n"); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	// 模拟 logrotate：重命名当前文件后请求重新打开
+	current := filepath.Join(dir, "requests", "requests.log")
+	rotated := current + ".1"
+	if err := os.Rename(current, rotated); err != nil {
+		t.Fatalf("重命名失败: %v", err)
+	}
+	if err := rl.reopen(); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if err := writeString(rl, "after\n"); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	if data, _ := os.ReadFile(rotated); string(data) != "before\n" {
+		t.Fatalf("重命名的文件 = %q, want %q", data, "before\n")
+	}
+	if data, _ := os.ReadFile(current); string(data) != "after\n" {
+		t.Fatalf("新文件 = %q, want %q", data, "after\n")
+	}
+}
+
+func writeString(rl *RequestLogger, s string) error {
+	_, err := rl.writer.Write([]byte(s))
+	return err
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerTiming 单个请求内各阶段的累计耗时，用于生成 Server-Timing 响应头
+// 同一名称多次记录时累加耗时，并发记录是安全的
+type ServerTiming struct {
+	mu    sync.Mutex
+	names []string
+	durs  map[string]time.Duration
+}
+
+type serverTimingKey struct{}
+
+// NewServerTiming 创建耗时记录
+func NewServerTiming() *ServerTiming {
+	return &ServerTiming{durs: make(map[string]time.Duration)}
+}
+
+// Add 累加指定阶段的耗时
+func (t *ServerTiming) Add(name string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.durs[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.durs[name] += d
+}
+
+// Header 生成 Server-Timing 响应头的值，如 db;dur=12.3, total;dur=45.6，耗时单位为毫秒
+func (t *ServerTiming) Header(total time.Duration) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := make([]string, 0, len(t.names)+1)
+	for _, name := range t.names {
+		parts = append(parts, formatTiming(name, t.durs[name]))
+	}
+	parts = append(parts, formatTiming("total", total))
+	return strings.Join(parts, ", ")
+}
+
+func formatTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.1f", name, float64(d)/float64(time.Millisecond))
+}
+
+// WithServerTiming 将耗时记录放入上下文
+func WithServerTiming(ctx context.Context, t *ServerTiming) context.Context {
+	return context.WithValue(ctx, serverTimingKey{}, t)
+}
+
+// AddServerTiming 在上下文的耗时记录中累加耗时，上下文中没有耗时记录时不做任何操作
+func AddServerTiming(ctx context.Context, name string, d time.Duration) {
+	if ctx == nil {
+		return
+	}
+	if t, ok := ctx.Value(serverTimingKey{}).(*ServerTiming); ok {
+		t.Add(name, d)
+	}
+}
+
+/*
+TrackServerTiming 开始计时，返回的函数结束计时并累加到上下文的耗时记录中
+用法: defer utils.TrackServerTiming(ctx, "cache")()
+ctx: 请求上下文
+name: 阶段名称，只能包含字母、数字和下划线等token字符
+*/
+func TrackServerTiming(ctx context.Context, name string) func() {
+	start := time.Now()
+	return func() {
+		AddServerTiming(ctx, name, time.Since(start))
+	}
+}
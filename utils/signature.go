@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"sort"
 	"strconv"
@@ -9,30 +12,101 @@ import (
 	"time"
 )
 
-// GenerateSignature 生成API请求签名
+// SignatureBodyHashKey JSON请求体哈希参与签名时使用的参数名
+const SignatureBodyHashKey = "body_hash"
+
+// 签名算法
+const (
+	// SignatureAlgoMD5 MD5(参数字符串&app_secret=密钥)，仅为兼容旧客户端保留的默认算法
+	SignatureAlgoMD5 = "md5"
+	// SignatureAlgoHMACSHA256 以AppSecret为密钥对参数字符串计算HMAC-SHA256，推荐新接入的客户端使用
+	SignatureAlgoHMACSHA256 = "hmac-sha256"
+)
+
+// IsSupportedSignatureAlgo 判断签名算法是否受支持，空字符串视为默认的MD5
+func IsSupportedSignatureAlgo(algo string) bool {
+	switch algo {
+	case "", SignatureAlgoMD5, SignatureAlgoHMACSHA256:
+		return true
+	}
+	return false
+}
+
+// GenerateSignature 使用MD5生成API请求签名
+// MD5签名仅为兼容旧客户端保留，新接入请使用 GenerateSignatureWithAlgo(params, appSecret, SignatureAlgoHMACSHA256)
 func GenerateSignature(params map[string]string, appSecret string) string {
-	// 按参数名排序
-	var keys []string
+	return GenerateSignatureWithAlgo(params, appSecret, SignatureAlgoMD5)
+}
+
+/*
+GenerateSignatureWithAlgo 使用指定算法生成API请求签名
+参数按名称排序后拼接为 key1=value1&key2=value2...，
+md5: 拼接 &app_secret=密钥 后计算MD5；hmac-sha256: 以密钥计算参数字符串的HMAC-SHA256
+params: 参与签名的参数
+appSecret: 应用密钥
+algo: 签名算法，为空时使用MD5
+返回: 十六进制签名，算法不受支持时返回空字符串
+*/
+func GenerateSignatureWithAlgo(params map[string]string, appSecret string, algo string) string {
+	switch algo {
+	case "", SignatureAlgoMD5:
+		hash := md5.New()
+		hash.Write([]byte(md5SignString(params, appSecret)))
+		return hex.EncodeToString(hash.Sum(nil))
+	case SignatureAlgoHMACSHA256:
+		mac := hmac.New(sha256.New, []byte(appSecret))
+		mac.Write([]byte(canonicalSignParams(params)))
+		return hex.EncodeToString(mac.Sum(nil))
+	default:
+		return ""
+	}
+}
+
+/*
+SignatureString 返回参与签名计算的原始字符串，用于客户端排查参数拼接问题
+MD5算法中的密钥以 *** 代替，不会暴露真实密钥
+params: 参与签名的参数
+algo: 签名算法，为空时使用MD5
+返回: 签名字符串，算法不受支持时返回空字符串
+*/
+func SignatureString(params map[string]string, algo string) string {
+	switch algo {
+	case "", SignatureAlgoMD5:
+		return md5SignString(params, "***")
+	case SignatureAlgoHMACSHA256:
+		return canonicalSignParams(params)
+	default:
+		return ""
+	}
+}
+
+// canonicalSignParams 参数按名称排序后拼接为 key1=value1&key2=value2...
+func canonicalSignParams(params map[string]string) string {
+	keys := make([]string, 0, len(params))
 	for k := range params {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
-	// 构建签名字符串
 	var signStr strings.Builder
-	for _, k := range keys {
+	for i, k := range keys {
+		if i > 0 {
+			signStr.WriteString("&")
+		}
 		signStr.WriteString(k)
 		signStr.WriteString("=")
 		signStr.WriteString(params[k])
-		signStr.WriteString("&")
 	}
-	signStr.WriteString("app_secret=")
-	signStr.WriteString(appSecret)
+	return signStr.String()
+}
 
-	// 计算MD5签名
-	hash := md5.New()
-	hash.Write([]byte(signStr.String()))
-	return hex.EncodeToString(hash.Sum(nil))
+// md5SignString MD5签名的原始字符串：排序后的参数再拼接 &app_secret=密钥
+func md5SignString(params map[string]string, appSecret string) string {
+	signStr := canonicalSignParams(params)
+	if signStr != "" {
+		signStr += "&"
+	}
+	return signStr + "app_secret=" + appSecret
 }
 
 // GenerateAPIParams 生成API请求参数
@@ -48,7 +122,37 @@ func GenerateAPIParams(appKey string, appSecret string, params map[string]string
 	return params
 }
 
-// GenerateNonce 生成随机字符串
+// GenerateAPIParamsWithBody 生成带请求体的API请求参数（不区分请求体类型），请求体以 body_hash 参与签名
+// 返回的参数中不包含 body_hash，服务端会根据实际收到的请求体重新计算
+func GenerateAPIParamsWithBody(appKey string, appSecret string, params map[string]string, body []byte) map[string]string {
+	params["app_key"] = appKey
+	params["timestamp"] = strconv.FormatInt(time.Now().Unix(), 10)
+	params["nonce"] = GenerateNonce()
+
+	signParams := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		signParams[k] = v
+	}
+	if len(body) > 0 {
+		signParams[SignatureBodyHashKey] = SignatureBodyHash(body)
+	}
+	params["sign"] = GenerateSignature(signParams, appSecret)
+
+	return params
+}
+
+// SignatureBodyHash 计算请求体的SHA-256十六进制摘要
+func SignatureBodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateNonce 生成随机字符串，服务端会拒绝有效期内重复的nonce
 func GenerateNonce() string {
-	return time.Now().Format("20060102150405.000")
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 随机源不可用时退化为时间戳
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
 }